@@ -0,0 +1,31 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/tshinag/monkey/token"
+)
+
+// AlternativePattern implements "a | b" pattern
+type AlternativePattern struct {
+	Token        token.Token // 最初の '|' トークン
+	Alternatives []Pattern
+}
+
+// TokenLiteral implements Node interface
+func (ap *AlternativePattern) TokenLiteral() string {
+	return ap.Token.Literal
+}
+
+// Pos implements Node interface
+func (ap *AlternativePattern) Pos() token.Position {
+	return ap.Token.Pos
+}
+
+func (ap *AlternativePattern) String() string {
+	alternatives := []string{}
+	for _, a := range ap.Alternatives {
+		alternatives = append(alternatives, a.String())
+	}
+	return strings.Join(alternatives, " | ")
+}
@@ -18,6 +18,11 @@ func (al *ArrayLiteral) TokenLiteral() string {
 	return al.Token.Literal
 }
 
+// Pos implements Node interface
+func (al *ArrayLiteral) Pos() token.Position {
+	return al.Token.Pos
+}
+
 func (al *ArrayLiteral) String() string {
 	var out bytes.Buffer
 	elements := []string{}
@@ -0,0 +1,40 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/tshinag/monkey/token"
+)
+
+// ArrayPattern implements array pattern
+type ArrayPattern struct {
+	Token    token.Token // '[' トークン
+	Elements []Pattern
+	Rest     *RestPattern
+}
+
+// TokenLiteral implements Node interface
+func (ap *ArrayPattern) TokenLiteral() string {
+	return ap.Token.Literal
+}
+
+// Pos implements Node interface
+func (ap *ArrayPattern) Pos() token.Position {
+	return ap.Token.Pos
+}
+
+func (ap *ArrayPattern) String() string {
+	var out bytes.Buffer
+	elements := []string{}
+	for _, el := range ap.Elements {
+		elements = append(elements, el.String())
+	}
+	if ap.Rest != nil {
+		elements = append(elements, ap.Rest.String())
+	}
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+	return out.String()
+}
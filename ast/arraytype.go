@@ -0,0 +1,23 @@
+package ast
+
+import "github.com/tshinag/monkey/token"
+
+// ArrayType implements type annotation of array "[element]"
+type ArrayType struct {
+	Token   token.Token // '[' トークン
+	Element TypeExpression
+}
+
+// TokenLiteral implements Node interface
+func (at *ArrayType) TokenLiteral() string {
+	return at.Token.Literal
+}
+
+// Pos implements Node interface
+func (at *ArrayType) Pos() token.Position {
+	return at.Token.Pos
+}
+
+func (at *ArrayType) String() string {
+	return "[" + at.Element.String() + "]"
+}
@@ -0,0 +1,37 @@
+package ast
+
+import (
+	"bytes"
+
+	"github.com/tshinag/monkey/token"
+)
+
+// AssignExpression implements assignment to existing variable or field
+type AssignExpression struct {
+	Token    token.Token // '=' または '+=' などのトークン
+	Target   Expression  // Identifier または PropertyExpression
+	Operator string      // "=", "+=", "-=", "*=", "/="
+	Value    Expression
+}
+
+// TokenLiteral implements Node interface
+func (ae *AssignExpression) TokenLiteral() string {
+	return ae.Token.Literal
+}
+
+// Pos implements Node interface
+func (ae *AssignExpression) Pos() token.Position {
+	return ae.Token.Pos
+}
+
+func (ae *AssignExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ae.Target.String())
+	out.WriteString(" " + ae.Operator + " ")
+	out.WriteString(ae.Value.String())
+	out.WriteString(")")
+
+	return out.String()
+}
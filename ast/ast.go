@@ -2,12 +2,15 @@ package ast
 
 import (
 	"bytes"
+
+	"github.com/tshinag/monkey/token"
 )
 
 // Node is a node of AST tree
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() token.Position
 }
 
 // Statement is the expression of statement
@@ -20,6 +23,16 @@ type Expression interface {
 	Node
 }
 
+// Pattern is the expression of pattern in match arm
+type Pattern interface {
+	Node
+}
+
+// TypeExpression is the expression of type annotation
+type TypeExpression interface {
+	Node
+}
+
 // Program is a set of Statement
 type Program struct {
 	Statements []Statement
@@ -33,6 +46,14 @@ func (p *Program) TokenLiteral() string {
 	return ""
 }
 
+// Pos returns the position of the first statement
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+
 func (p *Program) String() string {
 	var out bytes.Buffer
 
@@ -1,6 +1,7 @@
 package ast
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/tshinag/monkey/token"
@@ -27,3 +28,51 @@ func TestString(t *testing.T) {
 		t.Errorf("program.String() wrong. got=%q", program.String())
 	}
 }
+
+func TestInspect(t *testing.T) {
+	ident := func(name string) *Identifier {
+		return &Identifier{Token: token.Token{Type: token.IDENT, Literal: name}, Value: name}
+	}
+	// let f = fn(x: int) { if (x) { x } else { y } }
+	program := &Program{
+		Statements: []Statement{
+			&LetStatement{
+				Token: token.Token{Type: token.LET, Literal: "let"},
+				Name:  ident("f"),
+				Value: &FunctionLiteral{
+					Token:          token.Token{Type: token.FUNCTION, Literal: "fn"},
+					Parameters:     []*Identifier{ident("x")},
+					ParameterTypes: []TypeExpression{&NamedType{Name: "int"}},
+					Body: &BlockStatement{Statements: []Statement{
+						&ExpressionStatement{Expression: &IfExpression{
+							Condition:   ident("x"),
+							Consequence: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: ident("x")}}},
+							Alternative: &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: ident("y")}}},
+						}},
+					}},
+				},
+			},
+		},
+	}
+
+	names := []string{}
+	Inspect(program, func(node Node) bool {
+		switch node := node.(type) {
+		case *Identifier:
+			names = append(names, node.Value)
+		case *NamedType:
+			names = append(names, ":"+node.Name)
+		case *BlockStatement:
+			// else 節の中には入らない
+			if len(node.Statements) == 1 && node.Statements[0].String() == "y" {
+				return false
+			}
+		}
+		return true
+	})
+
+	expected := "f x :int x x"
+	if actual := strings.Join(names, " "); actual != expected {
+		t.Errorf("wrong nodes visited. expected=%q, got=%q", expected, actual)
+	}
+}
@@ -0,0 +1,35 @@
+package ast
+
+import (
+	"bytes"
+
+	"github.com/tshinag/monkey/token"
+)
+
+// AwaitExpression implements "await promise"
+type AwaitExpression struct {
+	Token token.Token // 'await' トークン
+	Value Expression
+}
+
+// TokenLiteral implements Node interface
+func (ae *AwaitExpression) TokenLiteral() string {
+	return ae.Token.Literal
+}
+
+// Pos implements Node interface
+func (ae *AwaitExpression) Pos() token.Position {
+	return ae.Token.Pos
+}
+
+func (ae *AwaitExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ae.TokenLiteral())
+	out.WriteString(" ")
+	out.WriteString(ae.Value.String())
+	out.WriteString(")")
+
+	return out.String()
+}
@@ -0,0 +1,23 @@
+package ast
+
+import "github.com/tshinag/monkey/token"
+
+// BindingPattern implements pattern which binds the matched value to identifier
+type BindingPattern struct {
+	Token token.Token // token.IDENT トークン
+	Name  *Identifier
+}
+
+// TokenLiteral implements Node interface
+func (bp *BindingPattern) TokenLiteral() string {
+	return bp.Token.Literal
+}
+
+// Pos implements Node interface
+func (bp *BindingPattern) Pos() token.Position {
+	return bp.Token.Pos
+}
+
+func (bp *BindingPattern) String() string {
+	return bp.Name.String()
+}
@@ -17,6 +17,11 @@ func (bs *BlockStatement) TokenLiteral() string {
 	return bs.Token.Literal
 }
 
+// Pos implements Node interface
+func (bs *BlockStatement) Pos() token.Position {
+	return bs.Token.Pos
+}
+
 func (bs *BlockStatement) String() string {
 	var out bytes.Buffer
 
@@ -13,6 +13,11 @@ func (b *Boolean) TokenLiteral() string {
 	return b.Token.Literal
 }
 
+// Pos implements Node interface
+func (b *Boolean) Pos() token.Position {
+	return b.Token.Pos
+}
+
 func (b *Boolean) String() string {
 	return b.Token.Literal
 }
@@ -12,6 +12,7 @@ type CallExpression struct {
 	Token     token.Token // The '(' token
 	Function  Expression  // Identifier or FunctionLiteral
 	Arguments []Expression
+	Optional  bool // "function?.(arguments)" の場合
 }
 
 // TokenLiteral implements Node interface
@@ -19,6 +20,11 @@ func (ce *CallExpression) TokenLiteral() string {
 	return ce.Token.Literal
 }
 
+// Pos implements Node interface
+func (ce *CallExpression) Pos() token.Position {
+	return ce.Token.Pos
+}
+
 func (ce *CallExpression) String() string {
 	var out bytes.Buffer
 
@@ -28,6 +34,9 @@ func (ce *CallExpression) String() string {
 	}
 
 	out.WriteString(ce.Function.String())
+	if ce.Optional {
+		out.WriteString("?.")
+	}
 	out.WriteString("(")
 	out.WriteString(strings.Join(args, ", "))
 	out.WriteString(")")
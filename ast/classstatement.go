@@ -0,0 +1,53 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/tshinag/monkey/token"
+)
+
+// ClassStatement implements class declaration
+// "class Name extends Superclass { method(params) { body } ... }"
+type ClassStatement struct {
+	Token      token.Token // 'class' トークン
+	Name       *Identifier
+	Superclass *Identifier // extends がなければ nil
+	Methods    []*FunctionLiteral
+}
+
+// TokenLiteral implements Node interface
+func (cs *ClassStatement) TokenLiteral() string {
+	return cs.Token.Literal
+}
+
+// Pos implements Node interface
+func (cs *ClassStatement) Pos() token.Position {
+	return cs.Token.Pos
+}
+
+func (cs *ClassStatement) String() string {
+	var out bytes.Buffer
+
+	methods := []string{}
+	for _, m := range cs.Methods {
+		params := m.parameterStrings()
+		method := m.Name + "(" + strings.Join(params, ", ") + ") " + m.returnTypeString() + m.Body.String()
+		if m.Async {
+			method = "async " + method
+		}
+		methods = append(methods, method)
+	}
+
+	out.WriteString(cs.TokenLiteral() + " ")
+	out.WriteString(cs.Name.String())
+	if cs.Superclass != nil {
+		out.WriteString(" extends ")
+		out.WriteString(cs.Superclass.String())
+	}
+	out.WriteString(" { ")
+	out.WriteString(strings.Join(methods, " "))
+	out.WriteString(" }")
+
+	return out.String()
+}
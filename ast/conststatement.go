@@ -0,0 +1,44 @@
+package ast
+
+import (
+	"bytes"
+
+	"github.com/tshinag/monkey/token"
+)
+
+// ConstStatement implements const statement
+type ConstStatement struct {
+	Token token.Token // token.CONST トークン
+	Name  *Identifier
+	Type  TypeExpression // 型注釈。なければ nil
+	Value Expression
+}
+
+// TokenLiteral implements Node interface
+func (cs *ConstStatement) TokenLiteral() string {
+	return cs.Token.Literal
+}
+
+// Pos implements Node interface
+func (cs *ConstStatement) Pos() token.Position {
+	return cs.Token.Pos
+}
+
+func (cs *ConstStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(cs.TokenLiteral() + " ")
+	out.WriteString(cs.Name.String())
+	if cs.Type != nil {
+		out.WriteString(": " + cs.Type.String())
+	}
+	out.WriteString(" = ")
+
+	if cs.Value != nil {
+		out.WriteString(cs.Value.String())
+	}
+
+	out.WriteString(";")
+
+	return out.String()
+}
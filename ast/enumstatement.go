@@ -0,0 +1,70 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/tshinag/monkey/token"
+)
+
+// EnumStatement implements enum declaration "enum Result { Ok(value), Err(error) }"
+type EnumStatement struct {
+	Token    token.Token // 'enum' トークン
+	Name     *Identifier
+	Variants []*EnumVariant
+}
+
+// TokenLiteral implements Node interface
+func (es *EnumStatement) TokenLiteral() string {
+	return es.Token.Literal
+}
+
+// Pos implements Node interface
+func (es *EnumStatement) Pos() token.Position {
+	return es.Token.Pos
+}
+
+func (es *EnumStatement) String() string {
+	var out bytes.Buffer
+
+	variants := []string{}
+	for _, v := range es.Variants {
+		variants = append(variants, v.String())
+	}
+
+	out.WriteString(es.TokenLiteral() + " ")
+	out.WriteString(es.Name.String())
+	out.WriteString(" { ")
+	out.WriteString(strings.Join(variants, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// EnumVariant implements a variant of enum declaration
+type EnumVariant struct {
+	Token  token.Token // バリアント名のトークン
+	Name   *Identifier
+	Fields []*Identifier // ペイロードがなければ空
+}
+
+// TokenLiteral implements Node interface
+func (ev *EnumVariant) TokenLiteral() string {
+	return ev.Token.Literal
+}
+
+// Pos implements Node interface
+func (ev *EnumVariant) Pos() token.Position {
+	return ev.Token.Pos
+}
+
+func (ev *EnumVariant) String() string {
+	if len(ev.Fields) == 0 {
+		return ev.Name.String()
+	}
+	fields := []string{}
+	for _, f := range ev.Fields {
+		fields = append(fields, f.String())
+	}
+	return ev.Name.String() + "(" + strings.Join(fields, ", ") + ")"
+}
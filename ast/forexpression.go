@@ -0,0 +1,38 @@
+package ast
+
+import (
+	"bytes"
+
+	"github.com/tshinag/monkey/token"
+)
+
+// ForExpression implements "for (pattern in iterable) { body }"
+type ForExpression struct {
+	Token    token.Token // 'for' トークン
+	Pattern  Pattern     // 各要素を束縛するパターン
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+// TokenLiteral implements Node interface
+func (fe *ForExpression) TokenLiteral() string {
+	return fe.Token.Literal
+}
+
+// Pos implements Node interface
+func (fe *ForExpression) Pos() token.Position {
+	return fe.Token.Pos
+}
+
+func (fe *ForExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for (")
+	out.WriteString(fe.Pattern.String())
+	out.WriteString(" in ")
+	out.WriteString(fe.Iterable.String())
+	out.WriteString(") ")
+	out.WriteString(fe.Body.String())
+
+	return out.String()
+}
@@ -9,9 +9,14 @@ import (
 
 // FunctionLiteral implements function literal
 type FunctionLiteral struct {
-	Token      token.Token // 'fn' トークン
-	Parameters []*Identifier
-	Body       *BlockStatement
+	Token          token.Token // 'fn' トークン、またはラムダ式の '|' トークン
+	Name           string      // 宣言または let で束縛された名前。無名関数なら空
+	Parameters     []*Identifier
+	ParameterTypes []TypeExpression // 引数の型注釈。注釈のない引数は nil
+	ReturnType     TypeExpression   // 戻り値の型注釈。なければ nil
+	Body           *BlockStatement
+	Generator      bool // 本体が yield を含む場合
+	Async          bool // "async fn" の場合
 }
 
 // TokenLiteral implements Node interface
@@ -19,19 +24,62 @@ func (fl *FunctionLiteral) TokenLiteral() string {
 	return fl.Token.Literal
 }
 
+// Pos implements Node interface
+func (fl *FunctionLiteral) Pos() token.Position {
+	return fl.Token.Pos
+}
+
 func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 
-	params := []string{}
-	for _, p := range fl.Parameters {
-		params = append(params, p.String())
+	if fl.Async {
+		out.WriteString("async ")
+	}
+
+	params := fl.parameterStrings()
+
+	if fl.Token.Type == token.PIPE {
+		out.WriteString("|")
+		out.WriteString(strings.Join(params, ", "))
+		out.WriteString("| ")
+		out.WriteString(fl.Body.String())
+		return out.String()
 	}
 
 	out.WriteString(fl.TokenLiteral())
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(") ")
+	out.WriteString(fl.returnTypeString())
 	out.WriteString(fl.Body.String())
 
 	return out.String()
 }
+
+// ParameterType returns the type annotation of the i-th parameter, or nil
+func (fl *FunctionLiteral) ParameterType(i int) TypeExpression {
+	if i < len(fl.ParameterTypes) {
+		return fl.ParameterTypes[i]
+	}
+	return nil
+}
+
+// parameterStrings returns the parameters with their type annotations
+func (fl *FunctionLiteral) parameterStrings() []string {
+	params := []string{}
+	for i, p := range fl.Parameters {
+		if t := fl.ParameterType(i); t != nil {
+			params = append(params, p.String()+": "+t.String())
+			continue
+		}
+		params = append(params, p.String())
+	}
+	return params
+}
+
+func (fl *FunctionLiteral) returnTypeString() string {
+	if fl.ReturnType == nil {
+		return ""
+	}
+	return "-> " + fl.ReturnType.String() + " "
+}
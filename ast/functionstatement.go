@@ -0,0 +1,44 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/tshinag/monkey/token"
+)
+
+// FunctionStatement implements named function declaration
+type FunctionStatement struct {
+	Token    token.Token // 'fn' トークン
+	Name     *Identifier
+	Function *FunctionLiteral
+}
+
+// TokenLiteral implements Node interface
+func (fs *FunctionStatement) TokenLiteral() string {
+	return fs.Token.Literal
+}
+
+// Pos implements Node interface
+func (fs *FunctionStatement) Pos() token.Position {
+	return fs.Token.Pos
+}
+
+func (fs *FunctionStatement) String() string {
+	var out bytes.Buffer
+
+	params := fs.Function.parameterStrings()
+
+	if fs.Function.Async {
+		out.WriteString("async ")
+	}
+	out.WriteString(fs.TokenLiteral() + " ")
+	out.WriteString(fs.Name.String())
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") ")
+	out.WriteString(fs.Function.returnTypeString())
+	out.WriteString(fs.Function.Body.String())
+
+	return out.String()
+}
@@ -0,0 +1,38 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/tshinag/monkey/token"
+)
+
+// FunctionType implements type annotation of function "fn(parameters) -> return"
+type FunctionType struct {
+	Token      token.Token // 'fn' トークン
+	Parameters []TypeExpression
+	Return     TypeExpression
+}
+
+// TokenLiteral implements Node interface
+func (ft *FunctionType) TokenLiteral() string {
+	return ft.Token.Literal
+}
+
+// Pos implements Node interface
+func (ft *FunctionType) Pos() token.Position {
+	return ft.Token.Pos
+}
+
+func (ft *FunctionType) String() string {
+	var out bytes.Buffer
+	params := []string{}
+	for _, p := range ft.Parameters {
+		params = append(params, p.String())
+	}
+	out.WriteString("fn(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") -> ")
+	out.WriteString(ft.Return.String())
+	return out.String()
+}
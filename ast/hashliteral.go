@@ -11,6 +11,7 @@ import (
 type HashLiteral struct {
 	Token token.Token
 	Pairs map[Expression]Expression
+	Keys  []Expression // Pairs のキーと SpreadElement を記述順に保持する
 }
 
 // TokenLiteral implements Node interface
@@ -18,11 +19,20 @@ func (hl *HashLiteral) TokenLiteral() string {
 	return hl.Token.Literal
 }
 
+// Pos implements Node interface
+func (hl *HashLiteral) Pos() token.Position {
+	return hl.Token.Pos
+}
+
 func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 	pairs := []string{}
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, key := range hl.Keys {
+		if spread, ok := key.(*SpreadElement); ok {
+			pairs = append(pairs, spread.String())
+			continue
+		}
+		pairs = append(pairs, key.String()+":"+hl.Pairs[key].String())
 	}
 	out.WriteString("{")
 	out.WriteString(strings.Join(pairs, ", "))
@@ -0,0 +1,46 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/tshinag/monkey/token"
+)
+
+// HashPattern implements hash pattern
+type HashPattern struct {
+	Token token.Token // '{' トークン
+	Pairs []HashPatternPair
+	Rest  *RestPattern
+}
+
+// HashPatternPair is the pair of key and value pattern in hash pattern
+type HashPatternPair struct {
+	Key   Expression
+	Value Pattern
+}
+
+// TokenLiteral implements Node interface
+func (hp *HashPattern) TokenLiteral() string {
+	return hp.Token.Literal
+}
+
+// Pos implements Node interface
+func (hp *HashPattern) Pos() token.Position {
+	return hp.Token.Pos
+}
+
+func (hp *HashPattern) String() string {
+	var out bytes.Buffer
+	pairs := []string{}
+	for _, pair := range hp.Pairs {
+		pairs = append(pairs, pair.Key.String()+":"+pair.Value.String())
+	}
+	if hp.Rest != nil {
+		pairs = append(pairs, hp.Rest.String())
+	}
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+	return out.String()
+}
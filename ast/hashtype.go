@@ -0,0 +1,24 @@
+package ast
+
+import "github.com/tshinag/monkey/token"
+
+// HashType implements type annotation of hash "{key: value}"
+type HashType struct {
+	Token token.Token // '{' トークン
+	Key   TypeExpression
+	Value TypeExpression
+}
+
+// TokenLiteral implements Node interface
+func (ht *HashType) TokenLiteral() string {
+	return ht.Token.Literal
+}
+
+// Pos implements Node interface
+func (ht *HashType) Pos() token.Position {
+	return ht.Token.Pos
+}
+
+func (ht *HashType) String() string {
+	return "{" + ht.Key.String() + ": " + ht.Value.String() + "}"
+}
@@ -13,6 +13,11 @@ func (i *Identifier) TokenLiteral() string {
 	return i.Token.Literal
 }
 
+// Pos implements Node interface
+func (i *Identifier) Pos() token.Position {
+	return i.Token.Pos
+}
+
 func (i *Identifier) String() string {
 	return i.Value
 }
@@ -11,6 +11,7 @@ type IfExpression struct {
 	Token       token.Token // 'if' トークン
 	Condition   Expression
 	Consequence *BlockStatement
+	ElseIf      *IfExpression // "else if" で続く場合のみ
 	Alternative *BlockStatement
 }
 
@@ -19,6 +20,11 @@ func (ie *IfExpression) TokenLiteral() string {
 	return ie.Token.Literal
 }
 
+// Pos implements Node interface
+func (ie *IfExpression) Pos() token.Position {
+	return ie.Token.Pos
+}
+
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
 
@@ -27,7 +33,10 @@ func (ie *IfExpression) String() string {
 	out.WriteString(" ")
 	out.WriteString(ie.Consequence.String())
 
-	if ie.Alternative != nil {
+	if ie.ElseIf != nil {
+		out.WriteString("else ")
+		out.WriteString(ie.ElseIf.String())
+	} else if ie.Alternative != nil {
 		out.WriteString("else ")
 		out.WriteString(ie.Alternative.String())
 	}
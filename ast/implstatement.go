@@ -0,0 +1,42 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/tshinag/monkey/token"
+)
+
+// ImplStatement implements method declarations "impl Point { fn len(self) { ... } }"
+type ImplStatement struct {
+	Token   token.Token // 'impl' トークン
+	Name    *Identifier
+	Methods []*FunctionStatement
+}
+
+// TokenLiteral implements Node interface
+func (is *ImplStatement) TokenLiteral() string {
+	return is.Token.Literal
+}
+
+// Pos implements Node interface
+func (is *ImplStatement) Pos() token.Position {
+	return is.Token.Pos
+}
+
+func (is *ImplStatement) String() string {
+	var out bytes.Buffer
+
+	methods := []string{}
+	for _, m := range is.Methods {
+		methods = append(methods, m.String())
+	}
+
+	out.WriteString(is.TokenLiteral() + " ")
+	out.WriteString(is.Name.String())
+	out.WriteString(" { ")
+	out.WriteString(strings.Join(methods, " "))
+	out.WriteString(" }")
+
+	return out.String()
+}
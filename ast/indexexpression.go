@@ -8,9 +8,10 @@ import (
 
 // IndexExpression implements index expression
 type IndexExpression struct {
-	Token token.Token // '[' トークン
-	Left  Expression
-	Index Expression
+	Token    token.Token // '[' トークン
+	Left     Expression
+	Index    Expression
+	Optional bool // "left?.[index]" の場合
 }
 
 // TokenLiteral implements Node interface
@@ -18,10 +19,18 @@ func (ie *IndexExpression) TokenLiteral() string {
 	return ie.Token.Literal
 }
 
+// Pos implements Node interface
+func (ie *IndexExpression) Pos() token.Position {
+	return ie.Token.Pos
+}
+
 func (ie *IndexExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
 	out.WriteString(ie.Left.String())
+	if ie.Optional {
+		out.WriteString("?.")
+	}
 	out.WriteString("[")
 	out.WriteString(ie.Index.String())
 	out.WriteString("])")
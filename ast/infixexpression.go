@@ -19,6 +19,11 @@ func (oe *InfixExpression) TokenLiteral() string {
 	return oe.Token.Literal
 }
 
+// Pos implements Node interface
+func (oe *InfixExpression) Pos() token.Position {
+	return oe.Token.Pos
+}
+
 func (oe *InfixExpression) String() string {
 	var out bytes.Buffer
 
@@ -13,6 +13,11 @@ func (il *IntegerLiteral) TokenLiteral() string {
 	return il.Token.Literal
 }
 
+// Pos implements Node interface
+func (il *IntegerLiteral) Pos() token.Position {
+	return il.Token.Pos
+}
+
 func (il *IntegerLiteral) String() string {
 	return il.Token.Literal
 }
@@ -10,6 +10,7 @@ import (
 type LetStatement struct {
 	Token token.Token // token.LET トークン
 	Name  *Identifier
+	Type  TypeExpression // 型注釈。なければ nil
 	Value Expression
 }
 
@@ -18,11 +19,19 @@ func (ls *LetStatement) TokenLiteral() string {
 	return ls.Token.Literal
 }
 
+// Pos implements Node interface
+func (ls *LetStatement) Pos() token.Position {
+	return ls.Token.Pos
+}
+
 func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(ls.TokenLiteral() + " ")
 	out.WriteString(ls.Name.String())
+	if ls.Type != nil {
+		out.WriteString(": " + ls.Type.String())
+	}
 	out.WriteString(" = ")
 
 	if ls.Value != nil {
@@ -0,0 +1,23 @@
+package ast
+
+import "github.com/tshinag/monkey/token"
+
+// LiteralPattern implements pattern which matches the value equal to literal
+type LiteralPattern struct {
+	Token token.Token // リテラルの最初のトークン
+	Value Expression
+}
+
+// TokenLiteral implements Node interface
+func (lp *LiteralPattern) TokenLiteral() string {
+	return lp.Token.Literal
+}
+
+// Pos implements Node interface
+func (lp *LiteralPattern) Pos() token.Position {
+	return lp.Token.Pos
+}
+
+func (lp *LiteralPattern) String() string {
+	return lp.Value.String()
+}
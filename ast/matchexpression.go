@@ -0,0 +1,74 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/tshinag/monkey/token"
+)
+
+// MatchExpression implements match expression
+type MatchExpression struct {
+	Token   token.Token // 'match' トークン
+	Subject Expression
+	Arms    []*MatchArm
+}
+
+// TokenLiteral implements Node interface
+func (me *MatchExpression) TokenLiteral() string {
+	return me.Token.Literal
+}
+
+// Pos implements Node interface
+func (me *MatchExpression) Pos() token.Position {
+	return me.Token.Pos
+}
+
+func (me *MatchExpression) String() string {
+	var out bytes.Buffer
+
+	arms := []string{}
+	for _, a := range me.Arms {
+		arms = append(arms, a.String())
+	}
+
+	out.WriteString("match (")
+	out.WriteString(me.Subject.String())
+	out.WriteString(") {")
+	out.WriteString(strings.Join(arms, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// MatchArm implements an arm of match expression
+type MatchArm struct {
+	Token   token.Token // パターンの最初のトークン
+	Pattern Pattern
+	Guard   Expression
+	Body    Expression
+}
+
+// TokenLiteral implements Node interface
+func (ma *MatchArm) TokenLiteral() string {
+	return ma.Token.Literal
+}
+
+// Pos implements Node interface
+func (ma *MatchArm) Pos() token.Position {
+	return ma.Token.Pos
+}
+
+func (ma *MatchArm) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ma.Pattern.String())
+	if ma.Guard != nil {
+		out.WriteString(" if ")
+		out.WriteString(ma.Guard.String())
+	}
+	out.WriteString(" => ")
+	out.WriteString(ma.Body.String())
+
+	return out.String()
+}
@@ -0,0 +1,23 @@
+package ast
+
+import "github.com/tshinag/monkey/token"
+
+// NamedType implements type annotation by name like "int" or "Point"
+type NamedType struct {
+	Token token.Token // 型名のトークン
+	Name  string
+}
+
+// TokenLiteral implements Node interface
+func (nt *NamedType) TokenLiteral() string {
+	return nt.Token.Literal
+}
+
+// Pos implements Node interface
+func (nt *NamedType) Pos() token.Position {
+	return nt.Token.Pos
+}
+
+func (nt *NamedType) String() string {
+	return nt.Name
+}
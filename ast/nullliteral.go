@@ -0,0 +1,22 @@
+package ast
+
+import "github.com/tshinag/monkey/token"
+
+// NullLiteral implements null literal
+type NullLiteral struct {
+	Token token.Token
+}
+
+// TokenLiteral implements Node interface
+func (nl *NullLiteral) TokenLiteral() string {
+	return nl.Token.Literal
+}
+
+// Pos implements Node interface
+func (nl *NullLiteral) Pos() token.Position {
+	return nl.Token.Pos
+}
+
+func (nl *NullLiteral) String() string {
+	return nl.Token.Literal
+}
@@ -0,0 +1,36 @@
+package ast
+
+import (
+	"bytes"
+
+	"github.com/tshinag/monkey/token"
+)
+
+// PipeExpression implements pipeline operator "left |> right"
+type PipeExpression struct {
+	Token token.Token // '|>' トークン
+	Left  Expression  // 関数の第1引数として渡される値
+	Right Expression  // 呼び出し式、または関数を返す式
+}
+
+// TokenLiteral implements Node interface
+func (pe *PipeExpression) TokenLiteral() string {
+	return pe.Token.Literal
+}
+
+// Pos implements Node interface
+func (pe *PipeExpression) Pos() token.Position {
+	return pe.Token.Pos
+}
+
+func (pe *PipeExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(pe.Left.String())
+	out.WriteString(" |> ")
+	out.WriteString(pe.Right.String())
+	out.WriteString(")")
+
+	return out.String()
+}
@@ -18,6 +18,11 @@ func (pe *PrefixExpression) TokenLiteral() string {
 	return pe.Token.Literal
 }
 
+// Pos implements Node interface
+func (pe *PrefixExpression) Pos() token.Position {
+	return pe.Token.Pos
+}
+
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 
@@ -0,0 +1,39 @@
+package ast
+
+import (
+	"bytes"
+
+	"github.com/tshinag/monkey/token"
+)
+
+// PropertyExpression implements property access such as "hash.key" or "hash?.key"
+type PropertyExpression struct {
+	Token    token.Token // '.' または '?.' トークン
+	Left     Expression
+	Property *Identifier
+	Optional bool
+}
+
+// TokenLiteral implements Node interface
+func (pe *PropertyExpression) TokenLiteral() string {
+	return pe.Token.Literal
+}
+
+// Pos implements Node interface
+func (pe *PropertyExpression) Pos() token.Position {
+	return pe.Token.Pos
+}
+
+func (pe *PropertyExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(pe.Left.String())
+	if pe.Optional {
+		out.WriteString("?.")
+	} else {
+		out.WriteString(".")
+	}
+	out.WriteString(pe.Property.String())
+	out.WriteString(")")
+	return out.String()
+}
@@ -0,0 +1,26 @@
+package ast
+
+import "github.com/tshinag/monkey/token"
+
+// RestPattern implements "...rest" in array or hash pattern
+type RestPattern struct {
+	Token token.Token // '...' トークン
+	Name  *Identifier // nil if the rest is not bound
+}
+
+// TokenLiteral implements Node interface
+func (rp *RestPattern) TokenLiteral() string {
+	return rp.Token.Literal
+}
+
+// Pos implements Node interface
+func (rp *RestPattern) Pos() token.Position {
+	return rp.Token.Pos
+}
+
+func (rp *RestPattern) String() string {
+	if rp.Name != nil {
+		return rp.Token.Literal + rp.Name.String()
+	}
+	return rp.Token.Literal
+}
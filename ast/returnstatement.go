@@ -17,6 +17,11 @@ func (rs *ReturnStatement) TokenLiteral() string {
 	return rs.Token.Literal
 }
 
+// Pos implements Node interface
+func (rs *ReturnStatement) Pos() token.Position {
+	return rs.Token.Pos
+}
+
 func (rs *ReturnStatement) String() string {
 	var out bytes.Buffer
 
@@ -0,0 +1,84 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/tshinag/monkey/token"
+)
+
+// SelectExpression implements select expression waiting on channel operations
+type SelectExpression struct {
+	Token token.Token // 'select' トークン
+	Cases []*SelectCase
+}
+
+// TokenLiteral implements Node interface
+func (se *SelectExpression) TokenLiteral() string {
+	return se.Token.Literal
+}
+
+// Pos implements Node interface
+func (se *SelectExpression) Pos() token.Position {
+	return se.Token.Pos
+}
+
+func (se *SelectExpression) String() string {
+	var out bytes.Buffer
+
+	cases := []string{}
+	for _, c := range se.Cases {
+		cases = append(cases, c.String())
+	}
+
+	out.WriteString("select {")
+	out.WriteString(strings.Join(cases, " "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// SelectCase implements "case" or "default" clause of select expression
+type SelectCase struct {
+	Token     token.Token     // 'case' または 'default' トークン
+	Name      *Identifier     // "case let v = recv(ch):" で受信した値を束縛する名前
+	Operation *CallExpression // recv(ch) または send(ch, value)。default なら nil
+	Body      *BlockStatement
+}
+
+// TokenLiteral implements Node interface
+func (sc *SelectCase) TokenLiteral() string {
+	return sc.Token.Literal
+}
+
+// Pos implements Node interface
+func (sc *SelectCase) Pos() token.Position {
+	return sc.Token.Pos
+}
+
+// IsDefault reports whether the clause is "default"
+func (sc *SelectCase) IsDefault() bool {
+	return sc.Token.Type == token.DEFAULT
+}
+
+// IsSend reports whether the clause waits for send(ch, value)
+func (sc *SelectCase) IsSend() bool {
+	return sc.Operation != nil && sc.Operation.Function.String() == "send"
+}
+
+func (sc *SelectCase) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(sc.TokenLiteral())
+	if !sc.IsDefault() {
+		out.WriteString(" ")
+		if sc.Name != nil {
+			out.WriteString("let " + sc.Name.String() + " = ")
+		}
+		out.WriteString(sc.Operation.String())
+	}
+	out.WriteString(": ")
+	out.WriteString(sc.Body.String())
+
+	return out.String()
+}
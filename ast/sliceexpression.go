@@ -0,0 +1,46 @@
+package ast
+
+import (
+	"bytes"
+
+	"github.com/tshinag/monkey/token"
+)
+
+// SliceExpression implements slice expression such as "array[start:end:step]"
+type SliceExpression struct {
+	Token token.Token // '[' トークン
+	Left  Expression
+	Start Expression // 省略時は nil
+	End   Expression // 省略時は nil
+	Step  Expression // 省略時は nil
+}
+
+// TokenLiteral implements Node interface
+func (se *SliceExpression) TokenLiteral() string {
+	return se.Token.Literal
+}
+
+// Pos implements Node interface
+func (se *SliceExpression) Pos() token.Position {
+	return se.Token.Pos
+}
+
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	out.WriteString("[")
+	if se.Start != nil {
+		out.WriteString(se.Start.String())
+	}
+	out.WriteString(":")
+	if se.End != nil {
+		out.WriteString(se.End.String())
+	}
+	if se.Step != nil {
+		out.WriteString(":")
+		out.WriteString(se.Step.String())
+	}
+	out.WriteString("])")
+	return out.String()
+}
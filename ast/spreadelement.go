@@ -0,0 +1,23 @@
+package ast
+
+import "github.com/tshinag/monkey/token"
+
+// SpreadElement implements "...value" in array literal, hash literal or call arguments
+type SpreadElement struct {
+	Token token.Token // '...' トークン
+	Value Expression
+}
+
+// TokenLiteral implements Node interface
+func (se *SpreadElement) TokenLiteral() string {
+	return se.Token.Literal
+}
+
+// Pos implements Node interface
+func (se *SpreadElement) Pos() token.Position {
+	return se.Token.Pos
+}
+
+func (se *SpreadElement) String() string {
+	return se.Token.Literal + se.Value.String()
+}
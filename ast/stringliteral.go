@@ -13,6 +13,11 @@ func (il *StringLiteral) TokenLiteral() string {
 	return il.Token.Literal
 }
 
+// Pos implements Node interface
+func (il *StringLiteral) Pos() token.Position {
+	return il.Token.Pos
+}
+
 func (il *StringLiteral) String() string {
 	return il.Token.Literal
 }
@@ -0,0 +1,42 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/tshinag/monkey/token"
+)
+
+// StructStatement implements struct declaration "struct Point { x, y }"
+type StructStatement struct {
+	Token  token.Token // 'struct' トークン
+	Name   *Identifier
+	Fields []*Identifier
+}
+
+// TokenLiteral implements Node interface
+func (ss *StructStatement) TokenLiteral() string {
+	return ss.Token.Literal
+}
+
+// Pos implements Node interface
+func (ss *StructStatement) Pos() token.Position {
+	return ss.Token.Pos
+}
+
+func (ss *StructStatement) String() string {
+	var out bytes.Buffer
+
+	fields := []string{}
+	for _, f := range ss.Fields {
+		fields = append(fields, f.String())
+	}
+
+	out.WriteString(ss.TokenLiteral() + " ")
+	out.WriteString(ss.Name.String())
+	out.WriteString(" { ")
+	out.WriteString(strings.Join(fields, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
@@ -0,0 +1,87 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/tshinag/monkey/token"
+)
+
+// SwitchExpression implements switch expression
+type SwitchExpression struct {
+	Token   token.Token // 'switch' トークン
+	Subject Expression
+	Cases   []*SwitchCase
+}
+
+// TokenLiteral implements Node interface
+func (se *SwitchExpression) TokenLiteral() string {
+	return se.Token.Literal
+}
+
+// Pos implements Node interface
+func (se *SwitchExpression) Pos() token.Position {
+	return se.Token.Pos
+}
+
+func (se *SwitchExpression) String() string {
+	var out bytes.Buffer
+
+	cases := []string{}
+	for _, c := range se.Cases {
+		cases = append(cases, c.String())
+	}
+
+	out.WriteString("switch (")
+	out.WriteString(se.Subject.String())
+	out.WriteString(") {")
+	out.WriteString(strings.Join(cases, " "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// SwitchCase implements "case" or "default" clause of switch expression
+type SwitchCase struct {
+	Token       token.Token // 'case' または 'default' トークン
+	Values      []Expression
+	Body        *BlockStatement
+	Fallthrough bool
+}
+
+// TokenLiteral implements Node interface
+func (sc *SwitchCase) TokenLiteral() string {
+	return sc.Token.Literal
+}
+
+// Pos implements Node interface
+func (sc *SwitchCase) Pos() token.Position {
+	return sc.Token.Pos
+}
+
+// IsDefault reports whether the clause is "default"
+func (sc *SwitchCase) IsDefault() bool {
+	return sc.Token.Type == token.DEFAULT
+}
+
+func (sc *SwitchCase) String() string {
+	var out bytes.Buffer
+
+	values := []string{}
+	for _, v := range sc.Values {
+		values = append(values, v.String())
+	}
+
+	out.WriteString(sc.TokenLiteral())
+	if !sc.IsDefault() {
+		out.WriteString(" ")
+		out.WriteString(strings.Join(values, ", "))
+	}
+	out.WriteString(": ")
+	out.WriteString(sc.Body.String())
+	if sc.Fallthrough {
+		out.WriteString(" fallthrough;")
+	}
+
+	return out.String()
+}
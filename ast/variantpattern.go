@@ -0,0 +1,45 @@
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/tshinag/monkey/token"
+)
+
+// VariantPattern implements pattern which matches the enum value
+// like "Result.Ok(value)" or "Option.None"
+type VariantPattern struct {
+	Token   token.Token // enum 名のトークン
+	Enum    *Identifier
+	Variant *Identifier
+	Fields  []Pattern // ペイロードのパターン
+}
+
+// TokenLiteral implements Node interface
+func (vp *VariantPattern) TokenLiteral() string {
+	return vp.Token.Literal
+}
+
+// Pos implements Node interface
+func (vp *VariantPattern) Pos() token.Position {
+	return vp.Token.Pos
+}
+
+func (vp *VariantPattern) String() string {
+	var out bytes.Buffer
+	out.WriteString(vp.Enum.String())
+	out.WriteString(".")
+	out.WriteString(vp.Variant.String())
+	if len(vp.Fields) == 0 {
+		return out.String()
+	}
+	fields := []string{}
+	for _, f := range vp.Fields {
+		fields = append(fields, f.String())
+	}
+	out.WriteString("(")
+	out.WriteString(strings.Join(fields, ", "))
+	out.WriteString(")")
+	return out.String()
+}
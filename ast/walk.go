@@ -0,0 +1,227 @@
+package ast
+
+// Inspect traverses the tree in depth-first order, calling f for each node.
+// If f returns false, the children of the node are skipped.
+// Type annotations are visited as well as statements, expressions and patterns.
+func Inspect(node Node, f func(Node) bool) {
+	if !f(node) {
+		return
+	}
+	for _, child := range children(node) {
+		Inspect(child, f)
+	}
+}
+
+// children returns the non-nil child nodes in source order
+func children(node Node) []Node {
+	c := &childList{}
+	switch n := node.(type) {
+	case *Program:
+		for _, s := range n.Statements {
+			c.add(s)
+		}
+	case *BlockStatement:
+		for _, s := range n.Statements {
+			c.add(s)
+		}
+	case *LetStatement:
+		c.add(n.Name)
+		c.addType(n.Type)
+		c.add(n.Value)
+	case *ConstStatement:
+		c.add(n.Name)
+		c.addType(n.Type)
+		c.add(n.Value)
+	case *ReturnStatement:
+		c.add(n.ReturnValue)
+	case *ExpressionStatement:
+		c.add(n.Expression)
+	case *FunctionStatement:
+		c.add(n.Name)
+		c.add(n.Function)
+	case *StructStatement:
+		c.add(n.Name)
+		for _, f := range n.Fields {
+			c.add(f)
+		}
+	case *ImplStatement:
+		c.add(n.Name)
+		for _, m := range n.Methods {
+			c.add(m)
+		}
+	case *ClassStatement:
+		c.add(n.Name)
+		if n.Superclass != nil {
+			c.add(n.Superclass)
+		}
+		for _, m := range n.Methods {
+			c.add(m)
+		}
+	case *EnumStatement:
+		c.add(n.Name)
+		for _, v := range n.Variants {
+			c.add(v)
+		}
+	case *EnumVariant:
+		c.add(n.Name)
+		for _, f := range n.Fields {
+			c.add(f)
+		}
+	case *PrefixExpression:
+		c.add(n.Right)
+	case *InfixExpression:
+		c.add(n.Left)
+		c.add(n.Right)
+	case *AssignExpression:
+		c.add(n.Target)
+		c.add(n.Value)
+	case *PipeExpression:
+		c.add(n.Left)
+		c.add(n.Right)
+	case *FunctionLiteral:
+		for i, p := range n.Parameters {
+			c.add(p)
+			c.addType(n.ParameterType(i))
+		}
+		c.addType(n.ReturnType)
+		c.add(n.Body)
+	case *CallExpression:
+		c.add(n.Function)
+		for _, a := range n.Arguments {
+			c.add(a)
+		}
+	case *ArrayLiteral:
+		for _, e := range n.Elements {
+			c.add(e)
+		}
+	case *HashLiteral:
+		for _, k := range n.Keys {
+			c.add(k)
+			if v, ok := n.Pairs[k]; ok {
+				c.add(v)
+			}
+		}
+	case *IndexExpression:
+		c.add(n.Left)
+		c.add(n.Index)
+	case *SliceExpression:
+		c.add(n.Left)
+		c.add(n.Start)
+		c.add(n.End)
+		c.add(n.Step)
+	case *PropertyExpression:
+		c.add(n.Left)
+		c.add(n.Property)
+	case *SpreadElement:
+		c.add(n.Value)
+	case *IfExpression:
+		c.add(n.Condition)
+		c.add(n.Consequence)
+		if n.ElseIf != nil {
+			c.add(n.ElseIf)
+		}
+		if n.Alternative != nil {
+			c.add(n.Alternative)
+		}
+	case *MatchExpression:
+		c.add(n.Subject)
+		for _, arm := range n.Arms {
+			c.add(arm)
+		}
+	case *MatchArm:
+		c.add(n.Pattern)
+		c.add(n.Guard)
+		c.add(n.Body)
+	case *SwitchExpression:
+		c.add(n.Subject)
+		for _, sc := range n.Cases {
+			c.add(sc)
+		}
+	case *SwitchCase:
+		for _, v := range n.Values {
+			c.add(v)
+		}
+		c.add(n.Body)
+	case *ForExpression:
+		c.add(n.Pattern)
+		c.add(n.Iterable)
+		c.add(n.Body)
+	case *SelectExpression:
+		for _, sc := range n.Cases {
+			c.add(sc)
+		}
+	case *SelectCase:
+		if n.Name != nil {
+			c.add(n.Name)
+		}
+		if n.Operation != nil {
+			c.add(n.Operation)
+		}
+		c.add(n.Body)
+	case *YieldExpression:
+		c.add(n.Value)
+	case *AwaitExpression:
+		c.add(n.Value)
+	case *BindingPattern:
+		c.add(n.Name)
+	case *LiteralPattern:
+		c.add(n.Value)
+	case *AlternativePattern:
+		for _, a := range n.Alternatives {
+			c.add(a)
+		}
+	case *ArrayPattern:
+		for _, e := range n.Elements {
+			c.add(e)
+		}
+		if n.Rest != nil {
+			c.add(n.Rest)
+		}
+	case *HashPattern:
+		for _, pair := range n.Pairs {
+			c.add(pair.Key)
+			c.add(pair.Value)
+		}
+		if n.Rest != nil {
+			c.add(n.Rest)
+		}
+	case *RestPattern:
+		if n.Name != nil {
+			c.add(n.Name)
+		}
+	case *VariantPattern:
+		c.add(n.Enum)
+		c.add(n.Variant)
+		for _, f := range n.Fields {
+			c.add(f)
+		}
+	case *ArrayType:
+		c.addType(n.Element)
+	case *HashType:
+		c.addType(n.Key)
+		c.addType(n.Value)
+	case *FunctionType:
+		for _, p := range n.Parameters {
+			c.addType(p)
+		}
+		c.addType(n.Return)
+	}
+	return c.nodes
+}
+
+type childList struct {
+	nodes []Node
+}
+
+// add appends the node unless it is a nil interface
+func (c *childList) add(node Node) {
+	if node != nil {
+		c.nodes = append(c.nodes, node)
+	}
+}
+
+func (c *childList) addType(t TypeExpression) {
+	if t != nil {
+		c.nodes = append(c.nodes, t)
+	}
+}
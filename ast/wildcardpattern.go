@@ -0,0 +1,22 @@
+package ast
+
+import "github.com/tshinag/monkey/token"
+
+// WildcardPattern implements "_" pattern which matches any value
+type WildcardPattern struct {
+	Token token.Token // '_' トークン
+}
+
+// TokenLiteral implements Node interface
+func (wp *WildcardPattern) TokenLiteral() string {
+	return wp.Token.Literal
+}
+
+// Pos implements Node interface
+func (wp *WildcardPattern) Pos() token.Position {
+	return wp.Token.Pos
+}
+
+func (wp *WildcardPattern) String() string {
+	return wp.Token.Literal
+}
@@ -0,0 +1,35 @@
+package ast
+
+import (
+	"bytes"
+
+	"github.com/tshinag/monkey/token"
+)
+
+// YieldExpression implements yield expression in generator functions
+type YieldExpression struct {
+	Token token.Token // 'yield' トークン
+	Value Expression  // 値を省略した場合は nil
+}
+
+// TokenLiteral implements Node interface
+func (ye *YieldExpression) TokenLiteral() string {
+	return ye.Token.Literal
+}
+
+// Pos implements Node interface
+func (ye *YieldExpression) Pos() token.Position {
+	return ye.Token.Pos
+}
+
+func (ye *YieldExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ye.TokenLiteral())
+	if ye.Value != nil {
+		out.WriteString(" ")
+		out.WriteString(ye.Value.String())
+	}
+
+	return out.String()
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/tshinag/monkey/types"
+)
+
+// check reports the type errors of the scripts without running them
+func check(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintf(stderr, "usage: monkey check FILE...\n")
+		return 2
+	}
+	status := 0
+	for _, path := range args {
+		program, ok := parseFile(path, stderr)
+		if !ok {
+			status = 1
+			continue
+		}
+		for _, err := range types.Check(program) {
+			fmt.Fprintf(stdout, "%s:%s\n", path, err)
+			status = 1
+		}
+	}
+	return status
+}
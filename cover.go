@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tshinag/monkey/coverage"
+)
+
+// cover prints the sources annotated with the counts of the coverage profile
+func cover(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cover", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	htmlOutput := flags.Bool("html", false, "write an HTML page instead of text")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintf(stderr, "usage: monkey cover [-html] PROFILE\n")
+		return 2
+	}
+
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "%s\n", err)
+		return 1
+	}
+	defer f.Close()
+	profile, err := coverage.ReadProfile(f)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", flags.Arg(0), err)
+		return 1
+	}
+	write := profile.WriteText
+	if *htmlOutput {
+		write = profile.WriteHTML
+	}
+	if err := write(stdout); err != nil {
+		fmt.Fprintf(stderr, "%s\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,131 @@
+package coverage
+
+import (
+	"sort"
+	"sync/atomic"
+
+	"github.com/tshinag/monkey/ast"
+	"github.com/tshinag/monkey/evaluator"
+	"github.com/tshinag/monkey/token"
+)
+
+type statementCounter struct {
+	file  string
+	pos   token.Position
+	count int64
+}
+
+type branchCounter struct {
+	file      string
+	pos       token.Position
+	then, els int64
+}
+
+// Coverage counts the evaluations of the statements and the arms of the if expressions
+// in the programs added to it. The counters are updated atomically, so that tasks can run concurrently.
+type Coverage struct {
+	statements map[ast.Statement]*statementCounter
+	branches   map[*ast.IfExpression]*branchCounter
+}
+
+// New returns the coverage without programs
+func New() *Coverage {
+	return &Coverage{
+		statements: make(map[ast.Statement]*statementCounter),
+		branches:   make(map[*ast.IfExpression]*branchCounter),
+	}
+}
+
+// Add registers the statements and the if expressions of the program in the file.
+// It must be called before Start.
+func (c *Coverage) Add(file string, program *ast.Program) {
+	ast.Inspect(program, func(node ast.Node) bool {
+		var statements []ast.Statement
+		switch node := node.(type) {
+		case *ast.Program:
+			statements = node.Statements
+		case *ast.BlockStatement:
+			statements = node.Statements
+		case *ast.IfExpression:
+			c.branches[node] = &branchCounter{file: file, pos: node.Pos()}
+		}
+		for _, s := range statements {
+			c.statements[s] = &statementCounter{file: file, pos: s.Pos()}
+		}
+		return true
+	})
+}
+
+// Start attaches the coverage to the evaluator
+func (c *Coverage) Start() {
+	evaluator.SetCoverage(c)
+}
+
+// Stop detaches the coverage from the evaluator
+func (c *Coverage) Stop() {
+	evaluator.SetCoverage(nil)
+}
+
+// Statement implements evaluator.Coverage
+func (c *Coverage) Statement(stmt ast.Statement) {
+	// 追加されていないプログラムの文は数えない
+	if counter, ok := c.statements[stmt]; ok {
+		atomic.AddInt64(&counter.count, 1)
+	}
+}
+
+// Branch implements evaluator.Coverage
+func (c *Coverage) Branch(ie *ast.IfExpression, taken bool) {
+	counter, ok := c.branches[ie]
+	if !ok {
+		return
+	}
+	if taken {
+		atomic.AddInt64(&counter.then, 1)
+	} else {
+		atomic.AddInt64(&counter.els, 1)
+	}
+}
+
+// Profile returns the counts so far
+func (c *Coverage) Profile() *Profile {
+	p := &Profile{}
+	for _, counter := range c.statements {
+		p.Statements = append(p.Statements, Statement{
+			File:  counter.file,
+			Pos:   counter.pos,
+			Count: atomic.LoadInt64(&counter.count),
+		})
+	}
+	for _, counter := range c.branches {
+		p.Branches = append(p.Branches, Branch{
+			File: counter.file,
+			Pos:  counter.pos,
+			Then: atomic.LoadInt64(&counter.then),
+			Else: atomic.LoadInt64(&counter.els),
+		})
+	}
+	p.sort()
+	return p
+}
+
+func less(fileA string, a token.Position, fileB string, b token.Position) bool {
+	if fileA != fileB {
+		return fileA < fileB
+	}
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Column < b.Column
+}
+
+func (p *Profile) sort() {
+	sort.Slice(p.Statements, func(i, j int) bool {
+		a, b := p.Statements[i], p.Statements[j]
+		return less(a.File, a.Pos, b.File, b.Pos)
+	})
+	sort.Slice(p.Branches, func(i, j int) bool {
+		a, b := p.Branches[i], p.Branches[j]
+		return less(a.File, a.Pos, b.File, b.Pos)
+	})
+}
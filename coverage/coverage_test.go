@@ -0,0 +1,172 @@
+package coverage
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tshinag/monkey/evaluator"
+	"github.com/tshinag/monkey/lexer"
+	"github.com/tshinag/monkey/object"
+	"github.com/tshinag/monkey/parser"
+)
+
+const testScript = `let sign = fn(n) {
+  if (n < 0) {
+    -1
+  } else if (n == 0) {
+    0
+  } else {
+    1
+  }
+}
+sign(1) + sign(2)
+if (false) { 1 }`
+
+const testProfile = `mode: count
+stmt test.mk:1:1 1
+stmt test.mk:2:3 2
+stmt test.mk:3:5 0
+stmt test.mk:5:5 0
+stmt test.mk:7:5 2
+stmt test.mk:10:1 1
+stmt test.mk:11:1 1
+stmt test.mk:11:14 0
+if test.mk:2:3 0 2
+if test.mk:4:10 0 2
+if test.mk:11:1 0 1
+`
+
+func measure(t *testing.T, file, source string) *Profile {
+	t.Helper()
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has errors: %v", p.Errors())
+	}
+	c := New()
+	c.Add(file, program)
+	c.Start()
+	evaluator.Eval(program, object.NewEnvironment())
+	c.Stop()
+	return c.Profile()
+}
+
+func TestProfile(t *testing.T) {
+	profile := measure(t, "test.mk", testScript)
+	var out bytes.Buffer
+	if err := profile.Write(&out); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	}
+	if out.String() != testProfile {
+		t.Errorf("wrong profile. expected=\n%s\ngot=\n%s", testProfile, out.String())
+	}
+
+	expected := "62.5% of statements (5/8), 50.0% of branches (3/6)"
+	if summary := profile.Summary().String(); summary != expected {
+		t.Errorf("wrong summary. expected=%q, got=%q", expected, summary)
+	}
+}
+
+func TestReadProfile(t *testing.T) {
+	// 二回分のプロファイルを連結すると足し合わされる
+	profile, err := ReadProfile(strings.NewReader(testProfile + testProfile))
+	if err != nil {
+		t.Fatalf("ReadProfile failed: %s", err)
+	}
+	var out bytes.Buffer
+	profile.Write(&out)
+	expected := strings.NewReplacer(" 1\n", " 2\n", " 2\n", " 4\n").Replace(testProfile)
+	if out.String() != expected {
+		t.Errorf("wrong profile. expected=\n%s\ngot=\n%s", expected, out.String())
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"stmt test.mk:1 1", "line 1: invalid location \"test.mk:1\""},
+		{"stmt test.mk:1:1 -1", "line 1: invalid count \"-1\""},
+		{"mode: count\nif test.mk:1:1 1", "line 2: invalid record \"if test.mk:1:1 1\""},
+		{"branch test.mk:1:1 1 1", "line 1: invalid record \"branch test.mk:1:1 1 1\""},
+	}
+	for _, tt := range tests {
+		_, err := ReadProfile(strings.NewReader(tt.input))
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	f, err := ioutil.TempFile("", "cover*.mk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(testScript + "\n")
+	f.Close()
+
+	profile := measure(t, f.Name(), testScript)
+	var out bytes.Buffer
+	if err := profile.WriteText(&out); err != nil {
+		t.Fatalf("WriteText failed: %s", err)
+	}
+	expected := f.Name() + `: 62.5% of statements (5/8), 50.0% of branches (3/6)
+      1    1 | let sign = fn(n) {
+      2    2 |   if (n < 0) {  [then 0, else 2]
+  #####    3 |     -1
+      -    4 |   } else if (n == 0) {  [then 0, else 2]
+  #####    5 |     0
+      -    6 |   } else {
+      2    7 |     1
+      -    8 |   }
+      -    9 | }
+      1   10 | sign(1) + sign(2)
+     1*   11 | if (false) { 1 }  [then 0, else 1]
+`
+	if out.String() != expected {
+		t.Errorf("wrong text. expected=\n%s\ngot=\n%s", expected, out.String())
+	}
+
+	out.Reset()
+	if err := profile.WriteHTML(&out); err != nil {
+		t.Fatalf("WriteHTML failed: %s", err)
+	}
+	for _, s := range []string{
+		`<span class="uncovered"><span class="count">0</span>    -1</span>`,
+		`<span class="covered"><span class="count">2</span>  if (n &lt; 0) {</span><span class="branch partial">[then 0, else 2]</span>`,
+		`<span class="missed"><span class="count">1*</span>if (false) { 1 }</span>`,
+	} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("HTML doesn't contain %q", s)
+		}
+	}
+}
+
+func TestWriteTextMissedStatements(t *testing.T) {
+	// 関数の本体が呼ばれなかった行は、定義が評価されても "*" を付ける
+	source := "let g = fn() { 1 };\nlet h = fn() { 2 }; h()"
+	f, err := ioutil.TempFile("", "cover*.mk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(source + "\n")
+	f.Close()
+
+	profile := measure(t, f.Name(), source)
+	var out bytes.Buffer
+	if err := profile.WriteText(&out); err != nil {
+		t.Fatalf("WriteText failed: %s", err)
+	}
+	expected := f.Name() + `: 80.0% of statements (4/5), 100.0% of branches (0/0)
+     1*    1 | let g = fn() { 1 };
+      1    2 | let h = fn() { 2 }; h()
+`
+	if out.String() != expected {
+		t.Errorf("wrong text. expected=\n%s\ngot=\n%s", expected, out.String())
+	}
+}
@@ -0,0 +1,208 @@
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tshinag/monkey/token"
+)
+
+// profileHeader is the first line of the profile file
+const profileHeader = "mode: count"
+
+// Profile is the counts of the statements and the branches sorted by the positions
+type Profile struct {
+	Statements []Statement
+	Branches   []Branch
+}
+
+// Statement is the number of times the statement was evaluated
+type Statement struct {
+	File  string
+	Pos   token.Position
+	Count int64
+}
+
+// Branch is the number of times each arm of the if expression was taken.
+// Else counts the evaluations where the condition was falsy, even without else.
+type Branch struct {
+	File string
+	Pos  token.Position
+	Then int64
+	Else int64
+}
+
+// Write writes the profile as text, such as:
+//
+//	mode: count
+//	stmt main.mk:1:1 1
+//	if main.mk:2:1 1 0
+func (p *Profile) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, profileHeader)
+	for _, s := range p.Statements {
+		fmt.Fprintf(bw, "stmt %s:%s %d\n", s.File, s.Pos, s.Count)
+	}
+	for _, b := range p.Branches {
+		fmt.Fprintf(bw, "if %s:%s %d %d\n", b.File, b.Pos, b.Then, b.Else)
+	}
+	return bw.Flush()
+}
+
+// ReadProfile reads the profile written by Write.
+// The counts of the same position are added up, so that the profiles of several runs can be concatenated.
+func ReadProfile(r io.Reader) (*Profile, error) {
+	p := &Profile{}
+	statements := map[string]int{}
+	branches := map[string]int{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line == profileHeader {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			return nil, errors.Errorf("line %d: invalid record %q", n, line)
+		}
+		file, pos, err := parseLocation(fields[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", n)
+		}
+		counts, err := parseCounts(fields[2:])
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", n)
+		}
+		key := fields[1]
+		switch {
+		case fields[0] == "stmt" && len(counts) == 1:
+			i, ok := statements[key]
+			if !ok {
+				i = len(p.Statements)
+				statements[key] = i
+				p.Statements = append(p.Statements, Statement{File: file, Pos: pos})
+			}
+			p.Statements[i].Count += counts[0]
+		case fields[0] == "if" && len(counts) == 2:
+			i, ok := branches[key]
+			if !ok {
+				i = len(p.Branches)
+				branches[key] = i
+				p.Branches = append(p.Branches, Branch{File: file, Pos: pos})
+			}
+			p.Branches[i].Then += counts[0]
+			p.Branches[i].Else += counts[1]
+		default:
+			return nil, errors.Errorf("line %d: invalid record %q", n, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	p.sort()
+	return p, nil
+}
+
+// parseLocation parses "file:line:column", where the file may contain colons
+func parseLocation(s string) (string, token.Position, error) {
+	col := strings.LastIndex(s, ":")
+	if col < 0 {
+		return "", token.Position{}, errors.Errorf("invalid location %q", s)
+	}
+	line := strings.LastIndex(s[:col], ":")
+	if line < 0 {
+		return "", token.Position{}, errors.Errorf("invalid location %q", s)
+	}
+	l, err1 := strconv.Atoi(s[line+1 : col])
+	c, err2 := strconv.Atoi(s[col+1:])
+	if err1 != nil || err2 != nil {
+		return "", token.Position{}, errors.Errorf("invalid location %q", s)
+	}
+	return s[:line], token.Position{Line: l, Column: c}, nil
+}
+
+func parseCounts(fields []string) ([]int64, error) {
+	counts := make([]int64, len(fields))
+	for i, f := range fields {
+		count, err := strconv.ParseInt(f, 10, 64)
+		if err != nil || count < 0 {
+			return nil, errors.Errorf("invalid count %q", f)
+		}
+		counts[i] = count
+	}
+	return counts, nil
+}
+
+// Summary is the number of the statements and the branches, and how many of them were covered.
+// Each if expression has two branches.
+type Summary struct {
+	Statements        int
+	CoveredStatements int
+	Branches          int
+	CoveredBranches   int
+}
+
+// Summary returns the summary of the files, or of all the files if no files are given
+func (p *Profile) Summary(files ...string) Summary {
+	included := func(file string) bool {
+		if len(files) == 0 {
+			return true
+		}
+		for _, f := range files {
+			if f == file {
+				return true
+			}
+		}
+		return false
+	}
+	var s Summary
+	for _, stmt := range p.Statements {
+		if !included(stmt.File) {
+			continue
+		}
+		s.Statements++
+		if stmt.Count > 0 {
+			s.CoveredStatements++
+		}
+	}
+	for _, b := range p.Branches {
+		if !included(b.File) {
+			continue
+		}
+		s.Branches += 2
+		if b.Then > 0 {
+			s.CoveredBranches++
+		}
+		if b.Else > 0 {
+			s.CoveredBranches++
+		}
+	}
+	return s
+}
+
+// StatementPercent returns the percentage of the covered statements, which is 100 without statements
+func (s Summary) StatementPercent() float64 {
+	return percent(s.CoveredStatements, s.Statements)
+}
+
+// BranchPercent returns the percentage of the covered branches, which is 100 without branches
+func (s Summary) BranchPercent() float64 {
+	return percent(s.CoveredBranches, s.Branches)
+}
+
+func percent(covered, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return float64(covered) * 100 / float64(total)
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("%.1f%% of statements (%d/%d), %.1f%% of branches (%d/%d)",
+		s.StatementPercent(), s.CoveredStatements, s.Statements,
+		s.BranchPercent(), s.CoveredBranches, s.Branches)
+}
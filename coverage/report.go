@@ -0,0 +1,206 @@
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// annotatedLine is a line of the source with the counts of the statements starting on it
+type annotatedLine struct {
+	text       string
+	statements []int64
+	branches   []Branch
+}
+
+// count returns the largest count of the statements on the line, or -1 if the line has no statements
+func (l *annotatedLine) count() int64 {
+	count := int64(-1)
+	for _, c := range l.statements {
+		if c > count {
+			count = c
+		}
+	}
+	return count
+}
+
+// missed reports whether a statement on the line was never evaluated while another was,
+// as in "let g = fn() { 1 };" where the body of the function is never called
+func (l *annotatedLine) missed() bool {
+	for _, c := range l.statements {
+		if c == 0 {
+			return l.count() > 0
+		}
+	}
+	return false
+}
+
+func (l *annotatedLine) branchText() string {
+	arms := []string{}
+	for _, b := range l.branches {
+		arms = append(arms, fmt.Sprintf("[then %d, else %d]", b.Then, b.Else))
+	}
+	return strings.Join(arms, " ")
+}
+
+// partial reports whether an arm of an if expression on the line was never taken
+func (l *annotatedLine) partial() bool {
+	for _, b := range l.branches {
+		if b.Then == 0 || b.Else == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// files returns the files in the profile in order
+func (p *Profile) files() []string {
+	seen := map[string]bool{}
+	files := []string{}
+	for _, s := range p.Statements {
+		if !seen[s.File] {
+			seen[s.File] = true
+			files = append(files, s.File)
+		}
+	}
+	for _, b := range p.Branches {
+		if !seen[b.File] {
+			seen[b.File] = true
+			files = append(files, b.File)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// annotate reads the source of the file and attaches the counts to the lines
+func (p *Profile) annotate(file string) ([]*annotatedLine, error) {
+	source, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read source")
+	}
+	lines := []*annotatedLine{}
+	for _, text := range strings.Split(strings.TrimSuffix(string(source), "\n"), "\n") {
+		lines = append(lines, &annotatedLine{text: strings.TrimRight(text, "\r")})
+	}
+	at := func(line int) *annotatedLine {
+		if line < 1 || line > len(lines) {
+			return &annotatedLine{}
+		}
+		return lines[line-1]
+	}
+	for _, s := range p.Statements {
+		if s.File == file {
+			l := at(s.Pos.Line)
+			l.statements = append(l.statements, s.Count)
+		}
+	}
+	for _, b := range p.Branches {
+		if b.File == file {
+			l := at(b.Pos.Line)
+			l.branches = append(l.branches, b)
+		}
+	}
+	return lines, nil
+}
+
+// WriteText writes the sources annotated with the counts in the style of gcov.
+// The count of a line is the largest count of the statements starting on it, followed by "*"
+// if some of them were never evaluated. "#####" marks the lines never evaluated
+// and "-" the lines without statements.
+func (p *Profile) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for i, file := range p.files() {
+		lines, err := p.annotate(file)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			fmt.Fprintln(bw)
+		}
+		fmt.Fprintf(bw, "%s: %s\n", file, p.Summary(file))
+		for n, l := range lines {
+			count := "-"
+			switch c := l.count(); {
+			case c == 0:
+				count = "#####"
+			case c > 0:
+				count = strconv.FormatInt(c, 10)
+				if l.missed() {
+					count += "*"
+				}
+			}
+			text := l.text
+			if branches := l.branchText(); branches != "" {
+				text += "  " + branches
+			}
+			fmt.Fprintf(bw, "%7s %4d | %s\n", count, n+1, text)
+		}
+	}
+	return bw.Flush()
+}
+
+const htmlHeader = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Monkey coverage</title>
+<style>
+body { font-family: sans-serif; }
+pre { line-height: 1.4; }
+.count { color: #888; display: inline-block; width: 5em; text-align: right; margin-right: 1em; }
+.covered { background: #dfd; }
+.uncovered { background: #fdd; }
+.missed { background: #ffd; }
+.branch { color: #06c; margin-left: 2em; }
+.branch.partial { color: #c60; font-weight: bold; }
+</style>
+</head>
+<body>
+`
+
+// WriteHTML writes the sources annotated with the counts as an HTML page.
+// The lines with covered statements are green, the lines never evaluated are red,
+// and the lines with both are yellow.
+func (p *Profile) WriteHTML(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprint(bw, htmlHeader)
+	fmt.Fprintf(bw, "<h1>Coverage: %s</h1>\n", html.EscapeString(p.Summary().String()))
+	for _, file := range p.files() {
+		lines, err := p.annotate(file)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(bw, "<h2>%s: %s</h2>\n<pre>\n", html.EscapeString(file), html.EscapeString(p.Summary(file).String()))
+		for _, l := range lines {
+			class, count := "", ""
+			switch c := l.count(); {
+			case c == 0:
+				class, count = "uncovered", "0"
+			case c > 0 && l.missed():
+				class, count = "missed", strconv.FormatInt(c, 10)+"*"
+			case c > 0:
+				class, count = "covered", strconv.FormatInt(c, 10)
+			}
+			fmt.Fprintf(bw, `<span class="%s"><span class="count">%s</span>%s</span>`, class, count, html.EscapeString(l.text))
+			if branches := l.branchText(); branches != "" {
+				branchClass := "branch"
+				if l.partial() {
+					branchClass += " partial"
+				}
+				fmt.Fprintf(bw, `<span class="%s">%s</span>`, branchClass, html.EscapeString(branches))
+			}
+			fmt.Fprintln(bw)
+		}
+		fmt.Fprint(bw, "</pre>\n")
+	}
+	fmt.Fprint(bw, "</body>\n</html>\n")
+	return bw.Flush()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	"github.com/tshinag/monkey/dap"
+)
+
+// serveDAP runs the debug adapter on stdin and stdout, or on the first connection to the address.
+// The script given on the command line is the one debugged on attach requests.
+func serveDAP(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("dap", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	listen := flags.String("listen", "", "the TCP address such as 127.0.0.1:4711 to accept the client on")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 1 {
+		fmt.Fprintf(stderr, "usage: monkey dap [-listen ADDR] [FILE]\n")
+		return 2
+	}
+
+	var server *dap.Server
+	if *listen == "" {
+		server = dap.NewServer(os.Stdin, stdout, stderr)
+	} else {
+		listener, err := net.Listen("tcp", *listen)
+		if err != nil {
+			fmt.Fprintf(stderr, "dap: %s\n", err)
+			return 1
+		}
+		fmt.Fprintf(stderr, "dap: listening on %s\n", listener.Addr())
+		conn, err := listener.Accept()
+		listener.Close()
+		if err != nil {
+			fmt.Fprintf(stderr, "dap: %s\n", err)
+			return 1
+		}
+		defer conn.Close()
+		server = dap.NewServer(conn, conn, stderr)
+	}
+	server.Program = flags.Arg(0)
+	if err := server.Serve(); err != nil {
+		fmt.Fprintf(stderr, "dap: %s\n", err)
+		return 1
+	}
+	return 0
+}
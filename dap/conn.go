@@ -0,0 +1,112 @@
+package dap
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/tshinag/monkey/internal/framing"
+)
+
+// message is any message of DAP. It is only used for reading, since the fields depend on the type.
+type message struct {
+	Seq        int             `json:"seq"`
+	Type       string          `json:"type"` // "request", "response" または "event"
+	Command    string          `json:"command"`
+	Arguments  json.RawMessage `json:"arguments"`
+	Event      string          `json:"event"`
+	RequestSeq int             `json:"request_seq"`
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// header is the fields common to the messages written
+type header struct {
+	Seq  int    `json:"seq"`
+	Type string `json:"type"`
+}
+
+func (h *header) setSeq(seq int) {
+	h.Seq = seq
+}
+
+// sequenced is the message numbered when it is written
+type sequenced interface {
+	setSeq(seq int)
+}
+
+type request struct {
+	header
+	Command   string      `json:"command"`
+	Arguments interface{} `json:"arguments,omitempty"`
+}
+
+type response struct {
+	header
+	RequestSeq int         `json:"request_seq"`
+	Success    bool        `json:"success"`
+	Command    string      `json:"command"`
+	Message    string      `json:"message,omitempty"` // 失敗した場合の理由
+	Body       interface{} `json:"body,omitempty"`
+}
+
+type event struct {
+	header
+	Event string      `json:"event"`
+	Body  interface{} `json:"body,omitempty"`
+}
+
+// conn reads and writes the messages of DAP with its base protocol,
+// numbering the messages written
+type conn struct {
+	framed *framing.Conn
+	mutex  sync.Mutex // seq の採番と書き込みの順序を揃える
+	seq    int
+}
+
+func newConn(r io.Reader, w io.Writer) *conn {
+	return &conn{framed: framing.NewConn(r, w)}
+}
+
+// read returns the next message. It returns io.EOF at the end of the stream.
+func (c *conn) read() (*message, error) {
+	content, err := c.framed.Read()
+	if err != nil {
+		return nil, err
+	}
+	m := &message{}
+	if err := json.Unmarshal(content, m); err != nil {
+		return nil, errors.Wrap(err, "invalid message")
+	}
+	return m, nil
+}
+
+func (c *conn) write(m sequenced) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.seq++
+	m.setSeq(c.seq)
+	content, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return c.framed.Write(content)
+}
+
+// reply sends the response to the request. If err is not nil, the request failed.
+func (c *conn) reply(req *message, body interface{}, err error) error {
+	r := &response{header: header{Type: "response"}, RequestSeq: req.Seq, Command: req.Command, Success: err == nil}
+	if err != nil {
+		r.Message = err.Error()
+	} else {
+		r.Body = body
+	}
+	return c.write(r)
+}
+
+// send sends the event
+func (c *conn) send(name string, body interface{}) error {
+	return c.write(&event{header: header{Type: "event"}, Event: name, Body: body})
+}
@@ -0,0 +1,373 @@
+package dap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testScript = `let fact = fn(n) {
+  if (n < 2) {
+    return 1
+  }
+  n * fact(n - 1)
+}
+let xs = [1, {"a": 2}]
+puts(fact(3))
+xs`
+
+func TestSession(t *testing.T) {
+	path := writeScript(t, testScript)
+	defer os.Remove(path)
+	c := newTestClient(t, "")
+
+	var capabilities Capabilities
+	if err := c.call("initialize", nil, &capabilities); err != nil {
+		t.Fatalf("initialize failed: %s", err)
+	}
+	if !capabilities.SupportsConfigurationDoneRequest || !capabilities.SupportsConditionalBreakpoints {
+		t.Errorf("wrong capabilities: %+v", capabilities)
+	}
+	if err := c.call("threads", nil, nil); err == nil || err.Error() != "no program launched" {
+		t.Errorf("expected no program launched error, got=%v", err)
+	}
+	if err := c.call("launch", LaunchArguments{Program: path, StopOnEntry: true}, nil); err != nil {
+		t.Fatalf("launch failed: %s", err)
+	}
+	c.event("initialized")
+
+	var breakpoints SetBreakpointsResponse
+	err := c.call("setBreakpoints", SetBreakpointsArguments{
+		Source:      Source{Path: path},
+		Breakpoints: []SourceBreakpoint{{Line: 5, Condition: "n == 2"}, {Line: 8, Condition: "n =="}},
+	}, &breakpoints)
+	if err != nil {
+		t.Fatalf("setBreakpoints failed: %s", err)
+	}
+	expectBreakpoints(t, breakpoints, []string{"1 5 verified", "0 8 could not parse \"n ==\": no prefix parse function for EOF found"})
+	breakpoints = SetBreakpointsResponse{}
+	err = c.call("setBreakpoints", SetBreakpointsArguments{
+		Source:      Source{Path: "other.mk"},
+		Breakpoints: []SourceBreakpoint{{Line: 1}},
+	}, &breakpoints)
+	if err != nil {
+		t.Fatalf("setBreakpoints failed: %s", err)
+	}
+	expectBreakpoints(t, breakpoints, []string{"0 1 breakpoints can only be set in " + path})
+
+	if err := c.call("configurationDone", nil, nil); err != nil {
+		t.Fatalf("configurationDone failed: %s", err)
+	}
+	expectStopped(t, c.event("stopped"), "entry", nil)
+	c.expectStackTrace([]string{"<main> 1:1"})
+
+	c.resume("continue")
+	expectStopped(t, c.event("stopped"), "breakpoint", []int{1})
+	c.expectStackTrace([]string{"fact 5:3", "fact 5:3", "<main> 8:1"})
+
+	var evaluated EvaluateResponse
+	if err := c.call("evaluate", EvaluateArguments{Expression: "n * 10", FrameID: 2}, &evaluated); err != nil {
+		t.Fatalf("evaluate failed: %s", err)
+	}
+	if evaluated.Result != "30" || evaluated.Type != "INTEGER" {
+		t.Errorf("wrong evaluation: %+v", evaluated)
+	}
+	if err := c.call("evaluate", EvaluateArguments{Expression: "m"}, nil); err == nil || err.Error() != "identifier not found: m" {
+		t.Errorf("expected identifier not found error, got=%v", err)
+	}
+
+	scopes := c.scopes(1)
+	if len(scopes) != 2 || scopes[0].Name != "local" || scopes[1].Name != "global" {
+		t.Fatalf("wrong scopes: %+v", scopes)
+	}
+	expectVariables(t, c.variables(scopes[0].VariablesReference), []string{"n = 2 (INTEGER)"})
+
+	c.resume("stepOut")
+	expectStopped(t, c.event("stopped"), "step", nil)
+	c.expectStackTrace([]string{"<main> 9:1"})
+	scopes = c.scopes(1)
+	if len(scopes) != 1 || scopes[0].Name != "global" {
+		t.Fatalf("wrong scopes: %+v", scopes)
+	}
+	globals := c.variables(scopes[0].VariablesReference)
+	expectVariables(t, globals, []string{"fact = fn fact(n) (FUNCTION)", "xs = [1, {a: 2}] (ARRAY) +"})
+	elements := c.variables(globals[1].VariablesReference)
+	expectVariables(t, elements, []string{"[0] = 1 (INTEGER)", "[1] = {a: 2} (HASH) +"})
+	expectVariables(t, c.variables(elements[1].VariablesReference), []string{"a = 2 (INTEGER)"})
+
+	c.resume("next")
+	var exited ExitedEvent
+	json.Unmarshal(c.event("exited"), &exited)
+	if exited.ExitCode != 0 {
+		t.Errorf("wrong exit code: %d", exited.ExitCode)
+	}
+	c.event("terminated")
+	if output := c.output(); output != "stdout: 6\n" {
+		t.Errorf("wrong output: %q", output)
+	}
+	if err := c.call("continue", nil, nil); err == nil || err.Error() != "program is not stopped" {
+		t.Errorf("expected program is not stopped error, got=%v", err)
+	}
+
+	if err := c.call("disconnect", nil, nil); err != nil {
+		t.Fatalf("disconnect failed: %s", err)
+	}
+	if err := c.wait(); err != nil {
+		t.Errorf("Serve returned error: %s", err)
+	}
+}
+
+func TestAttach(t *testing.T) {
+	path := writeScript(t, "let f = fn() { 1 }\nf(1, 2)")
+	defer os.Remove(path)
+
+	c := newTestClient(t, "")
+	if err := c.call("attach", AttachArguments{}, nil); err == nil || err.Error() != "no program to attach to" {
+		t.Errorf("expected no program error, got=%v", err)
+	}
+	if err := c.call("launch", LaunchArguments{Program: "none.mk"}, nil); err == nil {
+		t.Errorf("launch of missing program succeeded")
+	}
+	c.call("disconnect", nil, nil)
+
+	c = newTestClient(t, path)
+	if err := c.call("attach", AttachArguments{}, nil); err != nil {
+		t.Fatalf("attach failed: %s", err)
+	}
+	c.event("initialized")
+	if err := c.call("configurationDone", nil, nil); err != nil {
+		t.Fatalf("configurationDone failed: %s", err)
+	}
+	var exited ExitedEvent
+	json.Unmarshal(c.event("exited"), &exited)
+	if exited.ExitCode != 1 {
+		t.Errorf("wrong exit code: %d", exited.ExitCode)
+	}
+	c.event("terminated")
+	expected := "stderr: " + path + ": ERROR: wrong number of arguments to `f`. got=2, want=0\n"
+	if output := c.output(); output != expected {
+		t.Errorf("wrong output. expected=%q, got=%q", expected, output)
+	}
+	c.call("disconnect", nil, nil)
+
+	// 停止中に切断するとスクリプトは破棄される
+	c = newTestClient(t, path)
+	c.call("attach", AttachArguments{StopOnEntry: true}, nil)
+	c.event("initialized")
+	c.call("configurationDone", nil, nil)
+	c.event("stopped")
+	if err := c.call("disconnect", nil, nil); err != nil {
+		t.Fatalf("disconnect failed: %s", err)
+	}
+	if err := c.wait(); err != nil {
+		t.Errorf("Serve returned error: %s", err)
+	}
+}
+
+func writeScript(t *testing.T, source string) string {
+	t.Helper()
+	f, err := ioutil.TempFile("", "dap*.mk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(source); err != nil {
+		t.Fatal(err)
+	}
+	path, _ := filepath.Abs(f.Name())
+	return path
+}
+
+func expectBreakpoints(t *testing.T, actual SetBreakpointsResponse, expected []string) {
+	t.Helper()
+	lines := []string{}
+	for _, bp := range actual.Breakpoints {
+		status := bp.Message
+		if bp.Verified {
+			status = "verified"
+		}
+		lines = append(lines, fmt.Sprintf("%d %d %s", bp.ID, bp.Line, status))
+	}
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("wrong breakpoints. expected=%q, got=%q", expected, lines)
+	}
+}
+
+func expectStopped(t *testing.T, raw json.RawMessage, reason string, ids []int) {
+	t.Helper()
+	var stopped StoppedEvent
+	json.Unmarshal(raw, &stopped)
+	if stopped.Reason != reason || fmt.Sprint(stopped.HitBreakpointIDs) != fmt.Sprint(ids) || stopped.ThreadID != threadID {
+		t.Errorf("wrong stopped event: %+v", stopped)
+	}
+}
+
+func expectVariables(t *testing.T, actual []Variable, expected []string) {
+	t.Helper()
+	lines := []string{}
+	for _, v := range actual {
+		line := fmt.Sprintf("%s = %s (%s)", v.Name, v.Value, v.Type)
+		if v.VariablesReference != 0 {
+			line += " +"
+		}
+		lines = append(lines, line)
+	}
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("wrong variables. expected=%q, got=%q", expected, lines)
+	}
+}
+
+// testClient is the client of the server connected with pipes
+type testClient struct {
+	t           *testing.T
+	conn        *conn
+	responses   chan *message
+	events      chan *message
+	outputs     chan OutputEvent
+	serveResult chan error
+}
+
+func newTestClient(t *testing.T, program string) *testClient {
+	serverInput, clientOutput := io.Pipe()
+	clientInput, serverOutput := io.Pipe()
+	c := &testClient{
+		t:           t,
+		conn:        newConn(clientInput, clientOutput),
+		responses:   make(chan *message, 16),
+		events:      make(chan *message, 16),
+		outputs:     make(chan OutputEvent, 16),
+		serveResult: make(chan error, 1),
+	}
+	server := NewServer(serverInput, serverOutput, ioutil.Discard)
+	server.Program = program
+	go func() {
+		c.serveResult <- server.Serve()
+	}()
+	// サーバーの書き込みが詰まらないように、別の goroutine で読み続ける
+	go func() {
+		for {
+			m, err := c.conn.read()
+			if err != nil {
+				return
+			}
+			switch {
+			case m.Type == "event" && m.Event == "output":
+				var output OutputEvent
+				json.Unmarshal(m.Body, &output)
+				c.outputs <- output
+			case m.Type == "event":
+				c.events <- m
+			default:
+				c.responses <- m
+			}
+		}
+	}()
+	return c
+}
+
+func (c *testClient) call(command string, arguments, body interface{}) error {
+	c.t.Helper()
+	if err := c.conn.write(&request{header: header{Type: "request"}, Command: command, Arguments: arguments}); err != nil {
+		c.t.Fatalf("could not write request: %s", err)
+	}
+	select {
+	case m := <-c.responses:
+		if m.Command != command {
+			c.t.Fatalf("wrong response. expected=%s, got=%s", command, m.Command)
+		}
+		if !m.Success {
+			return fmt.Errorf("%s", m.Message)
+		}
+		if body != nil {
+			return json.Unmarshal(m.Body, body)
+		}
+		return nil
+	case <-time.After(5 * time.Second):
+		c.t.Fatalf("timeout waiting for %s", command)
+		return nil
+	}
+}
+
+// event waits for the next event, which must be the one named name, and returns its body
+func (c *testClient) event(name string) json.RawMessage {
+	c.t.Helper()
+	select {
+	case m := <-c.events:
+		if m.Event != name {
+			c.t.Fatalf("wrong event. expected=%s, got=%s", name, m.Event)
+		}
+		return m.Body
+	case <-time.After(5 * time.Second):
+		c.t.Fatalf("timeout waiting for %s event", name)
+		return nil
+	}
+}
+
+// output returns the output events received so far as "category: output"
+func (c *testClient) output() string {
+	var out strings.Builder
+	for {
+		select {
+		case output := <-c.outputs:
+			fmt.Fprintf(&out, "%s: %s", output.Category, output.Output)
+		default:
+			return out.String()
+		}
+	}
+}
+
+func (c *testClient) resume(command string) {
+	c.t.Helper()
+	if err := c.call(command, nil, nil); err != nil {
+		c.t.Fatalf("%s failed: %s", command, err)
+	}
+}
+
+func (c *testClient) expectStackTrace(expected []string) {
+	c.t.Helper()
+	var trace StackTraceResponse
+	if err := c.call("stackTrace", StackTraceArguments{ThreadID: threadID}, &trace); err != nil {
+		c.t.Fatalf("stackTrace failed: %s", err)
+	}
+	frames := []string{}
+	for _, f := range trace.StackFrames {
+		frames = append(frames, fmt.Sprintf("%s %d:%d", f.Name, f.Line, f.Column))
+	}
+	if strings.Join(frames, "\n") != strings.Join(expected, "\n") || trace.TotalFrames != len(expected) {
+		c.t.Errorf("wrong stack trace. expected=%q, got=%q", expected, frames)
+	}
+}
+
+func (c *testClient) scopes(frameID int) []Scope {
+	c.t.Helper()
+	var scopes ScopesResponse
+	if err := c.call("scopes", ScopesArguments{FrameID: frameID}, &scopes); err != nil {
+		c.t.Fatalf("scopes failed: %s", err)
+	}
+	return scopes.Scopes
+}
+
+func (c *testClient) variables(reference int) []Variable {
+	c.t.Helper()
+	var variables VariablesResponse
+	if err := c.call("variables", VariablesArguments{VariablesReference: reference}, &variables); err != nil {
+		c.t.Fatalf("variables failed: %s", err)
+	}
+	return variables.Variables
+}
+
+func (c *testClient) wait() error {
+	select {
+	case err := <-c.serveResult:
+		return err
+	case <-time.After(5 * time.Second):
+		c.t.Fatalf("timeout waiting for Serve to return")
+		return nil
+	}
+}
@@ -0,0 +1,163 @@
+package dap
+
+// The types of DAP used by the server. Only the fields the server reads or writes are defined.
+
+// Capabilities are the features supported by the debug adapter
+type Capabilities struct {
+	SupportsConfigurationDoneRequest bool `json:"supportsConfigurationDoneRequest"`
+	SupportsConditionalBreakpoints   bool `json:"supportsConditionalBreakpoints"`
+	SupportsEvaluateForHovers        bool `json:"supportsEvaluateForHovers"`
+	SupportsTerminateRequest         bool `json:"supportsTerminateRequest"`
+}
+
+// LaunchArguments are the arguments of launch
+type LaunchArguments struct {
+	Program     string `json:"program"`
+	StopOnEntry bool   `json:"stopOnEntry"`
+}
+
+// AttachArguments are the arguments of attach. The program is the one given to the server.
+type AttachArguments struct {
+	StopOnEntry bool `json:"stopOnEntry"`
+}
+
+// Source is the script
+type Source struct {
+	Name string `json:"name,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+// SourceBreakpoint is the breakpoint requested by the client
+type SourceBreakpoint struct {
+	Line      int    `json:"line"`
+	Condition string `json:"condition,omitempty"`
+}
+
+// SetBreakpointsArguments are the arguments of setBreakpoints, which replace all the breakpoints in the source
+type SetBreakpointsArguments struct {
+	Source      Source             `json:"source"`
+	Breakpoints []SourceBreakpoint `json:"breakpoints"`
+}
+
+// Breakpoint is the breakpoint actually set
+type Breakpoint struct {
+	ID       int    `json:"id,omitempty"`
+	Verified bool   `json:"verified"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message,omitempty"`
+	Source   Source `json:"source"`
+}
+
+// SetBreakpointsResponse is the body of the response to setBreakpoints
+type SetBreakpointsResponse struct {
+	Breakpoints []Breakpoint `json:"breakpoints"`
+}
+
+// Thread is the thread of the script. The script has only one.
+type Thread struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// ThreadsResponse is the body of the response to threads
+type ThreadsResponse struct {
+	Threads []Thread `json:"threads"`
+}
+
+// StackTraceArguments are the arguments of stackTrace
+type StackTraceArguments struct {
+	ThreadID   int `json:"threadId"`
+	StartFrame int `json:"startFrame"`
+	Levels     int `json:"levels"` // 0 ならすべて
+}
+
+// StackFrame is the function call in progress
+type StackFrame struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Source Source `json:"source"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// StackTraceResponse is the body of the response to stackTrace
+type StackTraceResponse struct {
+	StackFrames []StackFrame `json:"stackFrames"`
+	TotalFrames int          `json:"totalFrames"`
+}
+
+// ScopesArguments are the arguments of scopes
+type ScopesArguments struct {
+	FrameID int `json:"frameId"`
+}
+
+// Scope is the variables bound in an environment visible from the frame
+type Scope struct {
+	Name               string `json:"name"`
+	PresentationHint   string `json:"presentationHint,omitempty"`
+	VariablesReference int    `json:"variablesReference"`
+	Expensive          bool   `json:"expensive"`
+}
+
+// ScopesResponse is the body of the response to scopes
+type ScopesResponse struct {
+	Scopes []Scope `json:"scopes"`
+}
+
+// VariablesArguments are the arguments of variables
+type VariablesArguments struct {
+	VariablesReference int `json:"variablesReference"`
+}
+
+// Variable is the name and the value. The value is expandable if VariablesReference is not 0.
+type Variable struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	Type               string `json:"type,omitempty"`
+	VariablesReference int    `json:"variablesReference"`
+}
+
+// VariablesResponse is the body of the response to variables
+type VariablesResponse struct {
+	Variables []Variable `json:"variables"`
+}
+
+// EvaluateArguments are the arguments of evaluate. Without the frame, it is evaluated in the innermost one.
+type EvaluateArguments struct {
+	Expression string `json:"expression"`
+	FrameID    int    `json:"frameId"`
+	Context    string `json:"context"`
+}
+
+// EvaluateResponse is the body of the response to evaluate
+type EvaluateResponse struct {
+	Result             string `json:"result"`
+	Type               string `json:"type,omitempty"`
+	VariablesReference int    `json:"variablesReference"`
+}
+
+// ContinueResponse is the body of the response to continue
+type ContinueResponse struct {
+	AllThreadsContinued bool `json:"allThreadsContinued"`
+}
+
+// StoppedEvent is the body of the stopped event
+type StoppedEvent struct {
+	Reason            string `json:"reason"`
+	Description       string `json:"description,omitempty"`
+	ThreadID          int    `json:"threadId"`
+	Text              string `json:"text,omitempty"`
+	AllThreadsStopped bool   `json:"allThreadsStopped"`
+	HitBreakpointIDs  []int  `json:"hitBreakpointIds,omitempty"`
+}
+
+// OutputEvent is the body of the output event
+type OutputEvent struct {
+	Category string `json:"category"` // "console", "stdout" または "stderr"
+	Output   string `json:"output"`
+}
+
+// ExitedEvent is the body of the exited event
+type ExitedEvent struct {
+	ExitCode int `json:"exitCode"`
+}
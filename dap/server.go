@@ -0,0 +1,278 @@
+package dap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"github.com/tshinag/monkey/debugger"
+	"github.com/tshinag/monkey/lexer"
+	"github.com/tshinag/monkey/parser"
+)
+
+// threadID is the id of the only thread of the script
+const threadID = 1
+
+// Server is the debug adapter of Monkey. It handles the requests one by one,
+// while the script runs on another goroutine.
+type Server struct {
+	// Program is the script debugged on attach requests. Launch requests name the script themselves.
+	Program string
+
+	conn         *conn
+	logger       io.Writer
+	handlers     map[string]func(arguments json.RawMessage) (interface{}, error)
+	session      *session
+	deferred     []func() // 応答を送ったあとに実行する
+	disconnected bool
+}
+
+// NewServer returns the server reading the requests from r and writing to w.
+// The errors which can't be sent to the client are logged to logger.
+func NewServer(r io.Reader, w io.Writer, logger io.Writer) *Server {
+	s := &Server{conn: newConn(r, w), logger: logger}
+	s.handlers = map[string]func(json.RawMessage) (interface{}, error){
+		"initialize":        s.initialize,
+		"launch":            s.launch,
+		"attach":            s.attach,
+		"setBreakpoints":    s.setBreakpoints,
+		"configurationDone": s.configurationDone,
+		"threads":           s.threads,
+		"stackTrace":        s.stackTrace,
+		"scopes":            s.scopes,
+		"variables":         s.variables,
+		"evaluate":          s.evaluate,
+		"continue":          s.resume((*debugger.Debugger).Continue),
+		"next":              s.resume((*debugger.Debugger).StepOver),
+		"stepIn":            s.resume((*debugger.Debugger).StepIn),
+		"stepOut":           s.resume((*debugger.Debugger).StepOut),
+		"pause":             s.pause,
+		"terminate":         s.terminate,
+		"disconnect":        s.disconnect,
+	}
+	return s
+}
+
+// Serve handles the requests until the disconnect request or the end of input.
+// The script is abandoned if it is still running.
+func (s *Server) Serve() error {
+	defer s.kill()
+	for {
+		m, err := s.conn.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if m.Type != "request" {
+			// クライアントへの要求はしないので、応答は受け取らない
+			continue
+		}
+		body, err := s.handle(m)
+		if err != nil {
+			fmt.Fprintf(s.logger, "%s: %s\n", m.Command, err)
+		}
+		if err := s.conn.reply(m, body, err); err != nil {
+			return err
+		}
+		for _, f := range s.deferred {
+			f()
+		}
+		s.deferred = nil
+		if s.disconnected {
+			return nil
+		}
+	}
+}
+
+// handle calls the handler of the command, converting the panics to errors
+func (s *Server) handle(m *message) (body interface{}, err error) {
+	handler, ok := s.handlers[m.Command]
+	if !ok {
+		return nil, errors.Errorf("unsupported request: %s", m.Command)
+	}
+	if s.session == nil && m.Command != "initialize" && m.Command != "launch" && m.Command != "attach" && m.Command != "disconnect" {
+		return nil, errors.New("no program launched")
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			body, err = nil, errors.Errorf("%s: %v", m.Command, r)
+		}
+	}()
+	return handler(m.Arguments)
+}
+
+// decode unmarshals the arguments of the request, which may be omitted
+func decode(arguments json.RawMessage, v interface{}) error {
+	if len(arguments) == 0 {
+		return nil
+	}
+	return errors.Wrap(json.Unmarshal(arguments, v), "invalid arguments")
+}
+
+// after runs f after the response is sent, such as the events which must follow it
+func (s *Server) after(f func()) {
+	s.deferred = append(s.deferred, f)
+}
+
+func (s *Server) kill() {
+	if s.session != nil {
+		s.session.kill()
+	}
+}
+
+func (s *Server) initialize(json.RawMessage) (interface{}, error) {
+	return &Capabilities{
+		SupportsConfigurationDoneRequest: true,
+		SupportsConditionalBreakpoints:   true,
+		SupportsEvaluateForHovers:        true,
+		SupportsTerminateRequest:         true,
+	}, nil
+}
+
+func (s *Server) launch(raw json.RawMessage) (interface{}, error) {
+	var args LaunchArguments
+	if err := decode(raw, &args); err != nil {
+		return nil, err
+	}
+	if args.Program == "" {
+		return nil, errors.New("program is not specified")
+	}
+	return nil, s.start(args.Program, args.StopOnEntry)
+}
+
+func (s *Server) attach(raw json.RawMessage) (interface{}, error) {
+	var args AttachArguments
+	if err := decode(raw, &args); err != nil {
+		return nil, err
+	}
+	if s.Program == "" {
+		return nil, errors.New("no program to attach to")
+	}
+	return nil, s.start(s.Program, args.StopOnEntry)
+}
+
+// start prepares the session of the script, which runs after configurationDone
+func (s *Server) start(path string, stopOnEntry bool) error {
+	if s.session != nil {
+		return errors.New("program already launched")
+	}
+	source, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "could not read program")
+	}
+	p := parser.New(lexer.New(string(source)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		if err, ok := p.Errors()[0].(*parser.Error); ok {
+			return errors.Errorf("%s:%s: %s", path, err.Pos, err)
+		}
+		return errors.Errorf("%s: %s", path, p.Errors()[0])
+	}
+	s.session = newSession(s.conn, path, program, stopOnEntry)
+	// 設定の要求を受け付けられるようになった
+	s.after(func() {
+		s.send("initialized", nil)
+	})
+	return nil
+}
+
+func (s *Server) send(name string, body interface{}) {
+	if err := s.conn.send(name, body); err != nil {
+		fmt.Fprintf(s.logger, "%s: %s\n", name, err)
+	}
+}
+
+func (s *Server) setBreakpoints(raw json.RawMessage) (interface{}, error) {
+	var args SetBreakpointsArguments
+	if err := decode(raw, &args); err != nil {
+		return nil, err
+	}
+	return s.session.setBreakpoints(args), nil
+}
+
+func (s *Server) configurationDone(json.RawMessage) (interface{}, error) {
+	if !s.session.start() {
+		return nil, errors.New("program already running")
+	}
+	return nil, nil
+}
+
+func (s *Server) threads(json.RawMessage) (interface{}, error) {
+	return &ThreadsResponse{Threads: []Thread{{ID: threadID, Name: "main"}}}, nil
+}
+
+func (s *Server) stackTrace(raw json.RawMessage) (interface{}, error) {
+	var args StackTraceArguments
+	if err := decode(raw, &args); err != nil {
+		return nil, err
+	}
+	return s.session.inspect(func() (interface{}, error) {
+		return s.session.stackTrace(args), nil
+	})
+}
+
+func (s *Server) scopes(raw json.RawMessage) (interface{}, error) {
+	var args ScopesArguments
+	if err := decode(raw, &args); err != nil {
+		return nil, err
+	}
+	return s.session.inspect(func() (interface{}, error) {
+		return s.session.scopes(args)
+	})
+}
+
+func (s *Server) variables(raw json.RawMessage) (interface{}, error) {
+	var args VariablesArguments
+	if err := decode(raw, &args); err != nil {
+		return nil, err
+	}
+	return s.session.inspect(func() (interface{}, error) {
+		return s.session.variables(args)
+	})
+}
+
+func (s *Server) evaluate(raw json.RawMessage) (interface{}, error) {
+	var args EvaluateArguments
+	if err := decode(raw, &args); err != nil {
+		return nil, err
+	}
+	return s.session.inspect(func() (interface{}, error) {
+		return s.session.evaluate(args)
+	})
+}
+
+// resume returns the handler resuming the stopped script in the way of f.
+// The script resumes after the response so that the next stopped event follows it.
+func (s *Server) resume(f func(*debugger.Debugger)) func(json.RawMessage) (interface{}, error) {
+	return func(json.RawMessage) (interface{}, error) {
+		resume, err := s.session.resume(f)
+		if err != nil {
+			return nil, err
+		}
+		s.after(resume)
+		return &ContinueResponse{AllThreadsContinued: true}, nil
+	}
+}
+
+func (s *Server) pause(json.RawMessage) (interface{}, error) {
+	s.session.debugger.Pause()
+	return nil, nil
+}
+
+func (s *Server) terminate(json.RawMessage) (interface{}, error) {
+	s.session.kill()
+	s.after(func() {
+		s.send("terminated", nil)
+	})
+	return nil, nil
+}
+
+func (s *Server) disconnect(json.RawMessage) (interface{}, error) {
+	s.kill()
+	s.disconnected = true
+	return nil, nil
+}
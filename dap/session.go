@@ -0,0 +1,327 @@
+package dap
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/tshinag/monkey/ast"
+	"github.com/tshinag/monkey/debugger"
+	"github.com/tshinag/monkey/evaluator"
+	"github.com/tshinag/monkey/object"
+)
+
+// session is the script being debugged. While the script is stopped, its goroutine
+// runs the commands sent by the server, so that the stopped evaluation is inspected
+// on the goroutine the debugger expects.
+type session struct {
+	conn     *conn
+	path     string
+	program  *ast.Program
+	debugger *debugger.Debugger
+	commands chan func() bool // true を返すと評価を再開する
+	done     chan struct{}    // Run が戻ると閉じる
+
+	mu      sync.Mutex
+	started bool
+	stopped bool
+
+	references []interface{} // variablesReference - 1 番目の参照先。停止するたびに作り直す
+}
+
+func newSession(c *conn, path string, program *ast.Program, stopOnEntry bool) *session {
+	s := &session{
+		conn:     c,
+		path:     path,
+		program:  program,
+		commands: make(chan func() bool),
+		done:     make(chan struct{}),
+	}
+	s.debugger = debugger.New(stopOnEntry, s.onStop)
+	return s
+}
+
+// start runs the script on another goroutine, and reports false if it has already started
+func (s *session) start() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return false
+	}
+	s.started = true
+
+	evaluator.SetOutput(&outputWriter{conn: s.conn, category: "stdout"})
+	go func() {
+		defer close(s.done)
+		result, finished := s.debugger.Run(func() object.Object {
+			env := object.NewEnvironment()
+			if evaluated := evaluator.Eval(s.program, env); isError(evaluated) {
+				return evaluated
+			}
+			if err := evaluator.RunEventLoop(); err != nil {
+				return err
+			}
+			return nil
+		})
+		if !finished {
+			return
+		}
+		evaluator.SetOutput(os.Stdout)
+		exitCode := 0
+		if err, ok := result.(*object.Error); ok {
+			s.conn.send("output", &OutputEvent{Category: "stderr", Output: fmt.Sprintf("%s: %s\n", s.path, err.Inspect())})
+			exitCode = 1
+		}
+		s.conn.send("exited", &ExitedEvent{ExitCode: exitCode})
+		s.conn.send("terminated", nil)
+	}()
+	return true
+}
+
+func isError(obj object.Object) bool {
+	_, ok := obj.(*object.Error)
+	return ok
+}
+
+// onStop is the handler of the debugger. It reports the stop and runs the commands until one resumes.
+func (s *session) onStop(stop *debugger.Stop) {
+	s.references = nil
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+
+	body := &StoppedEvent{Reason: string(stop.Reason), ThreadID: threadID, AllThreadsStopped: true}
+	if stop.Breakpoint != nil {
+		body.HitBreakpointIDs = []int{stop.Breakpoint.ID}
+	}
+	if stop.Err != nil {
+		body.Text = stop.Err.Error()
+		s.conn.send("output", &OutputEvent{Category: "stderr", Output: stop.Err.Error() + "\n"})
+	}
+	s.conn.send("stopped", body)
+	for command := range s.commands {
+		if command() {
+			return
+		}
+	}
+}
+
+// inspect runs f on the goroutine of the stopped script
+func (s *session) inspect(f func() (interface{}, error)) (interface{}, error) {
+	s.mu.Lock()
+	stopped := s.stopped
+	s.mu.Unlock()
+	if !stopped {
+		return nil, errors.New("program is not stopped")
+	}
+	var body interface{}
+	var err error
+	done := make(chan struct{})
+	s.commands <- func() bool {
+		defer close(done)
+		body, err = f()
+		return false
+	}
+	<-done
+	return body, err
+}
+
+// resume returns the function resuming the stopped script in the way of f
+func (s *session) resume(f func(*debugger.Debugger)) (func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.stopped {
+		return nil, errors.New("program is not stopped")
+	}
+	s.stopped = false
+	return func() {
+		s.commands <- func() bool {
+			f(s.debugger)
+			return true
+		}
+	}, nil
+}
+
+// kill abandons the script. The goroutine of the script never resumes.
+// It waits for Run to return, so that the evaluator is detached from the debugger.
+func (s *session) kill() {
+	s.debugger.Kill()
+	s.mu.Lock()
+	started := s.started
+	if s.stopped {
+		s.stopped = false
+		s.commands <- func() bool { return true }
+	}
+	s.mu.Unlock()
+	if started {
+		<-s.done
+	}
+}
+
+func (s *session) setBreakpoints(args SetBreakpointsArguments) *SetBreakpointsResponse {
+	body := &SetBreakpointsResponse{Breakpoints: []Breakpoint{}}
+	if !samePath(args.Source.Path, s.path) {
+		for _, b := range args.Breakpoints {
+			body.Breakpoints = append(body.Breakpoints, Breakpoint{
+				Line:    b.Line,
+				Message: fmt.Sprintf("breakpoints can only be set in %s", s.path),
+				Source:  args.Source,
+			})
+		}
+		return body
+	}
+	s.debugger.ClearBreakpoints()
+	for _, b := range args.Breakpoints {
+		bp, err := s.debugger.SetBreakpoint(b.Line, b.Condition)
+		if err != nil {
+			body.Breakpoints = append(body.Breakpoints, Breakpoint{Line: b.Line, Message: err.Error(), Source: args.Source})
+			continue
+		}
+		body.Breakpoints = append(body.Breakpoints, Breakpoint{ID: bp.ID, Verified: true, Line: bp.Line, Source: args.Source})
+	}
+	return body
+}
+
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+func (s *session) source() Source {
+	return Source{Name: filepath.Base(s.path), Path: s.path}
+}
+
+// stackTrace returns the frames, whose ids are the indices from the innermost plus one
+func (s *session) stackTrace(args StackTraceArguments) *StackTraceResponse {
+	frames := s.debugger.Frames()
+	body := &StackTraceResponse{StackFrames: []StackFrame{}, TotalFrames: len(frames)}
+	for i := args.StartFrame; i < len(frames); i++ {
+		if args.Levels > 0 && len(body.StackFrames) == args.Levels {
+			break
+		}
+		frame := StackFrame{ID: i + 1, Name: frames[i].Function, Source: s.source()}
+		if node := frames[i].Node; node != nil {
+			frame.Line, frame.Column = node.Pos().Line, node.Pos().Column
+		}
+		body.StackFrames = append(body.StackFrames, frame)
+	}
+	return body
+}
+
+func (s *session) scopes(args ScopesArguments) (*ScopesResponse, error) {
+	scopes, err := s.debugger.Scopes(args.FrameID - 1)
+	if err != nil {
+		return nil, err
+	}
+	body := &ScopesResponse{Scopes: []Scope{}}
+	for _, scope := range scopes {
+		hint := "locals"
+		if scope.Name == "global" {
+			hint = "globals"
+		}
+		body.Scopes = append(body.Scopes, Scope{
+			Name:               scope.Name,
+			PresentationHint:   hint,
+			VariablesReference: s.reference(scope.Variables),
+		})
+	}
+	return body, nil
+}
+
+// reference returns the variablesReference of the scope or the object valid until the script resumes
+func (s *session) reference(v interface{}) int {
+	s.references = append(s.references, v)
+	return len(s.references)
+}
+
+func (s *session) variables(args VariablesArguments) (*VariablesResponse, error) {
+	i := args.VariablesReference - 1
+	if i < 0 || i >= len(s.references) {
+		return nil, errors.Errorf("invalid variablesReference %d", args.VariablesReference)
+	}
+	body := &VariablesResponse{Variables: []Variable{}}
+	switch v := s.references[i].(type) {
+	case []debugger.Variable:
+		for _, variable := range v {
+			body.Variables = append(body.Variables, s.variable(variable.Name, variable.Value))
+		}
+	case *object.Array:
+		for i, e := range v.Elements {
+			body.Variables = append(body.Variables, s.variable(fmt.Sprintf("[%d]", i), e))
+		}
+	case *object.Hash:
+		for _, pair := range v.SortedPairs() {
+			body.Variables = append(body.Variables, s.variable(pair.Key.Inspect(), pair.Value))
+		}
+	case *object.Struct:
+		for i, name := range v.Definition.Fields {
+			body.Variables = append(body.Variables, s.variable(name, v.Value(i)))
+		}
+	}
+	return body, nil
+}
+
+// variable returns the variable whose value is expandable if it is a non-empty array, hash or struct
+func (s *session) variable(name string, value object.Object) Variable {
+	return Variable{
+		Name:               name,
+		Value:              value.Inspect(),
+		Type:               string(value.Type()),
+		VariablesReference: s.expandable(value),
+	}
+}
+
+func (s *session) expandable(value object.Object) int {
+	switch v := value.(type) {
+	case *object.Array:
+		if len(v.Elements) == 0 {
+			return 0
+		}
+	case *object.Hash:
+		if len(v.Pairs) == 0 {
+			return 0
+		}
+	case *object.Struct:
+		if len(v.Definition.Fields) == 0 {
+			return 0
+		}
+	default:
+		return 0
+	}
+	return s.reference(value)
+}
+
+func (s *session) evaluate(args EvaluateArguments) (*EvaluateResponse, error) {
+	frame := 0
+	if args.FrameID > 0 {
+		frame = args.FrameID - 1
+	}
+	result, err := s.debugger.Evaluate(args.Expression, frame)
+	if err != nil {
+		return nil, err
+	}
+	if err, ok := result.(*object.Error); ok {
+		return nil, errors.New(err.Message)
+	}
+	return &EvaluateResponse{
+		Result:             result.Inspect(),
+		Type:               string(result.Type()),
+		VariablesReference: s.expandable(result),
+	}, nil
+}
+
+// outputWriter sends the output of the script as the output events
+type outputWriter struct {
+	conn     *conn
+	category string
+}
+
+func (w *outputWriter) Write(p []byte) (int, error) {
+	if err := w.conn.send("output", &OutputEvent{Category: w.category, Output: string(p)}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/tshinag/monkey/debugger"
+	"github.com/tshinag/monkey/evaluator"
+	"github.com/tshinag/monkey/object"
+)
+
+// debug runs the script under the command line debugger reading the commands from stdin
+func debug(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprintf(stderr, "usage: monkey debug FILE\n")
+		return 2
+	}
+	program, ok := parseFile(args[0], stderr)
+	if !ok {
+		return 1
+	}
+	source, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		fmt.Fprintf(stderr, "%s\n", err)
+		return 1
+	}
+
+	cli := debugger.NewCLI(args[0], string(source), os.Stdin, stdout)
+	env := object.NewEnvironment()
+	evaluated, finished := cli.Debugger.Run(func() object.Object {
+		if evaluated := evaluator.Eval(program, env); isError(evaluated) {
+			return evaluated
+		}
+		if err := evaluator.RunEventLoop(); err != nil {
+			return err
+		}
+		return nil
+	})
+	if !finished {
+		return 1
+	}
+	if err, ok := evaluated.(*object.Error); ok {
+		fmt.Fprintf(stderr, "%s: %s\n", args[0], err.Inspect())
+		return 1
+	}
+	fmt.Fprintf(stdout, "Script finished\n")
+	return 0
+}
+
+func isError(obj object.Object) bool {
+	_, ok := obj.(*object.Error)
+	return ok
+}
@@ -0,0 +1,75 @@
+package debugger
+
+import (
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"github.com/tshinag/monkey/ast"
+)
+
+// Breakpoint stops the evaluation before the statements on the line.
+// If it has a condition, it stops only when the condition is truthy.
+type Breakpoint struct {
+	ID        int
+	Line      int
+	Condition string // 条件がなければ空
+	condition *ast.Program
+	hits      int32
+}
+
+// Hits returns the number of times the breakpoint stopped the evaluation
+func (bp *Breakpoint) Hits() int {
+	return int(atomic.LoadInt32(&bp.hits))
+}
+
+func (bp *Breakpoint) hit() {
+	atomic.AddInt32(&bp.hits, 1)
+}
+
+// SetBreakpoint adds the breakpoint at the line, whose condition may be empty
+func (d *Debugger) SetBreakpoint(line int, condition string) (*Breakpoint, error) {
+	if line < 1 {
+		return nil, errors.Errorf("invalid line %d", line)
+	}
+	bp := &Breakpoint{Line: line, Condition: condition}
+	if condition != "" {
+		program, err := parse(condition)
+		if err != nil {
+			return nil, err
+		}
+		bp.condition = program
+	}
+	d.breakpointMu.Lock()
+	defer d.breakpointMu.Unlock()
+	d.nextID++
+	bp.ID = d.nextID
+	d.breakpoints = append(d.breakpoints, bp)
+	return bp, nil
+}
+
+// ClearBreakpoint removes the breakpoint with the id, and reports whether it existed
+func (d *Debugger) ClearBreakpoint(id int) bool {
+	d.breakpointMu.Lock()
+	defer d.breakpointMu.Unlock()
+	for i, bp := range d.breakpoints {
+		if bp.ID == id {
+			d.breakpoints = append(d.breakpoints[:i:i], d.breakpoints[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ClearBreakpoints removes all the breakpoints
+func (d *Debugger) ClearBreakpoints() {
+	d.breakpointMu.Lock()
+	defer d.breakpointMu.Unlock()
+	d.breakpoints = nil
+}
+
+// Breakpoints returns the breakpoints in the order they were set
+func (d *Debugger) Breakpoints() []*Breakpoint {
+	d.breakpointMu.Lock()
+	defer d.breakpointMu.Unlock()
+	return append([]*Breakpoint{}, d.breakpoints...)
+}
@@ -0,0 +1,252 @@
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const prompt = "(mdb) "
+
+const help = `commands:
+  break LINE [if EXPR]  set a breakpoint, stopping only when EXPR is truthy if given (b)
+  delete [ID]           delete the breakpoint, or all the breakpoints (d)
+  breakpoints           list the breakpoints (bl)
+  continue              run until a breakpoint (c)
+  step                  run to the next statement, entering calls (s)
+  next                  run to the next statement, stepping over calls (n)
+  out                   run until the current function returns (o)
+  print EXPR            evaluate the expression in the selected frame (p)
+  vars                  print the variables visible from the selected frame (v)
+  backtrace             print the calls in progress (bt)
+  frame N               select the frame N of the backtrace (f)
+  list                  print the source around the selected frame (l)
+  quit                  abandon the script (q)
+`
+
+// noArguments is the set of the commands which take no arguments
+var noArguments = map[string]bool{
+	"help": true, "h": true,
+	"breakpoints": true, "bl": true,
+	"continue": true, "c": true,
+	"step": true, "s": true,
+	"next": true, "n": true,
+	"out": true, "o": true,
+	"vars": true, "v": true,
+	"backtrace": true, "bt": true,
+	"list": true, "l": true,
+	"quit": true, "q": true,
+}
+
+// CLI is the command line interface of the debugger, reading the commands from in on each stop
+type CLI struct {
+	Debugger *Debugger
+	path     string
+	lines    []string
+	scanner  *bufio.Scanner
+	out      io.Writer
+	frame    int // 選択中のフレーム
+}
+
+// NewCLI returns the interface debugging the source of the file at the path.
+// It stops before the first statement.
+func NewCLI(path, source string, in io.Reader, out io.Writer) *CLI {
+	c := &CLI{
+		path:    path,
+		lines:   strings.Split(source, "\n"),
+		scanner: bufio.NewScanner(in),
+		out:     out,
+	}
+	c.Debugger = New(true, c.stopped)
+	return c
+}
+
+func (c *CLI) stopped(stop *Stop) {
+	c.frame = 0
+	c.printStop(stop)
+	for {
+		fmt.Fprint(c.out, prompt)
+		if !c.scanner.Scan() {
+			fmt.Fprintln(c.out)
+			c.Debugger.Kill()
+			return
+		}
+		if c.execute(strings.TrimSpace(c.scanner.Text())) {
+			return
+		}
+	}
+}
+
+func (c *CLI) printStop(stop *Stop) {
+	switch stop.Reason {
+	case ReasonEntry:
+		fmt.Fprintf(c.out, "Stopped at entry, ")
+	case ReasonBreakpoint:
+		fmt.Fprintf(c.out, "Breakpoint %d, ", stop.Breakpoint.ID)
+	case ReasonPause:
+		fmt.Fprintf(c.out, "Paused, ")
+	}
+	fmt.Fprintf(c.out, "%s\n", c.location(stop.Frame))
+	if stop.Err != nil {
+		fmt.Fprintf(c.out, "error: %s\n", stop.Err)
+	}
+	c.printLine(stop.Frame.Node.Pos().Line)
+}
+
+// location returns where the frame is, such as "fact at fact.mk:3:5"
+func (c *CLI) location(f *Frame) string {
+	if f.Node == nil {
+		return f.Function
+	}
+	return fmt.Sprintf("%s at %s:%s", f.Function, c.path, f.Node.Pos())
+}
+
+func (c *CLI) printLine(line int) {
+	if line >= 1 && line <= len(c.lines) {
+		fmt.Fprintf(c.out, "%d\t%s\n", line, c.lines[line-1])
+	}
+}
+
+// execute runs the command, and reports whether the evaluation resumes
+func (c *CLI) execute(line string) bool {
+	command, arg := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		command, arg = line[:i], strings.TrimSpace(line[i+1:])
+	}
+	// "step out" のような打ち間違いを黙って step として実行しない
+	if arg != "" && noArguments[command] {
+		fmt.Fprintf(c.out, "error: %s takes no arguments, got %q (type help for the commands)\n", command, arg)
+		return false
+	}
+	d := c.Debugger
+	switch command {
+	case "":
+	case "help", "h":
+		fmt.Fprint(c.out, help)
+	case "break", "b":
+		c.setBreakpoint(arg)
+	case "delete", "d":
+		c.deleteBreakpoint(arg)
+	case "breakpoints", "bl":
+		for _, bp := range d.Breakpoints() {
+			fmt.Fprintf(c.out, "%d\t%s:%d%s\thit %d times\n", bp.ID, c.path, bp.Line, condition(bp), bp.Hits())
+		}
+	case "continue", "c":
+		d.Continue()
+		return true
+	case "step", "s":
+		d.StepIn()
+		return true
+	case "next", "n":
+		d.StepOver()
+		return true
+	case "out", "o":
+		d.StepOut()
+		return true
+	case "print", "p":
+		value, err := d.Evaluate(arg, c.frame)
+		if err != nil {
+			fmt.Fprintf(c.out, "error: %s\n", err)
+			break
+		}
+		fmt.Fprintf(c.out, "%s\n", value.Inspect())
+	case "vars", "v":
+		scopes, err := d.Scopes(c.frame)
+		if err != nil {
+			fmt.Fprintf(c.out, "error: %s\n", err)
+			break
+		}
+		for _, scope := range scopes {
+			fmt.Fprintf(c.out, "%s:\n", scope.Name)
+			for _, v := range scope.Variables {
+				fmt.Fprintf(c.out, "  %s = %s\n", v.Name, v.Value.Inspect())
+			}
+		}
+	case "backtrace", "bt":
+		for i, f := range d.Frames() {
+			marker := " "
+			if i == c.frame {
+				marker = "*"
+			}
+			fmt.Fprintf(c.out, "%s#%d  %s\n", marker, i, c.location(f))
+		}
+	case "frame", "f":
+		n, err := strconv.Atoi(arg)
+		frames := d.Frames()
+		if err != nil || n < 0 || n >= len(frames) {
+			fmt.Fprintf(c.out, "error: no frame %q\n", arg)
+			break
+		}
+		c.frame = n
+		fmt.Fprintf(c.out, "#%d  %s\n", n, c.location(frames[n]))
+	case "list", "l":
+		c.list()
+	case "quit", "q":
+		d.Kill()
+		return true
+	default:
+		fmt.Fprintf(c.out, "unknown command: %s (type help for the commands)\n", command)
+	}
+	return false
+}
+
+// setBreakpoint parses "LINE [if EXPR]" and sets the breakpoint
+func (c *CLI) setBreakpoint(arg string) {
+	lineArg, cond := arg, ""
+	if i := strings.Index(arg, " if "); i >= 0 {
+		lineArg, cond = strings.TrimSpace(arg[:i]), strings.TrimSpace(arg[i+len(" if "):])
+	}
+	line, err := strconv.Atoi(lineArg)
+	if err != nil {
+		fmt.Fprintf(c.out, "error: invalid line %q\n", lineArg)
+		return
+	}
+	bp, err := c.Debugger.SetBreakpoint(line, cond)
+	if err != nil {
+		fmt.Fprintf(c.out, "error: %s\n", err)
+		return
+	}
+	fmt.Fprintf(c.out, "Breakpoint %d at %s:%d%s\n", bp.ID, c.path, bp.Line, condition(bp))
+}
+
+func (c *CLI) deleteBreakpoint(arg string) {
+	if arg == "" {
+		c.Debugger.ClearBreakpoints()
+		fmt.Fprintf(c.out, "Deleted all breakpoints\n")
+		return
+	}
+	id, err := strconv.Atoi(arg)
+	if err != nil || !c.Debugger.ClearBreakpoint(id) {
+		fmt.Fprintf(c.out, "error: no breakpoint %q\n", arg)
+		return
+	}
+	fmt.Fprintf(c.out, "Deleted breakpoint %d\n", id)
+}
+
+func condition(bp *Breakpoint) string {
+	if bp.Condition == "" {
+		return ""
+	}
+	return " if " + bp.Condition
+}
+
+// list prints the lines around the statement of the selected frame, marking the statement
+func (c *CLI) list() {
+	f := c.Debugger.Frames()[c.frame]
+	if f.Node == nil {
+		return
+	}
+	current := f.Node.Pos().Line
+	for line := current - 2; line <= current+2; line++ {
+		if line < 1 || line > len(c.lines) {
+			continue
+		}
+		marker := "  "
+		if line == current {
+			marker = "=>"
+		}
+		fmt.Fprintf(c.out, "%s %d\t%s\n", marker, line, c.lines[line-1])
+	}
+}
@@ -0,0 +1,343 @@
+package debugger
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"github.com/tshinag/monkey/ast"
+	"github.com/tshinag/monkey/evaluator"
+	"github.com/tshinag/monkey/lexer"
+	"github.com/tshinag/monkey/object"
+	"github.com/tshinag/monkey/parser"
+)
+
+// Reason is why the evaluation stopped
+type Reason string
+
+// The reasons of stops
+const (
+	ReasonEntry      Reason = "entry"
+	ReasonStep       Reason = "step"
+	ReasonBreakpoint Reason = "breakpoint"
+	ReasonPause      Reason = "pause"
+)
+
+// Stop describes where and why the evaluation stopped
+type Stop struct {
+	Reason     Reason
+	Breakpoint *Breakpoint // ReasonBreakpoint の場合のみ
+	Err        error       // 条件の評価に失敗した場合
+	Frame      *Frame      // 停止した関数呼び出し
+}
+
+// Frame is the function call in progress
+type Frame struct {
+	Function string              // 関数名。トップレベルは "<main>"
+	Node     ast.Node            // 最後に実行を始めた文
+	Env      *object.Environment // その文の環境
+}
+
+type mode int
+
+const (
+	modeContinue mode = iota
+	modeStepIn
+	modeStepOver
+	modeStepOut
+)
+
+// Debugger stops the evaluation at breakpoints and steps, and calls the handler on each stop.
+// The evaluation resumes when the handler returns. The methods inspecting the stopped
+// evaluation and choosing how to resume must be called from the handler.
+//
+// Tasks started by spawn and async functions share the call stack with the main script,
+// so backtraces and stepping over calls are only exact for single-threaded scripts.
+type Debugger struct {
+	mu      sync.Mutex // 評価している goroutine の間で停止を直列化する
+	handler func(*Stop)
+	frames  []*Frame
+	mode    mode
+	depth   int  // ステップ実行を始めたときの呼び出しの深さ
+	started bool // 一度でも停止したか
+
+	breakpointMu sync.Mutex // ブレークポイントは実行中にも変更される
+	breakpoints  []*Breakpoint
+	nextID       int
+
+	evaluating int32 // デバッガ自身が式を評価している間は 1
+	pausing    int32 // 一時停止が要求されていれば 1
+	killed     chan struct{}
+	killOnce   sync.Once
+}
+
+// New returns the debugger calling the handler on each stop.
+// If stopOnEntry is true, it stops before the first statement.
+func New(stopOnEntry bool, handler func(*Stop)) *Debugger {
+	d := &Debugger{
+		handler: handler,
+		frames:  []*Frame{{Function: "<main>"}},
+		killed:  make(chan struct{}),
+	}
+	if stopOnEntry {
+		d.mode = modeStepIn
+	}
+	return d
+}
+
+// Run calls eval with the debugger attached, and returns its result.
+// It returns false without waiting for eval if the debugger is killed.
+func (d *Debugger) Run(eval func() object.Object) (object.Object, bool) {
+	evaluator.SetDebugger(d)
+	defer evaluator.SetDebugger(nil)
+	done := make(chan object.Object, 1)
+	go func() {
+		done <- eval()
+	}()
+	select {
+	case result := <-done:
+		return result, true
+	case <-d.killed:
+		return nil, false
+	}
+}
+
+// Before implements evaluator.Debugger
+func (d *Debugger) Before(node ast.Node, env *object.Environment) {
+	if !isStoppable(node) || atomic.LoadInt32(&d.evaluating) == 1 {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.waitIfKilled()
+
+	frame := d.frames[len(d.frames)-1]
+	newLine := startsLine(frame.Node, node)
+	frame.Node, frame.Env = node, env
+	if !newLine {
+		return
+	}
+	if stop := d.check(node, env); stop != nil {
+		stop.Frame = frame
+		d.started = true
+		d.mode = modeContinue
+		d.handler(stop)
+		d.waitIfKilled()
+	}
+}
+
+// Call implements evaluator.Debugger
+func (d *Debugger) Call(fn *object.Function, env *object.Environment) {
+	if atomic.LoadInt32(&d.evaluating) == 1 {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	name := fn.Name
+	if name == "" {
+		name = "<anonymous>"
+	}
+	d.frames = append(d.frames, &Frame{Function: name, Env: env})
+}
+
+// Return implements evaluator.Debugger
+func (d *Debugger) Return(fn *object.Function, result object.Object) {
+	if atomic.LoadInt32(&d.evaluating) == 1 {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.frames) > 1 {
+		d.frames = d.frames[:len(d.frames)-1]
+	}
+}
+
+// waitIfKilled blocks the evaluation forever after Kill. Run has already returned by then.
+func (d *Debugger) waitIfKilled() {
+	select {
+	case <-d.killed:
+		d.mu.Unlock()
+		select {}
+	default:
+	}
+}
+
+// isStoppable reports whether the evaluation can stop before the node.
+// It stops at statements, except for blocks and the hoisted function declarations.
+func isStoppable(node ast.Node) bool {
+	switch node.(type) {
+	case *ast.ExpressionStatement, *ast.LetStatement, *ast.ConstStatement, *ast.ReturnStatement,
+		*ast.StructStatement, *ast.EnumStatement, *ast.ClassStatement, *ast.ImplStatement:
+		return true
+	}
+	return false
+}
+
+// startsLine reports whether the statement starts a new line of execution after prev,
+// which is a different line or the same line again, such as the next iteration of a loop.
+// The statements nested in prev on the same line don't start a line.
+func startsLine(prev, node ast.Node) bool {
+	if prev == nil {
+		return true
+	}
+	p, n := prev.Pos(), node.Pos()
+	return p.Line != n.Line || n.Column <= p.Column
+}
+
+// check returns the stop before the statement, or nil if the evaluation goes on
+func (d *Debugger) check(node ast.Node, env *object.Environment) *Stop {
+	line := node.Pos().Line
+	for _, bp := range d.Breakpoints() {
+		if bp.Line != line {
+			continue
+		}
+		if bp.condition != nil {
+			result := d.eval(bp.condition, env)
+			if err, ok := result.(*object.Error); ok {
+				return &Stop{Reason: ReasonBreakpoint, Breakpoint: bp, Err: errors.Errorf("condition %s: %s", bp.Condition, err.Message)}
+			}
+			if !evaluator.IsTruthy(result) {
+				continue
+			}
+		}
+		bp.hit()
+		return &Stop{Reason: ReasonBreakpoint, Breakpoint: bp}
+	}
+	if atomic.CompareAndSwapInt32(&d.pausing, 1, 0) {
+		return &Stop{Reason: ReasonPause}
+	}
+
+	stepped := false
+	switch d.mode {
+	case modeStepIn:
+		stepped = true
+	case modeStepOver:
+		stepped = len(d.frames) <= d.depth
+	case modeStepOut:
+		stepped = len(d.frames) < d.depth
+	}
+	if !stepped {
+		return nil
+	}
+	if !d.started {
+		return &Stop{Reason: ReasonEntry}
+	}
+	return &Stop{Reason: ReasonStep}
+}
+
+// Continue resumes the evaluation until a breakpoint
+func (d *Debugger) Continue() {
+	d.mode = modeContinue
+}
+
+// StepIn resumes the evaluation until the next statement, entering function calls
+func (d *Debugger) StepIn() {
+	d.mode = modeStepIn
+}
+
+// StepOver resumes the evaluation until the next statement of the current function
+func (d *Debugger) StepOver() {
+	d.mode, d.depth = modeStepOver, len(d.frames)
+}
+
+// StepOut resumes the evaluation until the current function returns
+func (d *Debugger) StepOut() {
+	d.mode, d.depth = modeStepOut, len(d.frames)
+}
+
+// Pause requests the running evaluation to stop before the next statement.
+// Unlike the other methods, it is called while the evaluation is running.
+func (d *Debugger) Pause() {
+	atomic.StoreInt32(&d.pausing, 1)
+}
+
+// Kill abandons the evaluation. Run returns immediately, and the evaluation never resumes.
+func (d *Debugger) Kill() {
+	d.killOnce.Do(func() {
+		close(d.killed)
+	})
+}
+
+// Frames returns the calls in progress, the innermost first
+func (d *Debugger) Frames() []*Frame {
+	frames := make([]*Frame, len(d.frames))
+	for i, f := range d.frames {
+		frames[len(frames)-1-i] = f
+	}
+	return frames
+}
+
+// Evaluate evaluates the source in the environment of the frame,
+// whose index is 0 for the innermost call
+func (d *Debugger) Evaluate(source string, frame int) (object.Object, error) {
+	frames := d.Frames()
+	if frame < 0 || frame >= len(frames) {
+		return nil, errors.Errorf("no frame %d", frame)
+	}
+	env := frames[frame].Env
+	if env == nil {
+		return nil, errors.New("frame has no environment yet")
+	}
+	program, err := parse(source)
+	if err != nil {
+		return nil, err
+	}
+	result := d.eval(program, env)
+	if result == nil {
+		result = evaluator.NULL
+	}
+	return result, nil
+}
+
+// eval evaluates the node without stopping in it
+func (d *Debugger) eval(node ast.Node, env *object.Environment) object.Object {
+	atomic.StoreInt32(&d.evaluating, 1)
+	defer atomic.StoreInt32(&d.evaluating, 0)
+	return evaluator.Eval(node, env)
+}
+
+func parse(source string) (*ast.Program, error) {
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, errors.Wrapf(p.Errors()[0], "could not parse %q", source)
+	}
+	return program, nil
+}
+
+// Scope is the variables bound in an environment
+type Scope struct {
+	Name      string // "local" または "global"
+	Variables []Variable
+}
+
+// Variable is the name and the value bound in a scope
+type Variable struct {
+	Name  string
+	Value object.Object
+}
+
+// Scopes returns the non-empty scopes visible from the frame, the innermost first
+func (d *Debugger) Scopes(frame int) ([]Scope, error) {
+	frames := d.Frames()
+	if frame < 0 || frame >= len(frames) {
+		return nil, errors.Errorf("no frame %d", frame)
+	}
+	scopes := []Scope{}
+	for env := frames[frame].Env; env != nil; env = env.Outer() {
+		names := env.Names()
+		if len(names) == 0 {
+			continue
+		}
+		scope := Scope{Name: "local"}
+		if env.Outer() == nil {
+			scope.Name = "global"
+		}
+		for _, name := range names {
+			value, _ := env.Get(name)
+			scope.Variables = append(scope.Variables, Variable{Name: name, Value: value})
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
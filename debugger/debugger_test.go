@@ -0,0 +1,156 @@
+package debugger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tshinag/monkey/evaluator"
+	"github.com/tshinag/monkey/lexer"
+	"github.com/tshinag/monkey/object"
+	"github.com/tshinag/monkey/parser"
+)
+
+const factorial = `let fact = fn(n) {
+  if (n < 2) {
+    return 1
+  }
+  n * fact(n - 1)
+}
+let x = fact(3)
+x + 1`
+
+func run(t *testing.T, d *Debugger, source string) (object.Object, bool) {
+	t.Helper()
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has errors: %v", p.Errors())
+	}
+	return d.Run(func() object.Object {
+		return evaluator.Eval(program, object.NewEnvironment())
+	})
+}
+
+func TestStops(t *testing.T) {
+	tests := []struct {
+		commands []func(*Debugger)
+		expected []string
+	}{
+		{
+			[]func(*Debugger){(*Debugger).StepIn, (*Debugger).StepIn, (*Debugger).StepIn, (*Debugger).Continue},
+			[]string{"entry <main> 1:1", "step <main> 7:1", "step fact 2:3", "step fact 5:3"},
+		},
+		{
+			[]func(*Debugger){(*Debugger).StepOver, (*Debugger).StepOver, (*Debugger).StepOver},
+			[]string{"entry <main> 1:1", "step <main> 7:1", "step <main> 8:1"},
+		},
+		{
+			[]func(*Debugger){(*Debugger).StepIn, (*Debugger).StepIn, (*Debugger).StepOut, (*Debugger).Continue},
+			[]string{"entry <main> 1:1", "step <main> 7:1", "step fact 2:3", "step <main> 8:1"},
+		},
+	}
+
+	for _, tt := range tests {
+		stops := []string{}
+		var d *Debugger
+		d = New(true, func(stop *Stop) {
+			stops = append(stops, string(stop.Reason)+" "+stop.Frame.Function+" "+stop.Frame.Node.Pos().String())
+			if len(stops) <= len(tt.commands) {
+				tt.commands[len(stops)-1](d)
+			}
+		})
+		result, finished := run(t, d, factorial)
+		if !finished || result.Inspect() != "7" {
+			t.Errorf("wrong result. finished=%t, result=%v", finished, result)
+		}
+		if strings.Join(stops, ", ") != strings.Join(tt.expected, ", ") {
+			t.Errorf("wrong stops. expected=%q, got=%q", tt.expected, stops)
+		}
+	}
+}
+
+func TestBreakpoints(t *testing.T) {
+	tests := []struct {
+		line      int
+		condition string
+		expected  []string
+	}{
+		{5, "", []string{"3", "2"}},
+		{5, "n == 2", []string{"2"}},
+		{3, "", []string{"1"}},
+		{4, "", nil},
+		{5, "m == 2", []string{"error: condition m == 2: identifier not found: m", "error: condition m == 2: identifier not found: m"}},
+	}
+
+	for _, tt := range tests {
+		stops := []string{}
+		var d *Debugger
+		d = New(false, func(stop *Stop) {
+			if stop.Err != nil {
+				stops = append(stops, "error: "+stop.Err.Error())
+				return
+			}
+			n, err := d.Evaluate("n", 0)
+			if err != nil {
+				t.Fatalf("Evaluate failed: %s", err)
+			}
+			stops = append(stops, n.Inspect())
+		})
+		bp, err := d.SetBreakpoint(tt.line, tt.condition)
+		if err != nil {
+			t.Fatalf("SetBreakpoint failed: %s", err)
+		}
+		run(t, d, factorial)
+		if strings.Join(stops, ", ") != strings.Join(tt.expected, ", ") {
+			t.Errorf("wrong stops at %d if %q. expected=%q, got=%q", tt.line, tt.condition, tt.expected, stops)
+		}
+		if tt.condition == "" && bp.Hits() != len(tt.expected) {
+			t.Errorf("wrong hits. expected=%d, got=%d", len(tt.expected), bp.Hits())
+		}
+	}
+}
+
+func TestCLI(t *testing.T) {
+	input := `break 5 if n == 2
+step out
+continue
+print n * 10
+backtrace
+frame 1
+print n
+vars
+delete 1
+next
+quit
+`
+	expected := `Stopped at entry, <main> at fact.mk:1:1
+1	let fact = fn(n) {
+(mdb) Breakpoint 1 at fact.mk:5 if n == 2
+(mdb) error: step takes no arguments, got "out" (type help for the commands)
+(mdb) Breakpoint 1, fact at fact.mk:5:3
+5	  n * fact(n - 1)
+(mdb) 20
+(mdb) *#0  fact at fact.mk:5:3
+ #1  fact at fact.mk:5:3
+ #2  <main> at fact.mk:7:1
+(mdb) #1  fact at fact.mk:5:3
+(mdb) 3
+(mdb) local:
+  n = 3
+global:
+  fact = fn fact(n)
+(mdb) Deleted breakpoint 1
+(mdb) <main> at fact.mk:8:1
+8	x + 1
+(mdb) `
+
+	var out bytes.Buffer
+	cli := NewCLI("fact.mk", factorial, strings.NewReader(input), &out)
+	if _, finished := run(t, cli.Debugger, factorial); finished {
+		t.Errorf("the debugger was not killed")
+	}
+	if out.String() != expected {
+		t.Errorf("wrong output. expected=\n%s\ngot=\n%s", expected, out.String())
+	}
+}
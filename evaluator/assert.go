@@ -0,0 +1,139 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tshinag/monkey/object"
+)
+
+// fnAssert fails with the optional message unless the condition is truthy
+func fnAssert(args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 2 {
+		return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+	if isTruthy(args[0]) {
+		return NULL
+	}
+	return newError("assertion failed%s", assertMessage(args[1:]))
+}
+
+// fnAssertEq fails unless the actual value, the first argument, equals the expected one.
+// It lists the different elements of arrays and hashes.
+func fnAssertEq(args ...object.Object) object.Object {
+	if len(args) < 2 || len(args) > 3 {
+		return newError("wrong number of arguments. got=%d, want=2 or 3", len(args))
+	}
+	actual, expected := args[0], args[1]
+	diffs := diff("", expected, actual)
+	if len(diffs) == 0 {
+		return NULL
+	}
+	// 値そのものが違う場合は一行で、配列やハッシュの中が違う場合はその一覧を続ける
+	if len(diffs) == 1 && strings.HasPrefix(diffs[0], "expected ") {
+		return newError("assert_eq failed%s: %s", assertMessage(args[2:]), diffs[0])
+	}
+	var out strings.Builder
+	fmt.Fprintf(&out, "assert_eq failed%s: expected %s, got %s", assertMessage(args[2:]), expected.Inspect(), actual.Inspect())
+	for _, d := range diffs {
+		out.WriteString("\n  ")
+		out.WriteString(d)
+	}
+	return newError("%s", out.String())
+}
+
+// fnAssertError fails unless calling the function without arguments returns an error,
+// whose message contains the optional substring
+func fnAssertError(args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 2 {
+		return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+	substring := ""
+	if len(args) == 2 {
+		s, ok := args[1].(*object.String)
+		if !ok {
+			return newError("second argument to `assert_error` must be STRING, got %s", args[1].Type())
+		}
+		substring = s.Value
+	}
+	result := evalFunction(args[0], []object.Object{})
+	err, ok := result.(*object.Error)
+	if !ok {
+		return newError("assert_error failed: expected an error, got %s", result.Inspect())
+	}
+	if !strings.Contains(err.Message, substring) {
+		return newError("assert_error failed: expected an error containing %q, got %q", substring, err.Message)
+	}
+	return NULL
+}
+
+func assertMessage(args []object.Object) string {
+	if len(args) == 0 {
+		return ""
+	}
+	if s, ok := args[0].(*object.String); ok {
+		return ": " + s.Value
+	}
+	return ": " + args[0].Inspect()
+}
+
+// diff returns the differences between the values, such as "[1]: expected 2, got 3".
+// Arrays and hashes are compared element by element, and the other values with ==.
+func diff(path string, expected, actual object.Object) []string {
+	prefix := ""
+	if path != "" {
+		prefix = path + ": "
+	}
+	switch expected := expected.(type) {
+	case *object.Array:
+		actual, ok := actual.(*object.Array)
+		if !ok {
+			break
+		}
+		diffs := []string{}
+		if len(expected.Elements) != len(actual.Elements) {
+			diffs = append(diffs, fmt.Sprintf("%sexpected length %d, got %d", prefix, len(expected.Elements), len(actual.Elements)))
+		}
+		for i := 0; i < len(expected.Elements) && i < len(actual.Elements); i++ {
+			diffs = append(diffs, diff(fmt.Sprintf("%s[%d]", path, i), expected.Elements[i], actual.Elements[i])...)
+		}
+		return diffs
+	case *object.Hash:
+		actual, ok := actual.(*object.Hash)
+		if !ok {
+			break
+		}
+		diffs := []string{}
+		for _, pair := range expected.SortedPairs() {
+			keyPath := path + "[" + inspectKey(pair.Key) + "]"
+			other, ok := actual.Pairs[pair.Key.(object.Hashable).HashKey()]
+			if !ok {
+				diffs = append(diffs, fmt.Sprintf("%s: missing, expected %s", keyPath, pair.Value.Inspect()))
+				continue
+			}
+			diffs = append(diffs, diff(keyPath, pair.Value, other.Value)...)
+		}
+		for _, pair := range actual.SortedPairs() {
+			if _, ok := expected.Pairs[pair.Key.(object.Hashable).HashKey()]; !ok {
+				keyPath := path + "[" + inspectKey(pair.Key) + "]"
+				diffs = append(diffs, fmt.Sprintf("%s: unexpected %s", keyPath, pair.Value.Inspect()))
+			}
+		}
+		return diffs
+	}
+	if expected.Type() != actual.Type() {
+		return []string{fmt.Sprintf("%sexpected %s (%s), got %s (%s)", prefix, expected.Inspect(), expected.Type(), actual.Inspect(), actual.Type())}
+	}
+	if !objectsEqual(actual, expected) {
+		return []string{fmt.Sprintf("%sexpected %s, got %s", prefix, expected.Inspect(), actual.Inspect())}
+	}
+	return nil
+}
+
+// inspectKey quotes the string keys, so that "1" and 1 are distinguished
+func inspectKey(key object.Object) string {
+	if s, ok := key.(*object.String); ok {
+		return fmt.Sprintf("%q", s.Value)
+	}
+	return key.Inspect()
+}
@@ -0,0 +1,128 @@
+package evaluator
+
+import (
+	"sync"
+
+	"github.com/tshinag/monkey/ast"
+	"github.com/tshinag/monkey/object"
+)
+
+// coroutine runs the body of an async function on its own goroutine.
+// Whoever resumes it waits until it awaits or returns, so only one of them runs at a time.
+type coroutine struct {
+	resume    chan struct{}
+	suspended chan struct{}
+}
+
+// newAsyncCall starts running fn until its first await, and returns the promise of its result
+func newAsyncCall(fn *object.Function, args []object.Object) *object.Promise {
+	promise := &object.Promise{}
+	co := &coroutine{
+		resume:    make(chan struct{}),
+		suspended: make(chan struct{}),
+	}
+	frame := &object.Frame{Function: fn, Await: co.await}
+	env := object.NewFrameEnvironment(fn.Env, frame)
+	bindParameters(fn, args, env)
+
+	go func() {
+		<-co.resume
+		result := unwrapReturnValue(Eval(fn.Body, env))
+		if err, ok := result.(*object.Error); ok && err.Function == "" {
+			err.Function = fn.Name
+		}
+		if result == nil {
+			result = NULL
+		}
+		promise.Settle(result)
+		co.suspended <- struct{}{}
+	}()
+	co.run()
+
+	return promise
+}
+
+// run resumes the coroutine and waits until it is suspended again
+func (co *coroutine) run() *object.Error {
+	if profiler != nil {
+		defer profiler.Switch(co)()
+	}
+	co.resume <- struct{}{}
+	<-co.suspended
+	return nil
+}
+
+// await suspends the coroutine until promise is settled. The event loop resumes it.
+func (co *coroutine) await(promise *object.Promise) object.Object {
+	promise.OnSettle(func() {
+		loop.enqueue(co.run)
+	})
+	co.suspended <- struct{}{}
+	<-co.resume
+	value, _ := promise.Result()
+	return value
+}
+
+// evalAwaitExpression suspends the async function until the promise is settled.
+// Outside async functions, it runs the event loop until then.
+// A rejected promise results in its error, and other values are returned as they are.
+func evalAwaitExpression(ae *ast.AwaitExpression, env *object.Environment) object.Object {
+	value := Eval(ae.Value, env)
+	if isError(value) {
+		return value
+	}
+	promise, ok := value.(*object.Promise)
+	if !ok {
+		return value
+	}
+	if frame := env.Frame(); frame != nil && frame.Await != nil {
+		return frame.Await(promise)
+	}
+	return loop.runUntilSettled(promise)
+}
+
+// fnAll returns the promise of the array of the results of promises,
+// which is rejected as soon as one of them is rejected
+func fnAll(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+	array, ok := args[0].(*object.Array)
+	if !ok {
+		return newError("argument to `all` must be ARRAY, got %s", args[0].Type())
+	}
+
+	all := &object.Promise{}
+	results := make([]object.Object, len(array.Elements))
+	remaining := len(array.Elements)
+	// Promise は spawn されたタスクから決着することもあるので、remaining を排他する
+	var mu sync.Mutex
+	if remaining == 0 {
+		all.Settle(&object.Array{Elements: results})
+		return all
+	}
+	for i, element := range array.Elements {
+		promise, ok := element.(*object.Promise)
+		if !ok {
+			promise = &object.Promise{}
+			promise.Settle(element)
+		}
+		i := i
+		promise.OnSettle(func() {
+			value, _ := promise.Result()
+			if isError(value) {
+				all.Settle(value)
+				return
+			}
+			mu.Lock()
+			results[i] = value
+			remaining--
+			done := remaining == 0
+			mu.Unlock()
+			if done {
+				all.Settle(&object.Array{Elements: results})
+			}
+		})
+	}
+	return all
+}
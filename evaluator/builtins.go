@@ -2,17 +2,64 @@ package evaluator
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"sort"
+	"unicode/utf8"
 
 	"github.com/tshinag/monkey/object"
 )
 
 var builtins = map[string]*object.Builtin{
-	"len":   &object.Builtin{Fn: fnLen},
 	"first": &object.Builtin{Fn: fnFirst},
 	"last":  &object.Builtin{Fn: fnLast},
 	"rest":  &object.Builtin{Fn: fnRest},
 	"push":  &object.Builtin{Fn: fnPush},
 	"puts":  &object.Builtin{Fn: fnPuts},
+	// イテレータ
+	"iter":    &object.Builtin{Fn: fnIter},
+	"next":    &object.Builtin{Fn: fnNext},
+	"range":   &object.Builtin{Fn: fnRange},
+	"take":    &object.Builtin{Fn: fnTake},
+	"collect": &object.Builtin{Fn: fnCollect},
+	// 並行処理
+	"wait":    &object.Builtin{Fn: fnWait},
+	"channel": &object.Builtin{Fn: fnChannel},
+	"send":    &object.Builtin{Fn: fnSend},
+	"recv":    &object.Builtin{Fn: fnRecv},
+	"close":   &object.Builtin{Fn: fnClose},
+	// 非同期処理
+	"sleep":          &object.Builtin{Fn: fnSleep},
+	"clear_timeout":  &object.Builtin{Fn: fnClearTimeout},
+	"clear_interval": &object.Builtin{Fn: fnClearInterval},
+	"now":            &object.Builtin{Fn: fnNow},
+	"all":            &object.Builtin{Fn: fnAll},
+}
+
+// The builtins calling back into Monkey functions refer to Eval through evalFunction,
+// so they are registered here to avoid an initialization cycle.
+func init() {
+	builtins["len"] = &object.Builtin{Fn: fnLen}
+	builtins["map"] = &object.Builtin{Fn: fnMap}
+	builtins["filter"] = &object.Builtin{Fn: fnFilter}
+	builtins["reduce"] = &object.Builtin{Fn: fnReduce}
+	builtins["spawn"] = &object.Builtin{Fn: fnSpawn}
+	builtins["set_timeout"] = &object.Builtin{Fn: fnSetTimeout}
+	builtins["set_interval"] = &object.Builtin{Fn: fnSetInterval}
+	// テスト
+	builtins["assert"] = &object.Builtin{Fn: fnAssert}
+	builtins["assert_eq"] = &object.Builtin{Fn: fnAssertEq}
+	builtins["assert_error"] = &object.Builtin{Fn: fnAssertError}
+}
+
+// BuiltinNames returns the sorted names of the builtin functions
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 func fnLen(args ...object.Object) object.Object {
@@ -25,12 +72,15 @@ func fnLen(args ...object.Object) object.Object {
 	case *object.Array:
 		return fnLenArray(arg)
 	default:
+		if method, ok := findOperatorMethod(arg, "__len__"); ok {
+			return evalFunction(method, []object.Object{})
+		}
 		return newError("argument to `len` not supported, got %s", arg.Type())
 	}
 }
 
 func fnLenString(str *object.String) object.Object {
-	return &object.Integer{Value: int64(len(str.Value))}
+	return &object.Integer{Value: int64(utf8.RuneCountInString(str.Value))}
 }
 
 func fnLenArray(arr *object.Array) object.Object {
@@ -118,9 +168,169 @@ func fnPushArray(arr *object.Array, obj object.Object) object.Object {
 	return &object.Array{Elements: newElements}
 }
 
+// output is where puts writes
+var output io.Writer = os.Stdout
+
+// SetOutput redirects the output of puts to w, such as when stdout is used for a protocol
+func SetOutput(w io.Writer) {
+	output = w
+}
+
 func fnPuts(args ...object.Object) object.Object {
 	for _, arg := range args {
-		fmt.Println(arg.Inspect())
+		fmt.Fprintln(output, arg.Inspect())
 	}
 	return NULL
 }
+
+func fnIter(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+	it, ok := iterate(args[0])
+	if !ok {
+		return newError("argument to `iter` must be iterable, got %s", args[0].Type())
+	}
+	return it
+}
+
+// fnNext returns {"value": value, "done": false}, or {"value": null, "done": true}
+// after the last value
+func fnNext(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+	it, ok := args[0].(*object.Iterator)
+	if !ok {
+		return newError("argument to `next` must be ITERATOR, got %s", args[0].Type())
+	}
+	value, ok := it.Next()
+	if !ok {
+		return newIteratorResult(NULL, true)
+	}
+	if isError(value) {
+		return value
+	}
+	return newIteratorResult(value, false)
+}
+
+func newIteratorResult(value object.Object, done bool) *object.Hash {
+	valueKey := &object.String{Value: "value"}
+	doneKey := &object.String{Value: "done"}
+	return &object.Hash{Pairs: map[object.HashKey]object.HashPair{
+		valueKey.HashKey(): {Key: valueKey, Value: value},
+		doneKey.HashKey():  {Key: doneKey, Value: referenceBooleanObject(done)},
+	}}
+}
+
+// fnRange accepts range(end), range(start, end) or range(start, end, step)
+func fnRange(args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 3 {
+		return newError("wrong number of arguments. got=%d, want=1..3", len(args))
+	}
+	bounds := []int64{0, 0, 1}
+	for i, arg := range args {
+		integer, ok := arg.(*object.Integer)
+		if !ok {
+			return newError("argument to `range` must be INTEGER, got %s", arg.Type())
+		}
+		bounds[i] = integer.Value
+	}
+	if len(args) == 1 {
+		bounds[0], bounds[1] = 0, bounds[0]
+	}
+	if bounds[2] == 0 {
+		return newError("range step cannot be zero")
+	}
+	return newRangeIterator(bounds[0], bounds[1], bounds[2])
+}
+
+func fnTake(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+	it, ok := iterate(args[0])
+	if !ok {
+		return newError("argument to `take` must be iterable, got %s", args[0].Type())
+	}
+	n, ok := args[1].(*object.Integer)
+	if !ok || n.Value < 0 {
+		return newError("second argument to `take` must be non-negative INTEGER, got %s", args[1].Inspect())
+	}
+	return newArrayFromIterator(it, n.Value)
+}
+
+func fnCollect(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+	it, ok := iterate(args[0])
+	if !ok {
+		return newError("argument to `collect` must be iterable, got %s", args[0].Type())
+	}
+	return newArrayFromIterator(it, -1)
+}
+
+func newArrayFromIterator(it *object.Iterator, limit int64) object.Object {
+	elements := collect(it, limit)
+	if len(elements) == 1 && isError(elements[0]) {
+		return elements[0]
+	}
+	return &object.Array{Elements: elements}
+}
+
+// fnMap returns an array for an array, otherwise a lazy iterator
+func fnMap(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+	it, ok := iterate(args[0])
+	if !ok {
+		return newError("argument to `map` must be iterable, got %s", args[0].Type())
+	}
+	mapped := newMapIterator(it, args[1])
+	if _, ok := args[0].(*object.Array); ok {
+		return newArrayFromIterator(mapped, -1)
+	}
+	return mapped
+}
+
+// fnFilter returns an array for an array, otherwise a lazy iterator
+func fnFilter(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+	it, ok := iterate(args[0])
+	if !ok {
+		return newError("argument to `filter` must be iterable, got %s", args[0].Type())
+	}
+	filtered := newFilterIterator(it, args[1])
+	if _, ok := args[0].(*object.Array); ok {
+		return newArrayFromIterator(filtered, -1)
+	}
+	return filtered
+}
+
+func fnReduce(args ...object.Object) object.Object {
+	if len(args) != 3 {
+		return newError("wrong number of arguments. got=%d, want=3", len(args))
+	}
+	it, ok := iterate(args[0])
+	if !ok {
+		return newError("argument to `reduce` must be iterable, got %s", args[0].Type())
+	}
+	accumulator := args[1]
+	for {
+		value, ok := it.Next()
+		if !ok {
+			return accumulator
+		}
+		if isError(value) {
+			return value
+		}
+		accumulator = evalFunction(args[2], []object.Object{accumulator, value})
+		if isError(accumulator) {
+			return accumulator
+		}
+	}
+}
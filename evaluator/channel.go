@@ -0,0 +1,199 @@
+package evaluator
+
+import (
+	"reflect"
+
+	"github.com/tshinag/monkey/ast"
+	"github.com/tshinag/monkey/object"
+)
+
+// fnSpawn runs the function with the rest of arguments on its own goroutine
+func fnSpawn(args ...object.Object) object.Object {
+	if len(args) < 1 {
+		return newError("wrong number of arguments. got=%d, want=1+", len(args))
+	}
+	fn := args[0]
+	if !isCallable(fn) {
+		return newError("argument to `spawn` must be FUNCTION, got %s", fn.Type())
+	}
+	task := &object.Task{Done: make(chan struct{})}
+	go func() {
+		defer close(task.Done)
+		task.Result = evalFunction(fn, args[1:])
+	}()
+	return task
+}
+
+// fnWait waits for a task and returns its result, or for an array of tasks
+// and returns the array of their results
+func fnWait(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+	switch arg := args[0].(type) {
+	case *object.Task:
+		return waitTask(arg)
+	case *object.Array:
+		results := make([]object.Object, len(arg.Elements))
+		for i, element := range arg.Elements {
+			task, ok := element.(*object.Task)
+			if !ok {
+				return newError("argument to `wait` must be TASK, got %s", element.Type())
+			}
+			results[i] = waitTask(task)
+			if isError(results[i]) {
+				return results[i]
+			}
+		}
+		return &object.Array{Elements: results}
+	default:
+		return newError("argument to `wait` must be TASK, got %s", arg.Type())
+	}
+}
+
+func waitTask(task *object.Task) object.Object {
+	<-task.Done
+	if task.Result == nil {
+		return NULL
+	}
+	return task.Result
+}
+
+func fnChannel(args ...object.Object) object.Object {
+	if len(args) > 1 {
+		return newError("wrong number of arguments. got=%d, want=0..1", len(args))
+	}
+	size := int64(0)
+	if len(args) == 1 {
+		integer, ok := args[0].(*object.Integer)
+		if !ok || integer.Value < 0 {
+			return newError("argument to `channel` must be non-negative INTEGER, got %s", args[0].Inspect())
+		}
+		size = integer.Value
+	}
+	return &object.Channel{Values: make(chan object.Object, size)}
+}
+
+func fnSend(args ...object.Object) (result object.Object) {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+	ch, ok := args[0].(*object.Channel)
+	if !ok {
+		return newError("argument to `send` must be CHANNEL, got %s", args[0].Type())
+	}
+	defer recoverClosedChannel(&result)
+	ch.Values <- args[1]
+	return NULL
+}
+
+// fnRecv returns the next value sent to the channel, or null after it is closed
+func fnRecv(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+	ch, ok := args[0].(*object.Channel)
+	if !ok {
+		return newError("argument to `recv` must be CHANNEL, got %s", args[0].Type())
+	}
+	value, ok := <-ch.Values
+	if !ok {
+		return NULL
+	}
+	return value
+}
+
+func fnClose(args ...object.Object) (result object.Object) {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+	ch, ok := args[0].(*object.Channel)
+	if !ok {
+		return newError("argument to `close` must be CHANNEL, got %s", args[0].Type())
+	}
+	defer recoverClosedChannel(&result)
+	close(ch.Values)
+	return NULL
+}
+
+// recoverClosedChannel turns the panic of sending to or closing a closed channel into an error
+func recoverClosedChannel(result *object.Object) {
+	if r := recover(); r != nil {
+		if err, ok := r.(error); ok {
+			*result = newError("%s", err)
+			return
+		}
+		panic(r)
+	}
+}
+
+func newChannelIterator(ch *object.Channel) *object.Iterator {
+	return &object.Iterator{Kind: "channel", NextFn: func() (object.Object, bool) {
+		value, ok := <-ch.Values
+		return value, ok
+	}}
+}
+
+// evalSelectExpression evaluates the channels and the values to send in source order,
+// then runs the body of a case whose operation can proceed. If several can proceed,
+// one of them is chosen at random. The default clause runs if none can proceed.
+func evalSelectExpression(se *ast.SelectExpression, env *object.Environment) object.Object {
+	cases := make([]reflect.SelectCase, 0, len(se.Cases))
+	clauses := make([]*ast.SelectCase, 0, len(se.Cases))
+	for _, sc := range se.Cases {
+		if sc.IsDefault() {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectDefault})
+			clauses = append(clauses, sc)
+			continue
+		}
+		evaluated := Eval(sc.Operation.Arguments[0], env)
+		if isError(evaluated) {
+			return evaluated
+		}
+		ch, ok := evaluated.(*object.Channel)
+		if !ok {
+			return newError("argument to `%s` must be CHANNEL, got %s",
+				sc.Operation.Function, evaluated.Type())
+		}
+		selectCase := reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ch.Values)}
+		if sc.IsSend() {
+			value := Eval(sc.Operation.Arguments[1], env)
+			if isError(value) {
+				return value
+			}
+			selectCase.Dir = reflect.SelectSend
+			selectCase.Send = reflect.ValueOf(&value).Elem()
+		}
+		cases = append(cases, selectCase)
+		clauses = append(clauses, sc)
+	}
+	if len(cases) == 0 {
+		return newError("select has no cases")
+	}
+
+	chosen, received, ok, err := selectChannels(cases)
+	if err != nil {
+		return err
+	}
+
+	clause := clauses[chosen]
+	clauseEnv := object.NewEnclosedEnvironment(env)
+	if clause.Name != nil {
+		value := object.Object(NULL)
+		if ok {
+			value = received.Interface().(object.Object)
+		}
+		clauseEnv.Set(clause.Name.Value, value)
+	}
+	result := evalBlockStatement(clause.Body, clauseEnv)
+	if result == nil {
+		return NULL
+	}
+	return result
+}
+
+func selectChannels(cases []reflect.SelectCase) (chosen int, received reflect.Value, ok bool, err object.Object) {
+	defer recoverClosedChannel(&err)
+	chosen, received, ok = reflect.Select(cases)
+	return chosen, received, ok, nil
+}
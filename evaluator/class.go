@@ -0,0 +1,96 @@
+package evaluator
+
+import (
+	"github.com/tshinag/monkey/ast"
+	"github.com/tshinag/monkey/object"
+)
+
+func evalClassStatement(cs *ast.ClassStatement, env *object.Environment) object.Object {
+	class := &object.Class{Name: cs.Name.Value, Methods: make(map[string]*object.Function)}
+	if cs.Superclass != nil {
+		superclass := evalIdentifier(cs.Superclass, env)
+		if isError(superclass) {
+			return superclass
+		}
+		sc, ok := superclass.(*object.Class)
+		if !ok {
+			return newError("cannot extend %s: not a class", superclass.Type())
+		}
+		class.Superclass = sc
+	}
+	for _, method := range cs.Methods {
+		fn := newFunction(method, env)
+		fn.Name = class.Name + "." + method.Name
+		class.Methods[method.Name] = fn
+	}
+	if err := env.Declare(cs.Name.Value, class, false); err != nil {
+		return newError("%s", err)
+	}
+	return nil
+}
+
+// newInstance constructs an instance and initializes it with "init" method
+func newInstance(class *object.Class, args []object.Object) object.Object {
+	instance := object.NewInstance(class)
+	init, definer, ok := class.FindMethod("init")
+	if !ok {
+		if len(args) != 0 {
+			return newError("wrong number of arguments to `%s`. got=%d, want=0",
+				class.Name, len(args))
+		}
+		return instance
+	}
+	result := evalFunction(bindMethod(instance, init, definer), args)
+	if isError(result) {
+		return result
+	}
+	return instance
+}
+
+// bindMethod returns the method which sees the receiver as "self",
+// and the methods of the superclass of definer as "super"
+func bindMethod(receiver *object.Instance, method *object.Function, definer *object.Class) *object.Function {
+	env := object.NewEnclosedEnvironment(method.Env)
+	env.Set("self", receiver)
+	if definer.Superclass != nil {
+		env.Set("super", &object.Super{Receiver: receiver, Class: definer.Superclass})
+	}
+	return &object.Function{
+		Name:       method.Name,
+		Parameters: method.Parameters,
+		Body:       method.Body,
+		Env:        env,
+		Generator:  method.Generator,
+		Async:      method.Async,
+	}
+}
+
+func evalInstanceProperty(instance *object.Instance, name string) object.Object {
+	if value, ok := instance.Get(name); ok {
+		return value
+	}
+	if method, definer, ok := instance.Class.FindMethod(name); ok {
+		return bindMethod(instance, method, definer)
+	}
+	return newError("%s has no field or method %s", instance.Class.Name, name)
+}
+
+func evalSuperProperty(super *object.Super, name string) object.Object {
+	if method, definer, ok := super.Class.FindMethod(name); ok {
+		return bindMethod(super.Receiver, method, definer)
+	}
+	return newError("%s has no method %s", super.Class.Name, name)
+}
+
+func evalInstanceOf(left, right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Class:
+		instance, ok := left.(*object.Instance)
+		return referenceBooleanObject(ok && instance.Class.IsSubclassOf(right))
+	case *object.StructDefinition:
+		s, ok := left.(*object.Struct)
+		return referenceBooleanObject(ok && s.Definition == right)
+	default:
+		return newError("right operand of instanceof must be a class or struct, got %s", right.Type())
+	}
+}
@@ -0,0 +1,19 @@
+package evaluator
+
+import "github.com/tshinag/monkey/ast"
+
+// Coverage counts the statements and the branches evaluated
+type Coverage interface {
+	// Statement is called before the statement of a program or a block is evaluated
+	Statement(stmt ast.Statement)
+	// Branch is called when the if expression takes the consequence, or the other arm if taken is false
+	Branch(ie *ast.IfExpression, taken bool)
+}
+
+// coverage is nil unless coverage is measured
+var coverage Coverage
+
+// SetCoverage attaches the coverage to the evaluator. nil detaches it.
+func SetCoverage(c Coverage) {
+	coverage = c
+}
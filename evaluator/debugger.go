@@ -0,0 +1,30 @@
+package evaluator
+
+import (
+	"github.com/tshinag/monkey/ast"
+	"github.com/tshinag/monkey/object"
+)
+
+// Debugger observes the evaluation. The methods are called on the goroutine evaluating the node,
+// so a debugger can pause the evaluation by blocking in them.
+type Debugger interface {
+	// Before is called before Eval evaluates the node in the environment
+	Before(node ast.Node, env *object.Environment)
+	// Call is called before the body of the function is evaluated in the environment of the call
+	Call(fn *object.Function, env *object.Environment)
+	// Return is called after the function returns the result
+	Return(fn *object.Function, result object.Object)
+}
+
+// debugger is nil unless a debugger is attached, so that Eval only pays for a nil check
+var debugger Debugger
+
+// SetDebugger attaches the debugger to the evaluator. nil detaches it.
+func SetDebugger(d Debugger) {
+	debugger = d
+}
+
+// IsTruthy reports whether the object is regarded as true by conditions
+func IsTruthy(obj object.Object) bool {
+	return isTruthy(obj)
+}
@@ -0,0 +1,181 @@
+package evaluator
+
+import (
+	"strings"
+
+	"github.com/tshinag/monkey/ast"
+	"github.com/tshinag/monkey/object"
+)
+
+func evalEnumStatement(es *ast.EnumStatement, env *object.Environment) object.Object {
+	enum := &object.Enum{Name: es.Name.Value}
+	for _, v := range es.Variants {
+		fields := make([]string, len(v.Fields))
+		for i, field := range v.Fields {
+			fields[i] = field.Value
+		}
+		enum.Variants = append(enum.Variants, &object.EnumVariant{Enum: enum, Name: v.Name.Value, Fields: fields})
+	}
+	if err := env.Declare(es.Name.Value, enum, false); err != nil {
+		return newError("%s", err)
+	}
+	return nil
+}
+
+// evalEnumProperty returns the constructor of the variant,
+// or the value itself if the variant has no payload
+func evalEnumProperty(enum *object.Enum, name string) object.Object {
+	variant, ok := enum.Variant(name)
+	if !ok {
+		return newError("%s has no variant %s", enum.Name, name)
+	}
+	if len(variant.Fields) == 0 {
+		return &object.EnumValue{Variant: variant}
+	}
+	return variant
+}
+
+func newEnumValue(variant *object.EnumVariant, args []object.Object) object.Object {
+	if len(args) != len(variant.Fields) {
+		return newError("wrong number of arguments to `%s.%s`. got=%d, want=%d",
+			variant.Enum.Name, variant.Name, len(args), len(variant.Fields))
+	}
+	values := make([]object.Object, len(args))
+	copy(values, args)
+	return &object.EnumValue{Variant: variant, Values: values}
+}
+
+func evalEnumValueProperty(ev *object.EnumValue, name string) object.Object {
+	if value, ok := ev.Get(name); ok {
+		return value
+	}
+	return newError("%s.%s has no field %s", ev.Variant.Enum.Name, ev.Variant.Name, name)
+}
+
+func evalEnumInfixExpression(operator string, left, right *object.EnumValue) object.Object {
+	switch operator {
+	case "==":
+		return referenceBooleanObject(enumValuesEqual(left, right))
+	case "!=":
+		return referenceBooleanObject(!enumValuesEqual(left, right))
+	default:
+		return newErrorInfixExpression(operator, left, right)
+	}
+}
+
+func enumValuesEqual(left, right *object.EnumValue) bool {
+	if left.Variant != right.Variant {
+		return false
+	}
+	for i := range left.Values {
+		if !objectsEqual(left.Values[i], right.Values[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchVariantPattern matches the enum value of the variant.
+// The pattern without payload patterns matches regardless of the payload.
+func matchVariantPattern(pattern *ast.VariantPattern, value object.Object, env *object.Environment) bool {
+	ev, ok := value.(*object.EnumValue)
+	if !ok || !isPatternVariant(pattern, ev.Variant, env) {
+		return false
+	}
+	if len(pattern.Fields) == 0 {
+		return true
+	}
+	if len(pattern.Fields) != len(ev.Values) {
+		return false
+	}
+	for i, field := range pattern.Fields {
+		if !matchPattern(field, ev.Values[i], env) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkVariantPattern reports the pattern naming a variant which doesn't exist,
+// or having a different number of payload patterns than the fields of the variant
+func checkVariantPattern(pattern *ast.VariantPattern, env *object.Environment) object.Object {
+	obj, ok := env.Get(pattern.Enum.Value)
+	if !ok {
+		return newError("identifier not found: %s", pattern.Enum.Value)
+	}
+	enum, ok := obj.(*object.Enum)
+	if !ok {
+		return newError("%s is not an enum: %s", pattern.Enum.Value, obj.Type())
+	}
+	variant, ok := enum.Variant(pattern.Variant.Value)
+	if !ok {
+		return newError("%s has no variant %s", enum.Name, pattern.Variant.Value)
+	}
+	// ペイロードのパターンを省略した場合はどのペイロードにも一致する
+	if len(pattern.Fields) != 0 && len(pattern.Fields) != len(variant.Fields) {
+		return newError("wrong number of fields for %s.%s: got=%d, want=%d",
+			enum.Name, variant.Name, len(pattern.Fields), len(variant.Fields))
+	}
+	for _, field := range pattern.Fields {
+		if err := checkPattern(field, env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isPatternVariant(pattern *ast.VariantPattern, variant *object.EnumVariant, env *object.Environment) bool {
+	enum, ok := env.Get(pattern.Enum.Value)
+	return ok && enum == variant.Enum && pattern.Variant.Value == variant.Name
+}
+
+// checkExhaustiveMatch reports the variants of the enum
+// which no arm of the match expression covers
+func checkExhaustiveMatch(me *ast.MatchExpression, enum *object.Enum, env *object.Environment) object.Object {
+	covered := make(map[*object.EnumVariant]bool)
+	for _, arm := range me.Arms {
+		if arm.Guard != nil {
+			continue
+		}
+		if coverVariants(arm.Pattern, enum, covered, env) {
+			return nil
+		}
+	}
+	missing := []string{}
+	for _, v := range enum.Variants {
+		if !covered[v] {
+			missing = append(missing, v.Name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return newError("non-exhaustive match on %s: missing %s", enum.Name, strings.Join(missing, ", "))
+}
+
+// coverVariants marks the variants the pattern always matches,
+// and returns true if the pattern matches any value
+func coverVariants(pattern ast.Pattern, enum *object.Enum, covered map[*object.EnumVariant]bool, env *object.Environment) bool {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern, *ast.BindingPattern:
+		return true
+	case *ast.AlternativePattern:
+		for _, a := range pattern.Alternatives {
+			if coverVariants(a, enum, covered, env) {
+				return true
+			}
+		}
+	case *ast.VariantPattern:
+		for _, field := range pattern.Fields {
+			if !coverVariants(field, enum, map[*object.EnumVariant]bool{}, env) {
+				return false
+			}
+		}
+		for _, v := range enum.Variants {
+			if isPatternVariant(pattern, v, env) {
+				covered[v] = true
+			}
+		}
+	}
+	return false
+}
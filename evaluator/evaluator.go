@@ -18,12 +18,15 @@ var (
 
 // Eval evaluates ast.Node
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	if debugger != nil {
+		debugger.Before(node, env)
+	}
 	switch node := node.(type) {
 	// statements
 	case *ast.Program:
 		return evalProgram(node, env)
 	case *ast.BlockStatement:
-		return evalBlockStatement(node, env)
+		return evalBlockStatement(node, object.NewEnclosedEnvironment(env))
 	case *ast.ExpressionStatement:
 		return Eval(node.Expression, env)
 	case *ast.ReturnStatement:
@@ -37,29 +40,52 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(val) {
 			return val
 		}
-		env.Set(node.Name.Value, val)
+		if err := env.Declare(node.Name.Value, val, false); err != nil {
+			return newError("%s", err)
+		}
+		return nil
+	case *ast.ConstStatement:
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		if err := env.Declare(node.Name.Value, val, true); err != nil {
+			return newError("%s", err)
+		}
 		return nil
 	// expressions
 	case *ast.CallExpression:
-		return evalCallExpression(node, env)
+		result, _ := evalCallExpression(node, env)
+		return result
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env)
+	case *ast.FunctionStatement:
+		// 関数宣言はブロックの先頭で巻き上げ済み
+		return nil
+	case *ast.StructStatement:
+		return evalStructStatement(node, env)
+	case *ast.ImplStatement:
+		return evalImplStatement(node, env)
+	case *ast.ClassStatement:
+		return evalClassStatement(node, env)
+	case *ast.EnumStatement:
+		return evalEnumStatement(node, env)
 	case *ast.FunctionLiteral:
-		params := node.Parameters
-		body := node.Body
-		return &object.Function{Parameters: params, Env: env, Body: body}
+		return newFunction(node, env)
 	case *ast.ArrayLiteral:
 		return evalArrayLiteral(node, env)
+	case *ast.PipeExpression:
+		return evalPipeExpression(node, env)
 	case *ast.IndexExpression:
-		left := Eval(node.Left, env)
-		if isError(left) {
-			return left
-		}
-		index := Eval(node.Index, env)
-		if isError(index) {
-			return index
-		}
-		return evalIndexExpression(left, index)
+		result, _ := evalIndexAccess(node, env)
+		return result
+	case *ast.PropertyExpression:
+		result, _ := evalPropertyExpression(node, env)
+		return result
+	case *ast.SliceExpression:
+		return evalSliceExpression(node, env)
 	case *ast.HashLiteral:
 		return evalHashLiteral(node, env)
 	case *ast.PrefixExpression:
@@ -69,6 +95,9 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		}
 		return evalPrefixExpression(node.Operator, right)
 	case *ast.InfixExpression:
+		if node.Operator == "??" {
+			return evalNullishExpression(node, env)
+		}
 		left := Eval(node.Left, env)
 		if isError(left) {
 			return left
@@ -80,18 +109,38 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return evalInfixExpression(node.Operator, left, right)
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
+	case *ast.SwitchExpression:
+		return evalSwitchExpression(node, env)
+	case *ast.MatchExpression:
+		return evalMatchExpression(node, env)
+	case *ast.SelectExpression:
+		return evalSelectExpression(node, env)
+	case *ast.ForExpression:
+		return evalForExpression(node, env)
+	case *ast.YieldExpression:
+		return evalYieldExpression(node, env)
+	case *ast.AwaitExpression:
+		return evalAwaitExpression(node, env)
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
 	case *ast.Boolean:
 		return referenceBooleanObject(node.Value)
+	case *ast.NullLiteral:
+		return NULL
 	}
 	return nil
 }
 
 func evalProgram(program *ast.Program, env *object.Environment) (result object.Object) {
+	if err := hoistFunctions(program.Statements, env); err != nil {
+		return err
+	}
 	for _, statement := range program.Statements {
+		if coverage != nil {
+			coverage.Statement(statement)
+		}
 		result = Eval(statement, env)
 		switch result := result.(type) {
 		case *object.ReturnValue:
@@ -104,7 +153,13 @@ func evalProgram(program *ast.Program, env *object.Environment) (result object.O
 }
 
 func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) (result object.Object) {
+	if err := hoistFunctions(block.Statements, env); err != nil {
+		return err
+	}
 	for _, statement := range block.Statements {
+		if coverage != nil {
+			coverage.Statement(statement)
+		}
 		result = Eval(statement, env)
 		switch result := result.(type) {
 		case *object.ReturnValue:
@@ -116,60 +171,284 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) (res
 	return result
 }
 
-func evalCallExpression(node *ast.CallExpression, env *object.Environment) object.Object {
-	function := Eval(node.Function, env)
+// hoistFunctions binds the functions declared in statements before running them,
+// so that declarations in the same block can call each other.
+func hoistFunctions(statements []ast.Statement, env *object.Environment) *object.Error {
+	for _, statement := range statements {
+		if fs, ok := statement.(*ast.FunctionStatement); ok {
+			fn := newFunction(fs.Function, env)
+			if err := env.Declare(fs.Name.Value, fn, false); err != nil {
+				return newError("%s", err)
+			}
+		}
+	}
+	return nil
+}
+
+func newFunction(node *ast.FunctionLiteral, env *object.Environment) *object.Function {
+	return &object.Function{
+		Name:       node.Name,
+		Parameters: node.Parameters,
+		Env:        env,
+		Body:       node.Body,
+		Generator:  node.Generator,
+		Async:      node.Async,
+	}
+}
+
+// evalChainLink evaluates the left side of property access, index or call.
+// skipped is true when an optional link in the chain found null, so that
+// the rest of the chain must be skipped.
+func evalChainLink(node ast.Expression, env *object.Environment) (result object.Object, skipped bool) {
+	switch node := node.(type) {
+	case *ast.CallExpression:
+		return evalCallExpression(node, env)
+	case *ast.IndexExpression:
+		return evalIndexAccess(node, env)
+	case *ast.PropertyExpression:
+		return evalPropertyExpression(node, env)
+	default:
+		return Eval(node, env), false
+	}
+}
+
+func evalCallExpression(node *ast.CallExpression, env *object.Environment) (object.Object, bool) {
+	function, skipped := evalChainLink(node.Function, env)
+	if skipped || node.Optional && function == NULL {
+		return NULL, true
+	}
+	if isError(function) {
+		return function, false
+	}
+	args := evalExpressions(node.Arguments, env, "call arguments")
+	if len(args) == 1 && isError(args[0]) {
+		return args[0], false
+	}
+	return callFunction(function, args, node), false
+}
+
+// evalPipeExpression calls the right side with the left value as the first argument.
+// A right side that is not a call is evaluated to a function and called with the value only.
+func evalPipeExpression(node *ast.PipeExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	call, ok := node.Right.(*ast.CallExpression)
+	if !ok {
+		function := Eval(node.Right, env)
+		if isError(function) {
+			return function
+		}
+		return callFunction(function, []object.Object{left}, node)
+	}
+
+	function, skipped := evalChainLink(call.Function, env)
+	if skipped || call.Optional && function == NULL {
+		return NULL
+	}
 	if isError(function) {
 		return function
 	}
-	var args []object.Object
-	for _, e := range node.Arguments {
-		arg := Eval(e, env)
-		if isError(arg) {
-			return arg
+	args := evalExpressions(call.Arguments, env, "call arguments")
+	if len(args) == 1 && isError(args[0]) {
+		return args[0]
+	}
+	return callFunction(function, append([]object.Object{left}, args...), call)
+}
+
+func evalIndexAccess(node *ast.IndexExpression, env *object.Environment) (object.Object, bool) {
+	left, skipped := evalChainLink(node.Left, env)
+	if skipped || node.Optional && left == NULL {
+		return NULL, true
+	}
+	if isError(left) {
+		return left, false
+	}
+	index := Eval(node.Index, env)
+	if isError(index) {
+		return index, false
+	}
+	return evalIndexExpression(left, index), false
+}
+
+func evalPropertyExpression(node *ast.PropertyExpression, env *object.Environment) (object.Object, bool) {
+	left, skipped := evalChainLink(node.Left, env)
+	if skipped || node.Optional && left == NULL {
+		return NULL, true
+	}
+	if isError(left) {
+		return left, false
+	}
+	return evalPropertyAccess(left, node.Property.Value), false
+}
+
+func evalPropertyAccess(left object.Object, name string) object.Object {
+	switch left := left.(type) {
+	case *object.Hash:
+		return evalHashIndexExpression(left, &object.String{Value: name})
+	case *object.Struct:
+		return evalStructProperty(left, name)
+	case *object.StructDefinition:
+		return evalStructDefinitionProperty(left, name)
+	case *object.Instance:
+		return evalInstanceProperty(left, name)
+	case *object.Super:
+		return evalSuperProperty(left, name)
+	case *object.Enum:
+		return evalEnumProperty(left, name)
+	case *object.EnumValue:
+		return evalEnumValueProperty(left, name)
+	case *object.Iterator:
+		if name == "next" {
+			return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+				return fnNext(append([]object.Object{left}, args...)...)
+			}}
 		}
-		args = append(args, arg)
+		return newError("unknown iterator property: %s", name)
 	}
-	return evalFunction(function, args)
+	return newError("property access not supported: %s", left.Type())
+}
+
+func evalNullishExpression(node *ast.InfixExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) || left != NULL {
+		return left
+	}
+	return Eval(node.Right, env)
+}
+
+// evalExpressions evaluates expressions expanding spread elements.
+// If an evaluation fails, it returns the error as the only element.
+func evalExpressions(exps []ast.Expression, env *object.Environment, context string) []object.Object {
+	result := make([]object.Object, 0, len(exps))
+	for _, e := range exps {
+		if spread, ok := e.(*ast.SpreadElement); ok {
+			value := Eval(spread.Value, env)
+			if isError(value) {
+				return []object.Object{value}
+			}
+			// for-in と同じく、反復できる値はすべて展開できる
+			if array, ok := value.(*object.Array); ok {
+				result = append(result, array.Elements...)
+				continue
+			}
+			it, ok := iterate(value)
+			if !ok {
+				return []object.Object{newErrorSpread(value, context)}
+			}
+			elements := collect(it, -1)
+			if len(elements) == 1 && isError(elements[0]) {
+				return elements
+			}
+			result = append(result, elements...)
+			continue
+		}
+		evaluated := Eval(e, env)
+		if isError(evaluated) {
+			return []object.Object{evaluated}
+		}
+		result = append(result, evaluated)
+	}
+	return result
+}
+
+// Call calls the function with the arguments as a call expression does.
+// It is intended for the hosts calling into the scripts, such as the test runner.
+func Call(fn object.Object, args ...object.Object) object.Object {
+	return evalFunction(fn, args)
 }
 
 func evalFunction(fn object.Object, args []object.Object) object.Object {
+	return callFunction(fn, args, nil)
+}
+
+// callFunction calls the function at the call site, which is nil
+// if the function is called by a builtin or an operator
+func callFunction(fn object.Object, args []object.Object, site ast.Node) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
+		if len(args) != len(fn.Parameters) {
+			if fn.Name != "" {
+				return newError("wrong number of arguments to `%s`. got=%d, want=%d",
+					fn.Name, len(args), len(fn.Parameters))
+			}
+			return newError("wrong number of arguments. got=%d, want=%d",
+				len(args), len(fn.Parameters))
+		}
+		if fn.Generator {
+			return newGenerator(fn, args)
+		}
+		if fn.Async {
+			return newAsyncCall(fn, args)
+		}
 		extendedEnv := extendFunctionEnv(fn, args)
-		evaluated := Eval(fn.Body, extendedEnv)
-		switch evaluated := evaluated.(type) {
-		case *object.ReturnValue:
-			return evaluated.Value
+		if profiler != nil {
+			defer profiler.Enter(fn, site)()
+		}
+		if debugger != nil {
+			debugger.Call(fn, extendedEnv)
+		}
+		evaluated := unwrapReturnValue(Eval(fn.Body, extendedEnv))
+		if err, ok := evaluated.(*object.Error); ok && err.Function == "" {
+			err.Function = fn.Name
+		}
+		if debugger != nil {
+			debugger.Return(fn, evaluated)
 		}
 		return evaluated
 	case *object.Builtin:
 		return fn.Fn(args...)
+	case *object.BoundMethod:
+		return evalBoundMethod(fn, args, site)
+	case *object.StructDefinition:
+		return newStruct(fn, args)
+	case *object.Class:
+		return newInstance(fn, args)
+	case *object.EnumVariant:
+		return newEnumValue(fn, args)
 	default:
 		return newError("not a function: %s", fn.Type())
 	}
 }
 
+// isCallable reports whether evalFunction can call obj
+func isCallable(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Function, *object.Builtin, *object.BoundMethod, *object.StructDefinition, *object.Class,
+		*object.EnumVariant:
+		return true
+	default:
+		return false
+	}
+}
+
 func evalArrayLiteral(node *ast.ArrayLiteral, env *object.Environment) object.Object {
-	var elements []object.Object
-	for _, e := range node.Elements {
-		element := Eval(e, env)
-		if isError(element) {
-			return element
-		}
-		elements = append(elements, element)
+	elements := evalExpressions(node.Elements, env, "array literal")
+	if len(elements) == 1 && isError(elements[0]) {
+		return elements[0]
 	}
 	return &object.Array{Elements: elements}
 }
 
 func evalIndexExpression(left, index object.Object) object.Object {
+	if method, ok := findOperatorMethod(left, "__index__"); ok {
+		return evalFunction(method, []object.Object{index})
+	}
 	switch left := left.(type) {
 	case *object.Array:
 		if i, ok := index.(*object.Integer); ok {
 			return evalArrayIndexExpression(left, i)
 		}
 		return newError("unusable as hash key: %s", index.Type())
+	case *object.String:
+		if i, ok := index.(*object.Integer); ok {
+			return evalStringIndexExpression(left, i)
+		}
+		return newError("string index must be INTEGER, got %s", index.Type())
 	case *object.Hash:
-		if i, ok := index.(object.Hashable); ok {
+		if i, ok := object.AsHashable(index); ok {
 			return evalHashIndexExpression(left, i)
 		}
 		return newError("unusable as hash key: %s", index.Type())
@@ -178,14 +457,119 @@ func evalIndexExpression(left, index object.Object) object.Object {
 }
 
 func evalArrayIndexExpression(array *object.Array, index *object.Integer) object.Object {
-	idx := index.Value
-	max := int64(len(array.Elements) - 1)
-	if idx < 0 || idx > max {
+	idx, ok := normalizeIndex(index.Value, len(array.Elements))
+	if !ok {
 		return NULL
 	}
 	return array.Elements[idx]
 }
 
+func evalStringIndexExpression(str *object.String, index *object.Integer) object.Object {
+	runes := []rune(str.Value)
+	idx, ok := normalizeIndex(index.Value, len(runes))
+	if !ok {
+		return NULL
+	}
+	return &object.String{Value: string(runes[idx])}
+}
+
+// normalizeIndex resolves negative index from the end, then checks its range
+func normalizeIndex(index int64, length int) (int64, bool) {
+	if index < 0 {
+		index += int64(length)
+	}
+	if index < 0 || index >= int64(length) {
+		return 0, false
+	}
+	return index, true
+}
+
+func evalSliceExpression(node *ast.SliceExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+	var bounds [3]*int64
+	for i, e := range []ast.Expression{node.Start, node.End, node.Step} {
+		if e == nil {
+			continue
+		}
+		bound := Eval(e, env)
+		if isError(bound) {
+			return bound
+		}
+		integer, ok := bound.(*object.Integer)
+		if !ok {
+			return newError("slice index must be INTEGER, got %s", bound.Type())
+		}
+		bounds[i] = &integer.Value
+	}
+	if bounds[2] != nil && *bounds[2] == 0 {
+		return newError("slice step cannot be zero")
+	}
+	switch left := left.(type) {
+	case *object.Array:
+		indices := sliceIndices(len(left.Elements), bounds[0], bounds[1], bounds[2])
+		elements := make([]object.Object, len(indices))
+		for i, idx := range indices {
+			elements[i] = left.Elements[idx]
+		}
+		return &object.Array{Elements: elements}
+	case *object.String:
+		runes := []rune(left.Value)
+		indices := sliceIndices(len(runes), bounds[0], bounds[1], bounds[2])
+		sliced := make([]rune, len(indices))
+		for i, idx := range indices {
+			sliced[i] = runes[idx]
+		}
+		return &object.String{Value: string(sliced)}
+	}
+	return newError("slice operator not supported: %s", left.Type())
+}
+
+// sliceIndices returns the indices selected by [start:end:step].
+// Omitted bounds default to the whole sequence in the direction of step, and
+// out-of-range bounds are clamped like Python's slices.
+func sliceIndices(length int, start, end, step *int64) []int64 {
+	n := int64(length)
+	s := int64(1)
+	if step != nil {
+		s = *step
+	}
+	clamp := func(bound *int64, def int64) int64 {
+		if bound == nil {
+			return def
+		}
+		b := *bound
+		if b < 0 {
+			b += n
+			if b < 0 {
+				if s < 0 {
+					return -1
+				}
+				return 0
+			}
+		} else if b >= n {
+			if s < 0 {
+				return n - 1
+			}
+			return n
+		}
+		return b
+	}
+	var from, to int64
+	if s > 0 {
+		from, to = clamp(start, 0), clamp(end, n)
+	} else {
+		from, to = clamp(start, n-1), clamp(end, -1)
+	}
+	indices := []int64{}
+	for i := from; (s > 0 && i < to) || (s < 0 && i > to); i += s {
+		indices = append(indices, i)
+	}
+	return indices
+}
+
 func evalHashIndexExpression(hash *object.Hash, index object.Hashable) object.Object {
 	if pair, ok := hash.Pairs[index.HashKey()]; ok {
 		return pair.Value
@@ -195,12 +579,27 @@ func evalHashIndexExpression(hash *object.Hash, index object.Hashable) object.Ob
 
 func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
 	pairs := make(map[object.HashKey]object.HashPair)
-	for keyNode, valueNode := range node.Pairs {
+	for _, keyNode := range node.Keys {
+		if spread, ok := keyNode.(*ast.SpreadElement); ok {
+			value := Eval(spread.Value, env)
+			if isError(value) {
+				return value
+			}
+			hash, ok := value.(*object.Hash)
+			if !ok {
+				return newErrorSpread(value, "hash literal")
+			}
+			for hashed, pair := range hash.Pairs {
+				pairs[hashed] = pair
+			}
+			continue
+		}
+		valueNode := node.Pairs[keyNode]
 		key := Eval(keyNode, env)
 		if isError(key) {
 			return key
 		}
-		hashKey, ok := key.(object.Hashable)
+		hashKey, ok := object.AsHashable(key)
 		if !ok {
 			return newError("unusable as hash key: %s", key.Type())
 		}
@@ -225,6 +624,9 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 }
 
 func evalPrefixExpression(operator string, right object.Object) object.Object {
+	if result, ok := evalOverloadedPrefixExpression(operator, right); ok {
+		return result
+	}
 	switch operator {
 	case "!":
 		return evalBangOperatorExpression(right)
@@ -259,6 +661,12 @@ func evalInfixExpression(
 	operator string,
 	left, right object.Object,
 ) object.Object {
+	if operator == "instanceof" {
+		return evalInstanceOf(left, right)
+	}
+	if result, ok := evalOverloadedInfixExpression(operator, left, right); ok {
+		return result
+	}
 	if li, ok := left.(*object.Integer); ok {
 		if ri, ok := right.(*object.Integer); ok {
 			return evalIntegerInfixExpression(operator, li, ri)
@@ -269,6 +677,11 @@ func evalInfixExpression(
 			return evalStringInfixExpression(operator, li, ri)
 		}
 	}
+	if li, ok := left.(*object.EnumValue); ok {
+		if ri, ok := right.(*object.EnumValue); ok {
+			return evalEnumInfixExpression(operator, li, ri)
+		}
+	}
 	return evalObjectInfixExpression(operator, left, right)
 }
 
@@ -333,8 +746,14 @@ func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Obje
 	if isError(condition) {
 		return condition
 	}
-	if isTruthy(condition) {
+	taken := isTruthy(condition)
+	if coverage != nil {
+		coverage.Branch(ie, taken)
+	}
+	if taken {
 		return Eval(ie.Consequence, env)
+	} else if ie.ElseIf != nil {
+		return Eval(ie.ElseIf, env)
 	} else if ie.Alternative != nil {
 		return Eval(ie.Alternative, env)
 	} else {
@@ -342,12 +761,262 @@ func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Obje
 	}
 }
 
+func evalSwitchExpression(se *ast.SwitchExpression, env *object.Environment) object.Object {
+	subject := Eval(se.Subject, env)
+	if isError(subject) {
+		return subject
+	}
+	matched := -1
+	for i, c := range se.Cases {
+		for _, v := range c.Values {
+			value := Eval(v, env)
+			if isError(value) {
+				return value
+			}
+			if objectsEqual(subject, value) {
+				matched = i
+				break
+			}
+		}
+		if matched >= 0 {
+			break
+		}
+	}
+	if matched < 0 {
+		for i, c := range se.Cases {
+			if c.IsDefault() {
+				matched = i
+			}
+		}
+	}
+	if matched < 0 {
+		return NULL
+	}
+	var result object.Object
+	for _, c := range se.Cases[matched:] {
+		result = Eval(c.Body, env)
+		switch result.(type) {
+		case *object.ReturnValue, *object.Error:
+			return result
+		}
+		if !c.Fallthrough {
+			break
+		}
+	}
+	if result == nil {
+		return NULL
+	}
+	return result
+}
+
+func evalForExpression(fe *ast.ForExpression, env *object.Environment) object.Object {
+	iterable := Eval(fe.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+	it, ok := iterate(iterable)
+	if !ok {
+		return newError("cannot iterate over %s", iterable.Type())
+	}
+	if err := checkPattern(fe.Pattern, env); err != nil {
+		return err
+	}
+	for {
+		value, ok := it.Next()
+		if !ok {
+			return NULL
+		}
+		if isError(value) {
+			return value
+		}
+		// 繰り返しごとに新しいスコープを作るので、クロージャはその回の値を捕捉する
+		loopEnv := object.NewEnclosedEnvironment(env)
+		if !matchPattern(fe.Pattern, value, loopEnv) {
+			return newError("for pattern does not match value: %s", value.Inspect())
+		}
+		result := evalBlockStatement(fe.Body, loopEnv)
+		switch result.(type) {
+		case *object.ReturnValue, *object.Error:
+			return result
+		}
+	}
+}
+
+func evalYieldExpression(ye *ast.YieldExpression, env *object.Environment) object.Object {
+	value := object.Object(NULL)
+	if ye.Value != nil {
+		value = Eval(ye.Value, env)
+		if isError(value) {
+			return value
+		}
+	}
+	frame := env.Frame()
+	if frame == nil || frame.Yield == nil {
+		return newError("yield outside generator")
+	}
+	frame.Yield(value)
+	return NULL
+}
+
+func evalMatchExpression(me *ast.MatchExpression, env *object.Environment) object.Object {
+	subject := Eval(me.Subject, env)
+	if isError(subject) {
+		return subject
+	}
+	// 一致しなかったことにすると後の _ に隠れるので、誤ったパターンは先に報告する
+	for _, arm := range me.Arms {
+		if err := checkPattern(arm.Pattern, env); err != nil {
+			return err
+		}
+	}
+	if ev, ok := subject.(*object.EnumValue); ok {
+		if err := checkExhaustiveMatch(me, ev.Variant.Enum, env); err != nil {
+			return err
+		}
+	}
+	for _, arm := range me.Arms {
+		armEnv := object.NewEnclosedEnvironment(env)
+		if !matchPattern(arm.Pattern, subject, armEnv) {
+			continue
+		}
+		if arm.Guard != nil {
+			guard := Eval(arm.Guard, armEnv)
+			if isError(guard) {
+				return guard
+			}
+			if !isTruthy(guard) {
+				continue
+			}
+		}
+		return Eval(arm.Body, armEnv)
+	}
+	return newError("no match arm for value: %s", subject.Inspect())
+}
+
+func matchPattern(pattern ast.Pattern, value object.Object, env *object.Environment) bool {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern:
+		return true
+	case *ast.BindingPattern:
+		env.Set(pattern.Name.Value, value)
+		return true
+	case *ast.LiteralPattern:
+		return objectsEqual(Eval(pattern.Value, env), value)
+	case *ast.AlternativePattern:
+		for _, a := range pattern.Alternatives {
+			if matchPattern(a, value, env) {
+				return true
+			}
+		}
+		return false
+	case *ast.ArrayPattern:
+		if array, ok := value.(*object.Array); ok {
+			return matchArrayPattern(pattern, array, env)
+		}
+		return false
+	case *ast.HashPattern:
+		if hash, ok := value.(*object.Hash); ok {
+			return matchHashPattern(pattern, hash, env)
+		}
+		return false
+	case *ast.VariantPattern:
+		return matchVariantPattern(pattern, value, env)
+	default:
+		return false
+	}
+}
+
+// checkPattern returns an error if the pattern can never be valid, like an unknown enum variant
+func checkPattern(pattern ast.Pattern, env *object.Environment) object.Object {
+	var patterns []ast.Pattern
+	switch pattern := pattern.(type) {
+	case *ast.VariantPattern:
+		return checkVariantPattern(pattern, env)
+	case *ast.AlternativePattern:
+		patterns = pattern.Alternatives
+	case *ast.ArrayPattern:
+		patterns = pattern.Elements
+	case *ast.HashPattern:
+		for _, pair := range pattern.Pairs {
+			patterns = append(patterns, pair.Value)
+		}
+	}
+	for _, p := range patterns {
+		if err := checkPattern(p, env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func matchArrayPattern(pattern *ast.ArrayPattern, array *object.Array, env *object.Environment) bool {
+	length := len(pattern.Elements)
+	if len(array.Elements) < length || pattern.Rest == nil && len(array.Elements) != length {
+		return false
+	}
+	for i, element := range pattern.Elements {
+		if !matchPattern(element, array.Elements[i], env) {
+			return false
+		}
+	}
+	if pattern.Rest != nil && pattern.Rest.Name != nil {
+		rest := make([]object.Object, len(array.Elements)-length)
+		copy(rest, array.Elements[length:])
+		env.Set(pattern.Rest.Name.Value, &object.Array{Elements: rest})
+	}
+	return true
+}
+
+func matchHashPattern(pattern *ast.HashPattern, hash *object.Hash, env *object.Environment) bool {
+	if pattern.Rest == nil && len(hash.Pairs) != len(pattern.Pairs) {
+		return false
+	}
+	matched := make(map[object.HashKey]bool)
+	for _, pair := range pattern.Pairs {
+		key, ok := object.AsHashable(Eval(pair.Key, env))
+		if !ok {
+			return false
+		}
+		hashKey := key.HashKey()
+		found, ok := hash.Pairs[hashKey]
+		if !ok || !matchPattern(pair.Value, found.Value, env) {
+			return false
+		}
+		matched[hashKey] = true
+	}
+	if pattern.Rest != nil && pattern.Rest.Name != nil {
+		rest := make(map[object.HashKey]object.HashPair)
+		for hashKey, pair := range hash.Pairs {
+			if !matched[hashKey] {
+				rest[hashKey] = pair
+			}
+		}
+		env.Set(pattern.Rest.Name.Value, &object.Hash{Pairs: rest})
+	}
+	return true
+}
+
+func objectsEqual(left, right object.Object) bool {
+	return evalInfixExpression("==", left, right) == TRUE
+}
+
+func unwrapReturnValue(obj object.Object) object.Object {
+	if returnValue, ok := obj.(*object.ReturnValue); ok {
+		return returnValue.Value
+	}
+	return obj
+}
+
 func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
 	env := object.NewEnclosedEnvironment(fn.Env)
+	bindParameters(fn, args, env)
+	return env
+}
+
+func bindParameters(fn *object.Function, args []object.Object, env *object.Environment) {
 	for i, param := range fn.Parameters {
 		env.Set(param.Value, args[i])
 	}
-	return env
 }
 
 func isTruthy(obj object.Object) bool {
@@ -381,6 +1050,10 @@ func newErrorInfixExpression(operator string, left, right object.Object) *object
 	return newErrorUnknownInfixOperator(operator, left, right)
 }
 
+func newErrorSpread(value object.Object, context string) *object.Error {
+	return newError("cannot spread %s in %s", value.Type(), context)
+}
+
 func newErrorTypeMismatch(operator string, left, right object.Object) *object.Error {
 	return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
 }
@@ -2,6 +2,7 @@ package evaluator
 
 import (
 	"testing"
+	"time"
 
 	"github.com/tshinag/monkey/lexer"
 	"github.com/tshinag/monkey/object"
@@ -30,7 +31,7 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
 	}
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		testIntegerObject(t, evaluated, tt.expected)
 	}
 }
@@ -44,7 +45,7 @@ func TestEvalStringExpression(t *testing.T) {
 		{`"Hello" + " " + "World!"`, "Hello World!"},
 	}
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		testStringObject(t, evaluated, tt.expected)
 	}
 }
@@ -83,7 +84,7 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{`"Hello World!" != "Hello," + " " + "world!"`, true},
 	}
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		testBooleanObject(t, evaluated, tt.expected)
 	}
 }
@@ -101,7 +102,7 @@ func TestBangOperator(t *testing.T) {
 		{"!!5", true},
 	}
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		testBooleanObject(t, evaluated, tt.expected)
 	}
 }
@@ -118,9 +119,13 @@ func TestIfElseExpressions(t *testing.T) {
 		{"if (1 > 2) { 10 }", nil},
 		{"if (1 > 2) { 10 } else { 20 }", 20},
 		{"if (1 < 2) { 10 } else { 20 }", 10},
+		{"if (1 > 2) { 10 } else if (1 < 2) { 20 } else { 30 }", 20},
+		{"if (1 > 2) { 10 } else if (1 > 2) { 20 } else { 30 }", 30},
+		{"if (1 > 2) { 10 } else if (1 > 2) { 20 }", nil},
+		{"if (1 > 2) { 10 } else if (1 > 2) { 20 } else if (true) { 40 }", 40},
 	}
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		integer, ok := tt.expected.(int)
 		if ok {
 			testIntegerObject(t, evaluated, int64(integer))
@@ -151,7 +156,7 @@ if (10 > 1) {
 		},
 	}
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		testIntegerObject(t, evaluated, tt.expected)
 	}
 }
@@ -210,7 +215,7 @@ func TestErrorHandling(t *testing.T) {
 		},
 	}
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		errObj, ok := evaluated.(*object.Error)
 		if !ok {
 			t.Errorf("no error object returned. got=%T(%+v)",
@@ -235,13 +240,122 @@ func TestLetStatements(t *testing.T) {
 		{"let a = 5; let b = a; let c = a + b + 5; c;", 15},
 	}
 	for _, tt := range tests {
-		testIntegerObject(t, testEval(tt.input), tt.expected)
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestBlockScoping(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let a = 1; if (true) { let a = 2; }; a", 1},
+		{"let a = 1; if (true) { let a = 2; a }", 2},
+		{"if (true) { let b = 2; }; b", "identifier not found: b"},
+		{"let a = 1; if (true) { a = 2; }; a", 2},
+		{"let a = 1; let f = fn() { a = a + 1; }; f(); f(); a", 3},
+		{"let a = 1; let f = fn(a) { a = 5; a }; f(2) + a", 6},
+		{"let a = 1; switch (1) { case 1: let a = 2; }; a", 1},
+		{"let a = 1; match (2) { x => if (true) { let a = x; a } }", 2},
+		{"let a = 1; match (2) { x => if (true) { let a = x; a } }; a", 1},
+		{
+			`let newCounter = fn() {
+				let count = 0;
+				fn() { count = count + 1 }
+			};
+			let c = newCounter();
+			c(); c();
+			c()`,
+			3,
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestDeclarationsAndAssignments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"const a = 5; a;", 5},
+		{"const a = 5; let b = a * 2; b;", 10},
+		{"let a = 5; a = 10; a;", 10},
+		{"let a = 5; let b = a = 10; a + b;", 20},
+		{"let a = 1; let b = 2; a = b = 3; a + b;", 6},
+		{"const a = 5; if (true) { let a = 1; a }", 1},
+		{"const a = 5; if (true) { const a = 1; a }", 1},
+		{"const a = 5; a = 10;", "cannot assign to constant: a"},
+		{"const a = 5; if (true) { a = 10; }", "cannot assign to constant: a"},
+		{"const a = 5; const a = 10;", "cannot redeclare constant: a"},
+		{"const a = 5; let a = 10;", "cannot redeclare constant: a"},
+		{"let a = 5; let a = 10;", "identifier already declared: a"},
+		{"let a = 5; const a = 10;", "identifier already declared: a"},
+		{"a = 5;", "identifier not found: a"},
+		{"let a = 5; a = b;", "identifier not found: b"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestAllowRedeclaration(t *testing.T) {
+	env := object.NewEnvironment()
+	env.AllowRedeclaration()
+
+	for _, input := range []string{"let a = 1;", "let a = a + 1;", "const b = a;"} {
+		evaluated := Eval(parser.New(lexer.New(input)).ParseProgram(), env)
+		if isError(evaluated) {
+			t.Fatalf("unexpected error for %q. got=%s", input, evaluated.Inspect())
+		}
+	}
+	a, _ := env.Get("a")
+	testIntegerObject(t, a, 2)
+
+	evaluated := Eval(parser.New(lexer.New("let b = 3;")).ParseProgram(), env)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+	}
+	if errObj.Message != "cannot redeclare constant: b" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
 	}
 }
 
 func TestFunctionObject(t *testing.T) {
 	input := "fn(x) { x + 2; };"
-	evaluated := testEval(input)
+	evaluated := testEval(t, input)
 	fn, ok := evaluated.(*object.Function)
 	if !ok {
 		t.Fatalf("object is not Function. got=%T (%+v)", evaluated, evaluated)
@@ -272,7 +386,220 @@ func TestFunctionApplication(t *testing.T) {
 		{"fn(x) { x; }(5)", 5},
 	}
 	for _, tt := range tests {
-		testIntegerObject(t, testEval(tt.input), tt.expected)
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestFunctionArity(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"fn(x, y) { x + y }(1)", "wrong number of arguments. got=1, want=2"},
+		{"fn() { 1 }(1, 2)", "wrong number of arguments. got=2, want=0"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestFunctionDeclarations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"fn add(a, b) { a + b }; add(1, 2)", 3},
+		{"let r = add(1, 2); fn add(a, b) { a + b }; r", 3},
+		{"fn fact(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(5)", 120},
+		{
+			`fn isEven(n) { if (n == 0) { true } else { isOdd(n - 1) } }
+			fn isOdd(n) { if (n == 0) { false } else { isEven(n - 1) } }
+			if (isEven(10)) { 1 } else { 0 }`,
+			1,
+		},
+		{
+			`let f = fn() {
+				let r = helper(2);
+				fn helper(x) { x * 10 }
+				r
+			};
+			f()`,
+			20,
+		},
+		{"if (true) { fn g() { 1 } }; g()", "identifier not found: g"},
+		{"fn f() { 1 }; fn f() { 2 }", "identifier already declared: f"},
+		{"let f = 1; fn f() { 2 }", "identifier already declared: f"},
+		{"fn add(a, b) { a + b }; add(1)", "wrong number of arguments to `add`. got=1, want=2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestFunctionNames(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedName    string
+		expectedInspect string
+	}{
+		{"fn add(a, b) { a + b }; add", "add", "fn add(a, b)"},
+		{"let double = fn(x) { x * 2 }; double", "double", "fn double(x)"},
+		{"const answer = fn() { 42 }; answer", "answer", "fn answer()"},
+		{"let f = fn(x) { x }; let g = f; g", "f", "fn f(x)"},
+		{"fn(x) { x }", "", "fn(x) {\nx\n}"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		fn, ok := evaluated.(*object.Function)
+		if !ok {
+			t.Errorf("object is not Function. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if fn.Name != tt.expectedName {
+			t.Errorf("fn.Name wrong. expected=%q, got=%q", tt.expectedName, fn.Name)
+		}
+		if fn.Inspect() != tt.expectedInspect {
+			t.Errorf("fn.Inspect() wrong. expected=%q, got=%q", tt.expectedInspect, fn.Inspect())
+		}
+	}
+}
+
+func TestErrorFunctionNames(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedFunction string
+		expectedInspect  string
+	}{
+		{
+			"fn add(a, b) { a + b }; add(1, true)",
+			"add",
+			"ERROR: type mismatch: INTEGER + BOOLEAN (in function add)",
+		},
+		{
+			"fn inner() { -true }; fn outer() { inner() }; outer()",
+			"inner",
+			"ERROR: unknown operator: -BOOLEAN (in function inner)",
+		},
+		{
+			"fn outer() { fn(x) { -x }(true) }; outer()",
+			"outer",
+			"ERROR: unknown operator: -BOOLEAN (in function outer)",
+		},
+		{
+			"fn(x) { -x }(true)",
+			"",
+			"ERROR: unknown operator: -BOOLEAN",
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Function != tt.expectedFunction {
+			t.Errorf("errObj.Function wrong. expected=%q, got=%q",
+				tt.expectedFunction, errObj.Function)
+		}
+		if errObj.Inspect() != tt.expectedInspect {
+			t.Errorf("errObj.Inspect() wrong. expected=%q, got=%q",
+				tt.expectedInspect, errObj.Inspect())
+		}
+	}
+}
+
+func TestLambdaLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let double = |x| x * 2; double(5);", 10},
+		{"let add = |x, y| x + y; add(2, 3);", 5},
+		{"(|| 42)()", 42},
+		{"let f = |x| { let y = x * 2; return y + 1; }; f(3)", 7},
+		{"let adder = |x| |y| x + y; adder(2)(3)", 5},
+		{"let apply = fn(f, x) { f(x) }; apply(|x| x - 1, 10)", 9},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+
+	evaluated := testEval(t, "let square = |x| x * x; square")
+	fn, ok := evaluated.(*object.Function)
+	if !ok {
+		t.Fatalf("object is not Function. got=%T (%+v)", evaluated, evaluated)
+	}
+	if fn.Name != "square" {
+		t.Errorf("function has wrong name. got=%q", fn.Name)
+	}
+}
+
+func TestPipeExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"[1, 2, 3] |> len", 3},
+		{"let double = |x| x * 2; 5 |> double |> double", 20},
+		{"let sub = fn(a, b) { a - b }; 10 |> sub(3)", 7},
+		{"let sub = fn(a, b) { a - b }; 10 |> sub(3) |> sub(2)", 5},
+		{"[1, 2] |> push(3) |> len", 3},
+		{"let args = [2, 3]; let f = fn(a, b, c) { a * b + c }; 4 |> f(...args)", 11},
+		{"1 + 2 |> |x| x * 10", 30},
+		{`let h = {"f": |x| x + 1}; 1 |> h.f()`, 2},
+		{`let h = {}; 1 |> h.f?.()`, nil},
+		{"1 |> 2", "not a function: INTEGER"},
+		{"1 |> f", "identifier not found: f"},
+		{"let sub = fn(a, b) { a - b }; 10 |> sub", "wrong number of arguments to `sub`. got=1, want=2"},
+		{"foo |> len", "identifier not found: foo"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
 	}
 }
 
@@ -285,7 +612,7 @@ let newAdder = fn(x) {
 let addTwo = newAdder(2);
 addTwo(2);`
 
-	testIntegerObject(t, testEval(input), 4)
+	testIntegerObject(t, testEval(t, input), 4)
 }
 
 func TestBuiltinFunctions(t *testing.T) {
@@ -296,12 +623,25 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`len("")`, 0},
 		{`len("four")`, 4},
 		{`len("hello world")`, 11},
+		{`len("日本語")`, 3},
 		{`len(1)`, "argument to `len` not supported, got INTEGER"},
 		{`len("one", "two")`, "wrong number of arguments. got=2, want=1"},
+		// テスト
+		{`assert(1 < 2); 1`, 1},
+		{`assert(1 > 2)`, "assertion failed"},
+		{`assert(false, "must hold")`, "assertion failed: must hold"},
+		{`assert_eq([1, {"a": 2}], [1, {"a": 2}]); 1`, 1},
+		{`assert_eq(1 + 1, 3)`, "assert_eq failed: expected 3, got 2"},
+		{`assert_eq("1", 1, "types")`, "assert_eq failed: types: expected 1 (INTEGER), got 1 (STRING)"},
+		{`assert_eq([1, 2, 4], [1, 3])`, "assert_eq failed: expected [1, 3], got [1, 2, 4]\n  expected length 2, got 3\n  [1]: expected 3, got 2"},
+		{`assert_eq({"a": [1], 2: 3}, {"a": [2], "b": 4})`, "assert_eq failed: expected {a: [2], b: 4}, got {2: 3, a: [1]}\n  [\"a\"][0]: expected 2, got 1\n  [\"b\"]: missing, expected 4\n  [2]: unexpected 3"},
+		{`assert_error(fn() { 1 + "a" }, "type mismatch"); 1`, 1},
+		{`assert_error(fn() { 1 })`, "assert_error failed: expected an error, got 1"},
+		{`assert_error(fn() { x }, "division")`, "assert_error failed: expected an error containing \"division\", got \"identifier not found: x\""},
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		switch expected := tt.expected.(type) {
 		case int:
 			testIntegerObject(t, evaluated, int64(expected))
@@ -322,7 +662,7 @@ func TestBuiltinFunctions(t *testing.T) {
 
 func TestArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
-	evaluated := testEval(input)
+	evaluated := testEval(t, input)
 	result, ok := evaluated.(*object.Array)
 	if !ok {
 		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
@@ -381,12 +721,20 @@ func TestArrayIndexExpressions(t *testing.T) {
 		},
 		{
 			"[1, 2, 3][-1]",
+			3,
+		},
+		{
+			"[1, 2, 3][-3]",
+			1,
+		},
+		{
+			"[1, 2, 3][-4]",
 			nil,
 		},
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		integer, ok := tt.expected.(int)
 		if ok {
 			testIntegerObject(t, evaluated, int64(integer))
@@ -396,6 +744,96 @@ func TestArrayIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestStringIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"abc"[0]`, "a"},
+		{`"abc"[2]`, "c"},
+		{`"abc"[-1]`, "c"},
+		{`"abc"[-3]`, "a"},
+		{`"日本語"[1]`, "本"},
+		{`"日本語"[-1]`, "語"},
+		{`"abc"[3]`, nil},
+		{`"abc"[-4]`, nil},
+		{`""[0]`, nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if str, ok := tt.expected.(string); ok {
+			testStringObject(t, evaluated, str)
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"[1, 2, 3, 4, 5][1:3]", []int64{2, 3}},
+		{"[1, 2, 3, 4, 5][:2]", []int64{1, 2}},
+		{"[1, 2, 3, 4, 5][3:]", []int64{4, 5}},
+		{"[1, 2, 3, 4, 5][:]", []int64{1, 2, 3, 4, 5}},
+		{"[1, 2, 3, 4, 5][::2]", []int64{1, 3, 5}},
+		{"[1, 2, 3, 4, 5][1::2]", []int64{2, 4}},
+		{"[1, 2, 3, 4, 5][-2:]", []int64{4, 5}},
+		{"[1, 2, 3, 4, 5][:-2]", []int64{1, 2, 3}},
+		{"[1, 2, 3, 4, 5][::-1]", []int64{5, 4, 3, 2, 1}},
+		{"[1, 2, 3, 4, 5][3:0:-1]", []int64{4, 3, 2}},
+		{"[1, 2, 3, 4, 5][-1:-3:-1]", []int64{5, 4}},
+		// out-of-range bounds are clamped
+		{"[1, 2, 3, 4, 5][3:100]", []int64{4, 5}},
+		{"[1, 2, 3, 4, 5][-100:2]", []int64{1, 2}},
+		{"[1, 2, 3, 4, 5][100:]", []int64{}},
+		{"[1, 2, 3, 4, 5][3:1]", []int64{}},
+		{"[1, 2, 3, 4, 5][100::-2]", []int64{5, 3, 1}},
+		{"[][0:1]", []int64{}},
+		{`"hello"[1:3]`, "el"},
+		{`"hello"[:-1]`, "hell"},
+		{`"hello"[::-1]`, "olleh"},
+		{`"日本語です"[1:3]`, "本語"},
+		{`"hello"[10:]`, ""},
+		{"[1, 2, 3][::0]", "slice step cannot be zero"},
+		{`[1, 2, 3]["a":]`, "slice index must be INTEGER, got STRING"},
+		{`{"a": 1}[0:1]`, "slice operator not supported: HASH"},
+		{"[1, 2, 3][true + 1:]", "type mismatch: BOOLEAN + INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case []int64:
+			array, ok := evaluated.(*object.Array)
+			if !ok {
+				t.Errorf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if len(array.Elements) != len(expected) {
+				t.Errorf("wrong num of elements for %q. want=%d, got=%d",
+					tt.input, len(expected), len(array.Elements))
+				continue
+			}
+			for i, e := range expected {
+				testIntegerObject(t, array.Elements[i], e)
+			}
+		case string:
+			if errObj, ok := evaluated.(*object.Error); ok {
+				if errObj.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q",
+						expected, errObj.Message)
+				}
+				continue
+			}
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
 func TestHashLiterals(t *testing.T) {
 	input := `let two = "two";
     {
@@ -407,7 +845,7 @@ func TestHashLiterals(t *testing.T) {
         false: 6
     }`
 
-	evaluated := testEval(input)
+	evaluated := testEval(t, input)
 	result, ok := evaluated.(*object.Hash)
 	if !ok {
 		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
@@ -432,6 +870,68 @@ func TestHashLiterals(t *testing.T) {
 	}
 }
 
+func TestSpreadElements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let a = [1, 2]; let b = [4]; [...a, 3, ...b]", []int64{1, 2, 3, 4}},
+		{"[...[], ...[]]", []int64{}},
+		{"let a = [1, 2]; [...a, ...a]", []int64{1, 2, 1, 2}},
+		{"let add = fn(x, y, z) { x + y + z }; let args = [2, 3]; add(1, ...args)", 6},
+		{"let add = fn(x, y) { x + y }; add(...[1, 2])", 3},
+		{`len(...["four"])`, 4},
+		{`let defaults = {"a": 1, "b": 2}; let overrides = {"b": 20}; let h = {...defaults, ...overrides}; h["a"] + h["b"]`, 21},
+		{`let defaults = {"a": 1}; let h = {"a": 10, ...defaults}; h["a"]`, 1},
+		{`let overrides = {"a": 10}; let h = {...overrides, "a": 1}; h["a"]`, 1},
+		{"[...1]", "cannot spread INTEGER in array literal"},
+		{`[..."ab"]`, []interface{}{"a", "b"}},
+		{`[...{"a": 1}]`, []interface{}{[]interface{}{"a", 1}}},
+		{`let c = channel(2); send(c, 1); send(c, 2); close(c); [...c]`, []int64{1, 2}},
+		{"{...[1]}", "cannot spread ARRAY in hash literal"},
+		{`len(..."abc")`, "wrong number of arguments. got=3, want=1"},
+		{"[...true]", "cannot spread BOOLEAN in array literal"},
+		{"[...foo]", "identifier not found: foo"},
+		{"let add = fn(x, y) { x + y }; add(...[1])", "wrong number of arguments to `add`. got=1, want=2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case []int64:
+			array, ok := evaluated.(*object.Array)
+			if !ok {
+				t.Errorf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if len(array.Elements) != len(expected) {
+				t.Errorf("wrong num of elements. want=%d, got=%d",
+					len(expected), len(array.Elements))
+				continue
+			}
+			for i, e := range expected {
+				testIntegerObject(t, array.Elements[i], e)
+			}
+		case []interface{}:
+			testArrayObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		}
+	}
+}
+
 func TestHashIndexExpressions(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -467,7 +967,7 @@ func TestHashIndexExpressions(t *testing.T) {
 		},
 	}
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		integer, ok := tt.expected.(int)
 		if ok {
 			testIntegerObject(t, evaluated, int64(integer))
@@ -477,10 +977,820 @@ func TestHashIndexExpressions(t *testing.T) {
 	}
 }
 
-func testEval(input string) object.Object {
+func TestSwitchExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"switch (1) { case 1: 10 case 2: 20 }", 10},
+		{"switch (2) { case 1: 10 case 2: 20 }", 20},
+		{"switch (3) { case 1: 10 case 2: 20 }", nil},
+		{"switch (3) { case 1: 10 default: 30 case 2: 20 }", 30},
+		{"switch (3) { case 1, 2: 10 case 3, 4: 20 }", 20},
+		{`switch ("b") { case "a": 1 case "b": 2 }`, 2},
+		{"switch (1 + 1) { case 1 * 2: 2 }", 2},
+		{"switch (1) { case 1: 10; 11 case 2: 20 }", 11},
+		{"switch (1) { case 1: }", nil},
+		{"switch (1) { case 1: 10 fallthrough; case 2: 20 }", 20},
+		{"switch (1) { case 1: 10 fallthrough case 2: 20 fallthrough case 3: 30 case 4: 40 }", 30},
+		{"switch (4) { default: 0 fallthrough case 1: 10 }", 10},
+		{"let f = fn(x) { switch (x) { case 1: return 10; case 2: 20 }; 30 }; f(1)", 10},
+		{"let f = fn(x) { switch (x) { case 1: return 10; case 2: 20 }; 30 }; f(2)", 30},
+		{"switch (1) { case 2: 20 case true + 1: 30 }", "type mismatch: BOOLEAN + INTEGER"},
+		{"switch (1) { case 1: true + 1 }", "type mismatch: BOOLEAN + INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)",
+					evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestMatchExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"match (1) { 1 => 10, _ => 20 }", 10},
+		{"match (2) { 1 => 10, _ => 20 }", 20},
+		{"match (-3) { -3 => 1, _ => 2 }", 1},
+		{`match ("b") { "a" => 1, "b" => 2 }`, 2},
+		{"match (true) { false => 1, true => 2 }", 2},
+		{"match (3) { 1 | 2 => 1, 3 | 4 => 2 }", 2},
+		{"match (5) { n => n * 2 }", 10},
+		{"match (5) { n if n > 10 => 1, n if n > 3 => 2, _ => 3 }", 2},
+		{"match ([]) { [] => 0, _ => 1 }", 0},
+		{"match ([1, 2]) { [x] => x, [x, y] => x + y }", 3},
+		{"match ([1, 2, 3]) { [x, y] => 0, [x, ...rest] => len(rest) }", 2},
+		{"match ([1, 2, 3]) { [_, ...] => 1 }", 1},
+		{"match ([1, [2, 3]]) { [a, [b, c]] => a + b + c }", 6},
+		{"match ([1, 2]) { [1, x] | [x, 1] => x }", 2},
+		{"match ([2, 1]) { [1, x] | [x, 1] => x }", 2},
+		{`match ({"a": 1}) { {"a": x} => x }`, 1},
+		{`match ({"a": 1, "b": 2}) { {"a": x} => x, _ => 0 }`, 0},
+		{`match ({"a": 1, "b": 2}) { {"a": x, ...} => x }`, 1},
+		{`match ({"a": 1, "b": 2, "c": 3}) { {"a": 1, ...rest} => rest["b"] + rest["c"] }`, 5},
+		{`match ({"a": 1, "b": 2, "c": 3}) { {"a": 1, ...rest} => len(rest["a"]) }`, "argument to `len` not supported, got NULL"},
+		{`match ({"a": 1, "b": 2}) { {"a": 1, ...rest} => rest["b"] }`, 2},
+		{`match ({"a": [1, 2]}) { {"a": [_, y]} => y }`, 2},
+		{"let x = 1; match (2) { x => x }", 2},
+		{"let x = 1; match (2) { y => y }; x", 1},
+		{"match (4) { 1 => 1 }", "no match arm for value: 4"},
+		{`match ("x") { 1 => 1 }`, "no match arm for value: x"},
+		{"match (1) { n if n + true => 1 }", "type mismatch: INTEGER + BOOLEAN"},
+		{"match (1 + true) { _ => 1 }", "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)",
+					evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestNullLiteral(t *testing.T) {
+	testNullObject(t, testEval(t, "null"))
+	testBooleanObject(t, testEval(t, "null == null"), true)
+	testBooleanObject(t, testEval(t, "null != 1"), true)
+	testBooleanObject(t, testEval(t, "!null"), true)
+	testIntegerObject(t, testEval(t, "match (null) { null => 1, _ => 2 }"), 1)
+	testIntegerObject(t, testEval(t, "match (0) { null => 1, _ => 2 }"), 2)
+}
+
+func TestPropertyAndOptionalChaining(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let h = {"a": {"b": 1}}; h.a.b`, 1},
+		{`let h = {"a": {"b": 1}}; h.a["b"]`, 1},
+		{`let h = {"a": 1}; h.b`, nil},
+		{`let h = {"a": 1}; h?.a`, 1},
+		{`let h = null; h?.a`, nil},
+		{`let h = null; h?.a.b.c`, nil},
+		{`let h = null; h?.a[0](1)`, nil},
+		{`let h = {"a": null}; h.a?.b`, nil},
+		{`let h = {"a": {"b": 1}}; h.a?.b`, 1},
+		{`let h = {}; h.a?.["b"]`, nil},
+		{`let xs = [1, 2]; xs?.[1]`, 2},
+		{`let xs = null; xs?.[1]`, nil},
+		{`let f = null; f?.(1)`, nil},
+		{`let f = fn(x) { x * 2 }; f?.(2)`, 4},
+		{`let h = {"f": fn(x) { x + 1 }}; h.f(1)`, 2},
+		{`let h = {}; h.f?.(1)`, nil},
+		{`let h = {}; h.a.b`, "property access not supported: NULL"},
+		{`let h = {}; h.a["b"]`, "index operator not supported: NULL"},
+		{`let h = {"a": null}; h?.a.b`, "property access not supported: NULL"},
+		{`let x = 1; x.a`, "property access not supported: INTEGER"},
+		{`let x = 1; x?.a`, "property access not supported: INTEGER"},
+		{`let h = null; h?.a[foo]`, nil},
+		{`let h = {"a": [1]}; h?.a[foo]`, "identifier not found: foo"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestNullishCoalescing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"null ?? 1", 1},
+		{"2 ?? 1", 2},
+		{"false ?? 1", false},
+		{"0 ?? 1", 0},
+		{"null ?? null ?? 3", 3},
+		{"null ?? null", nil},
+		{`let h = {}; h.a?.b ?? 5`, 5},
+		{`let h = {"a": {"b": 6}}; h.a?.b ?? 5`, 6},
+		{"1 ?? foo", 1},
+		{"null ?? foo", "identifier not found: foo"},
+		{"foo ?? 1", "identifier not found: foo"},
+		{"let x = null; x = x ?? 7; x", 7},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q",
+					expected, errObj.Message)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestGenerators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn gen() { yield 1; yield 2; yield 3 }; collect(gen())", "[1, 2, 3]"},
+		{"let gen = fn(n) { yield n; yield n * 2 }; collect(gen(5))", "[5, 10]"},
+		{"fn gen() { yield; }; collect(gen())", "[null]"},
+		{"fn gen() { yield 1; return 5; yield 2 }; collect(gen())", "[1]"},
+		{"fn gen() { yield 1 }; gen()", "generator iterator"},
+		{"fn gen() { yield 1 }; let it = gen(); [next(it), next(it), next(it)]",
+			"[{done: false, value: 1}, {done: true, value: null}, {done: true, value: null}]"},
+		{"fn gen() { yield 1 }; let it = gen(); it.next().value", "1"},
+		{"fn gen() { yield 1 }; gen().foo", "ERROR: unknown iterator property: foo"},
+		{"let naturals = fn() { for (i in range(0, 9223372036854775807)) { yield i } }; take(naturals(), 4)", "[0, 1, 2, 3]"},
+		{"let squares = |xs| { for (x in xs) { yield x * x } }; collect(squares([1, 2, 3]))", "[1, 4, 9]"},
+		{"fn inner() { yield 1; yield 2 }; fn outer() { for (x in inner()) { yield x * 10 } }; [...outer(), 3]", "[10, 20, 3]"},
+		{"let count = 0; fn gen() { count = count + 1; yield count }; let it = gen(); count", "0"},
+		{"let count = 0; fn gen() { count = count + 1; yield count }; let it = gen(); next(it); next(it); count", "1"},
+		{"fn gen() { let f = fn() { 1 }; yield f() + 1 }; collect(gen())", "[2]"},
+		{"fn gen() { yield 1; foo }; collect(gen())", "ERROR: identifier not found: foo (in function gen)"},
+		{"fn gen() { yield 1; foo }; let it = gen(); next(it); [next(it)]", "ERROR: identifier not found: foo (in function gen)"},
+		{"fn gen(a) { yield a }; gen()", "ERROR: wrong number of arguments to `gen`. got=0, want=1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestForExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let sum = 0; for (x in [1, 2, 3]) { sum = sum + x }; sum", "6"},
+		{`let s = ""; for (c in "héllo") { s = c + s }; s`, "olléh"},
+		{`let s = ""; for ([k, v] in {"b": 2, "a": 1}) { s = s + k }; s`, "ab"},
+		{"let sum = 0; for (i in range(5)) { sum = sum + i }; sum", "10"},
+		{"let xs = []; for (i in range(10, 0, -3)) { xs = push(xs, i) }; xs", "[10, 7, 4, 1]"},
+		{"for (x in []) { x }", "null"},
+		{"for (x in [1]) { x }", "null"},
+		{"let f = fn() { for (x in [1, 2, 3]) { if (x == 2) { return x * 10 } } }; f()", "20"},
+		{"let fs = []; for (x in [1, 2]) { fs = push(fs, fn() { x }) }; [fs[0](), fs[1]()]", "[1, 2]"},
+		{"for (x in [1]) { let y = x }; y", "ERROR: identifier not found: y"},
+		{"for (x in [1]) { let x = 2 }", "ERROR: identifier already declared: x"},
+		{"for (x in 1) { x }", "ERROR: cannot iterate over INTEGER"},
+		{"for ([a, b] in [[1, 2], 3]) { a }", "ERROR: for pattern does not match value: 3"},
+		{"for (x in [1, 2]) { foo }", "ERROR: identifier not found: foo"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestIteratorBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"collect(range(3))", "[0, 1, 2]"},
+		{"collect(range(1, 4))", "[1, 2, 3]"},
+		{"collect(range(0, 10, 4))", "[0, 4, 8]"},
+		{"collect(range(3, 0, -1))", "[3, 2, 1]"},
+		{"collect(range(3, 0))", "[]"},
+		{"range(3)", "range iterator"},
+		{"range(0, 1, 0)", "ERROR: range step cannot be zero"},
+		{`range("a")`, "ERROR: argument to `range` must be INTEGER, got STRING"},
+		{"range()", "ERROR: wrong number of arguments. got=0, want=1..3"},
+		{`collect("ab")`, "[a, b]"},
+		{`collect({1: "a", 2: "b"})`, "[[1, a], [2, b]]"},
+		{"collect(1)", "ERROR: argument to `collect` must be iterable, got INTEGER"},
+		{"iter([1])", "array iterator"},
+		{"let it = iter([1, 2]); next(it); collect(it)", "[2]"},
+		{"next([1])", "ERROR: argument to `next` must be ITERATOR, got ARRAY"},
+		{"take(range(10), 2)", "[0, 1]"},
+		{"take([1], 5)", "[1]"},
+		{"take([1], -1)", "ERROR: second argument to `take` must be non-negative INTEGER, got -1"},
+		{"map([1, 2, 3], |x| x * 2)", "[2, 4, 6]"},
+		{"map(range(3), |x| x * 2)", "map iterator"},
+		{"collect(map(range(3), |x| x * 2))", "[0, 2, 4]"},
+		{"filter([1, 2, 3, 4], |x| x > 2)", "[3, 4]"},
+		{"range(1, 100) |> filter(|x| x / 7 * 7 == x) |> map(|x| x * x) |> take(2)", "[49, 196]"},
+		{"reduce([1, 2, 3], 0, |acc, x| acc + x)", "6"},
+		{"reduce(range(1, 5), 1, |acc, x| acc * x)", "24"},
+		{`reduce([], "empty", |acc, x| x)`, "empty"},
+		{"map([1], |x| foo)", "ERROR: identifier not found: foo"},
+		{"filter([1], |x| foo)", "ERROR: identifier not found: foo"},
+		{"reduce([1], 0, |x| x)", "ERROR: wrong number of arguments. got=2, want=1"},
+		{"map(1, |x| x)", "ERROR: argument to `map` must be iterable, got INTEGER"},
+		{"[...range(3)]", "[0, 1, 2]"},
+		{"let f = fn(a, b) { a + b }; f(...iter([1, 2]))", "3"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestConcurrency(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"wait(spawn(fn(x) { x * 2 }, 21))", "42"},
+		{"wait(spawn(len, [1, 2]))", "2"},
+		{"wait(spawn(fn() { let x = 1 }))", "null"},
+		{"wait(spawn(fn() { foo }))", "ERROR: identifier not found: foo"},
+		{"wait(map([1, 2, 3], |x| spawn(|| x * x)))", "[1, 4, 9]"},
+		{"spawn(1)", "ERROR: argument to `spawn` must be FUNCTION, got INTEGER"},
+		{"wait(1)", "ERROR: argument to `wait` must be TASK, got INTEGER"},
+		{"wait([1])", "ERROR: argument to `wait` must be TASK, got INTEGER"},
+		{"channel()", "channel(0)"},
+		{"channel(-1)", "ERROR: argument to `channel` must be non-negative INTEGER, got -1"},
+		{"let ch = channel(1); send(ch, 5); recv(ch)", "5"},
+		{"let ch = channel(); spawn(send, ch, 7); recv(ch)", "7"},
+		{"let ch = channel(); close(ch); recv(ch)", "null"},
+		{"let ch = channel(); close(ch); send(ch, 1)", "ERROR: send on closed channel"},
+		{"let ch = channel(); close(ch); close(ch)", "ERROR: close of closed channel"},
+		{"recv(1)", "ERROR: argument to `recv` must be CHANNEL, got INTEGER"},
+		{`let ch = channel();
+		  spawn(fn() { for (i in range(5)) { send(ch, i) }; close(ch) });
+		  reduce(ch, 0, |acc, x| acc + x)`, "10"},
+		{`let results = channel(3);
+		  let tasks = map([1, 2, 3], |x| spawn(|| send(results, x * 10)));
+		  wait(tasks);
+		  close(results);
+		  reduce(results, 0, |acc, x| acc + x)`, "60"},
+		{`let count = 0;
+		  let lock = channel(1);
+		  let tasks = collect(map(range(20), |i| spawn(fn() {
+		    send(lock, true);
+		    count = count + 1;
+		    recv(lock);
+		  })));
+		  wait(tasks);
+		  count`, "20"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestSelectExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let a = channel(1); let b = channel(1); send(b, 2);
+		  select { case let v = recv(a): v case let v = recv(b): v * 10 }`, "20"},
+		{`let a = channel(1);
+		  select { case send(a, 3): recv(a) default: 0 }`, "3"},
+		{`let a = channel();
+		  select { case let v = recv(a): v default: "none" }`, "none"},
+		{`let a = channel(); close(a);
+		  select { case let v = recv(a): v }`, "null"},
+		{`let a = channel();
+		  spawn(fn() { send(a, 4) });
+		  select { case let v = recv(a): v + 1 }`, "5"},
+		{`let a = channel(); close(a);
+		  select { case send(a, 1): 1 }`, "ERROR: send on closed channel"},
+		{`select { case recv(1): 1 }`, "ERROR: argument to `recv` must be CHANNEL, got INTEGER"},
+		{`let a = channel(1); select { case send(a, foo): 1 }`, "ERROR: identifier not found: foo"},
+		{`select { default: 1 }`, "1"},
+		{`select { }`, "ERROR: select has no cases"},
+		{`let a = channel(1); send(a, 1); select { case let v = recv(a): let w = v }; v`, "ERROR: identifier not found: v"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestAsyncAwait(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"async fn f() { 1 }; f()", "promise (fulfilled: 1)"},
+		{"async fn f() { return 2; 3 }; await f()", "2"},
+		{"async fn f() { let x = 1 }; await f()", "null"},
+		{"let f = async fn(x) { x + 1 }; await f(1)", "2"},
+		{"let f = async |x| x * 3; await f(2)", "6"},
+		{"let f = async |x| x * 3; f", "async fn f(x)"},
+		{"await 5", "5"},
+		{"async fn f(x) { await sleep(100); x * 2 }; let r = await f(21); [r, now()]", "[42, 100]"},
+		{"async fn f() { await sleep(10) }; f()", "promise (fulfilled: null)"},
+		{"async fn f() { foo }; f()", "promise (rejected: identifier not found: foo)"},
+		{"async fn f() { foo }; await f()", "ERROR: identifier not found: foo (in function f)"},
+		{"async fn f() { await sleep(1); foo }; async fn g() { await f(); 1 }; await g()",
+			"ERROR: identifier not found: foo (in function f)"},
+		{`let log = [];
+		  async fn f() { log = push(log, 1); await sleep(0); log = push(log, 3) }
+		  let p = f();
+		  log = push(log, 2);
+		  await p;
+		  log`, "[1, 2, 3]"},
+		{`async fn slow(x, ms) { await sleep(ms); x }
+		  let r = await all([slow(1, 300), slow(2, 100), 3]);
+		  [r, now()]`, "[[1, 2, 3], 300]"},
+		{"await all([])", "[]"},
+		{"async fn f() { foo }; await all([f(), sleep(10)])", "ERROR: identifier not found: foo (in function f)"},
+		{"all(1)", "ERROR: argument to `all` must be ARRAY, got INTEGER"},
+		{`async fn worker(ch) { await sleep(50); send(ch, "done") }
+		  let ch = channel(1);
+		  await worker(ch);
+		  recv(ch)`, "done"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalAsync(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestTimers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let log = [];
+		  set_timeout(fn() { log = push(log, "b") }, 20);
+		  set_timeout(fn() { log = push(log, "a") }, 10);
+		  set_timeout(fn() { log = push(log, "c") }, 20);
+		  await sleep(30);
+		  log`, "[a, b, c]"},
+		{`let log = [];
+		  set_timeout(fn(x, y) { log = push(log, x + y) }, 5, 1, 2);
+		  await sleep(5);
+		  log`, "[3]"},
+		{`let ticks = [];
+		  let id = 0;
+		  id = set_interval(fn() {
+		    ticks = push(ticks, now());
+		    if (len(ticks) == 3) { clear_interval(id) }
+		  }, 100);
+		  await sleep(1000);
+		  ticks`, "[100, 200, 300]"},
+		{`let fired = false;
+		  let id = set_timeout(fn() { fired = true }, 10);
+		  clear_timeout(id);
+		  await sleep(20);
+		  fired`, "false"},
+		{"clear_timeout(100)", "null"},
+		{"now()", "0"},
+		{"await sleep(86400000); now()", "86400000"},
+		{"set_timeout(fn() { foo }, 10); 1", "ERROR: identifier not found: foo"},
+		{"set_timeout(fn() { foo }, 10); await sleep(20)", "ERROR: identifier not found: foo"},
+		{"set_timeout(1, 10)", "ERROR: argument to `set_timeout` must be FUNCTION, got INTEGER"},
+		{"set_timeout(fn() {}, -1)", "ERROR: delay of `set_timeout` must be non-negative INTEGER, got -1"},
+		{"set_interval(fn() {}, 0)", "ERROR: interval of `set_interval` must be positive, got 0"},
+		{`sleep("1")`, "ERROR: delay of `sleep` must be non-negative INTEGER, got 1"},
+		{`clear_interval("1")`, "ERROR: argument to `clear_interval` must be INTEGER, got STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalAsync(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestStructs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct Point { x, y }; Point(1, 2)", "Point{x: 1, y: 2}"},
+		{"struct Point { x, y }; Point", "struct Point { x, y }"},
+		{"struct Empty {}; Empty()", "Empty{}"},
+		{"struct Point { x, y }; let p = Point(1, 2); p.x + p.y", "3"},
+		{"struct Point { x, y }; let p = Point(1, 2); p.x = 10; p", "Point{x: 10, y: 2}"},
+		{"struct Point { x, y }; let p = Point(1, 2); let q = p; q.x = 5; p.x", "5"},
+		{"struct Point { x, y }; let p = Point(1, 2); p.z", "ERROR: Point has no field or method z"},
+		{"struct Point { x, y }; let p = Point(1, 2); p.z = 3", "ERROR: Point has no field z"},
+		{"struct Point { x, y }; Point(1)", "ERROR: wrong number of arguments to `Point`. got=1, want=2"},
+		{"struct Line { from, to }; struct Point { x, y }; let l = Line(Point(0, 0), Point(1, 2)); l.to.y = 5; l",
+			"Line{from: Point{x: 0, y: 0}, to: Point{x: 1, y: 5}}"},
+		{`struct Point { x, y }
+		  impl Point {
+		    fn norm(self) { self.x * self.x + self.y * self.y }
+		    fn scale(self, k) { Point(self.x * k, self.y * k) }
+		    fn move(self, dx) { self.x = self.x + dx; self }
+		    fn origin() { Point(0, 0) }
+		  }
+		  let p = Point(3, 4);
+		  [p.norm(), p.scale(2), p.move(1), Point.origin()]`,
+			"[25, Point{x: 6, y: 8}, Point{x: 4, y: 4}, Point{x: 0, y: 0}]"},
+		{"struct P { x }; impl P { fn get(self) { self.x } }; let g = P(7).get; g()", "7"},
+		{"struct P { x }; impl P { fn get(self) { self.x } }; P(7).get", "bound method fn P.get(self)"},
+		{"struct P { x }; impl P { fn get(self) { self.x } }; P.get(P(8))", "8"},
+		{"struct P { x }; impl P { fn get(self) { self.x } }; map([P(1), P(2)], |p| p.get())", "[1, 2]"},
+		{"struct P { x }; impl P { fn get(self) { self.x } }; P(1).get(2)",
+			"ERROR: wrong number of arguments to `P.get`. got=1, want=0"},
+		{"struct P { x }; impl P { fn get(self) { foo } }; P(1).get()",
+			"ERROR: identifier not found: foo (in function P.get)"},
+		{"struct P { x }; impl P { fn x(self) { 1 } }", "ERROR: duplicate field or method: P.x"},
+		{"struct P { x }; impl P { fn f(self) { 1 } }; impl P { fn f(self) { 2 } }", "ERROR: duplicate field or method: P.f"},
+		{"struct P { x }; impl P { fn a() { 1 } }; P(1).a()", "1"},
+		{"struct P { x }; impl P { fn a(n) { n } }; P(1).a(2)", "2"},
+		{"struct P { x }; impl P { fn a() { 1 } }; P(1).a(2)", "ERROR: wrong number of arguments to `P.a`. got=1, want=0"},
+		{"impl Q { fn f(self) { 1 } }", "ERROR: identifier not found: Q"},
+		{"let Q = 1; impl Q { fn f(self) { 1 } }", "ERROR: cannot impl INTEGER: not a struct"},
+		{"struct P { x }; P.nope", "ERROR: P has no method nope"},
+		{"struct P { x }; struct P { y }", "ERROR: identifier already declared: P"},
+		{"let h = {}; h.a = 1", "ERROR: property assignment not supported: HASH"},
+		{"struct P { x }; wait(spawn(P, 1))", "P{x: 1}"},
+		{"struct P { x }; impl P { fn get(self) { self.x } }; wait(spawn(P(3).get))", "3"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestClasses(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"class Counter { init(start) { self.n = start } inc() { self.n += 1 } }; let c = Counter(5); c.inc(); c.inc(); c",
+			"Counter{n: 7}"},
+		{"class Counter {}; Counter", "class Counter"},
+		{"class Empty {}; Empty()", "Empty{}"},
+		{"class Empty {}; Empty(1)", "ERROR: wrong number of arguments to `Empty`. got=1, want=0"},
+		{"class P { init(x) { self.x = x } }; P()", "ERROR: wrong number of arguments to `P.init`. got=0, want=1"},
+		{"class P { init() { foo } }; P()", "ERROR: identifier not found: foo (in function P.init)"},
+		{"class P { init() { self.b = 1; self.a = 2 } }; let p = P(); p.c = 3; p", "P{b: 1, a: 2, c: 3}"},
+		{"class P {}; P().x", "ERROR: P has no field or method x"},
+		{"class P { get() { self.x } }; let p = P(); p.x = 4; let g = p.get; p.x = 5; g()", "5"},
+		{"class P { get() { self.x } }; P().get", "fn P.get()"},
+		{"class P { init(x) { self.x = x } get() { self.x } }; map([P(1), P(2)], |p| p.get())", "[1, 2]"},
+		{`class Animal {
+		    init(name) { self.name = name }
+		    speak() { self.name + " makes a sound" }
+		    describe() { "I am " + self.name }
+		  }
+		  class Dog extends Animal {
+		    init(name) { super.init(name); self.tricks = 0 }
+		    speak() { super.speak() + ", woof" }
+		  }
+		  let d = Dog("Rex");
+		  [d.speak(), d.describe(), d]`,
+			`[Rex makes a sound, woof, I am Rex, Dog{name: Rex, tricks: 0}]`},
+		{`class A { name() { "A" } who() { self.name() } }
+		  class B extends A { name() { "B" + super.name() } }
+		  class C extends B { name() { "C" + super.name() } }
+		  C().who()`, "CBA"},
+		{"class A { init() { self.x = 1 } }; class B extends A {}; B()", "B{x: 1}"},
+		{"class A {}; class B extends A {}; let b = B(); [b instanceof B, b instanceof A, A() instanceof B]",
+			"[true, true, false]"},
+		{"class A {}; struct P { x }; [P(1) instanceof P, P(1) instanceof A, A() instanceof P, 1 instanceof A]",
+			"[true, false, false, false]"},
+		{"1 instanceof 2", "ERROR: right operand of instanceof must be a class or struct, got INTEGER"},
+		{"class A { f() { super.f() } }; A().f()", "ERROR: identifier not found: super (in function A.f)"},
+		{"class A {}; class B extends A { f() { super.g() } }; B().f()", "ERROR: A has no method g (in function B.f)"},
+		{"let A = 1; class B extends A {}", "ERROR: cannot extend INTEGER: not a class"},
+		{"class B extends A {}", "ERROR: identifier not found: A"},
+		{"class A {}; class A {}", "ERROR: identifier already declared: A"},
+		{"class A { inc() { self.n += 1 } }; A().inc()", "ERROR: A has no field or method n (in function A.inc)"},
+		{"class A { init() { self.n = 0 } }; let a = A(); wait([spawn(|| a.n += 1), spawn(|| a.n += 1)]); a.n > 0", "true"},
+		{"class P { init(x) { self.x = x } }; wait(spawn(P, 3))", "P{x: 3}"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEnums(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"enum Result { Ok(value), Err(error) }; Result", "enum Result { Ok(value), Err(error) }"},
+		{"enum Result { Ok(value), Err(error) }; Result.Ok", "Result.Ok(value)"},
+		{"enum Result { Ok(value), Err(error) }; [Result.Ok(1), Result.Err(\"boom\")]", "[Result.Ok(1), Result.Err(boom)]"},
+		{"enum Status { Pending, Done(result) }; Status.Pending", "Status.Pending"},
+		{"enum Status { Pending, Done(result) }; Status.Done(5).result", "5"},
+		{"enum Status { Pending, Done(result) }; Status.Done(5).value", "ERROR: Status.Done has no field value"},
+		{"enum Status { Pending }; Status.Running", "ERROR: Status has no variant Running"},
+		{"enum Result { Ok(value) }; Result.Ok()", "ERROR: wrong number of arguments to `Result.Ok`. got=0, want=1"},
+		{"enum Result { Ok(value) }; map([1, 2], Result.Ok)", "[Result.Ok(1), Result.Ok(2)]"},
+		{"enum E { A }; enum E { B }", "ERROR: identifier already declared: E"},
+		{`enum Status { Pending, Done(result) }
+		  [Status.Pending == Status.Pending, Status.Done(1) == Status.Done(1), Status.Done(1) == Status.Done(2),
+		   Status.Done([1]) == Status.Done([1]), Status.Pending != Status.Done(1), Status.Pending == 1]`,
+			"[true, true, false, false, true, false]"},
+		{"enum E { A, B }; enum F { A }; [E.A == F.A, E.A == E.B]", "[false, false]"},
+		{"enum E { A(x) }; E.A(1) + E.A(2)", "ERROR: unknown operator: ENUM_VALUE + ENUM_VALUE"},
+		{"enum Status { Pending, Done }; let h = {Status.Pending: 1, Status.Done: 2}; h[Status.Done]", "2"},
+		{"enum Status { Done(r) }; {Status.Done(1): 1}", "ERROR: unusable as hash key: ENUM_VALUE"},
+		{`enum Status { Pending, Done }
+		  match ({Status.Pending: 1, Status.Done: 2}) { {Status.Done: d, ...} => d, _ => 0 }`, "2"},
+		{`enum Status { Pending, Done }
+		  match ({Status.Done: 1}) { {Status.Pending: p} => p, _ => 0 }`, "0"},
+		{"enum Status { Done(r) }; {}[Status.Done(1)]", "ERROR: unusable as hash key: ENUM_VALUE"},
+		{`enum Result { Ok(value), Err(error) }
+		  let f = |r| match (r) { Result.Ok(v) => v * 2, Result.Err(e) => "failed: " + e };
+		  [f(Result.Ok(21)), f(Result.Err("boom"))]`,
+			"[42, failed: boom]"},
+		{`enum Shape { Circle(r), Rect(w, h), Empty }
+		  let area = |s| match (s) {
+		    Shape.Circle(r) => 3 * r * r,
+		    Shape.Rect(w, h) if w == h => w * w,
+		    Shape.Rect(w, h) => w * h,
+		    Shape.Empty => 0,
+		  };
+		  map([Shape.Circle(2), Shape.Rect(2, 2), Shape.Rect(2, 3), Shape.Empty], area)`,
+			"[12, 4, 6, 0]"},
+		{`enum Result { Ok(value), Err(error) }
+		  match (Result.Ok([1, 2])) { Result.Ok([a, b]) => a + b, Result.Ok(_) | Result.Err(_) => 0 }`, "3"},
+		{`enum Result { Ok(value), Err(error) }
+		  match (Result.Err(1)) { Result.Ok => "ok", Result.Err => "err" }`, "err"},
+		{`enum Result { Ok(value), Err(error) }
+		  match (Result.Ok(1)) { Result.Ok(v) => v, _ => 0 }`, "1"},
+		{`enum Result { Ok(value), Err(error) }
+		  match (Result.Ok(1)) { Result.Ok(v) => v }`,
+			"ERROR: non-exhaustive match on Result: missing Err"},
+		{`enum Status { Pending, Done(result), Failed(err) }
+		  match (Status.Pending) { Status.Pending => 0, Status.Done(1) => 1 }`,
+			"ERROR: non-exhaustive match on Status: missing Done, Failed"},
+		{`enum Result { Ok(value), Err(error) }
+		  match (Result.Ok(1)) { Result.Ok(v) if v > 0 => v, Result.Err(e) => 0 }`,
+			"ERROR: non-exhaustive match on Result: missing Ok"},
+		{`enum Result { Ok(value), Err(error) }
+		  match (Result.Ok(1)) { Result.Ok(v) => v, Reslt.Err(e) => 0 }`,
+			"ERROR: identifier not found: Reslt"},
+		{`match (1) { Result.Ok(v) => v, _ => 0 }`, "ERROR: identifier not found: Result"},
+		{`enum E { A(x) }
+		  match (E.A(1)) { E.B(x) => x, _ => 0 }`, "ERROR: E has no variant B"},
+		{`enum E { A(x) }
+		  match (1) { 1 => 1, [E.B] | _ => 0 }`, "ERROR: E has no variant B"},
+		{`let E = 1
+		  match (1) { E.A(x) => x, _ => 0 }`, "ERROR: E is not an enum: INTEGER"},
+		{`enum E { A(x) }
+		  match (E.A(1)) { E.A(x, y) => x, _ => 0 }`, "ERROR: wrong number of fields for E.A: got=2, want=1"},
+		{`enum E { A(x), B }
+		  match (E.B) { E.B(x) => x, _ => 0 }`, "ERROR: wrong number of fields for E.B: got=1, want=0"},
+		{`enum E { A(x) }
+		  for (E.A(x, y) in [E.A(1)]) { x }`, "ERROR: wrong number of fields for E.A: got=2, want=1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestOperatorOverloading(t *testing.T) {
+	vector := `struct Vec { x, y }
+	  impl Vec {
+	    fn __add__(self, o) { Vec(self.x + o.x, self.y + o.y) }
+	    fn __sub__(self, o) { Vec(self.x - o.x, self.y - o.y) }
+	    fn __mul__(self, k) { Vec(self.x * k, self.y * k) }
+	    fn __div__(self, k) { Vec(self.x / k, self.y / k) }
+	    fn __eq__(self, o) { match ([self.x - o.x, self.y - o.y]) { [0, 0] => true, _ => false } }
+	    fn __neg__(self) { Vec(-self.x, -self.y) }
+	    fn __index__(self, i) { match (i) { 0 => self.x, 1 => self.y, "x" => self.x, "y" => self.y } }
+	    fn __len__(self) { 2 }
+	  }
+	`
+	money := `class Money {
+	    init(cents) { self.cents = cents }
+	    __add__(o) { Money(self.cents + o.cents) }
+	    __lt__(o) { self.cents < o.cents }
+	    __gt__(o) { self.cents > o.cents }
+	    __eq__(o) { self.cents == o.cents }
+	  }
+	  class Euro extends Money {}
+	`
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{vector + "Vec(1, 2) + Vec(3, 4)", "Vec{x: 4, y: 6}"},
+		{vector + "Vec(1, 2) - Vec(3, 4)", "Vec{x: -2, y: -2}"},
+		{vector + "Vec(1, 2) * 3", "Vec{x: 3, y: 6}"},
+		{vector + "Vec(4, 2) / 2", "Vec{x: 2, y: 1}"},
+		{vector + "-Vec(1, 2)", "Vec{x: -1, y: -2}"},
+		{vector + "[Vec(1, 2) == Vec(1, 2), Vec(1, 2) == Vec(2, 1), Vec(1, 2) != Vec(1, 2), Vec(1, 2) != Vec(2, 1)]",
+			"[true, false, false, true]"},
+		{vector + "let v = Vec(5, 6); [v[0], v[1], v[\"y\"], len(v)]", "[5, 6, 6, 2]"},
+		{vector + "let v = Vec(1, 1); v += Vec(1, 2); v *= 2; v", "Vec{x: 4, y: 6}"},
+		{vector + "reduce([Vec(1, 0), Vec(0, 1), Vec(2, 2)], Vec(0, 0), |a, b| a + b)", "Vec{x: 3, y: 3}"},
+		{vector + "match (Vec(1, 2)) { v if v == Vec(1, 2) => \"same\", _ => \"other\" }", "same"},
+		{vector + "Vec(1, 2) < Vec(3, 4)", "ERROR: unknown operator: STRUCT < STRUCT"},
+		{vector + "Vec(1, 2) + 1", "ERROR: property access not supported: INTEGER (in function Vec.__add__)"},
+		{vector + "1 + Vec(1, 2)", "ERROR: type mismatch: INTEGER + STRUCT"},
+		{vector + "Vec(1, 2)[2]", "ERROR: no match arm for value: 2 (in function Vec.__index__)"},
+		{money + "let m = Money(150) + Money(250); m.cents", "400"},
+		{money + "[Money(1) < Money(2), Money(1) > Money(2), Money(3) == Money(3), Money(3) != Money(3)]",
+			"[true, false, true, false]"},
+		{money + "(Euro(1) + Euro(2)).cents", "3"},
+		{money + "Money(1) * 2", "ERROR: type mismatch: INSTANCE * INTEGER"},
+		{money + "-Money(1)", "ERROR: unknown operator: -INSTANCE"},
+		{money + "len(Money(1))", "ERROR: argument to `len` not supported, got INSTANCE"},
+		{money + "Money(1)[0]", "ERROR: index operator not supported: INSTANCE"},
+		{"struct P { x }; let p = P(1); [p == p, p == P(1), p != P(1)]", "[true, false, true]"},
+		{"struct P { x }; impl P { fn __eq__(self, o) { foo } }; P(1) != P(1)",
+			"ERROR: identifier not found: foo (in function P.__eq__)"},
+		{"struct P { x }; impl P { fn __ne__(self, o) { 42 } }; P(1) != P(1)", "42"},
+		{"struct P { x }; impl P { fn __add__(self) { 1 } }; P(1) + P(2)",
+			"ERROR: wrong number of arguments to `P.__add__`. got=1, want=0"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestCompoundAssignments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 5; x += 2; x", "7"},
+		{"let x = 5; x -= 2", "3"},
+		{"let x = 5; x *= 2; x /= 5; x", "2"},
+		{`let s = "a"; s += "b"; s`, "ab"},
+		{"let x = 1; let y = 2; x += y += 3; [x, y]", "[6, 5]"},
+		{"struct P { x }; let p = P(1); p.x += 10; p", "P{x: 11}"},
+		{"const x = 1; x += 1", "ERROR: cannot assign to constant: x"},
+		{"x += 1", "ERROR: identifier not found: x"},
+		{"let x = true; x += 1", "ERROR: type mismatch: BOOLEAN + INTEGER"},
+		{"struct P { x }; let p = P(1); let n = 0; let f = || { n += 1; p }; f().x += 5; [n, p.x]", "[1, 6]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestAwaitNeverSettles(t *testing.T) {
+	SetClock(NewVirtualClock(time.Unix(0, 0)))
+	defer SetClock(SystemClock{})
+
+	evaluated := loop.runUntilSettled(&object.Promise{})
+	errObj, ok := evaluated.(*object.Error)
+	if !ok || errObj.Message != "await on a promise which never settles" {
+		t.Errorf("wrong result. got=%T (%+v)", evaluated, evaluated)
+	}
+}
+
+// testEvalAsync evaluates input on a virtual clock, then runs the event loop to the end
+func testEvalAsync(t *testing.T, input string) object.Object {
+	t.Helper()
+	SetClock(NewVirtualClock(time.Unix(0, 0)))
+	defer SetClock(SystemClock{})
+
+	evaluated := testEval(t, input)
+	if err := RunEventLoop(); err != nil {
+		return err
+	}
+	return evaluated
+}
+
+func testEval(t *testing.T, input string) object.Object {
+	t.Helper()
 	l := lexer.New(input)
 	p := parser.New(l)
 	program := p.ParseProgram()
+	// 構文エラーを無視すると入力の一部しか評価されないので失敗にする
+	if errs := p.Errors(); len(errs) != 0 {
+		t.Fatalf("parser has errors for %q: %v", input, errs)
+	}
 	env := object.NewEnvironment()
 	return Eval(program, env)
 }
@@ -537,6 +1847,33 @@ func testNullObject(t *testing.T, obj object.Object) bool {
 	return true
 }
 
+// testArrayObject compares the elements of the array, which are integers, strings or arrays
+func testArrayObject(t *testing.T, obj object.Object, expected []interface{}) bool {
+	array, ok := obj.(*object.Array)
+	if !ok {
+		t.Errorf("object is not Array. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if len(array.Elements) != len(expected) {
+		t.Errorf("wrong num of elements. want=%d, got=%d", len(expected), len(array.Elements))
+		return false
+	}
+	for i, e := range expected {
+		switch e := e.(type) {
+		case int:
+			ok = testIntegerObject(t, array.Elements[i], int64(e))
+		case string:
+			ok = testStringObject(t, array.Elements[i], e)
+		case []interface{}:
+			ok = testArrayObject(t, array.Elements[i], e)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
 func BenchmarkIsTruthyWithTypeAssertion(b *testing.B) {
 	benchmarkIsTruthy(b, isTruthy)
 }
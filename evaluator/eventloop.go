@@ -0,0 +1,309 @@
+package evaluator
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/tshinag/monkey/object"
+)
+
+// Clock is the source of time for the timers of the event loop
+type Clock interface {
+	Now() time.Time
+	// Sleep waits until the duration passes
+	Sleep(d time.Duration)
+}
+
+// SystemClock is the clock of the operating system
+type SystemClock struct{}
+
+// Now returns the current time
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Sleep waits for d
+func (SystemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// VirtualClock is the clock which advances instantly when the event loop waits,
+// so that time-dependent scripts run deterministically
+type VirtualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewVirtualClock initializes VirtualClock starting at start
+func NewVirtualClock(start time.Time) *VirtualClock {
+	return &VirtualClock{now: start}
+}
+
+// Now returns the virtual time
+func (c *VirtualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Sleep advances the virtual time by d without waiting
+func (c *VirtualClock) Sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+var loop = newEventLoop(SystemClock{})
+
+// SetClock replaces the event loop with an empty one driven by clock
+func SetClock(clock Clock) {
+	loop = newEventLoop(clock)
+}
+
+// RunEventLoop runs the queued tasks and the timers until none is left.
+// It stops at the first error raised by a callback.
+func RunEventLoop() *object.Error {
+	for {
+		ran, err := loop.runOnce()
+		if err != nil {
+			return err
+		}
+		if !ran {
+			return nil
+		}
+	}
+}
+
+// eventLoop runs tasks one by one in the order they are queued,
+// and the callbacks of timers in the order they are due.
+type eventLoop struct {
+	mu     sync.Mutex
+	clock  Clock
+	start  time.Time
+	tasks  []func() *object.Error
+	timers timerQueue
+	byID   map[int64]*timer
+	nextID int64
+	seq    int64
+}
+
+type timer struct {
+	id       int64
+	due      time.Time
+	seq      int64 // 同時刻のタイマーは登録順に実行する
+	interval time.Duration
+	callback func() *object.Error
+	index    int
+}
+
+func newEventLoop(clock Clock) *eventLoop {
+	return &eventLoop{clock: clock, start: clock.Now(), byID: make(map[int64]*timer)}
+}
+
+func (l *eventLoop) enqueue(task func() *object.Error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tasks = append(l.tasks, task)
+}
+
+// addTimer calls callback after delay, and every interval after that if interval is positive
+func (l *eventLoop) addTimer(delay, interval time.Duration, callback func() *object.Error) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.nextID++
+	l.seq++
+	t := &timer{
+		id:       l.nextID,
+		due:      l.clock.Now().Add(delay),
+		seq:      l.seq,
+		interval: interval,
+		callback: callback,
+	}
+	heap.Push(&l.timers, t)
+	l.byID[t.id] = t
+	return t.id
+}
+
+func (l *eventLoop) clearTimer(id int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if t, ok := l.byID[id]; ok {
+		heap.Remove(&l.timers, t.index)
+		delete(l.byID, id)
+	}
+}
+
+// elapsed returns the time since the loop was created
+func (l *eventLoop) elapsed() time.Duration {
+	return l.clock.Now().Sub(l.start)
+}
+
+// runOnce runs a queued task, or the earliest timer after waiting for it.
+// It returns false when there is nothing left to run.
+func (l *eventLoop) runOnce() (bool, *object.Error) {
+	l.mu.Lock()
+	if len(l.tasks) > 0 {
+		task := l.tasks[0]
+		l.tasks = l.tasks[1:]
+		l.mu.Unlock()
+		return true, task()
+	}
+	if len(l.timers) == 0 {
+		l.mu.Unlock()
+		return false, nil
+	}
+	t := l.timers[0]
+	if wait := t.due.Sub(l.clock.Now()); wait > 0 {
+		l.mu.Unlock()
+		l.clock.Sleep(wait)
+		return true, nil
+	}
+	if t.interval > 0 {
+		l.seq++
+		t.due = t.due.Add(t.interval)
+		t.seq = l.seq
+		heap.Fix(&l.timers, t.index)
+	} else {
+		heap.Pop(&l.timers)
+		delete(l.byID, t.id)
+	}
+	l.mu.Unlock()
+	return true, t.callback()
+}
+
+// runUntilSettled runs the loop until promise is settled
+func (l *eventLoop) runUntilSettled(promise *object.Promise) object.Object {
+	for {
+		if value, settled := promise.Result(); settled {
+			return value
+		}
+		ran, err := l.runOnce()
+		if err != nil {
+			return err
+		}
+		if !ran {
+			return newError("await on a promise which never settles")
+		}
+	}
+}
+
+type timerQueue []*timer
+
+func (q timerQueue) Len() int { return len(q) }
+
+func (q timerQueue) Less(i, j int) bool {
+	if q[i].due.Equal(q[j].due) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].due.Before(q[j].due)
+}
+
+func (q timerQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *timerQueue) Push(x interface{}) {
+	t := x.(*timer)
+	t.index = len(*q)
+	*q = append(*q, t)
+}
+
+func (q *timerQueue) Pop() interface{} {
+	old := *q
+	t := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return t
+}
+
+func fnSleep(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+	delay, err := millisecondsArgument("sleep", args[0])
+	if err != nil {
+		return err
+	}
+	promise := &object.Promise{}
+	loop.addTimer(delay, 0, func() *object.Error {
+		promise.Settle(NULL)
+		return nil
+	})
+	return promise
+}
+
+// fnSetTimeout calls the function with the rest of arguments after the delay in milliseconds
+func fnSetTimeout(args ...object.Object) object.Object {
+	return setTimer("set_timeout", false, args)
+}
+
+// fnSetInterval calls the function with the rest of arguments every interval in milliseconds
+func fnSetInterval(args ...object.Object) object.Object {
+	return setTimer("set_interval", true, args)
+}
+
+func setTimer(name string, repeat bool, args []object.Object) object.Object {
+	if len(args) < 2 {
+		return newError("wrong number of arguments. got=%d, want=2+", len(args))
+	}
+	if !isCallable(args[0]) {
+		return newError("argument to `%s` must be FUNCTION, got %s", name, args[0].Type())
+	}
+	delay, err := millisecondsArgument(name, args[1])
+	if err != nil {
+		return err
+	}
+	interval := time.Duration(0)
+	if repeat {
+		if delay <= 0 {
+			return newError("interval of `%s` must be positive, got %s", name, args[1].Inspect())
+		}
+		interval = delay
+	}
+	fn, fnArgs := args[0], args[2:]
+	id := loop.addTimer(delay, interval, func() *object.Error {
+		if err, ok := evalFunction(fn, fnArgs).(*object.Error); ok {
+			return err
+		}
+		return nil
+	})
+	return &object.Integer{Value: id}
+}
+
+func fnClearTimeout(args ...object.Object) object.Object {
+	return clearTimer("clear_timeout", args)
+}
+
+func fnClearInterval(args ...object.Object) object.Object {
+	return clearTimer("clear_interval", args)
+}
+
+func clearTimer(name string, args []object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1", len(args))
+	}
+	id, ok := args[0].(*object.Integer)
+	if !ok {
+		return newError("argument to `%s` must be INTEGER, got %s", name, args[0].Type())
+	}
+	loop.clearTimer(id.Value)
+	return NULL
+}
+
+// fnNow returns the milliseconds since the event loop started
+func fnNow(args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError("wrong number of arguments. got=%d, want=0", len(args))
+	}
+	return &object.Integer{Value: loop.elapsed().Milliseconds()}
+}
+
+func millisecondsArgument(name string, arg object.Object) (time.Duration, *object.Error) {
+	ms, ok := arg.(*object.Integer)
+	if !ok || ms.Value < 0 {
+		return 0, newError("delay of `%s` must be non-negative INTEGER, got %s", name, arg.Inspect())
+	}
+	return time.Duration(ms.Value) * time.Millisecond, nil
+}
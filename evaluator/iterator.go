@@ -0,0 +1,170 @@
+package evaluator
+
+import (
+	"unicode/utf8"
+
+	"github.com/tshinag/monkey/object"
+)
+
+// iterate returns the iterator over the elements of an array, the characters of a string,
+// the [key, value] pairs of a hash ordered by key, the values received from a channel
+// until it is closed, or the iterator itself
+func iterate(obj object.Object) (*object.Iterator, bool) {
+	switch obj := obj.(type) {
+	case *object.Iterator:
+		return obj, true
+	case *object.Channel:
+		return newChannelIterator(obj), true
+	case *object.Array:
+		return newSliceIterator("array", obj.Elements), true
+	case *object.String:
+		return newStringIterator(obj.Value), true
+	case *object.Hash:
+		pairs := obj.SortedPairs()
+		elements := make([]object.Object, len(pairs))
+		for i, pair := range pairs {
+			elements[i] = &object.Array{Elements: []object.Object{pair.Key, pair.Value}}
+		}
+		return newSliceIterator("hash", elements), true
+	default:
+		return nil, false
+	}
+}
+
+func newSliceIterator(kind string, elements []object.Object) *object.Iterator {
+	i := 0
+	return &object.Iterator{Kind: kind, NextFn: func() (object.Object, bool) {
+		if i >= len(elements) {
+			return nil, false
+		}
+		i++
+		return elements[i-1], true
+	}}
+}
+
+func newStringIterator(str string) *object.Iterator {
+	i := 0
+	return &object.Iterator{Kind: "string", NextFn: func() (object.Object, bool) {
+		if i >= len(str) {
+			return nil, false
+		}
+		r, size := utf8.DecodeRuneInString(str[i:])
+		i += size
+		return &object.String{Value: string(r)}, true
+	}}
+}
+
+func newRangeIterator(start, end, step int64) *object.Iterator {
+	current := start
+	return &object.Iterator{Kind: "range", NextFn: func() (object.Object, bool) {
+		if step > 0 && current >= end || step < 0 && current <= end {
+			return nil, false
+		}
+		current += step
+		return &object.Integer{Value: current - step}, true
+	}}
+}
+
+// newMapIterator lazily applies fn to each value of it
+func newMapIterator(it *object.Iterator, fn object.Object) *object.Iterator {
+	return &object.Iterator{Kind: "map", NextFn: func() (object.Object, bool) {
+		value, ok := it.Next()
+		if !ok || isError(value) {
+			return value, ok
+		}
+		return evalFunction(fn, []object.Object{value}), true
+	}}
+}
+
+// newFilterIterator lazily skips the values of it for which fn is not truthy
+func newFilterIterator(it *object.Iterator, fn object.Object) *object.Iterator {
+	return &object.Iterator{Kind: "filter", NextFn: func() (object.Object, bool) {
+		for {
+			value, ok := it.Next()
+			if !ok || isError(value) {
+				return value, ok
+			}
+			keep := evalFunction(fn, []object.Object{value})
+			if isError(keep) {
+				return keep, true
+			}
+			if isTruthy(keep) {
+				return value, true
+			}
+		}
+	}}
+}
+
+// collect reads it to the end. If an error is produced, it returns the error as the only element.
+func collect(it *object.Iterator, limit int64) []object.Object {
+	elements := []object.Object{}
+	for limit < 0 || int64(len(elements)) < limit {
+		value, ok := it.Next()
+		if !ok {
+			break
+		}
+		if isError(value) {
+			return []object.Object{value}
+		}
+		elements = append(elements, value)
+	}
+	return elements
+}
+
+// generator runs the body of a generator function as a coroutine on its own goroutine.
+// The caller and the goroutine hand over control through unbuffered channels,
+// so only one of them runs at a time. The goroutine of a generator
+// which is not read to the end stays parked at its last yield.
+type generator struct {
+	resume  chan struct{}
+	yields  chan object.Object
+	started bool
+	done    bool
+}
+
+func newGenerator(fn *object.Function, args []object.Object) *object.Iterator {
+	g := &generator{
+		resume: make(chan struct{}),
+		yields: make(chan object.Object),
+	}
+	frame := &object.Frame{Function: fn, Yield: g.yield}
+	env := object.NewFrameEnvironment(fn.Env, frame)
+	bindParameters(fn, args, env)
+
+	run := func() {
+		defer close(g.yields)
+		result := Eval(fn.Body, env)
+		if err, ok := result.(*object.Error); ok {
+			if err.Function == "" {
+				err.Function = fn.Name
+			}
+			g.yields <- err
+		}
+	}
+
+	return &object.Iterator{Kind: "generator", NextFn: func() (object.Object, bool) {
+		if g.done {
+			return nil, false
+		}
+		if profiler != nil {
+			defer profiler.Switch(g)()
+		}
+		if g.started {
+			g.resume <- struct{}{}
+		} else {
+			g.started = true
+			go run()
+		}
+		value, ok := <-g.yields
+		if !ok || isError(value) {
+			g.done = true
+		}
+		return value, ok
+	}}
+}
+
+// yield passes value to the caller of next and waits until the generator is resumed
+func (g *generator) yield(value object.Object) {
+	g.yields <- value
+	<-g.resume
+}
@@ -0,0 +1,74 @@
+package evaluator
+
+import "github.com/tshinag/monkey/object"
+
+// infixOperatorMethods are the methods which user-defined types implement
+// to overload infix operators. "!=" negates "__eq__" unless "__ne__" is defined.
+var infixOperatorMethods = map[string]string{
+	"+":  "__add__",
+	"-":  "__sub__",
+	"*":  "__mul__",
+	"/":  "__div__",
+	"==": "__eq__",
+	"!=": "__ne__",
+	"<":  "__lt__",
+	">":  "__gt__",
+}
+
+// prefixOperatorMethods are the methods which overload prefix operators
+var prefixOperatorMethods = map[string]string{
+	"-": "__neg__",
+}
+
+// findOperatorMethod returns the method of struct or class instance bound to obj
+func findOperatorMethod(obj object.Object, name string) (object.Object, bool) {
+	switch obj := obj.(type) {
+	case *object.Struct:
+		if method, ok := obj.Definition.Method(name); ok {
+			return bindStructMethod(obj, method), true
+		}
+	case *object.Instance:
+		if method, definer, ok := obj.Class.FindMethod(name); ok {
+			return bindMethod(obj, method, definer), true
+		}
+	}
+	return nil, false
+}
+
+// evalOverloadedInfixExpression calls the method overloading the operator on left.
+// It returns false if left does not overload it.
+func evalOverloadedInfixExpression(operator string, left, right object.Object) (object.Object, bool) {
+	name, ok := infixOperatorMethods[operator]
+	if !ok {
+		return nil, false
+	}
+	if method, ok := findOperatorMethod(left, name); ok {
+		return evalFunction(method, []object.Object{right}), true
+	}
+	if operator != "!=" {
+		return nil, false
+	}
+	method, ok := findOperatorMethod(left, infixOperatorMethods["=="])
+	if !ok {
+		return nil, false
+	}
+	eq := evalFunction(method, []object.Object{right})
+	if isError(eq) {
+		return eq, true
+	}
+	return referenceBooleanObject(!isTruthy(eq)), true
+}
+
+// evalOverloadedPrefixExpression calls the method overloading the operator on right.
+// It returns false if right does not overload it.
+func evalOverloadedPrefixExpression(operator string, right object.Object) (object.Object, bool) {
+	name, ok := prefixOperatorMethods[operator]
+	if !ok {
+		return nil, false
+	}
+	method, ok := findOperatorMethod(right, name)
+	if !ok {
+		return nil, false
+	}
+	return evalFunction(method, []object.Object{}), true
+}
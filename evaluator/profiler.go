@@ -0,0 +1,26 @@
+package evaluator
+
+import (
+	"github.com/tshinag/monkey/ast"
+	"github.com/tshinag/monkey/object"
+)
+
+// Profiler measures the calls of the functions
+type Profiler interface {
+	// Enter is called before the body of the function is evaluated. site is the call expression,
+	// or nil if the function is called by a builtin or an operator.
+	// The returned function is called after the function returns.
+	Enter(fn *object.Function, site ast.Node) func()
+	// Switch is called before the evaluation is handed over to the coroutine of a generator
+	// or an async function, so that the calls in it are put on its own stack.
+	// The returned function is called after the coroutine is suspended again.
+	Switch(coroutine interface{}) func()
+}
+
+// profiler is nil unless a profiler is attached
+var profiler Profiler
+
+// SetProfiler attaches the profiler to the evaluator. nil detaches it.
+func SetProfiler(p Profiler) {
+	profiler = p
+}
@@ -0,0 +1,146 @@
+package evaluator
+
+import (
+	"strings"
+
+	"github.com/tshinag/monkey/ast"
+	"github.com/tshinag/monkey/object"
+)
+
+func evalStructStatement(ss *ast.StructStatement, env *object.Environment) object.Object {
+	fields := make([]string, len(ss.Fields))
+	for i, field := range ss.Fields {
+		fields[i] = field.Value
+	}
+	definition := &object.StructDefinition{Name: ss.Name.Value, Fields: fields}
+	if err := env.Declare(ss.Name.Value, definition, false); err != nil {
+		return newError("%s", err)
+	}
+	return nil
+}
+
+func evalImplStatement(is *ast.ImplStatement, env *object.Environment) object.Object {
+	target := evalIdentifier(is.Name, env)
+	if isError(target) {
+		return target
+	}
+	definition, ok := target.(*object.StructDefinition)
+	if !ok {
+		return newError("cannot impl %s: not a struct", target.Type())
+	}
+	for _, method := range is.Methods {
+		fn := newFunction(method.Function, env)
+		fn.Name = definition.Name + "." + method.Name.Value
+		if !definition.AddMethod(method.Name.Value, fn) {
+			return newError("duplicate field or method: %s", fn.Name)
+		}
+	}
+	return nil
+}
+
+func newStruct(definition *object.StructDefinition, args []object.Object) object.Object {
+	if len(args) != len(definition.Fields) {
+		return newError("wrong number of arguments to `%s`. got=%d, want=%d",
+			definition.Name, len(args), len(definition.Fields))
+	}
+	values := make([]object.Object, len(args))
+	copy(values, args)
+	return object.NewStruct(definition, values)
+}
+
+// evalBoundMethod calls the method passing the receiver as "self"
+func evalBoundMethod(bm *object.BoundMethod, args []object.Object, site ast.Node) object.Object {
+	if len(args)+1 != len(bm.Method.Parameters) {
+		return newError("wrong number of arguments to `%s`. got=%d, want=%d",
+			bm.Method.Name, len(args), len(bm.Method.Parameters)-1)
+	}
+	return callFunction(bm.Method, append([]object.Object{bm.Receiver}, args...), site)
+}
+
+func evalStructProperty(s *object.Struct, name string) object.Object {
+	if value, ok := s.Get(name); ok {
+		return value
+	}
+	if method, ok := s.Definition.Method(name); ok {
+		return bindStructMethod(s, method)
+	}
+	return newError("%s has no field or method %s", s.Definition.Name, name)
+}
+
+// bindStructMethod binds the method to the struct if its first parameter is "self".
+// The other methods are static, so they are returned as they are.
+func bindStructMethod(s *object.Struct, method *object.Function) object.Object {
+	if len(method.Parameters) == 0 || method.Parameters[0].Value != "self" {
+		return method
+	}
+	return &object.BoundMethod{Receiver: s, Method: method}
+}
+
+func evalStructDefinitionProperty(definition *object.StructDefinition, name string) object.Object {
+	if method, ok := definition.Method(name); ok {
+		return method
+	}
+	return newError("%s has no method %s", definition.Name, name)
+}
+
+func evalAssignExpression(ae *ast.AssignExpression, env *object.Environment) object.Object {
+	switch target := ae.Target.(type) {
+	case *ast.Identifier:
+		val := evalAssignedValue(ae, env, func() object.Object {
+			return evalIdentifier(target, env)
+		})
+		if isError(val) {
+			return val
+		}
+		if err := env.Assign(target.Value, val); err != nil {
+			return newError("%s", err)
+		}
+		return val
+	case *ast.PropertyExpression:
+		left := Eval(target.Left, env)
+		if isError(left) {
+			return left
+		}
+		val := evalAssignedValue(ae, env, func() object.Object {
+			return evalPropertyAccess(left, target.Property.Value)
+		})
+		if isError(val) {
+			return val
+		}
+		return evalPropertyAssignment(left, target.Property.Value, val)
+	default:
+		return newError("invalid assignment target: %s", ae.Target)
+	}
+}
+
+// evalAssignedValue evaluates the right-hand side of the assignment.
+// Compound assignment like "+=" applies the operator to the current value.
+func evalAssignedValue(ae *ast.AssignExpression, env *object.Environment, current func() object.Object) object.Object {
+	if ae.Operator == "=" {
+		return Eval(ae.Value, env)
+	}
+	cur := current()
+	if isError(cur) {
+		return cur
+	}
+	val := Eval(ae.Value, env)
+	if isError(val) {
+		return val
+	}
+	return evalInfixExpression(strings.TrimSuffix(ae.Operator, "="), cur, val)
+}
+
+func evalPropertyAssignment(left object.Object, name string, val object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Struct:
+		if !left.Set(name, val) {
+			return newError("%s has no field %s", left.Definition.Name, name)
+		}
+		return val
+	case *object.Instance:
+		left.Set(name, val)
+		return val
+	default:
+		return newError("property assignment not supported: %s", left.Type())
+	}
+}
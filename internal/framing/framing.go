@@ -0,0 +1,56 @@
+package framing
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Conn reads and writes the messages with the base protocol shared by LSP and DAP,
+// which is the header "Content-Length: n" followed by the content
+type Conn struct {
+	reader *textproto.Reader
+	writer io.Writer
+	mutex  sync.Mutex // 書き込みを直列化する
+}
+
+// NewConn returns the connection reading from r and writing to w
+func NewConn(r io.Reader, w io.Writer) *Conn {
+	return &Conn{reader: textproto.NewReader(bufio.NewReader(r)), writer: w}
+}
+
+// Read returns the content of the next message. It returns io.EOF at the end of the stream.
+func (c *Conn) Read() ([]byte, error) {
+	header, err := c.reader.ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF && len(header) == 0 {
+			return nil, io.EOF
+		}
+		return nil, errors.Wrap(err, "could not read header")
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, errors.Errorf("invalid Content-Length: %q", header.Get("Content-Length"))
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(c.reader.R, content); err != nil {
+		return nil, errors.Wrap(err, "could not read content")
+	}
+	return content, nil
+}
+
+// Write writes the content as a message. It is safe to call from multiple goroutines.
+func (c *Conn) Write(content []byte) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, err := fmt.Fprintf(c.writer, "Content-Length: %d\r\n\r\n", len(content)); err != nil {
+		return err
+	}
+	_, err := c.writer.Write(content)
+	return err
+}
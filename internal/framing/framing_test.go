@@ -0,0 +1,50 @@
+package framing
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadWrite(t *testing.T) {
+	var buf bytes.Buffer
+	c := NewConn(&buf, &buf)
+	for _, content := range []string{`{"a":1}`, "", "日本語\r\n"} {
+		if err := c.Write([]byte(content)); err != nil {
+			t.Fatalf("Write failed: %s", err)
+		}
+	}
+	if !strings.HasPrefix(buf.String(), "Content-Length: 7\r\n\r\n{\"a\":1}") {
+		t.Errorf("wrong framing: %q", buf.String())
+	}
+	for _, expected := range []string{`{"a":1}`, "", "日本語\r\n"} {
+		content, err := c.Read()
+		if err != nil {
+			t.Fatalf("Read failed: %s", err)
+		}
+		if string(content) != expected {
+			t.Errorf("wrong content. expected=%q, got=%q", expected, content)
+		}
+	}
+	if _, err := c.Read(); err != io.EOF {
+		t.Errorf("expected io.EOF at the end, got %v", err)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Content-Type: json\r\n\r\n{}", `invalid Content-Length: ""`},
+		{"Content-Length: -1\r\n\r\n", `invalid Content-Length: "-1"`},
+		{"Content-Length: 10\r\n\r\n{}", "could not read content: unexpected EOF"},
+	}
+	for _, tt := range tests {
+		_, err := NewConn(strings.NewReader(tt.input), nil).Read()
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%v", tt.input, tt.expected, err)
+		}
+	}
+}
@@ -10,11 +10,20 @@ type Lexer struct {
 	position     int  // 入力における現在の位置（現在の文字を指し示す）
 	readPosition int  // これから読み込む位置（現在の文字の次）
 	char         byte // 現在検査中の文字
+	line         int  // 現在の文字の行番号
+	lineStart    int  // 現在の行の先頭の位置
+	comments     []Comment
+}
+
+// Comment is the line comment "// text", which is skipped as whitespace
+type Comment struct {
+	Pos  token.Position // "//" の位置
+	Text string         // "//" の後から行末までの文字列
 }
 
 // New initializes Lexer with input string
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	l := &Lexer{input: input, line: 1}
 	l.readChar()
 	return l
 }
@@ -22,6 +31,17 @@ func New(input string) *Lexer {
 // NextToken tokenize current charactor, then reads next
 func (l *Lexer) NextToken() token.Token {
 	l.skipWhitespace()
+	for l.char == '/' && l.peekChar() == '/' {
+		l.readComment()
+		l.skipWhitespace()
+	}
+	pos := token.Position{Line: l.line, Column: l.position - l.lineStart + 1}
+	tok := l.readToken()
+	tok.Pos = pos
+	return tok
+}
+
+func (l *Lexer) readToken() token.Token {
 	switch l.char {
 	case '=':
 		if l.peekChar() == '=' {
@@ -29,12 +49,32 @@ func (l *Lexer) NextToken() token.Token {
 			defer l.readChar()
 			return token.New(token.EQ, literal)
 		}
+		if l.peekChar() == '>' {
+			literal := l.readTwoChar()
+			defer l.readChar()
+			return token.New(token.ARROW, literal)
+		}
 		defer l.readChar()
 		return token.NewChar(token.ASSIGN, l.char)
 	case '+':
+		if l.peekChar() == '=' {
+			literal := l.readTwoChar()
+			defer l.readChar()
+			return token.New(token.PLUSASSIGN, literal)
+		}
 		defer l.readChar()
 		return token.NewChar(token.PLUS, l.char)
 	case '-':
+		if l.peekChar() == '=' {
+			literal := l.readTwoChar()
+			defer l.readChar()
+			return token.New(token.MINUSASSIGN, literal)
+		}
+		if l.peekChar() == '>' {
+			literal := l.readTwoChar()
+			defer l.readChar()
+			return token.New(token.RARROW, literal)
+		}
 		defer l.readChar()
 		return token.NewChar(token.MINUS, l.char)
 	case '!':
@@ -46,9 +86,19 @@ func (l *Lexer) NextToken() token.Token {
 		defer l.readChar()
 		return token.NewChar(token.BANG, l.char)
 	case '/':
+		if l.peekChar() == '=' {
+			literal := l.readTwoChar()
+			defer l.readChar()
+			return token.New(token.SLASHASSIGN, literal)
+		}
 		defer l.readChar()
 		return token.NewChar(token.SLASH, l.char)
 	case '*':
+		if l.peekChar() == '=' {
+			literal := l.readTwoChar()
+			defer l.readChar()
+			return token.New(token.ASTERISKASSIGN, literal)
+		}
 		defer l.readChar()
 		return token.NewChar(token.ASTERISK, l.char)
 	case '<':
@@ -63,6 +113,35 @@ func (l *Lexer) NextToken() token.Token {
 	case ';':
 		defer l.readChar()
 		return token.NewChar(token.SEMICOLON, l.char)
+	case '|':
+		if l.peekChar() == '>' {
+			literal := l.readTwoChar()
+			defer l.readChar()
+			return token.New(token.PIPELINE, literal)
+		}
+		defer l.readChar()
+		return token.NewChar(token.PIPE, l.char)
+	case '.':
+		if l.peekChar() == '.' && l.peekSecondChar() == '.' {
+			literal := l.readThreeChar()
+			defer l.readChar()
+			return token.New(token.ELLIPSIS, literal)
+		}
+		defer l.readChar()
+		return token.NewChar(token.DOT, l.char)
+	case '?':
+		if l.peekChar() == '.' {
+			literal := l.readTwoChar()
+			defer l.readChar()
+			return token.New(token.QUESTIONDOT, literal)
+		}
+		if l.peekChar() == '?' {
+			literal := l.readTwoChar()
+			defer l.readChar()
+			return token.New(token.NULLISH, literal)
+		}
+		defer l.readChar()
+		return token.NewChar(token.ILLEGAL, l.char)
 	case '(':
 		defer l.readChar()
 		return token.NewChar(token.LPAREN, l.char)
@@ -136,6 +215,26 @@ func (l *Lexer) readTwoChar() string {
 	return string(current) + string(next)
 }
 
+func (l *Lexer) readThreeChar() string {
+	current := l.readTwoChar()
+	l.readChar()
+	return current + string(l.char)
+}
+
+// Comments returns the comments read so far
+func (l *Lexer) Comments() []Comment {
+	return l.comments
+}
+
+func (l *Lexer) readComment() {
+	pos := token.Position{Line: l.line, Column: l.position - l.lineStart + 1}
+	position := l.position + 2
+	for l.char != '\n' && l.char != 0 {
+		l.readChar()
+	}
+	l.comments = append(l.comments, Comment{Pos: pos, Text: l.input[position:l.position]})
+}
+
 func (l *Lexer) skipWhitespace() {
 	for isWhitespace(l.char) {
 		l.readChar()
@@ -143,6 +242,10 @@ func (l *Lexer) skipWhitespace() {
 }
 
 func (l *Lexer) readChar() {
+	if l.char == '\n' {
+		l.line++
+		l.lineStart = l.readPosition
+	}
 	if l.readPosition >= len(l.input) {
 		l.char = 0
 	} else {
@@ -159,6 +262,13 @@ func (l *Lexer) peekChar() byte {
 	return l.input[l.readPosition]
 }
 
+func (l *Lexer) peekSecondChar() byte {
+	if l.readPosition+1 >= len(l.input) {
+		return 0
+	}
+	return l.input[l.readPosition+1]
+}
+
 func isWhitespace(ch byte) bool {
 	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
 }
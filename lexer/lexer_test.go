@@ -30,6 +30,16 @@ func TestNextToken(t *testing.T) {
 	"foo bar"
 	[1, 2];
 	{"foo": "bar"}
+	match (x) { 1 | 2 => y, [a, ...rest] => z }
+	a.b?.c ?? null
+	xs |> map(|x| x)
+	for (x in xs) { yield x }
+	select
+	async await
+	struct impl
+	class A extends B { } a instanceof A
+	x += 1; x -= 1; x *= 2; x /= 2
+	enum ->
     `
 	tests := []struct {
 		expectedType    token.Type
@@ -121,6 +131,83 @@ func TestNextToken(t *testing.T) {
 		{token.COLON, ":"},
 		{token.STRING, "bar"},
 		{token.RBRACE, "}"},
+		{token.MATCH, "match"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.INT, "1"},
+		{token.PIPE, "|"},
+		{token.INT, "2"},
+		{token.ARROW, "=>"},
+		{token.IDENT, "y"},
+		{token.COMMA, ","},
+		{token.LBRACKET, "["},
+		{token.IDENT, "a"},
+		{token.COMMA, ","},
+		{token.ELLIPSIS, "..."},
+		{token.IDENT, "rest"},
+		{token.RBRACKET, "]"},
+		{token.ARROW, "=>"},
+		{token.IDENT, "z"},
+		{token.RBRACE, "}"},
+		{token.IDENT, "a"},
+		{token.DOT, "."},
+		{token.IDENT, "b"},
+		{token.QUESTIONDOT, "?."},
+		{token.IDENT, "c"},
+		{token.NULLISH, "??"},
+		{token.NULL, "null"},
+		{token.IDENT, "xs"},
+		{token.PIPELINE, "|>"},
+		{token.IDENT, "map"},
+		{token.LPAREN, "("},
+		{token.PIPE, "|"},
+		{token.IDENT, "x"},
+		{token.PIPE, "|"},
+		{token.IDENT, "x"},
+		{token.RPAREN, ")"},
+		{token.FOR, "for"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.IN, "in"},
+		{token.IDENT, "xs"},
+		{token.RPAREN, ")"},
+		{token.LBRACE, "{"},
+		{token.YIELD, "yield"},
+		{token.IDENT, "x"},
+		{token.RBRACE, "}"},
+		{token.SELECT, "select"},
+		{token.ASYNC, "async"},
+		{token.AWAIT, "await"},
+		{token.STRUCT, "struct"},
+		{token.IMPL, "impl"},
+		{token.CLASS, "class"},
+		{token.IDENT, "A"},
+		{token.EXTENDS, "extends"},
+		{token.IDENT, "B"},
+		{token.LBRACE, "{"},
+		{token.RBRACE, "}"},
+		{token.IDENT, "a"},
+		{token.INSTANCEOF, "instanceof"},
+		{token.IDENT, "A"},
+		{token.IDENT, "x"},
+		{token.PLUSASSIGN, "+="},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.MINUSASSIGN, "-="},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.ASTERISKASSIGN, "*="},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.SLASHASSIGN, "/="},
+		{token.INT, "2"},
+		{token.ENUM, "enum"},
+		{token.RARROW, "->"},
 		{token.EOF, ""},
 	}
 
@@ -140,3 +227,68 @@ func TestNextToken(t *testing.T) {
 		}
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := "let x = 10;\n  fn(a) {\n\t\"s\" }"
+	tests := []struct {
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+	}{
+		{"let", 1, 1},
+		{"x", 1, 5},
+		{"=", 1, 7},
+		{"10", 1, 9},
+		{";", 1, 11},
+		{"fn", 2, 3},
+		{"(", 2, 5},
+		{"a", 2, 6},
+		{")", 2, 7},
+		{"{", 2, 9},
+		{"s", 3, 2},
+		{"}", 3, 6},
+		{"", 3, 7},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.Pos.Line != tt.expectedLine || tok.Pos.Column != tt.expectedColumn {
+			t.Errorf("tests[%d] - position of %q wrong. expected=%d:%d, got=%s",
+				i, tok.Literal, tt.expectedLine, tt.expectedColumn, tok.Pos)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := "// head\nlet x = 10 / 2; // tail\n//\nx"
+	expectedTokens := []string{"let", "x", "=", "10", "/", "2", ";", "x", ""}
+
+	l := New(input)
+	for i, expected := range expectedTokens {
+		tok := l.NextToken()
+		if tok.Literal != expected {
+			t.Fatalf("tokens[%d] - literal wrong. expected=%q, got=%q", i, expected, tok.Literal)
+		}
+	}
+
+	expectedComments := []Comment{
+		{Pos: token.Position{Line: 1, Column: 1}, Text: " head"},
+		{Pos: token.Position{Line: 2, Column: 17}, Text: " tail"},
+		{Pos: token.Position{Line: 3, Column: 1}, Text: ""},
+	}
+	comments := l.Comments()
+	if len(comments) != len(expectedComments) {
+		t.Fatalf("wrong number of comments. expected=%d, got=%d", len(expectedComments), len(comments))
+	}
+	for i, expected := range expectedComments {
+		if comments[i] != expected {
+			t.Errorf("comments[%d] wrong. expected=%+v, got=%+v", i, expected, comments[i])
+		}
+	}
+}
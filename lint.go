@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tshinag/monkey/lint"
+)
+
+// defaultLintConfig is the config file used if -config is not given and the file exists
+const defaultLintConfig = ".monkeylint.json"
+
+// lintDiagnostic is the diagnostic printed by -json
+type lintDiagnostic struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// lintScripts reports the suspicious code in the scripts.
+// The exit code is non-zero if anything is reported.
+func lintScripts(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configPath := flags.String("config", "", "the JSON config enabling and disabling the rules")
+	jsonOutput := flags.Bool("json", false, "print the diagnostics as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintf(stderr, "usage: monkey lint [-config FILE] [-json] FILE...\n")
+		return 2
+	}
+
+	if *configPath == "" {
+		if _, err := os.Stat(defaultLintConfig); err == nil {
+			*configPath = defaultLintConfig
+		}
+	}
+	var config *lint.Config
+	if *configPath != "" {
+		var err error
+		if config, err = lint.LoadConfig(*configPath); err != nil {
+			fmt.Fprintf(stderr, "%s\n", err)
+			return 2
+		}
+	}
+
+	status := 0
+	reported := []lintDiagnostic{}
+	for _, path := range flags.Args() {
+		program, comments, ok := parseFileWithComments(path, stderr)
+		if !ok {
+			status = 1
+			continue
+		}
+		for _, d := range lint.Lint(program, comments, config) {
+			status = 1
+			if !*jsonOutput {
+				fmt.Fprintf(stdout, "%s:%s\n", path, d)
+				continue
+			}
+			reported = append(reported, lintDiagnostic{
+				File:    path,
+				Line:    d.Pos.Line,
+				Column:  d.Pos.Column,
+				Rule:    d.Rule,
+				Message: d.Message,
+			})
+		}
+	}
+	if *jsonOutput {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(reported); err != nil {
+			fmt.Fprintf(stderr, "%s\n", err)
+			return 2
+		}
+	}
+	return status
+}
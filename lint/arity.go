@@ -0,0 +1,130 @@
+package lint
+
+import (
+	"fmt"
+
+	"github.com/tshinag/monkey/ast"
+)
+
+// arity is the range of the number of arguments. max is -1 if variadic
+type arity struct {
+	min, max int
+}
+
+func (a arity) accepts(n int) bool {
+	return a.min <= n && (a.max < 0 || n <= a.max)
+}
+
+func (a arity) String() string {
+	switch {
+	case a.min == a.max:
+		return fmt.Sprint(a.min)
+	case a.max < 0:
+		return fmt.Sprintf("%d+", a.min)
+	}
+	return fmt.Sprintf("%d..%d", a.min, a.max)
+}
+
+// builtinArities are the numbers of arguments accepted by the builtin functions
+var builtinArities = map[string]arity{
+	"all":            {1, 1},
+	"assert":         {1, 2},
+	"assert_eq":      {2, 3},
+	"assert_error":   {1, 2},
+	"channel":        {0, 1},
+	"clear_interval": {1, 1},
+	"clear_timeout":  {1, 1},
+	"close":          {1, 1},
+	"collect":        {1, 1},
+	"filter":         {2, 2},
+	"first":          {1, 1},
+	"iter":           {1, 1},
+	"last":           {1, 1},
+	"len":            {1, 1},
+	"map":            {2, 2},
+	"next":           {1, 1},
+	"now":            {0, 0},
+	"push":           {2, 2},
+	"puts":           {0, -1},
+	"range":          {1, 3},
+	"recv":           {1, 1},
+	"reduce":         {3, 3},
+	"rest":           {1, 1},
+	"send":           {2, 2},
+	"set_interval":   {2, -1},
+	"set_timeout":    {2, -1},
+	"sleep":          {1, 1},
+	"spawn":          {1, -1},
+	"take":           {2, 2},
+	"wait":           {1, 1},
+}
+
+func checkArity(p *pass) {
+	piped := make(map[*ast.CallExpression]bool) // パイプの右辺の呼び出し式
+	ast.Inspect(p.program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.PipeExpression:
+			// 左辺は第1引数として渡される
+			if call, ok := node.Right.(*ast.CallExpression); ok {
+				piped[call] = true
+			} else {
+				p.checkCall(node, node.Right, nil, 1)
+			}
+		case *ast.CallExpression:
+			implicit := 0
+			if piped[node] {
+				implicit = 1
+			}
+			p.checkCall(node, node.Function, node.Arguments, implicit)
+		}
+		return true
+	})
+}
+
+func (p *pass) checkCall(call ast.Node, function ast.Expression, arguments []ast.Expression, implicit int) {
+	for _, a := range arguments {
+		// 展開される引数の数は静的には分からない
+		if _, ok := a.(*ast.SpreadElement); ok {
+			return
+		}
+	}
+	ident, ok := function.(*ast.Identifier)
+	if !ok {
+		return
+	}
+	want, ok := p.arityOf(ident)
+	if !ok {
+		return
+	}
+	if got := len(arguments) + implicit; !want.accepts(got) {
+		p.reportf(call, "wrong number of arguments to `%s`. got=%d, want=%s", ident.Value, got, want)
+	}
+}
+
+// arityOf returns the arity of the function the identifier refers to, if it is known statically
+func (p *pass) arityOf(ident *ast.Identifier) (arity, bool) {
+	b, ok := p.resolved.Uses[ident]
+	if !ok {
+		a, ok := builtinArities[ident.Value]
+		return a, ok
+	}
+	switch d := b.Declaration.(type) {
+	case *ast.FunctionStatement:
+		return functionArity(d.Function), !b.Assigned()
+	case *ast.LetStatement:
+		if fl, ok := d.Value.(*ast.FunctionLiteral); ok && !b.Assigned() {
+			return functionArity(fl), true
+		}
+	case *ast.ConstStatement:
+		if fl, ok := d.Value.(*ast.FunctionLiteral); ok {
+			return functionArity(fl), true
+		}
+	case *ast.StructStatement:
+		return arity{len(d.Fields), len(d.Fields)}, true
+	}
+	return arity{}, false
+}
+
+func functionArity(fl *ast.FunctionLiteral) arity {
+	return arity{len(fl.Parameters), len(fl.Parameters)}
+}
@@ -0,0 +1,42 @@
+package lint
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+// Config enables and disables the rules. The rules not listed are enabled.
+//
+//	{"rules": {"empty-block": false}}
+type Config struct {
+	Rules map[string]bool `json:"rules"`
+}
+
+// LoadConfig reads the config from the JSON file
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, errors.Wrapf(err, "invalid config %s", path)
+	}
+	for name := range config.Rules {
+		if findRule(name) == nil {
+			return nil, errors.Errorf("unknown rule %s in config %s", name, path)
+		}
+	}
+	return config, nil
+}
+
+// Enabled reports whether the rule is enabled. All the rules are enabled by nil config.
+func (c *Config) Enabled(rule string) bool {
+	if c == nil {
+		return true
+	}
+	enabled, ok := c.Rules[rule]
+	return !ok || enabled
+}
@@ -0,0 +1,86 @@
+package lint
+
+import (
+	"strings"
+
+	"github.com/tshinag/monkey/lexer"
+)
+
+// The comments "// lint:disable rule..." and "// lint:enable rule..." disable and enable
+// the rules from the line to the end of file, and "// lint:ignore rule..." disables them
+// on the line and the next line. The directive without rule names applies to all the rules.
+const (
+	directiveDisable = "lint:disable"
+	directiveEnable  = "lint:enable"
+	directiveIgnore  = "lint:ignore"
+)
+
+// directive is the inline comment disabling or enabling rules
+type directive struct {
+	line  int
+	kind  string
+	all   bool // 規則名がなく、全ての規則に適用する
+	rules map[string]bool
+}
+
+type directives []*directive
+
+func parseDirectives(comments []lexer.Comment) (directives, []*Diagnostic) {
+	ds := directives{}
+	errors := []*Diagnostic{}
+	for _, c := range comments {
+		fields := strings.FieldsFunc(c.Text, func(r rune) bool {
+			return r == ' ' || r == '\t' || r == ','
+		})
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case directiveDisable, directiveEnable, directiveIgnore:
+		default:
+			continue
+		}
+
+		// 規則名が全て未知の場合は、全ての規則ではなくどの規則にも適用しない
+		d := &directive{line: c.Pos.Line, kind: fields[0], all: len(fields) == 1, rules: make(map[string]bool)}
+		for _, name := range fields[1:] {
+			if findRule(name) == nil {
+				errors = append(errors, &Diagnostic{
+					Pos:     c.Pos,
+					Rule:    "directive",
+					Message: "unknown rule " + name + " in " + fields[0],
+				})
+				continue
+			}
+			d.rules[name] = true
+		}
+		ds = append(ds, d)
+	}
+	return ds, errors
+}
+
+func (d *directive) matches(rule string) bool {
+	return d.all || d.rules[rule]
+}
+
+// disabled reports whether the diagnostic is disabled by the directives before it
+func (ds directives) disabled(diagnostic *Diagnostic) bool {
+	line := diagnostic.Pos.Line
+	disabled := false
+	for _, d := range ds {
+		if d.line > line || !d.matches(diagnostic.Rule) {
+			continue
+		}
+		switch d.kind {
+		case directiveDisable:
+			disabled = true
+		case directiveEnable:
+			disabled = false
+		case directiveIgnore:
+			if line-d.line <= 1 {
+				return true
+			}
+		}
+	}
+	return disabled
+}
@@ -0,0 +1,64 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/tshinag/monkey/ast"
+	"github.com/tshinag/monkey/lexer"
+	"github.com/tshinag/monkey/resolver"
+	"github.com/tshinag/monkey/token"
+)
+
+// Diagnostic is the suspicious code found by a rule
+type Diagnostic struct {
+	Pos     token.Position
+	Rule    string
+	Message string
+}
+
+func (d *Diagnostic) Error() string {
+	return fmt.Sprintf("%s: %s (%s)", d.Pos, d.Message, d.Rule)
+}
+
+// Lint checks the program with the rules enabled by the config,
+// and drops the diagnostics disabled by the directives in the comments.
+// The config may be nil to enable all the rules.
+func Lint(program *ast.Program, comments []lexer.Comment, config *Config) []*Diagnostic {
+	p := &pass{program: program, resolved: resolver.Resolve(program)}
+	for _, rule := range Rules {
+		if config.Enabled(rule.Name) {
+			p.rule = rule.Name
+			rule.run(p)
+		}
+	}
+
+	directives, errors := parseDirectives(comments)
+	diagnostics := errors
+	for _, d := range p.diagnostics {
+		if !directives.disabled(d) {
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i].Pos, diagnostics[j].Pos
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+	return diagnostics
+}
+
+// pass is the state shared by the rules checking a program
+type pass struct {
+	program     *ast.Program
+	resolved    *resolver.Result
+	rule        string // 実行中の規則
+	diagnostics []*Diagnostic
+}
+
+func (p *pass) reportf(node ast.Node, format string, args ...interface{}) {
+	p.diagnostics = append(p.diagnostics, &Diagnostic{
+		Pos:     node.Pos(),
+		Rule:    p.rule,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
@@ -0,0 +1,169 @@
+package lint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tshinag/monkey/evaluator"
+	"github.com/tshinag/monkey/lexer"
+	"github.com/tshinag/monkey/parser"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 1; puts(x)", nil},
+		// unreachable
+		{"fn f() { return 1; puts(2) }", []string{"1:20: unreachable code after return (unreachable)"}},
+		{"fn f() { return g(); fn g() { 1 } }", nil},
+		// constant-condition
+		{"if (true) { 1 }", []string{"1:5: if condition is always true (constant-condition)"}},
+		{"if (!0) { 1 } else { 2 }", []string{"1:5: if condition is always false (constant-condition)"}},
+		{"if (null) { 1 } else if (1) { 2 }", []string{
+			"1:5: if condition is always false (constant-condition)",
+			"1:26: if condition is always true (constant-condition)",
+		}},
+		{"let x = 1; if (x) { 1 }", nil},
+		// literal-comparison
+		{`puts(1 == "1")`, []string{"1:8: comparison of int and string is always false (literal-comparison)"}},
+		{"puts(-1 != null)", []string{"1:9: comparison of int and null is always true (literal-comparison)"}},
+		{"puts(1 == 2)", nil},
+		// self-assignment
+		{"let x = 1; x = x; puts(x)", []string{"1:14: self-assignment of x (self-assignment)"}},
+		{"let x = 1; x += x; puts(x)", nil},
+		// empty-block
+		{"let x = 1; if (x) { } else { }", []string{
+			"1:19: empty if block (empty-block)",
+			"1:28: empty else block (empty-block)",
+		}},
+		{"for (x in [1]) {}", []string{"1:16: empty for block (empty-block)"}},
+		{"fn f() {}", nil},
+		// arity
+		{"fn f(a, b) { a + b } f(1)", []string{"1:23: wrong number of arguments to `f`. got=1, want=2 (arity)"}},
+		{"let f = fn(a) { a }; f(1, 2)", []string{"1:23: wrong number of arguments to `f`. got=2, want=1 (arity)"}},
+		{"let f = fn(a) { a }; f = fn(a, b) { a }; f(1, 2)", nil},
+		{"struct P { x, y }; P(1)", []string{"1:21: wrong number of arguments to `P`. got=1, want=2 (arity)"}},
+		{"len([], [])", []string{"1:4: wrong number of arguments to `len`. got=2, want=1 (arity)"}},
+		{"range()", []string{"1:6: wrong number of arguments to `range`. got=0, want=1..3 (arity)"}},
+		{"[1] |> map(fn(x) { x }); [1] |> len; [1] |> len(2)", []string{
+			"1:48: wrong number of arguments to `len`. got=2, want=1 (arity)",
+		}},
+		{"fn f(a, b) { a + b } f(...[1, 2]); puts(); spawn(f, 1, 2)", nil},
+		{"fn f(len) { len(1, 2) } f(1)", nil},
+	}
+
+	for _, tt := range tests {
+		actual := lintTestProgram(t, tt.input, nil)
+		if len(actual) != len(tt.expected) {
+			t.Errorf("wrong diagnostics for %q.\nexpected=%q\ngot=%q", tt.input, tt.expected, actual)
+			continue
+		}
+		for i := range tt.expected {
+			if actual[i] != tt.expected[i] {
+				t.Errorf("wrong diagnostic for %q. expected=%q, got=%q", tt.input, tt.expected[i], actual[i])
+			}
+		}
+	}
+}
+
+func TestDirectives(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"if (true) { 1 } // lint:ignore constant-condition", nil},
+		{"// lint:ignore\nif (true) { 1 }\nif (true) { 2 }", []string{
+			"3:5: if condition is always true (constant-condition)",
+		}},
+		{"// lint:disable empty-block, constant-condition\nif (true) { }\n// lint:enable empty-block\nif (true) { }", []string{
+			"4:11: empty if block (empty-block)",
+		}},
+		{"// lint:disable arity\nif (true) { }", []string{
+			"2:5: if condition is always true (constant-condition)",
+			"2:11: empty if block (empty-block)",
+		}},
+		{"// lint:ignore no-such-rule\n1", []string{"1:1: unknown rule no-such-rule in lint:ignore (directive)"}},
+		{"let x = 1; x = x // lint:disable self-asignment\nif (true) { 1 }", []string{
+			"1:14: self-assignment of x (self-assignment)",
+			"1:18: unknown rule self-asignment in lint:disable (directive)",
+			"2:5: if condition is always true (constant-condition)",
+		}},
+		{"// lint is great\nif (true) { 1 }", []string{"2:5: if condition is always true (constant-condition)"}},
+	}
+
+	for _, tt := range tests {
+		actual := lintTestProgram(t, tt.input, nil)
+		if strings.Join(actual, "\n") != strings.Join(tt.expected, "\n") {
+			t.Errorf("wrong diagnostics for %q.\nexpected=%q\ngot=%q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	writeFile(t, path, `{"rules": {"empty-block": false, "arity": true}}`)
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %s", err)
+	}
+	if config.Enabled("empty-block") || !config.Enabled("arity") || !config.Enabled("unreachable") {
+		t.Errorf("wrong rules enabled: %v", config.Rules)
+	}
+	actual := lintTestProgram(t, "if (true) { }", config)
+	expected := []string{"1:5: if condition is always true (constant-condition)"}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("wrong diagnostics. expected=%q, got=%q", expected, actual)
+	}
+
+	writeFile(t, path, `{"rules": {"no-such-rule": false}}`)
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "unknown rule no-such-rule") {
+		t.Errorf("expected unknown rule error, got=%v", err)
+	}
+	writeFile(t, path, `{"rules": [`)
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "invalid config") {
+		t.Errorf("expected invalid config error, got=%v", err)
+	}
+}
+
+func TestBuiltinArities(t *testing.T) {
+	names := evaluator.BuiltinNames()
+	if len(names) != len(builtinArities) {
+		t.Errorf("wrong number of builtin arities. expected=%d, got=%d", len(names), len(builtinArities))
+	}
+	for _, name := range names {
+		if _, ok := builtinArities[name]; !ok {
+			t.Errorf("no arity for builtin %s", name)
+		}
+	}
+}
+
+func lintTestProgram(t *testing.T, input string, config *Config) []string {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+	actual := []string{}
+	for _, d := range Lint(program, l.Comments(), config) {
+		actual = append(actual, d.Error())
+	}
+	return actual
+}
+
+func writeFile(t *testing.T, path, content string) {
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
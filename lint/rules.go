@@ -0,0 +1,152 @@
+package lint
+
+import (
+	"github.com/tshinag/monkey/ast"
+)
+
+// Rule is the check reporting a kind of suspicious code
+type Rule struct {
+	Name string
+	Doc  string
+	run  func(p *pass)
+}
+
+// Rules are the rules in alphabetical order
+var Rules = []*Rule{
+	{Name: "arity", Doc: "calls with the wrong number of arguments to known functions and builtins", run: checkArity},
+	{Name: "constant-condition", Doc: "if conditions which are always true or false", run: checkConstantCondition},
+	{Name: "empty-block", Doc: "empty blocks of if, else and for", run: checkEmptyBlock},
+	{Name: "literal-comparison", Doc: "comparisons of literals of different types, such as 1 == \"1\"", run: checkLiteralComparison},
+	{Name: "self-assignment", Doc: "assignments of a variable to itself", run: checkSelfAssignment},
+	{Name: "unreachable", Doc: "statements after return", run: checkUnreachable},
+}
+
+func findRule(name string) *Rule {
+	for _, rule := range Rules {
+		if rule.Name == name {
+			return rule
+		}
+	}
+	return nil
+}
+
+func checkUnreachable(p *pass) {
+	check := func(statements []ast.Statement) {
+		for i, statement := range statements {
+			if _, ok := statement.(*ast.ReturnStatement); !ok {
+				continue
+			}
+			for _, next := range statements[i+1:] {
+				// 関数宣言は巻き上げられるので到達できる
+				if _, ok := next.(*ast.FunctionStatement); !ok {
+					p.reportf(next, "unreachable code after return")
+					return
+				}
+			}
+			return
+		}
+	}
+	ast.Inspect(p.program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Program:
+			check(node.Statements)
+		case *ast.BlockStatement:
+			check(node.Statements)
+		}
+		return true
+	})
+}
+
+func checkConstantCondition(p *pass) {
+	ast.Inspect(p.program, func(node ast.Node) bool {
+		if ie, ok := node.(*ast.IfExpression); ok {
+			if truthy, ok := constantTruthiness(ie.Condition); ok {
+				p.reportf(ie.Condition, "if condition is always %t", truthy)
+			}
+		}
+		return true
+	})
+}
+
+// constantTruthiness returns the truthiness of the expression if it doesn't depend on variables
+func constantTruthiness(expression ast.Expression) (bool, bool) {
+	switch e := expression.(type) {
+	case *ast.Boolean:
+		return e.Value, true
+	case *ast.NullLiteral:
+		return false, true
+	case *ast.IntegerLiteral, *ast.StringLiteral, *ast.FunctionLiteral:
+		return true, true
+	case *ast.PrefixExpression:
+		if e.Operator == "!" {
+			truthy, ok := constantTruthiness(e.Right)
+			return !truthy, ok
+		}
+		if e.Operator == "-" {
+			_, ok := e.Right.(*ast.IntegerLiteral)
+			return true, ok
+		}
+	}
+	return false, false
+}
+
+func checkEmptyBlock(p *pass) {
+	check := func(block *ast.BlockStatement, kind string) {
+		if block != nil && len(block.Statements) == 0 {
+			p.reportf(block, "empty %s block", kind)
+		}
+	}
+	ast.Inspect(p.program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.IfExpression:
+			check(node.Consequence, "if")
+			check(node.Alternative, "else")
+		case *ast.ForExpression:
+			check(node.Body, "for")
+		}
+		return true
+	})
+}
+
+func checkLiteralComparison(p *pass) {
+	ast.Inspect(p.program, func(node ast.Node) bool {
+		ie, ok := node.(*ast.InfixExpression)
+		if !ok || ie.Operator != "==" && ie.Operator != "!=" {
+			return true
+		}
+		left, right := literalType(ie.Left), literalType(ie.Right)
+		if left != "" && right != "" && left != right {
+			p.reportf(ie, "comparison of %s and %s is always %t", left, right, ie.Operator == "!=")
+		}
+		return true
+	})
+}
+
+// literalType returns the type name of the literal, or "" if expression is not a literal
+func literalType(expression ast.Expression) string {
+	switch e := expression.(type) {
+	case *ast.IntegerLiteral:
+		return "int"
+	case *ast.StringLiteral:
+		return "string"
+	case *ast.Boolean:
+		return "bool"
+	case *ast.NullLiteral:
+		return "null"
+	case *ast.PrefixExpression:
+		if _, ok := e.Right.(*ast.IntegerLiteral); ok && e.Operator == "-" {
+			return "int"
+		}
+	}
+	return ""
+}
+
+func checkSelfAssignment(p *pass) {
+	ast.Inspect(p.program, func(node ast.Node) bool {
+		ae, ok := node.(*ast.AssignExpression)
+		if ok && ae.Operator == "=" && ae.Target.String() == ae.Value.String() {
+			p.reportf(ae, "self-assignment of %s", ae.Target)
+		}
+		return true
+	})
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tshinag/monkey/lsp"
+)
+
+// serveLSP runs the language server on stdin and stdout until the client exits
+func serveLSP(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 0 {
+		fmt.Fprintf(stderr, "usage: monkey lsp\n")
+		return 2
+	}
+	if err := lsp.NewServer(os.Stdin, stdout, stderr).Serve(); err != nil {
+		fmt.Fprintf(stderr, "lsp: %s\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,342 @@
+package lsp
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/tshinag/monkey/ast"
+	"github.com/tshinag/monkey/lexer"
+	"github.com/tshinag/monkey/parser"
+	"github.com/tshinag/monkey/resolver"
+	"github.com/tshinag/monkey/token"
+)
+
+// document is the text opened by the client
+type document struct {
+	uri      string
+	version  int
+	source   *source
+	errors   []error   // 構文エラー
+	analysis *analysis // 最後に構文エラーなく解析できた版。まだなければ nil
+}
+
+func newDocument(uri string, version int, text string) *document {
+	d := &document{uri: uri}
+	d.update(version, text)
+	return d
+}
+
+// update parses the new text. The analysis of the previous version is kept
+// if the text has syntax errors, which are common while typing.
+func (d *document) update(version int, text string) {
+	d.version = version
+	d.source = newSource(text)
+	l := lexer.New(text)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	d.errors = p.Errors()
+	if len(d.errors) == 0 {
+		d.analysis = analyze(d.source, program, l.Comments())
+	}
+}
+
+// source is the text with the tokens, which converts the positions between the lexer and LSP
+type source struct {
+	text    string
+	lines   []string
+	tokens  map[token.Position]token.Token
+	braces  []token.Position                  // '{' の位置。記述順
+	closing map[token.Position]token.Position // '{', '(', '[' の位置から対応する閉じ括弧の位置
+}
+
+// closers maps the opening brackets to the closing ones
+var closers = map[token.Type]token.Type{
+	token.LBRACE:   token.RBRACE,
+	token.LPAREN:   token.RPAREN,
+	token.LBRACKET: token.RBRACKET,
+}
+
+func newSource(text string) *source {
+	s := &source{
+		text:    text,
+		lines:   strings.Split(text, "\n"),
+		tokens:  make(map[token.Position]token.Token),
+		closing: make(map[token.Position]token.Position),
+	}
+	l := lexer.New(text)
+	// 括弧の種類ごとに開き括弧の位置を積む
+	opening := make(map[token.Type][]token.Position)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		s.tokens[tok.Pos] = tok
+		if tok.Type == token.LBRACE {
+			s.braces = append(s.braces, tok.Pos)
+		}
+		if closer, ok := closers[tok.Type]; ok {
+			opening[closer] = append(opening[closer], tok.Pos)
+			continue
+		}
+		if stack := opening[tok.Type]; len(stack) > 0 {
+			s.closing[stack[len(stack)-1]] = tok.Pos
+			opening[tok.Type] = stack[:len(stack)-1]
+		}
+	}
+	return s
+}
+
+// position converts the position of the lexer, whose column is the byte offset,
+// to the position of LSP, whose character is the offset in UTF-16 code units
+func (s *source) position(pos token.Position) Position {
+	line := pos.Line - 1
+	if line < 0 || line >= len(s.lines) {
+		return Position{Line: line}
+	}
+	text := s.lines[line]
+	n := pos.Column - 1
+	if n > len(text) {
+		n = len(text)
+	}
+	if n < 0 {
+		n = 0
+	}
+	return Position{Line: line, Character: len(utf16.Encode([]rune(text[:n])))}
+}
+
+// tokenPosition converts the position of LSP to the position of the lexer
+func (s *source) tokenPosition(p Position) token.Position {
+	pos := token.Position{Line: p.Line + 1, Column: 1}
+	if p.Line < 0 || p.Line >= len(s.lines) {
+		return pos
+	}
+	text := s.lines[p.Line]
+	units := 0
+	for offset, r := range text {
+		if units >= p.Character {
+			pos.Column = offset + 1
+			return pos
+		}
+		units += len(utf16.Encode([]rune{r}))
+	}
+	pos.Column = len(text) + 1
+	return pos
+}
+
+// tokenEnd returns the position just after the token at pos
+func (s *source) tokenEnd(pos token.Position) token.Position {
+	tok, ok := s.tokens[pos]
+	if !ok {
+		return token.Position{Line: pos.Line, Column: pos.Column + 1}
+	}
+	length := len(tok.Literal)
+	if tok.Type == token.STRING {
+		// 改行を含む文字列は最初の行の終わりまでとする
+		length += 2
+		if i := strings.IndexByte(tok.Literal, '\n'); i >= 0 {
+			length = i + 1
+		}
+	}
+	return token.Position{Line: pos.Line, Column: pos.Column + length}
+}
+
+// closingBrace returns the position of '}' closing the first '{' at or after pos
+func (s *source) closingBrace(pos token.Position) (token.Position, bool) {
+	i := sort.Search(len(s.braces), func(i int) bool {
+		return !before(s.braces[i], pos)
+	})
+	if i == len(s.braces) {
+		return token.Position{}, false
+	}
+	closing, ok := s.closing[s.braces[i]]
+	return closing, ok
+}
+
+// tokenRange returns the range of the token at pos
+func (s *source) tokenRange(pos token.Position) Range {
+	return Range{Start: s.position(pos), End: s.position(s.tokenEnd(pos))}
+}
+
+// lineAt returns the line of the position without the indentation
+func (s *source) lineAt(pos token.Position) string {
+	if pos.Line < 1 || pos.Line > len(s.lines) {
+		return ""
+	}
+	return strings.TrimSpace(s.lines[pos.Line-1])
+}
+
+// analysis is the syntax tree and the bindings of the document without syntax errors
+type analysis struct {
+	*source
+	program  *ast.Program
+	comments []lexer.Comment
+	resolved *resolver.Result
+	scopes   map[ast.Node]ast.Node       // ノードを直接含むスコープ
+	ends     map[ast.Node]token.Position // ノードの終わりの位置
+}
+
+func analyze(s *source, program *ast.Program, comments []lexer.Comment) *analysis {
+	a := &analysis{
+		source:   s,
+		program:  program,
+		comments: comments,
+		resolved: resolver.Resolve(program),
+		scopes:   make(map[ast.Node]ast.Node),
+		ends:     make(map[ast.Node]token.Position),
+	}
+	a.indexScopes(program)
+	return a
+}
+
+// isScope reports whether the node introduces a scope in the evaluator
+func isScope(node ast.Node) bool {
+	switch node.(type) {
+	case *ast.Program, *ast.BlockStatement, *ast.FunctionLiteral,
+		*ast.ForExpression, *ast.MatchArm, *ast.SelectCase:
+		return true
+	}
+	return false
+}
+
+func (a *analysis) indexScopes(scope ast.Node) {
+	ast.Inspect(scope, func(node ast.Node) bool {
+		if node == scope {
+			return true
+		}
+		a.scopes[node] = scope
+		if isScope(node) {
+			a.indexScopes(node)
+			return false
+		}
+		return true
+	})
+}
+
+// scopeOf returns the node of the scope where the binding is declared
+func (a *analysis) scopeOf(b *resolver.Binding) ast.Node {
+	// 引数は関数リテラル、select の束縛は case 自身のスコープに宣言される
+	if isScope(b.Declaration) {
+		return b.Declaration
+	}
+	return a.scopes[b.Declaration]
+}
+
+// end returns the position just after the node
+func (a *analysis) end(node ast.Node) token.Position {
+	if end, ok := a.ends[node]; ok {
+		return end
+	}
+	end := a.tokenEnd(node.Pos())
+	ast.Inspect(node, func(child ast.Node) bool {
+		if child == node {
+			return true
+		}
+		end = later(end, a.end(child))
+		return false
+	})
+	// 波括弧で終わるノードは、対応する '}' までとする
+	var braceFrom token.Position
+	switch n := node.(type) {
+	case *ast.BlockStatement, *ast.HashLiteral, *ast.StructStatement, *ast.ImplStatement,
+		*ast.ClassStatement, *ast.EnumStatement, *ast.SelectExpression:
+		braceFrom = node.Pos()
+	case *ast.MatchExpression:
+		braceFrom = a.end(n.Subject)
+	case *ast.SwitchExpression:
+		braceFrom = node.Pos()
+		if n.Subject != nil {
+			braceFrom = a.end(n.Subject)
+		}
+	}
+	if braceFrom.Line > 0 {
+		if closing, ok := a.closingBrace(braceFrom); ok {
+			end = later(end, token.Position{Line: closing.Line, Column: closing.Column + 1})
+		}
+	}
+	// 呼び出しや添字、配列は ')' や ']' までとする
+	switch node.(type) {
+	case *ast.CallExpression, *ast.IndexExpression, *ast.ArrayLiteral:
+		if closing, ok := a.closing[node.Pos()]; ok {
+			end = later(end, token.Position{Line: closing.Line, Column: closing.Column + 1})
+		}
+	}
+	a.ends[node] = end
+	return end
+}
+
+// contains reports whether pos is in the node, including the position just after it
+func (a *analysis) contains(node ast.Node, pos token.Position) bool {
+	if _, ok := node.(*ast.Program); ok {
+		return true
+	}
+	return !before(pos, node.Pos()) && !before(a.end(node), pos)
+}
+
+// identifierAt returns the identifier under the cursor at pos
+func (a *analysis) identifierAt(pos token.Position) *ast.Identifier {
+	var found *ast.Identifier
+	ast.Inspect(a.program, func(node ast.Node) bool {
+		if found != nil {
+			return false
+		}
+		if ident, ok := node.(*ast.Identifier); ok {
+			start := ident.Pos()
+			if start.Line == pos.Line && start.Column <= pos.Column && pos.Column <= start.Column+len(ident.Value) {
+				found = ident
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// bindingOf returns the binding the identifier refers to or declares
+func (a *analysis) bindingOf(ident *ast.Identifier) *resolver.Binding {
+	if b, ok := a.resolved.Uses[ident]; ok {
+		return b
+	}
+	for _, b := range a.resolved.Bindings {
+		if b.Name == ident {
+			return b
+		}
+	}
+	return nil
+}
+
+// visible returns the bindings in scope at pos. The inner binding hides the outer one of the same name.
+func (a *analysis) visible(pos token.Position) []*resolver.Binding {
+	byName := make(map[string]*resolver.Binding)
+	names := []string{}
+	for _, b := range a.resolved.Bindings {
+		scope := a.scopeOf(b)
+		if scope == nil || !a.contains(scope, pos) {
+			continue
+		}
+		// 関数宣言と、関数から遅れて参照されうるグローバルな宣言以外は、宣言より後でしか使えない
+		_, global := scope.(*ast.Program)
+		if b.Kind != resolver.Function && !global && before(pos, b.Name.Pos()) {
+			continue
+		}
+		prev, ok := byName[b.Name.Value]
+		if !ok {
+			names = append(names, b.Name.Value)
+		}
+		if !ok || !before(scope.Pos(), a.scopeOf(prev).Pos()) {
+			byName[b.Name.Value] = b
+		}
+	}
+	visible := make([]*resolver.Binding, len(names))
+	for i, name := range names {
+		visible[i] = byName[name]
+	}
+	return visible
+}
+
+func before(a, b token.Position) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+}
+
+func later(a, b token.Position) token.Position {
+	if before(a, b) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,308 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tshinag/monkey/ast"
+	"github.com/tshinag/monkey/evaluator"
+	"github.com/tshinag/monkey/lint"
+	"github.com/tshinag/monkey/parser"
+	"github.com/tshinag/monkey/resolver"
+	"github.com/tshinag/monkey/token"
+	"github.com/tshinag/monkey/types"
+)
+
+// diagnostics returns the syntax errors, or the problems found by the static checks if there is none
+func (d *document) diagnostics(config *lint.Config) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	if len(d.errors) != 0 {
+		for _, err := range d.errors {
+			pos := token.Position{Line: 1, Column: 1}
+			if pe, ok := err.(*parser.Error); ok {
+				pos = pe.Pos
+			}
+			diagnostics = append(diagnostics, d.source.diagnostic(pos, SeverityError, "", err.Error()))
+		}
+		return diagnostics
+	}
+
+	a := d.analysis
+	for _, rd := range a.resolved.Diagnostics {
+		severity := SeverityError
+		if rd.Severity == resolver.Warning {
+			severity = SeverityWarning
+		}
+		diagnostics = append(diagnostics, a.diagnostic(rd.Pos, severity, "", rd.Message))
+	}
+	for _, te := range types.Check(a.program) {
+		diagnostics = append(diagnostics, a.diagnostic(te.Pos, SeverityError, "", te.Message))
+	}
+	for _, ld := range lint.Lint(a.program, a.comments, config) {
+		diagnostics = append(diagnostics, a.diagnostic(ld.Pos, SeverityWarning, ld.Rule, ld.Message))
+	}
+	return diagnostics
+}
+
+func (s *source) diagnostic(pos token.Position, severity DiagnosticSeverity, code, message string) Diagnostic {
+	return Diagnostic{
+		Range:    s.tokenRange(pos),
+		Severity: severity,
+		Code:     code,
+		Source:   "monkey",
+		Message:  message,
+	}
+}
+
+// identifierAt returns the analysis of the document and the identifier at the position.
+// The identifier is nil if the document has never been parsed without errors.
+func (s *Server) identifierAt(params TextDocumentPositionParams) (*analysis, *ast.Identifier, error) {
+	d, err := s.document(params.TextDocument.URI)
+	if err != nil || d.analysis == nil {
+		return nil, nil, err
+	}
+	a := d.analysis
+	return a, a.identifierAt(a.tokenPosition(params.Position)), nil
+}
+
+func (s *Server) hover(raw json.RawMessage) (interface{}, error) {
+	var params TextDocumentPositionParams
+	if err := decode(raw, &params); err != nil {
+		return nil, err
+	}
+	a, ident, err := s.identifierAt(params)
+	if ident == nil {
+		return nil, err
+	}
+
+	var contents string
+	if b := a.bindingOf(ident); b != nil {
+		contents = fmt.Sprintf("```monkey\n%s\n```\n%s `%s`, declared at %s",
+			a.lineAt(b.Declaration.Pos()), b.Kind, b.Name.Value, b.Name.Pos())
+	} else if isBuiltin(ident.Value) {
+		contents = fmt.Sprintf("builtin function `%s`", ident.Value)
+	} else {
+		return nil, nil
+	}
+	r := a.tokenRange(ident.Pos())
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: contents}, Range: &r}, nil
+}
+
+func isBuiltin(name string) bool {
+	names := evaluator.BuiltinNames()
+	i := sort.SearchStrings(names, name)
+	return i < len(names) && names[i] == name
+}
+
+func (s *Server) definition(raw json.RawMessage) (interface{}, error) {
+	var params TextDocumentPositionParams
+	if err := decode(raw, &params); err != nil {
+		return nil, err
+	}
+	a, ident, err := s.identifierAt(params)
+	if ident == nil {
+		return nil, err
+	}
+	b := a.bindingOf(ident)
+	if b == nil {
+		return nil, nil
+	}
+	return &Location{URI: params.TextDocument.URI, Range: a.tokenRange(b.Name.Pos())}, nil
+}
+
+func (s *Server) references(raw json.RawMessage) (interface{}, error) {
+	var params ReferenceParams
+	if err := decode(raw, &params); err != nil {
+		return nil, err
+	}
+	a, ident, err := s.identifierAt(params.TextDocumentPositionParams)
+	if ident == nil {
+		return nil, err
+	}
+	b := a.bindingOf(ident)
+	if b == nil {
+		return nil, nil
+	}
+	locations := []Location{}
+	uri := params.TextDocument.URI
+	if params.Context.IncludeDeclaration {
+		locations = append(locations, Location{URI: uri, Range: a.tokenRange(b.Name.Pos())})
+	}
+	for _, use := range b.Uses {
+		locations = append(locations, Location{URI: uri, Range: a.tokenRange(use.Pos())})
+	}
+	return locations, nil
+}
+
+func (s *Server) completion(raw json.RawMessage) (interface{}, error) {
+	var params TextDocumentPositionParams
+	if err := decode(raw, &params); err != nil {
+		return nil, err
+	}
+	d, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []CompletionItem{}
+	bound := make(map[string]bool)
+	if a := d.analysis; a != nil {
+		for _, b := range a.visible(a.tokenPosition(params.Position)) {
+			items = append(items, CompletionItem{Label: b.Name.Value, Kind: completionKind(b), Detail: b.Kind.String()})
+			bound[b.Name.Value] = true
+		}
+	}
+	for _, name := range evaluator.BuiltinNames() {
+		if !bound[name] {
+			items = append(items, CompletionItem{Label: name, Kind: CompletionFunction, Detail: "builtin"})
+		}
+	}
+	for _, keyword := range token.Keywords() {
+		items = append(items, CompletionItem{Label: keyword, Kind: CompletionKeyword})
+	}
+	return items, nil
+}
+
+func completionKind(b *resolver.Binding) CompletionItemKind {
+	switch b.Kind {
+	case resolver.Function:
+		return CompletionFunction
+	case resolver.Const:
+		if isFunctionDeclaration(b) {
+			return CompletionFunction
+		}
+		return CompletionConstant
+	case resolver.Struct:
+		return CompletionStruct
+	case resolver.Class:
+		return CompletionClass
+	case resolver.Enum:
+		return CompletionEnum
+	}
+	if isFunctionDeclaration(b) {
+		return CompletionFunction
+	}
+	return CompletionVariable
+}
+
+// isFunctionDeclaration reports whether the binding is declared by let or const with a function literal
+func isFunctionDeclaration(b *resolver.Binding) bool {
+	switch d := b.Declaration.(type) {
+	case *ast.LetStatement:
+		_, ok := d.Value.(*ast.FunctionLiteral)
+		return ok
+	case *ast.ConstStatement:
+		_, ok := d.Value.(*ast.FunctionLiteral)
+		return ok
+	}
+	return false
+}
+
+func (s *Server) documentSymbol(raw json.RawMessage) (interface{}, error) {
+	var params DocumentSymbolParams
+	if err := decode(raw, &params); err != nil {
+		return nil, err
+	}
+	d, err := s.document(params.TextDocument.URI)
+	if err != nil || d.analysis == nil {
+		return nil, err
+	}
+	a := d.analysis
+	symbols := []DocumentSymbol{}
+	for _, statement := range a.program.Statements {
+		if symbol, ok := a.symbol(statement); ok {
+			symbols = append(symbols, symbol)
+		}
+	}
+	return symbols, nil
+}
+
+// symbol returns the symbol of the declaration at the top level
+func (a *analysis) symbol(statement ast.Statement) (DocumentSymbol, bool) {
+	switch s := statement.(type) {
+	case *ast.LetStatement:
+		kind := SymbolVariable
+		if _, ok := s.Value.(*ast.FunctionLiteral); ok {
+			kind = SymbolFunction
+		}
+		return a.newSymbol(s, s.Name, kind, "let"), true
+	case *ast.ConstStatement:
+		kind := SymbolConstant
+		if _, ok := s.Value.(*ast.FunctionLiteral); ok {
+			kind = SymbolFunction
+		}
+		return a.newSymbol(s, s.Name, kind, "const"), true
+	case *ast.FunctionStatement:
+		return a.newSymbol(s, s.Name, SymbolFunction, signature(s.Function)), true
+	case *ast.StructStatement:
+		symbol := a.newSymbol(s, s.Name, SymbolStruct, "struct")
+		for _, field := range s.Fields {
+			symbol.Children = append(symbol.Children, a.newSymbol(field, field, SymbolField, ""))
+		}
+		return symbol, true
+	case *ast.ImplStatement:
+		symbol := a.newSymbol(s, s.Name, SymbolStruct, "impl")
+		for _, method := range s.Methods {
+			symbol.Children = append(symbol.Children, a.newSymbol(method, method.Name, SymbolMethod, signature(method.Function)))
+		}
+		return symbol, true
+	case *ast.ClassStatement:
+		symbol := a.newSymbol(s, s.Name, SymbolClass, "class")
+		for _, method := range s.Methods {
+			child := a.newSymbol(method, &ast.Identifier{Token: method.Token, Value: method.Name}, SymbolMethod, signature(method))
+			symbol.Children = append(symbol.Children, child)
+		}
+		return symbol, true
+	case *ast.EnumStatement:
+		symbol := a.newSymbol(s, s.Name, SymbolEnum, "enum")
+		for _, variant := range s.Variants {
+			symbol.Children = append(symbol.Children, a.newSymbol(variant, variant.Name, SymbolEnumMember, ""))
+		}
+		return symbol, true
+	}
+	return DocumentSymbol{}, false
+}
+
+func (a *analysis) newSymbol(node ast.Node, name *ast.Identifier, kind SymbolKind, detail string) DocumentSymbol {
+	return DocumentSymbol{
+		Name:           name.Value,
+		Detail:         detail,
+		Kind:           kind,
+		Range:          Range{Start: a.position(node.Pos()), End: a.position(a.end(node))},
+		SelectionRange: a.tokenRange(name.Pos()),
+	}
+}
+
+// signature returns the parameters of the function such as "fn(a, b)"
+func signature(lit *ast.FunctionLiteral) string {
+	params := make([]string, len(lit.Parameters))
+	for i, p := range lit.Parameters {
+		params[i] = p.Value
+	}
+	return "fn(" + strings.Join(params, ", ") + ")"
+}
+
+func (s *Server) formatting(raw json.RawMessage) (interface{}, error) {
+	var params DocumentFormattingParams
+	if err := decode(raw, &params); err != nil {
+		return nil, err
+	}
+	d, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	// 構文エラーのある文書は括弧の対応が崩れているので整形しない
+	if len(d.errors) != 0 {
+		return nil, nil
+	}
+	src := d.source
+	formatted := format(src.text, params.Options)
+	if formatted == src.text {
+		return []TextEdit{}, nil
+	}
+	last := len(src.lines) - 1
+	end := src.position(token.Position{Line: last + 1, Column: len(src.lines[last]) + 1})
+	return []TextEdit{{Range: Range{End: end}, NewText: formatted}}, nil
+}
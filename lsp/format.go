@@ -0,0 +1,95 @@
+package lsp
+
+import (
+	"strings"
+
+	"github.com/tshinag/monkey/lexer"
+	"github.com/tshinag/monkey/token"
+)
+
+// format re-indents the lines by the nesting of brackets and removes the trailing spaces.
+// It works on the tokens rather than the syntax tree, so that the comments are kept as they are.
+func format(text string, options FormattingOptions) string {
+	lines := strings.Split(text, "\n")
+	depths := make([]int, len(lines))
+	verbatim := make([]bool, len(lines)) // 複数行の文字列の続きの行
+	inString := make([]bool, len(lines)) // 行末が文字列の中にある行
+
+	// 開き括弧ごとに、その中の行の深さを積む。同じ行で複数の括弧を開いても一段だけ深くなる
+	stack := []int{}
+	inner := func() int {
+		if len(stack) == 0 {
+			return 0
+		}
+		return stack[len(stack)-1]
+	}
+	next := 0 // 深さが決まっていない最初の行
+	l := lexer.New(text)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		line := tok.Pos.Line - 1
+		if line >= next {
+			for ; next < line; next++ {
+				depths[next] = inner()
+			}
+			depths[line] = inner()
+			if isClosing(tok.Type) && len(stack) > 0 {
+				depths[line] = inner() - 1
+			}
+			next = line + 1
+		}
+		switch {
+		case isOpening(tok.Type):
+			stack = append(stack, depths[line]+1)
+		case isClosing(tok.Type) && len(stack) > 0:
+			stack = stack[:len(stack)-1]
+		case tok.Type == token.STRING:
+			newlines := strings.Count(tok.Literal, "\n")
+			for i := 0; i < newlines && line+i < len(lines); i++ {
+				inString[line+i] = true
+				verbatim[line+i+1] = true
+			}
+			if newlines > 0 && line+newlines >= next {
+				next = line + newlines + 1
+			}
+		}
+	}
+	for ; next < len(lines); next++ {
+		depths[next] = inner()
+	}
+
+	unit := "\t"
+	if options.InsertSpaces && options.TabSize > 0 {
+		unit = strings.Repeat(" ", options.TabSize)
+	}
+	for i, line := range lines {
+		cr := ""
+		if strings.HasSuffix(line, "\r") {
+			cr = "\r"
+		}
+		// 文字列の中の空白は変えない
+		if inString[i] {
+			cr = ""
+		} else {
+			line = strings.TrimRight(line, " \t\r")
+		}
+		if verbatim[i] {
+			lines[i] = line + cr
+			continue
+		}
+		line = strings.TrimLeft(line, " \t")
+		if line == "" {
+			lines[i] = cr
+			continue
+		}
+		lines[i] = strings.Repeat(unit, depths[i]) + line + cr
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isOpening(t token.Type) bool {
+	return t == token.LBRACE || t == token.LPAREN || t == token.LBRACKET
+}
+
+func isClosing(t token.Type) bool {
+	return t == token.RBRACE || t == token.RPAREN || t == token.RBRACKET
+}
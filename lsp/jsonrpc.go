@@ -0,0 +1,99 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/tshinag/monkey/internal/framing"
+)
+
+// The error codes defined by JSON-RPC and LSP
+const (
+	codeParseError           = -32700
+	codeInvalidRequest       = -32600
+	codeMethodNotFound       = -32601
+	codeInvalidParams        = -32602
+	codeInternalError        = -32603
+	codeServerNotInitialized = -32002
+)
+
+// message is the request, the response or the notification of JSON-RPC 2.0
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"` // 通知なら nil
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *responseError   `json:"error,omitempty"`
+}
+
+// isRequest reports whether the message expects a response
+func (m *message) isRequest() bool {
+	return m.ID != nil && m.Method != ""
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *responseError) Error() string {
+	return fmt.Sprintf("%s (%d)", e.Message, e.Code)
+}
+
+func newResponseError(code int, format string, args ...interface{}) *responseError {
+	return &responseError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// conn reads and writes the messages of JSON-RPC with the base protocol of LSP
+type conn struct {
+	framed *framing.Conn
+}
+
+func newConn(r io.Reader, w io.Writer) *conn {
+	return &conn{framed: framing.NewConn(r, w)}
+}
+
+// read returns the next message. It returns io.EOF at the end of the stream.
+func (c *conn) read() (*message, error) {
+	content, err := c.framed.Read()
+	if err != nil {
+		return nil, err
+	}
+	m := &message{}
+	if err := json.Unmarshal(content, m); err != nil {
+		return nil, newResponseError(codeParseError, "invalid message: %s", err)
+	}
+	return m, nil
+}
+
+func (c *conn) write(m *message) error {
+	m.JSONRPC = "2.0"
+	content, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return c.framed.Write(content)
+}
+
+// notify sends the notification, which has no id
+func (c *conn) notify(method string, params interface{}) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return c.write(&message{Method: method, Params: raw})
+}
+
+// reply sends the response to the request with the id
+func (c *conn) reply(id *json.RawMessage, result interface{}, err *responseError) error {
+	if err != nil {
+		return c.write(&message{ID: id, Error: err})
+	}
+	raw, marshalErr := json.Marshal(result)
+	if marshalErr != nil {
+		return marshalErr
+	}
+	return c.write(&message{ID: id, Result: raw})
+}
@@ -0,0 +1,456 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tshinag/monkey/token"
+)
+
+const testURI = "file:///tmp/test.mk"
+
+func TestInitialize(t *testing.T) {
+	c := newTestClient(t)
+	defer c.close()
+
+	err := c.call("textDocument/hover", TextDocumentPositionParams{}, nil)
+	if rerr, ok := err.(*responseError); !ok || rerr.Code != codeServerNotInitialized {
+		t.Errorf("expected server not initialized error, got=%v", err)
+	}
+
+	var result InitializeResult
+	if err := c.call("initialize", InitializeParams{}, &result); err != nil {
+		t.Fatalf("initialize failed: %s", err)
+	}
+	capabilities := result.Capabilities
+	if capabilities.TextDocumentSync != textDocumentSyncFull || !capabilities.HoverProvider ||
+		!capabilities.DefinitionProvider || !capabilities.ReferencesProvider ||
+		!capabilities.DocumentSymbolProvider || !capabilities.DocumentFormattingProvider {
+		t.Errorf("wrong capabilities: %+v", capabilities)
+	}
+
+	err = c.call("textDocument/unknown", nil, nil)
+	if rerr, ok := err.(*responseError); !ok || rerr.Code != codeMethodNotFound {
+		t.Errorf("expected method not found error, got=%v", err)
+	}
+	err = c.call("textDocument/hover", TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: "file:///none"}}, nil)
+	if rerr, ok := err.(*responseError); !ok || rerr.Code != codeInvalidParams {
+		t.Errorf("expected invalid params error, got=%v", err)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	c := newTestClient(t)
+	c.initialize()
+	if err := c.call("shutdown", nil, nil); err != nil {
+		t.Fatalf("shutdown failed: %s", err)
+	}
+	c.notify("exit", nil)
+	if err := c.wait(); err != nil {
+		t.Errorf("Serve returned error after shutdown: %s", err)
+	}
+
+	c = newTestClient(t)
+	c.initialize()
+	c.notify("exit", nil)
+	if err := c.wait(); err != errExitWithoutShutdown {
+		t.Errorf("expected exit without shutdown, got=%v", err)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	c := newTestClient(t)
+	defer c.close()
+	c.initialize()
+
+	c.open("let = 1")
+	expectDiagnostics(t, c.diagnostics(), []string{
+		"0:4-0:5 error: expected next token to be IDENT, got = instead",
+		"0:4-0:5 error: no prefix parse function for = found",
+	})
+
+	c.change(2, "let x = 1;\nfn f() { let unused = 1; puts(y) }\nif (true) { x }")
+	expectDiagnostics(t, c.diagnostics(), []string{
+		"1:13-1:19 warning: declared and not used: unused",
+		"1:30-1:31 error: identifier not found: y",
+		"2:4-2:8 warning(constant-condition): if condition is always true",
+	})
+
+	c.change(3, "let n: int = \"s\"")
+	expectDiagnostics(t, c.diagnostics(), []string{
+		"0:13-0:16 error: cannot use string as int in declaration of n",
+	})
+
+	c.notify("textDocument/didClose", DidCloseTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: testURI}})
+	expectDiagnostics(t, c.diagnostics(), nil)
+}
+
+func TestHover(t *testing.T) {
+	c := newTestClient(t)
+	defer c.close()
+	c.initialize()
+	c.open("let x = 1;\nfn add(a, b) { a + b }\nputs(add(x, 2))")
+	c.diagnostics()
+
+	tests := []struct {
+		line, character int
+		expected        string
+	}{
+		{2, 10, "```monkey\nlet x = 1;\n```\nlet `x`, declared at 1:5"},
+		{1, 15, "```monkey\nfn add(a, b) { a + b }\n```\nparameter `a`, declared at 2:8"},
+		{2, 6, "```monkey\nfn add(a, b) { a + b }\n```\nfn `add`, declared at 2:4"},
+		{2, 0, "builtin function `puts`"},
+		{0, 9, ""},
+	}
+	for _, tt := range tests {
+		var hover *Hover
+		params := positionParams(tt.line, tt.character)
+		if err := c.call("textDocument/hover", params, &hover); err != nil {
+			t.Fatalf("hover failed: %s", err)
+		}
+		actual := ""
+		if hover != nil {
+			actual = hover.Contents.Value
+		}
+		if actual != tt.expected {
+			t.Errorf("wrong hover at %d:%d. expected=%q, got=%q", tt.line, tt.character, tt.expected, actual)
+		}
+	}
+}
+
+func TestDefinitionAndReferences(t *testing.T) {
+	c := newTestClient(t)
+	defer c.close()
+	c.initialize()
+	c.open("let total = 0;\nfn add(n) {\n  total = total + n\n}\nadd(total)")
+	c.diagnostics()
+
+	var location *Location
+	if err := c.call("textDocument/definition", positionParams(2, 18), &location); err != nil {
+		t.Fatalf("definition failed: %s", err)
+	}
+	if location == nil || formatRange(location.Range) != "1:7-1:8" {
+		t.Errorf("wrong definition of n. got=%+v", location)
+	}
+	if err := c.call("textDocument/definition", positionParams(4, 1), &location); err != nil {
+		t.Fatalf("definition failed: %s", err)
+	}
+	if location == nil || formatRange(location.Range) != "1:3-1:6" {
+		t.Errorf("wrong definition of add. got=%+v", location)
+	}
+
+	var locations []Location
+	params := ReferenceParams{TextDocumentPositionParams: positionParams(0, 6), Context: ReferenceContext{IncludeDeclaration: true}}
+	if err := c.call("textDocument/references", params, &locations); err != nil {
+		t.Fatalf("references failed: %s", err)
+	}
+	actual := []string{}
+	for _, l := range locations {
+		actual = append(actual, formatRange(l.Range))
+	}
+	expected := []string{"0:4-0:9", "2:2-2:7", "2:10-2:15", "4:4-4:9"}
+	if strings.Join(actual, " ") != strings.Join(expected, " ") {
+		t.Errorf("wrong references. expected=%v, got=%v", expected, actual)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	c := newTestClient(t)
+	defer c.close()
+	c.initialize()
+	c.open("let x = 1;\nfn f(a) {\n  let len = 2;\n  \n}\nstruct P { v }\n")
+	c.diagnostics()
+
+	tests := []struct {
+		line, character int
+		included        []string
+		excluded        []string
+	}{
+		{3, 2, []string{"x:variable", "f:function", "a:variable", "len:variable", "P:struct", "puts:function", "let:keyword"}, []string{"len:function"}},
+		{6, 0, []string{"x:variable", "f:function", "len:function"}, []string{"a:variable", "len:variable"}},
+	}
+	kinds := map[CompletionItemKind]string{
+		CompletionFunction: "function", CompletionVariable: "variable", CompletionStruct: "struct", CompletionKeyword: "keyword",
+	}
+	for _, tt := range tests {
+		var items []CompletionItem
+		if err := c.call("textDocument/completion", positionParams(tt.line, tt.character), &items); err != nil {
+			t.Fatalf("completion failed: %s", err)
+		}
+		actual := make(map[string]bool)
+		for _, item := range items {
+			actual[item.Label+":"+kinds[item.Kind]] = true
+		}
+		for _, name := range tt.included {
+			if !actual[name] {
+				t.Errorf("completion at %d:%d doesn't include %s", tt.line, tt.character, name)
+			}
+		}
+		for _, name := range tt.excluded {
+			if actual[name] {
+				t.Errorf("completion at %d:%d includes %s", tt.line, tt.character, name)
+			}
+		}
+	}
+}
+
+func TestDocumentSymbol(t *testing.T) {
+	c := newTestClient(t)
+	defer c.close()
+	c.initialize()
+	c.open("const limit = 10;\nfn add(a, b) {\n  a + b\n}\nstruct P { x, y }\nenum E { A, B(v) }\nclass C {\n  get() { 1 }\n}\nadd(1, 2)\nlet r = add(1, 2);\nlet xs = [1, [2]];\nlet y = xs[0];")
+	c.diagnostics()
+
+	var symbols []DocumentSymbol
+	if err := c.call("textDocument/documentSymbol", DocumentSymbolParams{TextDocument: TextDocumentIdentifier{URI: testURI}}, &symbols); err != nil {
+		t.Fatalf("documentSymbol failed: %s", err)
+	}
+	actual := []string{}
+	var describe func(prefix string, symbols []DocumentSymbol)
+	describe = func(prefix string, symbols []DocumentSymbol) {
+		for _, s := range symbols {
+			actual = append(actual, prefix+s.Name+" "+s.Detail+" "+formatRange(s.Range)+" "+formatRange(s.SelectionRange))
+			describe(prefix+s.Name+".", s.Children)
+		}
+	}
+	describe("", symbols)
+	expected := []string{
+		"limit const 0:0-0:16 0:6-0:11",
+		"add fn(a, b) 1:0-3:1 1:3-1:6",
+		"P struct 4:0-4:17 4:7-4:8",
+		"P.x  4:11-4:12 4:11-4:12",
+		"P.y  4:14-4:15 4:14-4:15",
+		"E enum 5:0-5:18 5:5-5:6",
+		"E.A  5:9-5:10 5:9-5:10",
+		"E.B  5:12-5:15 5:12-5:13",
+		"C class 6:0-8:1 6:6-6:7",
+		"C.get fn() 7:2-7:13 7:2-7:5",
+		"r let 10:0-10:17 10:4-10:5",
+		"xs let 11:0-11:17 11:4-11:6",
+		"y let 12:0-12:13 12:4-12:5",
+	}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("wrong symbols.\nexpected=%q\ngot=%q", expected, actual)
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	c := newTestClient(t)
+	defer c.close()
+	c.initialize()
+	c.open("fn f(x) {\nlet s = \"a\n  b\";   \n    // comment\nif (x) {\nputs([\n1,\n2\n])\n  }\n}\n")
+	c.diagnostics()
+
+	var edits []TextEdit
+	params := DocumentFormattingParams{
+		TextDocument: TextDocumentIdentifier{URI: testURI},
+		Options:      FormattingOptions{TabSize: 2, InsertSpaces: true},
+	}
+	if err := c.call("textDocument/formatting", params, &edits); err != nil {
+		t.Fatalf("formatting failed: %s", err)
+	}
+	if len(edits) != 1 {
+		t.Fatalf("wrong number of edits. got=%d", len(edits))
+	}
+	expected := "fn f(x) {\n  let s = \"a\n  b\";\n  // comment\n  if (x) {\n    puts([\n      1,\n      2\n    ])\n  }\n}\n"
+	if edits[0].NewText != expected {
+		t.Errorf("wrong formatting.\nexpected=%q\ngot=%q", expected, edits[0].NewText)
+	}
+	if formatRange(edits[0].Range) != "0:0-11:0" {
+		t.Errorf("wrong range of edit. got=%s", formatRange(edits[0].Range))
+	}
+
+	c.change(2, expected)
+	c.diagnostics()
+	if err := c.call("textDocument/formatting", params, &edits); err != nil {
+		t.Fatalf("formatting failed: %s", err)
+	}
+	if len(edits) != 0 {
+		t.Errorf("formatted document is changed: %q", edits)
+	}
+}
+
+func TestPositionConversion(t *testing.T) {
+	s := newSource("let s = \"é😀\"; s\n")
+	tests := []struct {
+		pos      token.Position
+		expected Position
+	}{
+		{token.Position{Line: 1, Column: 1}, Position{Line: 0, Character: 0}},
+		{token.Position{Line: 1, Column: 9}, Position{Line: 0, Character: 8}},
+		// é は UTF-8 で2バイト、😀 は4バイトで UTF-16 では2単位
+		{token.Position{Line: 1, Column: 17}, Position{Line: 0, Character: 13}},
+		{token.Position{Line: 2, Column: 1}, Position{Line: 1, Character: 0}},
+	}
+	for _, tt := range tests {
+		actual := s.position(tt.pos)
+		if actual != tt.expected {
+			t.Errorf("wrong position of %s. expected=%+v, got=%+v", tt.pos, tt.expected, actual)
+		}
+		if back := s.tokenPosition(actual); back != tt.pos {
+			t.Errorf("wrong token position of %+v. expected=%s, got=%s", actual, tt.pos, back)
+		}
+	}
+}
+
+// testClient is the client talking to the server running in the same process
+type testClient struct {
+	t           *testing.T
+	conn        *conn
+	id          int
+	input       io.Closer
+	responses   chan *message
+	published   chan PublishDiagnosticsParams
+	serveResult chan error
+}
+
+func newTestClient(t *testing.T) *testClient {
+	serverInput, clientOutput := io.Pipe()
+	clientInput, serverOutput := io.Pipe()
+	c := &testClient{
+		t:           t,
+		conn:        newConn(clientInput, clientOutput),
+		input:       clientOutput,
+		responses:   make(chan *message, 16),
+		published:   make(chan PublishDiagnosticsParams, 16),
+		serveResult: make(chan error, 1),
+	}
+	server := NewServer(serverInput, serverOutput, ioutil.Discard)
+	go func() {
+		c.serveResult <- server.Serve()
+		serverOutput.Close()
+	}()
+	// サーバーの書き込みが詰まらないように、別の goroutine で読み続ける
+	go func() {
+		for {
+			m, err := c.conn.read()
+			if err != nil {
+				close(c.responses)
+				return
+			}
+			if m.Method == "textDocument/publishDiagnostics" {
+				var params PublishDiagnosticsParams
+				if err := json.Unmarshal(m.Params, &params); err == nil {
+					c.published <- params
+				}
+				continue
+			}
+			c.responses <- m
+		}
+	}()
+	return c
+}
+
+func (c *testClient) call(method string, params, result interface{}) error {
+	c.id++
+	raw, _ := json.Marshal(params)
+	id := json.RawMessage(strconv.Itoa(c.id))
+	if err := c.conn.write(&message{ID: &id, Method: method, Params: raw}); err != nil {
+		c.t.Fatalf("could not write request: %s", err)
+	}
+	select {
+	case m, ok := <-c.responses:
+		if !ok {
+			c.t.Fatalf("connection closed while waiting for %s", method)
+		}
+		if string(*m.ID) != string(id) {
+			c.t.Fatalf("wrong response id. expected=%s, got=%s", id, *m.ID)
+		}
+		if m.Error != nil {
+			return m.Error
+		}
+		if result != nil {
+			return json.Unmarshal(m.Result, result)
+		}
+		return nil
+	case <-time.After(5 * time.Second):
+		c.t.Fatalf("timeout waiting for %s", method)
+		return nil
+	}
+}
+
+func (c *testClient) notify(method string, params interface{}) {
+	if err := c.conn.notify(method, params); err != nil {
+		c.t.Fatalf("could not write notification: %s", err)
+	}
+}
+
+func (c *testClient) initialize() {
+	if err := c.call("initialize", InitializeParams{}, nil); err != nil {
+		c.t.Fatalf("initialize failed: %s", err)
+	}
+	c.notify("initialized", struct{}{})
+}
+
+func (c *testClient) open(text string) {
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: testURI, Version: 1, Text: text},
+	})
+}
+
+func (c *testClient) change(version int, text string) {
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: testURI, Version: version},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: text}},
+	})
+}
+
+// diagnostics waits for the diagnostics published by the server
+func (c *testClient) diagnostics() []Diagnostic {
+	select {
+	case params := <-c.published:
+		return params.Diagnostics
+	case <-time.After(5 * time.Second):
+		c.t.Fatalf("timeout waiting for diagnostics")
+		return nil
+	}
+}
+
+// wait closes the input of the server and returns the result of Serve
+func (c *testClient) wait() error {
+	select {
+	case err := <-c.serveResult:
+		c.input.Close()
+		return err
+	case <-time.After(5 * time.Second):
+		c.t.Fatalf("timeout waiting for the server to exit")
+		return nil
+	}
+}
+
+func (c *testClient) close() {
+	c.input.Close()
+	<-c.serveResult
+}
+
+func positionParams(line, character int) TextDocumentPositionParams {
+	return TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: testURI},
+		Position:     Position{Line: line, Character: character},
+	}
+}
+
+func formatRange(r Range) string {
+	return fmt.Sprintf("%d:%d-%d:%d", r.Start.Line, r.Start.Character, r.End.Line, r.End.Character)
+}
+
+func expectDiagnostics(t *testing.T, diagnostics []Diagnostic, expected []string) {
+	actual := []string{}
+	severities := map[DiagnosticSeverity]string{SeverityError: "error", SeverityWarning: "warning"}
+	for _, d := range diagnostics {
+		severity := severities[d.Severity]
+		if d.Code != "" {
+			severity += "(" + d.Code + ")"
+		}
+		actual = append(actual, formatRange(d.Range)+" "+severity+": "+d.Message)
+	}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("wrong diagnostics.\nexpected=%q\ngot=%q", expected, actual)
+	}
+}
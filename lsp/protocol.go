@@ -0,0 +1,221 @@
+package lsp
+
+// The types of LSP used by the server. Only the fields the server reads or writes are defined.
+
+// Position is the zero-based line and the offset in UTF-16 code units
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is the half-open range in a document
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is the range in the document of the URI
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// DiagnosticSeverity is the severity of Diagnostic
+type DiagnosticSeverity int
+
+// The severities of diagnostics
+const (
+	SeverityError       DiagnosticSeverity = 1
+	SeverityWarning     DiagnosticSeverity = 2
+	SeverityInformation DiagnosticSeverity = 3
+	SeverityHint        DiagnosticSeverity = 4
+)
+
+// Diagnostic is the problem in a document
+type Diagnostic struct {
+	Range    Range              `json:"range"`
+	Severity DiagnosticSeverity `json:"severity"`
+	Code     string             `json:"code,omitempty"`
+	Source   string             `json:"source"`
+	Message  string             `json:"message"`
+}
+
+// PublishDiagnosticsParams is the parameter of textDocument/publishDiagnostics
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// InitializeParams is the parameter of initialize
+type InitializeParams struct {
+	RootURI string `json:"rootUri"`
+}
+
+// InitializeResult is the result of initialize
+type InitializeResult struct {
+	Capabilities ServerCapabilities `json:"capabilities"`
+	ServerInfo   ServerInfo         `json:"serverInfo"`
+}
+
+// ServerCapabilities are the features supported by the server
+type ServerCapabilities struct {
+	TextDocumentSync           int               `json:"textDocumentSync"`
+	HoverProvider              bool              `json:"hoverProvider"`
+	DefinitionProvider         bool              `json:"definitionProvider"`
+	ReferencesProvider         bool              `json:"referencesProvider"`
+	CompletionProvider         CompletionOptions `json:"completionProvider"`
+	DocumentSymbolProvider     bool              `json:"documentSymbolProvider"`
+	DocumentFormattingProvider bool              `json:"documentFormattingProvider"`
+}
+
+// textDocumentSyncFull means that the client sends the whole text on each change
+const textDocumentSyncFull = 1
+
+// CompletionOptions is the capability of completion
+type CompletionOptions struct {
+	TriggerCharacters []string `json:"triggerCharacters,omitempty"`
+}
+
+// ServerInfo is the name and the version of the server
+type ServerInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// TextDocumentItem is the document opened by the client
+type TextDocumentItem struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version"`
+	Text    string `json:"text"`
+}
+
+// TextDocumentIdentifier identifies the document by URI
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+// DidOpenTextDocumentParams is the parameter of textDocument/didOpen
+type DidOpenTextDocumentParams struct {
+	TextDocument TextDocumentItem `json:"textDocument"`
+}
+
+// DidChangeTextDocumentParams is the parameter of textDocument/didChange
+type DidChangeTextDocumentParams struct {
+	TextDocument   VersionedTextDocumentIdentifier  `json:"textDocument"`
+	ContentChanges []TextDocumentContentChangeEvent `json:"contentChanges"`
+}
+
+// VersionedTextDocumentIdentifier identifies the version of the document
+type VersionedTextDocumentIdentifier struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version"`
+}
+
+// TextDocumentContentChangeEvent is the whole new text, since the server only supports full sync
+type TextDocumentContentChangeEvent struct {
+	Text string `json:"text"`
+}
+
+// DidCloseTextDocumentParams is the parameter of textDocument/didClose
+type DidCloseTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// TextDocumentPositionParams is the position in a document
+type TextDocumentPositionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+// ReferenceParams is the parameter of textDocument/references
+type ReferenceParams struct {
+	TextDocumentPositionParams
+	Context ReferenceContext `json:"context"`
+}
+
+// ReferenceContext tells whether the declaration is included in the references
+type ReferenceContext struct {
+	IncludeDeclaration bool `json:"includeDeclaration"`
+}
+
+// MarkupContent is the documentation in Markdown
+type MarkupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// Hover is the result of textDocument/hover
+type Hover struct {
+	Contents MarkupContent `json:"contents"`
+	Range    *Range        `json:"range,omitempty"`
+}
+
+// CompletionItemKind is the kind of CompletionItem
+type CompletionItemKind int
+
+// The kinds of completion items used by the server
+const (
+	CompletionFunction CompletionItemKind = 3
+	CompletionVariable CompletionItemKind = 6
+	CompletionClass    CompletionItemKind = 7
+	CompletionEnum     CompletionItemKind = 13
+	CompletionKeyword  CompletionItemKind = 14
+	CompletionConstant CompletionItemKind = 21
+	CompletionStruct   CompletionItemKind = 22
+)
+
+// CompletionItem is the candidate of completion
+type CompletionItem struct {
+	Label  string             `json:"label"`
+	Kind   CompletionItemKind `json:"kind"`
+	Detail string             `json:"detail,omitempty"`
+}
+
+// SymbolKind is the kind of DocumentSymbol
+type SymbolKind int
+
+// The kinds of symbols used by the server
+const (
+	SymbolClass      SymbolKind = 5
+	SymbolMethod     SymbolKind = 6
+	SymbolField      SymbolKind = 8
+	SymbolEnum       SymbolKind = 10
+	SymbolFunction   SymbolKind = 12
+	SymbolVariable   SymbolKind = 13
+	SymbolConstant   SymbolKind = 14
+	SymbolEnumMember SymbolKind = 22
+	SymbolStruct     SymbolKind = 23
+)
+
+// DocumentSymbolParams is the parameter of textDocument/documentSymbol
+type DocumentSymbolParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// DocumentSymbol is the declaration in a document
+type DocumentSymbol struct {
+	Name           string           `json:"name"`
+	Detail         string           `json:"detail,omitempty"`
+	Kind           SymbolKind       `json:"kind"`
+	Range          Range            `json:"range"`
+	SelectionRange Range            `json:"selectionRange"`
+	Children       []DocumentSymbol `json:"children,omitempty"`
+}
+
+// DocumentFormattingParams is the parameter of textDocument/formatting
+type DocumentFormattingParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Options      FormattingOptions      `json:"options"`
+}
+
+// FormattingOptions is the indentation preferred by the client
+type FormattingOptions struct {
+	TabSize      int  `json:"tabSize"`
+	InsertSpaces bool `json:"insertSpaces"`
+}
+
+// TextEdit replaces the range with the text
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
@@ -0,0 +1,243 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tshinag/monkey/lint"
+)
+
+// lintConfigName is the config of the linter looked up in the root of the workspace
+const lintConfigName = ".monkeylint.json"
+
+// Server is the language server of Monkey. It handles the messages one by one,
+// so the handlers don't need locks.
+type Server struct {
+	conn        *conn
+	documents   map[string]*document
+	lintConfig  *lint.Config
+	initialized bool
+	shutdown    bool
+	handlers    map[string]func(params json.RawMessage) (interface{}, error)
+	logger      io.Writer
+}
+
+// NewServer returns the server reading the messages from r and writing to w.
+// The errors which can't be sent to the client are logged to logger.
+func NewServer(r io.Reader, w io.Writer, logger io.Writer) *Server {
+	s := &Server{
+		conn:      newConn(r, w),
+		documents: make(map[string]*document),
+		logger:    logger,
+	}
+	s.handlers = map[string]func(json.RawMessage) (interface{}, error){
+		"initialize":                  s.initialize,
+		"initialized":                 s.ignore,
+		"shutdown":                    s.shutdownServer,
+		"textDocument/didOpen":        s.didOpen,
+		"textDocument/didChange":      s.didChange,
+		"textDocument/didClose":       s.didClose,
+		"textDocument/didSave":        s.ignore,
+		"textDocument/hover":          s.hover,
+		"textDocument/definition":     s.definition,
+		"textDocument/references":     s.references,
+		"textDocument/completion":     s.completion,
+		"textDocument/documentSymbol": s.documentSymbol,
+		"textDocument/formatting":     s.formatting,
+	}
+	return s
+}
+
+// errExitWithoutShutdown is returned by Serve when the client exits without shutdown request
+var errExitWithoutShutdown = errors.New("exit without shutdown")
+
+// Serve handles the messages until the exit notification or the end of input
+func (s *Server) Serve() error {
+	for {
+		m, err := s.conn.read()
+		if err == io.EOF {
+			return nil
+		}
+		if rerr, ok := err.(*responseError); ok {
+			// 壊れたメッセージには id がないので null の id で返す
+			if err := s.conn.reply(nil, nil, rerr); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if m.Method == "exit" {
+			if !s.shutdown {
+				return errExitWithoutShutdown
+			}
+			return nil
+		}
+		if m.Method == "" {
+			// クライアントへの要求はしないので、応答は受け取らない
+			continue
+		}
+		result, rerr := s.handle(m)
+		if !m.isRequest() {
+			if rerr != nil {
+				fmt.Fprintf(s.logger, "%s: %s\n", m.Method, rerr.Message)
+			}
+			continue
+		}
+		if err := s.conn.reply(m.ID, result, rerr); err != nil {
+			return err
+		}
+	}
+}
+
+// handle calls the handler of the method, converting the errors and the panics to the response errors
+func (s *Server) handle(m *message) (result interface{}, rerr *responseError) {
+	handler, ok := s.handlers[m.Method]
+	if !ok && !m.isRequest() && strings.HasPrefix(m.Method, "$/") {
+		// "$/" で始まる通知は無視してよい
+		return nil, nil
+	}
+	if !ok {
+		return nil, newResponseError(codeMethodNotFound, "method not found: %s", m.Method)
+	}
+	if !s.initialized && m.Method != "initialize" {
+		return nil, newResponseError(codeServerNotInitialized, "server not initialized")
+	}
+	if s.shutdown {
+		return nil, newResponseError(codeInvalidRequest, "server is shutting down")
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			result, rerr = nil, newResponseError(codeInternalError, "%s: %v", m.Method, r)
+		}
+	}()
+	result, err := handler(m.Params)
+	if err != nil {
+		if e, ok := err.(*responseError); ok {
+			return nil, e
+		}
+		return nil, newResponseError(codeInternalError, "%s", err)
+	}
+	return result, nil
+}
+
+// decode unmarshals the parameters of the method
+func decode(params json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return newResponseError(codeInvalidParams, "invalid params: %s", err)
+	}
+	return nil
+}
+
+func (s *Server) ignore(json.RawMessage) (interface{}, error) {
+	return nil, nil
+}
+
+func (s *Server) initialize(raw json.RawMessage) (interface{}, error) {
+	var params InitializeParams
+	if err := decode(raw, &params); err != nil {
+		return nil, err
+	}
+	if s.initialized {
+		return nil, newResponseError(codeInvalidRequest, "server already initialized")
+	}
+	s.initialized = true
+	if path := pathOf(params.RootURI); path != "" {
+		configPath := filepath.Join(path, lintConfigName)
+		if _, err := os.Stat(configPath); err == nil {
+			config, err := lint.LoadConfig(configPath)
+			if err != nil {
+				fmt.Fprintf(s.logger, "%s\n", err)
+			}
+			s.lintConfig = config
+		}
+	}
+	return &InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync:           textDocumentSyncFull,
+			HoverProvider:              true,
+			DefinitionProvider:         true,
+			ReferencesProvider:         true,
+			CompletionProvider:         CompletionOptions{},
+			DocumentSymbolProvider:     true,
+			DocumentFormattingProvider: true,
+		},
+		ServerInfo: ServerInfo{Name: "monkey"},
+	}, nil
+}
+
+// pathOf returns the local path of the file URI, or "" if uri is not a file URI
+func pathOf(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+func (s *Server) shutdownServer(json.RawMessage) (interface{}, error) {
+	s.shutdown = true
+	return nil, nil
+}
+
+func (s *Server) didOpen(raw json.RawMessage) (interface{}, error) {
+	var params DidOpenTextDocumentParams
+	if err := decode(raw, &params); err != nil {
+		return nil, err
+	}
+	item := params.TextDocument
+	d := newDocument(item.URI, item.Version, item.Text)
+	s.documents[item.URI] = d
+	return nil, s.publishDiagnostics(d)
+}
+
+func (s *Server) didChange(raw json.RawMessage) (interface{}, error) {
+	var params DidChangeTextDocumentParams
+	if err := decode(raw, &params); err != nil {
+		return nil, err
+	}
+	d, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	if len(params.ContentChanges) == 0 {
+		return nil, nil
+	}
+	// 全文の同期なので最後の変更だけが意味を持つ
+	d.update(params.TextDocument.Version, params.ContentChanges[len(params.ContentChanges)-1].Text)
+	return nil, s.publishDiagnostics(d)
+}
+
+func (s *Server) didClose(raw json.RawMessage) (interface{}, error) {
+	var params DidCloseTextDocumentParams
+	if err := decode(raw, &params); err != nil {
+		return nil, err
+	}
+	delete(s.documents, params.TextDocument.URI)
+	return nil, s.conn.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+		URI:         params.TextDocument.URI,
+		Diagnostics: []Diagnostic{},
+	})
+}
+
+func (s *Server) document(uri string) (*document, error) {
+	d, ok := s.documents[uri]
+	if !ok {
+		return nil, newResponseError(codeInvalidParams, "document not opened: %s", uri)
+	}
+	return d, nil
+}
+
+func (s *Server) publishDiagnostics(d *document) error {
+	return s.conn.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+		URI:         d.uri,
+		Diagnostics: d.diagnostics(s.lintConfig),
+	})
+}
@@ -4,13 +4,42 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/user"
 
 	"github.com/tshinag/monkey/repl"
 )
 
+const usage = `usage:
+  monkey                 start REPL
+  monkey run [-profile FILE] [-top N] [-coverprofile FILE] [-covermin PERCENT] FILE
+                         run the script, profiling the functions with -profile
+                         and measuring the coverage with -coverprofile
+  monkey cover [-html] PROFILE
+                         print the sources annotated with the coverage profile
+  monkey test [-run REGEXP] [-v] [-format text|tap|junit] [-coverprofile FILE] [-covermin PERCENT] [PATH...]
+                         run the test functions in the *_test.mk files
+  monkey check FILE...   check the types of the scripts
+  monkey resolve FILE... report undefined, unused and shadowed names in the scripts
+  monkey lint [-config FILE] [-json] FILE...
+                         report suspicious code in the scripts
+  monkey debug FILE      run the script under the debugger
+  monkey lsp             start the language server on stdin and stdout
+  monkey dap [-listen ADDR] [FILE]
+                         start the debug adapter on stdin and stdout or on ADDR,
+                         attaching to FILE
+`
+
 func main() {
+	if len(os.Args) < 2 {
+		startREPL()
+		return
+	}
+	os.Exit(runCommand(os.Args[1], os.Args[2:], os.Stdout, os.Stderr))
+}
+
+func startREPL() {
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
@@ -19,3 +48,30 @@ func main() {
 	fmt.Printf("Feel free to type in commands\n")
 	repl.Start(os.Stdin, os.Stdout)
 }
+
+// runCommand runs the subcommand and returns the exit code
+func runCommand(name string, args []string, stdout, stderr io.Writer) int {
+	switch name {
+	case "run":
+		return run(args, stdout, stderr)
+	case "cover":
+		return cover(args, stdout, stderr)
+	case "test":
+		return test(args, stdout, stderr)
+	case "check":
+		return check(args, stdout, stderr)
+	case "resolve":
+		return resolve(args, stdout, stderr)
+	case "lint":
+		return lintScripts(args, stdout, stderr)
+	case "debug":
+		return debug(args, stdout, stderr)
+	case "lsp":
+		return serveLSP(args, stdout, stderr)
+	case "dap":
+		return serveDAP(args, stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "unknown command: %s\n%s", name, usage)
+		return 2
+	}
+}
@@ -0,0 +1,18 @@
+package object
+
+// BoundMethod is the implementation of method bound to its receiver.
+// Calling it passes the receiver as the first argument "self".
+type BoundMethod struct {
+	Receiver Object
+	Method   *Function
+}
+
+// Type returns the type of object
+func (bm *BoundMethod) Type() Type {
+	return BoundMethodType
+}
+
+// Inspect returns the string expression of object
+func (bm *BoundMethod) Inspect() string {
+	return "bound method " + bm.Method.Inspect()
+}
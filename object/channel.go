@@ -0,0 +1,18 @@
+package object
+
+import "fmt"
+
+// Channel is the implementation of channel shared between tasks
+type Channel struct {
+	Values chan Object
+}
+
+// Type returns the type of object
+func (c *Channel) Type() Type {
+	return ChannelType
+}
+
+// Inspect returns the string expression of object
+func (c *Channel) Inspect() string {
+	return fmt.Sprintf("channel(%d)", cap(c.Values))
+}
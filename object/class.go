@@ -0,0 +1,43 @@
+package object
+
+// Class is the implementation of class declared by "class Name extends Superclass { methods }".
+// Calling it constructs an instance and calls its "init" method.
+type Class struct {
+	Name       string
+	Superclass *Class // extends がなければ nil
+	Methods    map[string]*Function
+}
+
+// Type returns the type of object
+func (c *Class) Type() Type {
+	return ClassType
+}
+
+// Inspect returns the string expression of object
+func (c *Class) Inspect() string {
+	if c.Superclass != nil {
+		return "class " + c.Name + " extends " + c.Superclass.Name
+	}
+	return "class " + c.Name
+}
+
+// FindMethod looks up the method through the superclass chain.
+// It also returns the class which declares the method.
+func (c *Class) FindMethod(name string) (*Function, *Class, bool) {
+	for class := c; class != nil; class = class.Superclass {
+		if method, ok := class.Methods[name]; ok {
+			return method, class, true
+		}
+	}
+	return nil, nil, false
+}
+
+// IsSubclassOf reports whether c is other or inherits from it
+func (c *Class) IsSubclassOf(other *Class) bool {
+	for class := c; class != nil; class = class.Superclass {
+		if class == other {
+			return true
+		}
+	}
+	return false
+}
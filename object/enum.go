@@ -0,0 +1,59 @@
+package object
+
+import "strings"
+
+// Enum is the implementation of enum declared by "enum Name { Variant(fields), ... }".
+// Its properties are the variants.
+type Enum struct {
+	Name     string
+	Variants []*EnumVariant
+}
+
+// Type returns the type of object
+func (e *Enum) Type() Type {
+	return EnumType
+}
+
+// Inspect returns the string expression of object
+func (e *Enum) Inspect() string {
+	variants := []string{}
+	for _, v := range e.Variants {
+		variants = append(variants, v.signature())
+	}
+	return "enum " + e.Name + " { " + strings.Join(variants, ", ") + " }"
+}
+
+// Variant returns the variant declared by the name
+func (e *Enum) Variant(name string) (*EnumVariant, bool) {
+	for _, v := range e.Variants {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// EnumVariant is the implementation of enum variant with payload.
+// Calling it constructs an enum value.
+type EnumVariant struct {
+	Enum   *Enum
+	Name   string
+	Fields []string
+}
+
+// Type returns the type of object
+func (ev *EnumVariant) Type() Type {
+	return EnumVariantType
+}
+
+// Inspect returns the string expression of object
+func (ev *EnumVariant) Inspect() string {
+	return ev.Enum.Name + "." + ev.signature()
+}
+
+func (ev *EnumVariant) signature() string {
+	if len(ev.Fields) == 0 {
+		return ev.Name
+	}
+	return ev.Name + "(" + strings.Join(ev.Fields, ", ") + ")"
+}
@@ -0,0 +1,55 @@
+package object
+
+import (
+	"bytes"
+	"hash/fnv"
+	"strings"
+)
+
+// EnumValue is the implementation of value of enum variant
+type EnumValue struct {
+	Variant *EnumVariant
+	Values  []Object // Variant.Fields と同じ順序
+}
+
+// Type returns the type of object
+func (ev *EnumValue) Type() Type {
+	return EnumValueType
+}
+
+// Inspect returns the string expression of object
+func (ev *EnumValue) Inspect() string {
+	var out bytes.Buffer
+	out.WriteString(ev.Variant.Enum.Name)
+	out.WriteString(".")
+	out.WriteString(ev.Variant.Name)
+	if len(ev.Values) == 0 {
+		return out.String()
+	}
+	values := []string{}
+	for _, v := range ev.Values {
+		values = append(values, v.Inspect())
+	}
+	out.WriteString("(")
+	out.WriteString(strings.Join(values, ", "))
+	out.WriteString(")")
+	return out.String()
+}
+
+// Get returns the payload of the field, or false if the variant has no such field
+func (ev *EnumValue) Get(name string) (Object, bool) {
+	for i, field := range ev.Variant.Fields {
+		if field == name {
+			return ev.Values[i], true
+		}
+	}
+	return nil, false
+}
+
+// HashKey returns the hash key for hash map.
+// Only the values without payload are hashable. See AsHashable.
+func (ev *EnumValue) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(ev.Variant.Enum.Name + "." + ev.Variant.Name))
+	return HashKey{Type: ev.Type(), Value: h.Sum64()}
+}
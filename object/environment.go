@@ -1,9 +1,20 @@
 package object
 
-// Environment is the map of variables
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Environment is the map of variables.
+// It is safe for concurrent access from spawned tasks.
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	mu           sync.RWMutex
+	store        map[string]Object
+	consts       map[string]bool
+	outer        *Environment
+	frame        *Frame
+	redeclarable bool
 }
 
 // NewEnvironment initializes and returns Environment
@@ -19,9 +30,53 @@ func NewEnclosedEnvironment(outer *Environment) *Environment {
 	return env
 }
 
+// NewFrameEnvironment initializes and returns Environment for a function call
+func NewFrameEnvironment(outer *Environment, frame *Frame) *Environment {
+	env := NewEnclosedEnvironment(outer)
+	env.frame = frame
+	return env
+}
+
+// Frame returns the activation record of the innermost function call
+func (e *Environment) Frame() *Frame {
+	for env := e; env != nil; env = env.outer {
+		if env.frame != nil {
+			return env.frame
+		}
+	}
+	return nil
+}
+
+// Outer returns the enclosing environment, or nil if this is the outermost one
+func (e *Environment) Outer() *Environment {
+	return e.outer
+}
+
+// Names returns the sorted names bound in this scope, not including the outer scopes
+func (e *Environment) Names() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	names := make([]string, 0, len(e.store))
+	for name := range e.store {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AllowRedeclaration lets "let" rebind variables already declared in this scope.
+// It is intended for REPL sessions. Constants can't be redeclared even so.
+func (e *Environment) AllowRedeclaration() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.redeclarable = true
+}
+
 // Get returns the value bound to variable
 func (e *Environment) Get(name string) (Object, bool) {
+	e.mu.RLock()
 	obj, ok := e.store[name]
+	e.mu.RUnlock()
 	if ok || e.outer == nil {
 		return obj, ok
 	}
@@ -30,6 +85,57 @@ func (e *Environment) Get(name string) (Object, bool) {
 
 // Set binds the value to variable
 func (e *Environment) Set(name string, val Object) Object {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 	e.store[name] = val
 	return val
 }
+
+// Declare binds the value to a new variable in this scope
+func (e *Environment) Declare(name string, val Object, constant bool) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.store[name]; ok {
+		if e.consts[name] {
+			return fmt.Errorf("cannot redeclare constant: %s", name)
+		}
+		if !e.redeclarable {
+			return fmt.Errorf("identifier already declared: %s", name)
+		}
+	}
+	e.store[name] = val
+	if constant {
+		if e.consts == nil {
+			e.consts = make(map[string]bool)
+		}
+		e.consts[name] = true
+	} else {
+		delete(e.consts, name)
+	}
+	return nil
+}
+
+// Assign rebinds the value to the variable in the nearest scope declaring it
+func (e *Environment) Assign(name string, val Object) error {
+	if found, err := e.assignHere(name, val); found {
+		return err
+	}
+	if e.outer == nil {
+		return fmt.Errorf("identifier not found: %s", name)
+	}
+	return e.outer.Assign(name, val)
+}
+
+// assignHere rebinds the variable if it is declared in this scope
+func (e *Environment) assignHere(name string, val Object) (bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.store[name]; !ok {
+		return false, nil
+	}
+	if e.consts[name] {
+		return true, fmt.Errorf("cannot assign to constant: %s", name)
+	}
+	e.store[name] = val
+	return true, nil
+}
@@ -2,7 +2,8 @@ package object
 
 // Error is the evalutation error
 type Error struct {
-	Message string
+	Message  string
+	Function string // エラーが発生した名前付き関数
 }
 
 // Type returns the type of object
@@ -12,5 +13,8 @@ func (e *Error) Type() Type {
 
 // Inspect returns the string expression of object
 func (e *Error) Inspect() string {
+	if e.Function != "" {
+		return "ERROR: " + e.Message + " (in function " + e.Function + ")"
+	}
 	return "ERROR: " + e.Message
 }
@@ -0,0 +1,8 @@
+package object
+
+// Frame is the activation record of a function call
+type Frame struct {
+	Function *Function
+	Yield    func(Object)          // ジェネレータの場合、呼び出し元に値を渡して再開を待つ
+	Await    func(*Promise) Object // async 関数の場合、Promise の決着まで中断して結果を返す
+}
@@ -9,9 +9,12 @@ import (
 
 // Function is the implementation of function
 type Function struct {
+	Name       string
 	Parameters []*ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
+	Generator  bool // 呼び出すとジェネレータを返す
+	Async      bool // 呼び出すと Promise を返す
 }
 
 // Type returns the type of object
@@ -26,6 +29,17 @@ func (f *Function) Inspect() string {
 	for _, p := range f.Parameters {
 		params = append(params, p.String())
 	}
+	if f.Async {
+		out.WriteString("async ")
+	}
+	if f.Name != "" {
+		out.WriteString("fn ")
+		out.WriteString(f.Name)
+		out.WriteString("(")
+		out.WriteString(strings.Join(params, ", "))
+		out.WriteString(")")
+		return out.String()
+	}
 	out.WriteString("fn")
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
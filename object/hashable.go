@@ -4,3 +4,13 @@ package object
 type Hashable interface {
 	HashKey() HashKey
 }
+
+// AsHashable returns obj as Hashable if it can be used as hash key.
+// Enum values can be used only if they have no payload.
+func AsHashable(obj Object) (Hashable, bool) {
+	if ev, ok := obj.(*EnumValue); ok && len(ev.Values) > 0 {
+		return nil, false
+	}
+	h, ok := obj.(Hashable)
+	return h, ok
+}
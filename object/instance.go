@@ -0,0 +1,61 @@
+package object
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+)
+
+// Instance is the implementation of class instance.
+// Fields are created by assignment to "self.name".
+type Instance struct {
+	Class *Class
+
+	mu     sync.RWMutex
+	fields map[string]Object
+	order  []string // 代入された順序
+}
+
+// NewInstance initializes Instance without fields
+func NewInstance(class *Class) *Instance {
+	return &Instance{Class: class, fields: make(map[string]Object)}
+}
+
+// Type returns the type of object
+func (i *Instance) Type() Type {
+	return InstanceType
+}
+
+// Inspect returns the string expression of object
+func (i *Instance) Inspect() string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	var out bytes.Buffer
+	fields := []string{}
+	for _, name := range i.order {
+		fields = append(fields, name+": "+i.fields[name].Inspect())
+	}
+	out.WriteString(i.Class.Name)
+	out.WriteString("{")
+	out.WriteString(strings.Join(fields, ", "))
+	out.WriteString("}")
+	return out.String()
+}
+
+// Get returns the value of the field, or false if the instance has no such field
+func (i *Instance) Get(name string) (Object, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	val, ok := i.fields[name]
+	return val, ok
+}
+
+// Set updates the value of the field, creating it if needed
+func (i *Instance) Set(name string, val Object) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if _, ok := i.fields[name]; !ok {
+		i.order = append(i.order, name)
+	}
+	i.fields[name] = val
+}
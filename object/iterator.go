@@ -0,0 +1,31 @@
+package object
+
+import "sync"
+
+// IteratorFunction returns the next value, or false when the iteration is over
+type IteratorFunction func() (Object, bool)
+
+// Iterator is the implementation of iterator
+type Iterator struct {
+	Kind   string // 反復対象の種類。例えば "array" や "generator"
+	NextFn IteratorFunction
+	mu     sync.Mutex
+}
+
+// Next returns the next value, or false when the iteration is over.
+// It is safe to call from concurrent tasks.
+func (i *Iterator) Next() (Object, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.NextFn()
+}
+
+// Type returns the type of object
+func (i *Iterator) Type() Type {
+	return IteratorType
+}
+
+// Inspect returns the string expression of object
+func (i *Iterator) Inspect() string {
+	return i.Kind + " iterator"
+}
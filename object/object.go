@@ -24,6 +24,32 @@ const (
 	HashType = "HASH"
 	// BuiltinType is the type of built-in object
 	BuiltinType = "BUILTIN"
+	// IteratorType is the type of iterator
+	IteratorType = "ITERATOR"
+	// ChannelType is the type of channel
+	ChannelType = "CHANNEL"
+	// TaskType is the type of spawned task
+	TaskType = "TASK"
+	// PromiseType is the type of promise
+	PromiseType = "PROMISE"
+	// StructDefinitionType is the type of struct declaration
+	StructDefinitionType = "STRUCT_DEFINITION"
+	// StructType is the type of struct instance
+	StructType = "STRUCT"
+	// BoundMethodType is the type of method bound to its receiver
+	BoundMethodType = "BOUND_METHOD"
+	// ClassType is the type of class
+	ClassType = "CLASS"
+	// InstanceType is the type of class instance
+	InstanceType = "INSTANCE"
+	// SuperType is the type of "super" in methods
+	SuperType = "SUPER"
+	// EnumType is the type of enum
+	EnumType = "ENUM"
+	// EnumVariantType is the type of enum variant constructor
+	EnumVariantType = "ENUM_VARIANT"
+	// EnumValueType is the type of enum value
+	EnumValueType = "ENUM_VALUE"
 )
 
 // Object is the expression of object
@@ -1,6 +1,10 @@
 package object
 
-import "testing"
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := &String{Value: "Hello World"}
@@ -19,3 +23,175 @@ func TestStringHashKey(t *testing.T) {
 		t.Errorf("strings with different content have same hash keys")
 	}
 }
+
+func TestEnumValueHashKey(t *testing.T) {
+	enum := &Enum{Name: "Option"}
+	some := &EnumVariant{Enum: enum, Name: "Some", Fields: []string{"value"}}
+	none := &EnumVariant{Enum: enum, Name: "None"}
+	enum.Variants = []*EnumVariant{some, none}
+
+	none1 := &EnumValue{Variant: none}
+	none2 := &EnumValue{Variant: none}
+	if none1.HashKey() != none2.HashKey() {
+		t.Errorf("values of same variant have different hash keys")
+	}
+	if none1.HashKey() == (&String{Value: "Option.None"}).HashKey() {
+		t.Errorf("enum value and string have same hash keys")
+	}
+
+	if _, ok := AsHashable(none1); !ok {
+		t.Errorf("value without payload is not hashable")
+	}
+	if _, ok := AsHashable(&EnumValue{Variant: some, Values: []Object{&Integer{Value: 1}}}); ok {
+		t.Errorf("value with payload is hashable")
+	}
+	if _, ok := AsHashable(&Array{}); ok {
+		t.Errorf("array is hashable")
+	}
+}
+
+func TestEnvironmentDeclare(t *testing.T) {
+	outer := NewEnvironment()
+	if err := outer.Declare("a", &Integer{Value: 1}, false); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := outer.Declare("a", &Integer{Value: 2}, false); err == nil {
+		t.Errorf("redeclaration in the same scope did not fail")
+	}
+
+	inner := NewEnclosedEnvironment(outer)
+	if err := inner.Declare("a", &Integer{Value: 3}, true); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := inner.Assign("a", &Integer{Value: 4}); err == nil {
+		t.Errorf("assignment to constant did not fail")
+	}
+
+	obj, _ := outer.Get("a")
+	if obj.(*Integer).Value != 1 {
+		t.Errorf("outer binding was modified. got=%d", obj.(*Integer).Value)
+	}
+}
+
+func TestEnvironmentAssign(t *testing.T) {
+	outer := NewEnvironment()
+	outer.Declare("a", &Integer{Value: 1}, false)
+	inner := NewEnclosedEnvironment(outer)
+
+	if err := inner.Assign("a", &Integer{Value: 2}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	obj, _ := outer.Get("a")
+	if obj.(*Integer).Value != 2 {
+		t.Errorf("outer binding was not assigned. got=%d", obj.(*Integer).Value)
+	}
+	if _, ok := inner.store["a"]; ok {
+		t.Errorf("assignment declared a new binding in inner scope")
+	}
+
+	if err := inner.Assign("b", &Integer{Value: 1}); err == nil {
+		t.Errorf("assignment to undeclared variable did not fail")
+	}
+}
+
+func TestHashSortedPairs(t *testing.T) {
+	keys := []Object{
+		&String{Value: "b"},
+		&Integer{Value: 10},
+		&Boolean{Value: true},
+		&String{Value: "a"},
+		&Integer{Value: 2},
+	}
+	hash := &Hash{Pairs: map[HashKey]HashPair{}}
+	for _, key := range keys {
+		hash.Pairs[key.(Hashable).HashKey()] = HashPair{Key: key, Value: key}
+	}
+
+	expected := "{true: true, 2: 2, 10: 10, a: a, b: b}"
+	if hash.Inspect() != expected {
+		t.Errorf("hash.Inspect() wrong. expected=%q, got=%q", expected, hash.Inspect())
+	}
+}
+
+func TestEnvironmentFrame(t *testing.T) {
+	global := NewEnvironment()
+	if global.Frame() != nil {
+		t.Errorf("global environment has a frame")
+	}
+
+	frame := &Frame{}
+	inner := NewEnclosedEnvironment(NewFrameEnvironment(global, frame))
+	if inner.Frame() != frame {
+		t.Errorf("inner.Frame() is not the frame of the enclosing call")
+	}
+}
+
+func TestEnvironmentNames(t *testing.T) {
+	outer := NewEnvironment()
+	outer.Set("b", &Integer{Value: 1})
+	outer.Set("a", &Integer{Value: 2})
+	inner := NewEnclosedEnvironment(outer)
+	inner.Set("c", &Integer{Value: 3})
+
+	if names := fmt.Sprint(outer.Names()); names != "[a b]" {
+		t.Errorf("outer.Names() wrong. got=%s", names)
+	}
+	if names := fmt.Sprint(inner.Names()); names != "[c]" {
+		t.Errorf("inner.Names() wrong. got=%s", names)
+	}
+	if inner.Outer() != outer || outer.Outer() != nil {
+		t.Errorf("Outer() wrong")
+	}
+}
+
+func TestEnvironmentConcurrentAccess(t *testing.T) {
+	outer := NewEnvironment()
+	outer.Declare("shared", &Integer{Value: 0}, false)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			inner := NewEnclosedEnvironment(outer)
+			for j := 0; j < 100; j++ {
+				name := fmt.Sprintf("local%d", j)
+				inner.Declare(name, &Integer{Value: int64(j)}, false)
+				inner.Get(name)
+				inner.Assign("shared", &Integer{Value: int64(i)})
+				outer.Get("shared")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if _, ok := outer.Get("shared"); !ok {
+		t.Errorf("shared variable was lost")
+	}
+}
+
+func TestPromiseSettle(t *testing.T) {
+	promise := &Promise{}
+	called := 0
+	promise.OnSettle(func() { called++ })
+	if promise.Inspect() != "promise (pending)" {
+		t.Errorf("promise.Inspect() wrong. got=%q", promise.Inspect())
+	}
+
+	promise.Settle(&Integer{Value: 1})
+	promise.Settle(&Integer{Value: 2})
+	promise.OnSettle(func() { called++ })
+
+	if called != 2 {
+		t.Errorf("callbacks were called %d times, want 2", called)
+	}
+	if promise.Inspect() != "promise (fulfilled: 1)" {
+		t.Errorf("promise.Inspect() wrong. got=%q", promise.Inspect())
+	}
+
+	rejected := &Promise{}
+	rejected.Settle(&Error{Message: "failed"})
+	if rejected.Inspect() != "promise (rejected: failed)" {
+		t.Errorf("rejected.Inspect() wrong. got=%q", rejected.Inspect())
+	}
+}
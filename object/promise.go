@@ -0,0 +1,68 @@
+package object
+
+import "sync"
+
+// Promise is the implementation of the result of an asynchronous operation
+type Promise struct {
+	mu        sync.Mutex
+	settled   bool
+	value     Object // 失敗した場合は *Error
+	callbacks []func()
+}
+
+// Type returns the type of object
+func (p *Promise) Type() Type {
+	return PromiseType
+}
+
+// Inspect returns the string expression of object
+func (p *Promise) Inspect() string {
+	value, settled := p.Result()
+	switch {
+	case !settled:
+		return "promise (pending)"
+	case value.Type() == ErrorType:
+		return "promise (rejected: " + value.(*Error).Message + ")"
+	default:
+		return "promise (fulfilled: " + value.Inspect() + ")"
+	}
+}
+
+// Settle fulfills the promise with value, or rejects it if value is *Error.
+// Only the first call takes effect.
+func (p *Promise) Settle(value Object) {
+	p.mu.Lock()
+	if p.settled {
+		p.mu.Unlock()
+		return
+	}
+	p.settled = true
+	p.value = value
+	callbacks := p.callbacks
+	p.callbacks = nil
+	p.mu.Unlock()
+
+	for _, callback := range callbacks {
+		callback()
+	}
+}
+
+// Result returns the value, and whether the promise is settled
+func (p *Promise) Result() (Object, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.value, p.settled
+}
+
+// OnSettle calls callback once the promise is settled.
+// If it is already settled, callback is called immediately.
+func (p *Promise) OnSettle(callback func()) {
+	p.mu.Lock()
+	if !p.settled {
+		p.callbacks = append(p.callbacks, callback)
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Unlock()
+	callback()
+}
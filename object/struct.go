@@ -0,0 +1,67 @@
+package object
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+)
+
+// Struct is the implementation of struct instance with fixed fields
+type Struct struct {
+	Definition *StructDefinition
+
+	mu     sync.RWMutex
+	values []Object // Definition.Fields と同じ順序
+}
+
+// NewStruct initializes Struct with values in the order of the fields
+func NewStruct(definition *StructDefinition, values []Object) *Struct {
+	return &Struct{Definition: definition, values: values}
+}
+
+// Type returns the type of object
+func (s *Struct) Type() Type {
+	return StructType
+}
+
+// Inspect returns the string expression of object
+func (s *Struct) Inspect() string {
+	var out bytes.Buffer
+	fields := []string{}
+	for i, name := range s.Definition.Fields {
+		fields = append(fields, name+": "+s.Value(i).Inspect())
+	}
+	out.WriteString(s.Definition.Name)
+	out.WriteString("{")
+	out.WriteString(strings.Join(fields, ", "))
+	out.WriteString("}")
+	return out.String()
+}
+
+// Get returns the value of the field, or false if the struct has no such field
+func (s *Struct) Get(name string) (Object, bool) {
+	i, ok := s.Definition.FieldIndex(name)
+	if !ok {
+		return nil, false
+	}
+	return s.Value(i), true
+}
+
+// Set updates the value of the field, or returns false if the struct has no such field
+func (s *Struct) Set(name string, val Object) bool {
+	i, ok := s.Definition.FieldIndex(name)
+	if !ok {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[i] = val
+	return true
+}
+
+// Value returns the value of the i-th field
+func (s *Struct) Value(i int) Object {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.values[i]
+}
@@ -0,0 +1,61 @@
+package object
+
+import (
+	"strings"
+	"sync"
+)
+
+// StructDefinition is the implementation of struct declared by "struct Name { fields }".
+// Calling it constructs an instance.
+type StructDefinition struct {
+	Name   string
+	Fields []string
+
+	mu      sync.RWMutex
+	methods map[string]*Function
+}
+
+// Type returns the type of object
+func (sd *StructDefinition) Type() Type {
+	return StructDefinitionType
+}
+
+// Inspect returns the string expression of object
+func (sd *StructDefinition) Inspect() string {
+	return "struct " + sd.Name + " { " + strings.Join(sd.Fields, ", ") + " }"
+}
+
+// FieldIndex returns the position of the field, or false if the struct has no such field
+func (sd *StructDefinition) FieldIndex(name string) (int, bool) {
+	for i, field := range sd.Fields {
+		if field == name {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// Method returns the method declared by impl
+func (sd *StructDefinition) Method(name string) (*Function, bool) {
+	sd.mu.RLock()
+	defer sd.mu.RUnlock()
+	method, ok := sd.methods[name]
+	return method, ok
+}
+
+// AddMethod declares the method. It fails if the name is already used by a field or a method.
+func (sd *StructDefinition) AddMethod(name string, method *Function) bool {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	if _, ok := sd.FieldIndex(name); ok {
+		return false
+	}
+	if _, ok := sd.methods[name]; ok {
+		return false
+	}
+	if sd.methods == nil {
+		sd.methods = make(map[string]*Function)
+	}
+	sd.methods[name] = method
+	return true
+}
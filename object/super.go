@@ -0,0 +1,18 @@
+package object
+
+// Super is the implementation of "super" in methods.
+// Its properties are the methods of Class bound to Receiver.
+type Super struct {
+	Receiver *Instance
+	Class    *Class // メソッドを宣言したクラスのスーパークラス
+}
+
+// Type returns the type of object
+func (s *Super) Type() Type {
+	return SuperType
+}
+
+// Inspect returns the string expression of object
+func (s *Super) Inspect() string {
+	return "super " + s.Class.Name
+}
@@ -0,0 +1,22 @@
+package object
+
+// Task is the implementation of function running on its own goroutine
+type Task struct {
+	Done   chan struct{} // 関数が終了すると close される
+	Result Object        // Done が close された後に読むこと
+}
+
+// Type returns the type of object
+func (t *Task) Type() Type {
+	return TaskType
+}
+
+// Inspect returns the string expression of object
+func (t *Task) Inspect() string {
+	select {
+	case <-t.Done:
+		return "task (done)"
+	default:
+		return "task (running)"
+	}
+}
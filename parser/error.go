@@ -0,0 +1,19 @@
+package parser
+
+import "github.com/tshinag/monkey/token"
+
+// Error is the syntax error with the position where it is found.
+// The message doesn't include the position, so that the callers can format it.
+type Error struct {
+	Pos token.Position
+	err error
+}
+
+func (e *Error) Error() string {
+	return e.err.Error()
+}
+
+// Cause returns the underlying error for errors.Cause
+func (e *Error) Cause() error {
+	return e.err
+}
@@ -24,12 +24,22 @@ type Parser struct {
 
 	prefixParseFns map[token.Type]prefixParseFn
 	infixParseFns  map[token.Type]infixParseFn
+
+	functionDepth int  // 解析中の関数のネストの深さ
+	yielded       bool // 解析中の関数本体に yield が現れたか
+	inAsync       bool // 解析中の関数が async か
 }
 
 const (
 	_ int = iota
 	// LOWEST means the priority for initial state
 	LOWEST
+	// ASSIGN means the priority for "x = y"
+	ASSIGN
+	// PIPELINE means the priority for "x |> f"
+	PIPELINE
+	// COALESCE means the priority for "x ?? y"
+	COALESCE
 	// EQUALS means the priority for "=="
 	EQUALS // ==
 	// LESSGREATER means the priority for "<" or ">"
@@ -42,21 +52,31 @@ const (
 	PREFIX
 	// CALL means the priority for "f(x)"
 	CALL
-	// INDEX means the priority for "array[index]"
+	// INDEX means the priority for "array[index]" or "hash.key"
 	INDEX
 )
 
 var precedences = map[token.Type]int{
-	token.EQ:       EQUALS,
-	token.NOTEQ:    EQUALS,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.SLASH:    PRODUCT,
-	token.ASTERISK: PRODUCT,
-	token.LPAREN:   CALL,
-	token.LBRACKET: INDEX,
+	token.ASSIGN:         ASSIGN,
+	token.PLUSASSIGN:     ASSIGN,
+	token.MINUSASSIGN:    ASSIGN,
+	token.ASTERISKASSIGN: ASSIGN,
+	token.SLASHASSIGN:    ASSIGN,
+	token.PIPELINE:       PIPELINE,
+	token.NULLISH:        COALESCE,
+	token.EQ:             EQUALS,
+	token.NOTEQ:          EQUALS,
+	token.LT:             LESSGREATER,
+	token.INSTANCEOF:     LESSGREATER,
+	token.GT:             LESSGREATER,
+	token.PLUS:           SUM,
+	token.MINUS:          SUM,
+	token.SLASH:          PRODUCT,
+	token.ASTERISK:       PRODUCT,
+	token.LPAREN:         CALL,
+	token.LBRACKET:       INDEX,
+	token.DOT:            INDEX,
+	token.QUESTIONDOT:    INDEX,
 }
 
 // New initializes Parser
@@ -72,6 +92,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
+	p.registerPrefix(token.NULL, p.parseNullLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
@@ -79,6 +100,14 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
 	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
+	p.registerPrefix(token.SWITCH, p.parseSwitchExpression)
+	p.registerPrefix(token.PIPE, p.parseLambdaLiteral)
+	p.registerPrefix(token.FOR, p.parseForExpression)
+	p.registerPrefix(token.SELECT, p.parseSelectExpression)
+	p.registerPrefix(token.ASYNC, p.parseAsyncFunctionLiteral)
+	p.registerPrefix(token.AWAIT, p.parseAwaitExpression)
+	p.registerPrefix(token.YIELD, p.parseYieldExpression)
 
 	p.infixParseFns = make(map[token.Type]infixParseFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
@@ -89,8 +118,18 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.NOTEQ, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PLUSASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MINUSASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.ASTERISKASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.SLASHASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.INSTANCEOF, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.DOT, p.parsePropertyExpression)
+	p.registerInfix(token.QUESTIONDOT, p.parseOptionalChain)
+	p.registerInfix(token.NULLISH, p.parseInfixExpression)
+	p.registerInfix(token.PIPELINE, p.parsePipeExpression)
 
 	// 2つトークンを読み込む。curTokenとpeekTokenの両方がセットされる。
 	p.nextToken()
@@ -124,8 +163,28 @@ func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
 	case token.LET:
 		return p.parseLetStatement()
+	case token.CONST:
+		return p.parseConstStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.FUNCTION:
+		if p.isPeekToken(token.IDENT) {
+			return p.parseFunctionStatement(false)
+		}
+		return p.parseExpressionStatement()
+	case token.ASYNC:
+		if p.isPeekToken(token.FUNCTION) {
+			return p.parseAsyncFunctionStatement()
+		}
+		return p.parseExpressionStatement()
+	case token.STRUCT:
+		return p.parseStructStatement()
+	case token.IMPL:
+		return p.parseImplStatement()
+	case token.CLASS:
+		return p.parseClassStatement()
+	case token.ENUM:
+		return p.parseEnumStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -137,17 +196,309 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 		return nil
 	}
 	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if p.isPeekToken(token.COLON) {
+		p.nextToken()
+		p.nextToken()
+		stmt.Type = p.parseTypeExpression()
+		if stmt.Type == nil {
+			return nil
+		}
+	}
+	if !p.expectPeek(token.ASSIGN) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+	nameFunction(stmt.Value, stmt.Name.Value)
+	if p.isPeekToken(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseConstStatement() *ast.ConstStatement {
+	stmt := &ast.ConstStatement{Token: p.curToken}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if p.isPeekToken(token.COLON) {
+		p.nextToken()
+		p.nextToken()
+		stmt.Type = p.parseTypeExpression()
+		if stmt.Type == nil {
+			return nil
+		}
+	}
 	if !p.expectPeek(token.ASSIGN) {
 		return nil
 	}
 	p.nextToken()
 	stmt.Value = p.parseExpression(LOWEST)
+	nameFunction(stmt.Value, stmt.Name.Value)
+	if p.isPeekToken(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+// nameFunction names the function literal bound by let or const
+func nameFunction(value ast.Expression, name string) {
+	if fl, ok := value.(*ast.FunctionLiteral); ok && fl.Name == "" {
+		fl.Name = name
+	}
+}
+
+// parseAsyncFunctionStatement parses "async fn name() {}" as a declaration,
+// and "async fn() {}" as an expression statement
+func (p *Parser) parseAsyncFunctionStatement() ast.Statement {
+	asyncToken := p.curToken
+	p.nextToken()
+	if p.isPeekToken(token.IDENT) {
+		return p.parseFunctionStatement(true)
+	}
+
+	stmt := &ast.ExpressionStatement{Token: asyncToken}
+	lit := &ast.FunctionLiteral{Token: p.curToken, Async: true}
+	if !p.parseFunction(lit) {
+		return nil
+	}
+	stmt.Expression = p.parseExpressionFrom(lit, LOWEST)
+
+	if p.isPeekToken(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseFunctionStatement(async bool) ast.Statement {
+	stmt := &ast.FunctionStatement{Token: p.curToken}
+	p.nextToken()
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	stmt.Function = &ast.FunctionLiteral{Token: stmt.Token, Name: stmt.Name.Value, Async: async}
+	if !p.parseFunction(stmt.Function) {
+		return nil
+	}
+	if p.isPeekToken(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseStructStatement() ast.Statement {
+	stmt := &ast.StructStatement{Token: p.curToken}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Fields = []*ast.Identifier{}
+	declared := make(map[string]bool)
+	for !p.isPeekToken(token.RBRACE) {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		field := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if declared[field.Value] {
+			p.appendErrorDuplicateField("struct", stmt.Name, field)
+		}
+		declared[field.Value] = true
+		stmt.Fields = append(stmt.Fields, field)
+		if !p.isPeekToken(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+	p.nextToken()
+
+	if p.isPeekToken(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseEnumStatement() ast.Statement {
+	stmt := &ast.EnumStatement{Token: p.curToken}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Variants = []*ast.EnumVariant{}
+	declared := make(map[string]bool)
+	for !p.isPeekToken(token.RBRACE) {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		variant := p.parseEnumVariant()
+		if variant == nil {
+			return nil
+		}
+		if declared[variant.Name.Value] {
+			p.appendErrorDuplicateVariant(stmt.Name, variant.Name)
+		}
+		declared[variant.Name.Value] = true
+		stmt.Variants = append(stmt.Variants, variant)
+		if !p.isPeekToken(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+	p.nextToken()
+
+	if p.isPeekToken(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+// parseEnumVariant parses "Name" or "Name(field, ...)"
+func (p *Parser) parseEnumVariant() *ast.EnumVariant {
+	variant := &ast.EnumVariant{
+		Token: p.curToken,
+		Name:  &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+	}
+	if !p.isPeekToken(token.LPAREN) {
+		return variant
+	}
+	p.nextToken()
+
+	declared := make(map[string]bool)
+	for !p.isPeekToken(token.RPAREN) {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		field := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if declared[field.Value] {
+			p.appendErrorDuplicateField("variant", variant.Name, field)
+		}
+		declared[field.Value] = true
+		variant.Fields = append(variant.Fields, field)
+		if !p.isPeekToken(token.RPAREN) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+	p.nextToken()
+
+	return variant
+}
+
+func (p *Parser) parseImplStatement() ast.Statement {
+	stmt := &ast.ImplStatement{Token: p.curToken}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	p.nextToken()
+
+	stmt.Methods = []*ast.FunctionStatement{}
+	for !p.isCurToken(token.RBRACE) {
+		method := p.parseMethod()
+		if method == nil {
+			return nil
+		}
+		stmt.Methods = append(stmt.Methods, method)
+		p.nextToken()
+	}
+
+	if p.isPeekToken(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+// parseMethod parses "fn name() {}" or "async fn name() {}" in impl block
+func (p *Parser) parseMethod() *ast.FunctionStatement {
+	async := false
+	if p.isCurToken(token.ASYNC) {
+		async = true
+		p.nextToken()
+	}
+	if !p.isCurToken(token.FUNCTION) {
+		p.appendErrorCur(token.FUNCTION)
+		return nil
+	}
+	if !p.isPeekToken(token.IDENT) {
+		p.appendErrorPeek(token.IDENT)
+		return nil
+	}
+	method, ok := p.parseFunctionStatement(async).(*ast.FunctionStatement)
+	if !ok {
+		return nil
+	}
+	return method
+}
+
+func (p *Parser) parseClassStatement() ast.Statement {
+	stmt := &ast.ClassStatement{Token: p.curToken}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if p.isPeekToken(token.EXTENDS) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Superclass = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	p.nextToken()
+
+	stmt.Methods = []*ast.FunctionLiteral{}
+	declared := make(map[string]bool)
+	for !p.isCurToken(token.RBRACE) {
+		if p.isCurToken(token.SEMICOLON) {
+			p.nextToken()
+			continue
+		}
+		method := p.parseClassMethod()
+		if method == nil {
+			return nil
+		}
+		if declared[method.Name] {
+			p.appendErrorDuplicateMethod(stmt.Name, method.Name)
+		}
+		declared[method.Name] = true
+		stmt.Methods = append(stmt.Methods, method)
+		p.nextToken()
+	}
+
 	if p.isPeekToken(token.SEMICOLON) {
 		p.nextToken()
 	}
 	return stmt
 }
 
+// parseClassMethod parses "name(params) { body }" or "async name(params) { body }"
+func (p *Parser) parseClassMethod() *ast.FunctionLiteral {
+	lit := &ast.FunctionLiteral{}
+	if p.isCurToken(token.ASYNC) {
+		lit.Async = true
+		p.nextToken()
+	}
+	if !p.isCurToken(token.IDENT) {
+		p.appendErrorCur(token.IDENT)
+		return nil
+	}
+	lit.Token = p.curToken
+	lit.Name = p.curToken.Literal
+	if !p.parseFunction(lit) {
+		return nil
+	}
+	return lit
+}
+
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt := &ast.ReturnStatement{Token: p.curToken}
 	p.nextToken()
@@ -216,6 +567,16 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	if p.isPeekToken(token.ELSE) {
 		p.nextToken()
 
+		if p.isPeekToken(token.IF) {
+			p.nextToken()
+			elseIf, ok := p.parseIfExpression().(*ast.IfExpression)
+			if !ok {
+				return nil
+			}
+			expression.ElseIf = elseIf
+			return expression
+		}
+
 		if !p.expectPeek(token.LBRACE) {
 			return nil
 		}
@@ -226,100 +587,687 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return expression
 }
 
-func (p *Parser) parseFunctionLiteral() ast.Expression {
-	lit := &ast.FunctionLiteral{Token: p.curToken}
+func (p *Parser) parseSwitchExpression() ast.Expression {
+	expression := &ast.SwitchExpression{Token: p.curToken}
 
 	if !p.expectPeek(token.LPAREN) {
 		return nil
 	}
 
-	lit.Parameters = p.parseFunctionParameters()
+	p.nextToken()
+	expression.Subject = p.parseExpression(LOWEST)
 
-	if !p.expectPeek(token.LBRACE) {
+	if !p.expectPeek(token.RPAREN) {
 		return nil
 	}
 
-	lit.Body = p.parseBlockStatement()
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
 
-	return lit
-}
+	p.nextToken()
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
-	identifiers := []*ast.Identifier{}
+	expression.Cases = []*ast.SwitchCase{}
+	hasDefault := false
+	for !p.isCurToken(token.RBRACE) {
+		switchCase := p.parseSwitchCase()
+		if switchCase == nil {
+			return nil
+		}
+		if switchCase.IsDefault() {
+			if hasDefault {
+				p.appendErrorMultipleDefaults(expression.Token)
+			}
+			hasDefault = true
+		}
+		expression.Cases = append(expression.Cases, switchCase)
+	}
 
-	if p.isPeekToken(token.RPAREN) {
-		p.nextToken()
-		return identifiers
+	if len(expression.Cases) > 0 && expression.Cases[len(expression.Cases)-1].Fallthrough {
+		p.appendErrorFinalFallthrough()
 	}
 
-	p.nextToken()
+	return expression
+}
 
-	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	identifiers = append(identifiers, ident)
+func (p *Parser) parseSwitchCase() *ast.SwitchCase {
+	switchCase := &ast.SwitchCase{Token: p.curToken}
 
-	for p.isPeekToken(token.COMMA) {
-		p.nextToken()
+	switch p.curToken.Type {
+	case token.CASE:
 		p.nextToken()
-		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-		identifiers = append(identifiers, ident)
+		switchCase.Values = []ast.Expression{p.parseExpression(LOWEST)}
+		for p.isPeekToken(token.COMMA) {
+			p.nextToken()
+			p.nextToken()
+			switchCase.Values = append(switchCase.Values, p.parseExpression(LOWEST))
+		}
+	case token.DEFAULT:
+	default:
+		p.appendErrorCur(token.CASE)
+		return nil
 	}
 
-	if !p.expectPeek(token.RPAREN) {
+	if !p.expectPeek(token.COLON) {
 		return nil
 	}
 
-	return identifiers
-}
-
-func (p *Parser) parseBlockStatement() *ast.BlockStatement {
-	block := &ast.BlockStatement{Token: p.curToken}
-	block.Statements = []ast.Statement{}
+	switchCase.Body = &ast.BlockStatement{Token: p.curToken}
+	switchCase.Body.Statements = []ast.Statement{}
 
 	p.nextToken()
 
-	for !p.isCurToken(token.RBRACE) && !p.isCurToken(token.EOF) {
+	for !p.isSwitchClauseEnd() {
+		if p.isCurToken(token.FALLTHROUGH) {
+			switchCase.Fallthrough = true
+			if p.isPeekToken(token.SEMICOLON) {
+				p.nextToken()
+			}
+			p.nextToken()
+			if !p.isSwitchClauseEnd() {
+				p.appendErrorMisplacedFallthrough()
+				return nil
+			}
+			break
+		}
 		stmt := p.parseStatement()
 		if stmt != nil {
-			block.Statements = append(block.Statements, stmt)
+			switchCase.Body.Statements = append(switchCase.Body.Statements, stmt)
 		}
 		p.nextToken()
 	}
 
-	return block
-}
-
-func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
-	expression := &ast.InfixExpression{
-		Token:    p.curToken,
-		Operator: p.curToken.Literal,
-		Left:     left,
+	if p.isCurToken(token.EOF) {
+		p.appendErrorCur(token.RBRACE)
+		return nil
 	}
 
-	precedence := p.curPrecedence()
-	p.nextToken()
-	expression.Right = p.parseExpression(precedence)
+	return switchCase
+}
 
-	return expression
+func (p *Parser) isSwitchClauseEnd() bool {
+	return p.isCurToken(token.CASE) || p.isCurToken(token.DEFAULT) ||
+		p.isCurToken(token.RBRACE) || p.isCurToken(token.EOF)
 }
 
-func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
-	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+func (p *Parser) parseSelectExpression() ast.Expression {
+	expression := &ast.SelectExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
 
 	p.nextToken()
-	exp.Index = p.parseExpression(LOWEST)
 
-	if !p.expectPeek(token.RBRACKET) {
-		return nil
+	expression.Cases = []*ast.SelectCase{}
+	hasDefault := false
+	for !p.isCurToken(token.RBRACE) {
+		selectCase := p.parseSelectCase()
+		if selectCase == nil {
+			return nil
+		}
+		if selectCase.IsDefault() {
+			if hasDefault {
+				p.appendErrorMultipleDefaults(expression.Token)
+			}
+			hasDefault = true
+		}
+		expression.Cases = append(expression.Cases, selectCase)
 	}
 
-	return exp
+	return expression
 }
 
-func (p *Parser) parseArrayLiteral() ast.Expression {
-	array := &ast.ArrayLiteral{Token: p.curToken}
-	array.Elements = p.parseExpressions(token.RBRACKET, token.COMMA)
-	return array
-}
+func (p *Parser) parseSelectCase() *ast.SelectCase {
+	selectCase := &ast.SelectCase{Token: p.curToken}
+
+	switch p.curToken.Type {
+	case token.CASE:
+		p.nextToken()
+		if p.isCurToken(token.LET) {
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+			selectCase.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			if !p.expectPeek(token.ASSIGN) {
+				return nil
+			}
+			p.nextToken()
+		}
+		exp := p.parseExpression(LOWEST)
+		call, ok := exp.(*ast.CallExpression)
+		if !ok || !isChannelOperation(call, selectCase.Name == nil) {
+			if exp != nil {
+				p.appendErrorSelectCase(exp)
+			}
+			return nil
+		}
+		selectCase.Operation = call
+	case token.DEFAULT:
+	default:
+		p.appendErrorCur(token.CASE)
+		return nil
+	}
+
+	if !p.expectPeek(token.COLON) {
+		return nil
+	}
+
+	selectCase.Body = &ast.BlockStatement{Token: p.curToken}
+	selectCase.Body.Statements = []ast.Statement{}
+
+	p.nextToken()
+
+	for !p.isSwitchClauseEnd() {
+		stmt := p.parseStatement()
+		if stmt != nil {
+			selectCase.Body.Statements = append(selectCase.Body.Statements, stmt)
+		}
+		p.nextToken()
+	}
+
+	if p.isCurToken(token.EOF) {
+		p.appendErrorCur(token.RBRACE)
+		return nil
+	}
+
+	return selectCase
+}
+
+// isChannelOperation reports whether call is "recv(ch)", or "send(ch, value)" when sendable
+func isChannelOperation(call *ast.CallExpression, sendable bool) bool {
+	if call.Optional {
+		return false
+	}
+	for _, arg := range call.Arguments {
+		if _, ok := arg.(*ast.SpreadElement); ok {
+			return false
+		}
+	}
+	switch call.Function.String() {
+	case "recv":
+		return len(call.Arguments) == 1
+	case "send":
+		return sendable && len(call.Arguments) == 2
+	default:
+		return false
+	}
+}
+
+func (p *Parser) parseForExpression() ast.Expression {
+	expression := &ast.ForExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	expression.Pattern = p.parsePattern()
+	if expression.Pattern == nil {
+		return nil
+	}
+
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+
+	p.nextToken()
+	expression.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	expression.Body = p.parseBlockStatement()
+
+	return expression
+}
+
+func (p *Parser) parseYieldExpression() ast.Expression {
+	expression := &ast.YieldExpression{Token: p.curToken}
+
+	if p.functionDepth == 0 {
+		p.appendErrorYieldOutsideFunction()
+		return nil
+	}
+	p.yielded = true
+
+	if !p.isYieldValueEnd() {
+		p.nextToken()
+		expression.Value = p.parseExpression(LOWEST)
+	}
+
+	return expression
+}
+
+// isYieldValueEnd reports whether "yield" is used without a value
+func (p *Parser) isYieldValueEnd() bool {
+	switch p.peekToken.Type {
+	case token.SEMICOLON, token.RBRACE, token.RPAREN, token.RBRACKET, token.COMMA, token.EOF:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseAsyncFunctionLiteral parses "async fn(x) {}" or "async |x| x"
+func (p *Parser) parseAsyncFunctionLiteral() ast.Expression {
+	switch p.peekToken.Type {
+	case token.FUNCTION:
+		p.nextToken()
+		lit := &ast.FunctionLiteral{Token: p.curToken, Async: true}
+		if !p.parseFunction(lit) {
+			return nil
+		}
+		return lit
+	case token.PIPE:
+		p.nextToken()
+		return p.parseLambda(true)
+	default:
+		p.appendErrorPeek(token.FUNCTION)
+		return nil
+	}
+}
+
+func (p *Parser) parseAwaitExpression() ast.Expression {
+	expression := &ast.AwaitExpression{Token: p.curToken}
+
+	if p.functionDepth > 0 && !p.inAsync {
+		p.appendErrorAwaitOutsideAsync()
+		return nil
+	}
+
+	p.nextToken()
+	expression.Value = p.parseExpression(PREFIX)
+
+	return expression
+}
+
+func (p *Parser) parseFunctionLiteral() ast.Expression {
+	lit := &ast.FunctionLiteral{Token: p.curToken}
+	if !p.parseFunction(lit) {
+		return nil
+	}
+	return lit
+}
+
+// parseFunction parses the parameters and the body following "fn" or "fn name"
+func (p *Parser) parseFunction(lit *ast.FunctionLiteral) bool {
+	if !p.expectPeek(token.LPAREN) {
+		return false
+	}
+
+	if !p.parseFunctionParameters(lit) {
+		return false
+	}
+
+	if p.isPeekToken(token.RARROW) {
+		p.nextToken()
+		p.nextToken()
+		lit.ReturnType = p.parseTypeExpression()
+		if lit.ReturnType == nil {
+			return false
+		}
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return false
+	}
+
+	p.parseFunctionBody(lit, p.parseBlockStatement)
+
+	return true
+}
+
+// parseFunctionBody parses the body of lit with parse,
+// marking lit as a generator if the body yields
+func (p *Parser) parseFunctionBody(lit *ast.FunctionLiteral, parse func() *ast.BlockStatement) {
+	yielded, inAsync := p.yielded, p.inAsync
+	p.functionDepth++
+	p.yielded, p.inAsync = false, lit.Async
+
+	lit.Body = parse()
+	lit.Generator = p.yielded
+	if lit.Generator && lit.Async {
+		p.appendErrorAsyncGenerator()
+	}
+
+	p.functionDepth--
+	p.yielded, p.inAsync = yielded, inAsync
+}
+
+// parseLambdaLiteral parses "|x, y| expression" or "|x| { statements }"
+// into a function literal
+func (p *Parser) parseLambdaLiteral() ast.Expression {
+	return p.parseLambda(false)
+}
+
+func (p *Parser) parseLambda(async bool) ast.Expression {
+	lit := &ast.FunctionLiteral{Token: p.curToken, Async: async}
+
+	if !p.parseLambdaParameters(lit) {
+		return nil
+	}
+
+	if p.isPeekToken(token.LBRACE) {
+		p.nextToken()
+		p.parseFunctionBody(lit, p.parseBlockStatement)
+		return lit
+	}
+
+	p.nextToken()
+	p.parseFunctionBody(lit, func() *ast.BlockStatement {
+		body := &ast.ExpressionStatement{Token: p.curToken}
+		body.Expression = p.parseExpression(LOWEST)
+		return &ast.BlockStatement{Token: body.Token, Statements: []ast.Statement{body}}
+	})
+
+	return lit
+}
+
+func (p *Parser) parseLambdaParameters(lit *ast.FunctionLiteral) bool {
+	lit.Parameters = []*ast.Identifier{}
+
+	if p.isPeekToken(token.PIPE) {
+		p.nextToken()
+		return true
+	}
+
+	for {
+		if !p.expectPeek(token.IDENT) {
+			return false
+		}
+		if !p.parseParameter(lit) {
+			return false
+		}
+		if !p.isPeekToken(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	return p.expectPeek(token.PIPE)
+}
+
+func (p *Parser) parseFunctionParameters(lit *ast.FunctionLiteral) bool {
+	lit.Parameters = []*ast.Identifier{}
+
+	if p.isPeekToken(token.RPAREN) {
+		p.nextToken()
+		return true
+	}
+
+	p.nextToken()
+	if !p.parseParameter(lit) {
+		return false
+	}
+
+	for p.isPeekToken(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		if !p.parseParameter(lit) {
+			return false
+		}
+	}
+
+	return p.expectPeek(token.RPAREN)
+}
+
+// parseParameter parses "name" or "name: type" and appends it to lit
+func (p *Parser) parseParameter(lit *ast.FunctionLiteral) bool {
+	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	var annotation ast.TypeExpression
+	if p.isPeekToken(token.COLON) {
+		p.nextToken()
+		p.nextToken()
+		annotation = p.parseTypeExpression()
+		if annotation == nil {
+			return false
+		}
+	}
+	lit.Parameters = append(lit.Parameters, ident)
+	lit.ParameterTypes = append(lit.ParameterTypes, annotation)
+	return true
+}
+
+// parseTypeExpression parses type annotation:
+// "int", "[int]", "{string: int}" or "fn(int, string) -> bool"
+func (p *Parser) parseTypeExpression() ast.TypeExpression {
+	switch p.curToken.Type {
+	case token.IDENT, token.NULL:
+		return &ast.NamedType{Token: p.curToken, Name: p.curToken.Literal}
+	case token.LBRACKET:
+		t := &ast.ArrayType{Token: p.curToken}
+		p.nextToken()
+		t.Element = p.parseTypeExpression()
+		if t.Element == nil || !p.expectPeek(token.RBRACKET) {
+			return nil
+		}
+		return t
+	case token.LBRACE:
+		t := &ast.HashType{Token: p.curToken}
+		p.nextToken()
+		t.Key = p.parseTypeExpression()
+		if t.Key == nil || !p.expectPeek(token.COLON) {
+			return nil
+		}
+		p.nextToken()
+		t.Value = p.parseTypeExpression()
+		if t.Value == nil || !p.expectPeek(token.RBRACE) {
+			return nil
+		}
+		return t
+	case token.FUNCTION:
+		return p.parseFunctionType()
+	default:
+		p.appendErrorNoType(p.curToken.Type)
+		return nil
+	}
+}
+
+func (p *Parser) parseFunctionType() ast.TypeExpression {
+	t := &ast.FunctionType{Token: p.curToken, Parameters: []ast.TypeExpression{}}
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	for !p.isPeekToken(token.RPAREN) {
+		p.nextToken()
+		param := p.parseTypeExpression()
+		if param == nil {
+			return nil
+		}
+		t.Parameters = append(t.Parameters, param)
+		if !p.isPeekToken(token.RPAREN) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+	p.nextToken()
+	if !p.expectPeek(token.RARROW) {
+		return nil
+	}
+	p.nextToken()
+	t.Return = p.parseTypeExpression()
+	if t.Return == nil {
+		return nil
+	}
+	return t
+}
+
+func (p *Parser) parseBlockStatement() *ast.BlockStatement {
+	block := &ast.BlockStatement{Token: p.curToken}
+	block.Statements = []ast.Statement{}
+
+	p.nextToken()
+
+	for !p.isCurToken(token.RBRACE) && !p.isCurToken(token.EOF) {
+		stmt := p.parseStatement()
+		if stmt != nil {
+			block.Statements = append(block.Statements, stmt)
+		}
+		p.nextToken()
+	}
+
+	return block
+}
+
+func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
+	expression := &ast.InfixExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+		Left:     left,
+	}
+
+	precedence := p.curPrecedence()
+	p.nextToken()
+	expression.Right = p.parseExpression(precedence)
+
+	return expression
+}
+
+func (p *Parser) parseAssignExpression(left ast.Expression) ast.Expression {
+	// 左辺の解析に失敗した場合はそのエラーが報告済み
+	if left == nil {
+		return nil
+	}
+	if !isAssignable(left) {
+		p.appendErrorAssignTarget(left)
+		return nil
+	}
+	expression := &ast.AssignExpression{
+		Token:    p.curToken,
+		Target:   left,
+		Operator: p.curToken.Literal,
+	}
+
+	// 右結合にするため、優先順位を1つ下げて右辺を解析する
+	precedence := p.curPrecedence()
+	p.nextToken()
+	expression.Value = p.parseExpression(precedence - 1)
+
+	return expression
+}
+
+func (p *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
+	expression := &ast.PipeExpression{Token: p.curToken, Left: left}
+
+	precedence := p.curPrecedence()
+	p.nextToken()
+	expression.Right = p.parseExpression(precedence)
+
+	return expression
+}
+
+// isAssignable reports whether exp is a variable or a field
+func isAssignable(exp ast.Expression) bool {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		return true
+	case *ast.PropertyExpression:
+		return !exp.Optional
+	default:
+		return false
+	}
+}
+
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+
+	if p.isPeekToken(token.COLON) {
+		return p.parseSliceExpression(exp.Token, left, nil)
+	}
+
+	p.nextToken()
+	exp.Index = p.parseExpression(LOWEST)
+
+	if p.isPeekToken(token.COLON) {
+		return p.parseSliceExpression(exp.Token, left, exp.Index)
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return exp
+}
+
+func (p *Parser) parseSliceExpression(
+	tok token.Token,
+	left, start ast.Expression,
+) ast.Expression {
+	exp := &ast.SliceExpression{Token: tok, Left: left, Start: start}
+
+	// 1つ目の ':' に進む
+	p.nextToken()
+
+	if !p.isPeekToken(token.COLON) && !p.isPeekToken(token.RBRACKET) {
+		p.nextToken()
+		exp.End = p.parseExpression(LOWEST)
+	}
+
+	if p.isPeekToken(token.COLON) {
+		p.nextToken()
+		if !p.isPeekToken(token.RBRACKET) {
+			p.nextToken()
+			exp.Step = p.parseExpression(LOWEST)
+		}
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return exp
+}
+
+func (p *Parser) parsePropertyExpression(left ast.Expression) ast.Expression {
+	exp := &ast.PropertyExpression{Token: p.curToken, Left: left}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	exp.Property = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	return exp
+}
+
+func (p *Parser) parseOptionalChain(left ast.Expression) ast.Expression {
+	switch p.peekToken.Type {
+	case token.LBRACKET:
+		p.nextToken()
+		exp := p.parseIndexExpression(left)
+		index, ok := exp.(*ast.IndexExpression)
+		if !ok {
+			if exp != nil {
+				p.appendErrorOptionalSlice()
+			}
+			return nil
+		}
+		index.Optional = true
+		return index
+	case token.LPAREN:
+		p.nextToken()
+		call := p.parseCallExpression(left).(*ast.CallExpression)
+		call.Optional = true
+		return call
+	default:
+		exp := p.parsePropertyExpression(left)
+		if exp == nil {
+			return nil
+		}
+		exp.(*ast.PropertyExpression).Optional = true
+		return exp
+	}
+}
+
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{Token: p.curToken}
+	array.Elements = p.parseExpressions(token.RBRACKET, token.COMMA)
+	return array
+}
 
 func (p *Parser) parseHashLiteral() ast.Expression {
 	hash := &ast.HashLiteral{Token: p.curToken}
@@ -327,6 +1275,13 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 
 	for !p.isPeekToken(token.RBRACE) {
 		p.nextToken()
+		if p.isCurToken(token.ELLIPSIS) {
+			hash.Keys = append(hash.Keys, p.parseSpreadElement())
+			if !p.isPeekToken(token.RBRACE) && !p.expectPeek(token.COMMA) {
+				return nil
+			}
+			continue
+		}
 		key := p.parseExpression(LOWEST)
 		if !p.expectPeek(token.COLON) {
 			return nil
@@ -337,6 +1292,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 			return nil
 		}
 		hash.Pairs[key] = value
+		hash.Keys = append(hash.Keys, key)
 	}
 	if !p.expectPeek(token.RBRACE) {
 		return nil
@@ -359,12 +1315,12 @@ func (p *Parser) parseExpressions(end token.Type, delimiter token.Type) []ast.Ex
 	}
 
 	p.nextToken()
-	expressions = append(expressions, p.parseExpression(LOWEST))
+	expressions = append(expressions, p.parseElement())
 
 	for p.isPeekToken(delimiter) {
 		p.nextToken()
 		p.nextToken()
-		expressions = append(expressions, p.parseExpression(LOWEST))
+		expressions = append(expressions, p.parseElement())
 	}
 
 	if !p.expectPeek(end) {
@@ -374,6 +1330,307 @@ func (p *Parser) parseExpressions(end token.Type, delimiter token.Type) []ast.Ex
 	return expressions
 }
 
+func (p *Parser) parseMatchExpression() ast.Expression {
+	expression := &ast.MatchExpression{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	expression.Subject = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	expression.Arms = []*ast.MatchArm{}
+	catchAll := false
+	for !p.isPeekToken(token.RBRACE) {
+		p.nextToken()
+		arm := p.parseMatchArm()
+		if arm == nil {
+			return nil
+		}
+		if catchAll {
+			p.appendErrorUnreachableArm(arm)
+		}
+		if arm.Guard == nil && isCatchAllPattern(arm.Pattern) {
+			catchAll = true
+		}
+		expression.Arms = append(expression.Arms, arm)
+		if !p.isPeekToken(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return expression
+}
+
+func (p *Parser) parseMatchArm() *ast.MatchArm {
+	arm := &ast.MatchArm{Token: p.curToken}
+
+	arm.Pattern = p.parsePattern()
+	if arm.Pattern == nil {
+		return nil
+	}
+
+	if p.isPeekToken(token.IF) {
+		p.nextToken()
+		p.nextToken()
+		arm.Guard = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectPeek(token.ARROW) {
+		return nil
+	}
+
+	p.nextToken()
+	arm.Body = p.parseExpression(LOWEST)
+
+	return arm
+}
+
+func (p *Parser) parsePattern() ast.Pattern {
+	pattern := p.parsePrimaryPattern()
+	if pattern == nil || !p.isPeekToken(token.PIPE) {
+		return pattern
+	}
+
+	alternative := &ast.AlternativePattern{
+		Token:        p.peekToken,
+		Alternatives: []ast.Pattern{pattern},
+	}
+	for p.isPeekToken(token.PIPE) {
+		p.nextToken()
+		p.nextToken()
+		pattern := p.parsePrimaryPattern()
+		if pattern == nil {
+			return nil
+		}
+		alternative.Alternatives = append(alternative.Alternatives, pattern)
+	}
+
+	return alternative
+}
+
+func (p *Parser) parsePrimaryPattern() ast.Pattern {
+	switch p.curToken.Type {
+	case token.IDENT:
+		if p.curToken.Literal == "_" {
+			return &ast.WildcardPattern{Token: p.curToken}
+		}
+		if p.isPeekToken(token.DOT) {
+			return p.parseVariantPattern()
+		}
+		return &ast.BindingPattern{
+			Token: p.curToken,
+			Name:  &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+		}
+	case token.INT, token.STRING, token.TRUE, token.FALSE, token.NULL, token.MINUS:
+		return p.parseLiteralPattern()
+	case token.LBRACKET:
+		return p.parseArrayPattern()
+	case token.LBRACE:
+		return p.parseHashPattern()
+	default:
+		p.appendErrorNoPattern(p.curToken.Type)
+		return nil
+	}
+}
+
+func (p *Parser) parseLiteralPattern() ast.Pattern {
+	pattern := &ast.LiteralPattern{Token: p.curToken}
+	pattern.Value = p.parsePatternLiteral()
+	if pattern.Value == nil {
+		return nil
+	}
+	return pattern
+}
+
+func (p *Parser) parsePatternLiteral() ast.Expression {
+	switch p.curToken.Type {
+	case token.INT:
+		return p.parseIntegerLiteral()
+	case token.STRING:
+		return p.parseStringLiteral()
+	case token.TRUE, token.FALSE:
+		return p.parseBoolean()
+	case token.NULL:
+		return p.parseNullLiteral()
+	case token.MINUS:
+		expression := &ast.PrefixExpression{
+			Token:    p.curToken,
+			Operator: p.curToken.Literal,
+		}
+		if !p.expectPeek(token.INT) {
+			return nil
+		}
+		expression.Right = p.parseIntegerLiteral()
+		if expression.Right == nil {
+			return nil
+		}
+		return expression
+	default:
+		p.appendErrorNoPattern(p.curToken.Type)
+		return nil
+	}
+}
+
+func (p *Parser) parseArrayPattern() ast.Pattern {
+	pattern := &ast.ArrayPattern{Token: p.curToken, Elements: []ast.Pattern{}}
+
+	for !p.isPeekToken(token.RBRACKET) {
+		p.nextToken()
+		if p.isCurToken(token.ELLIPSIS) {
+			pattern.Rest = p.parseRestPattern()
+			break
+		}
+		element := p.parsePattern()
+		if element == nil {
+			return nil
+		}
+		pattern.Elements = append(pattern.Elements, element)
+		if !p.isPeekToken(token.RBRACKET) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return pattern
+}
+
+func (p *Parser) parseHashPattern() ast.Pattern {
+	pattern := &ast.HashPattern{Token: p.curToken, Pairs: []ast.HashPatternPair{}}
+
+	for !p.isPeekToken(token.RBRACE) {
+		p.nextToken()
+		if p.isCurToken(token.ELLIPSIS) {
+			pattern.Rest = p.parseRestPattern()
+			break
+		}
+		key := p.parseHashPatternKey()
+		if key == nil {
+			return nil
+		}
+		if !p.expectPeek(token.COLON) {
+			return nil
+		}
+		p.nextToken()
+		value := p.parsePattern()
+		if value == nil {
+			return nil
+		}
+		pattern.Pairs = append(pattern.Pairs, ast.HashPatternPair{Key: key, Value: value})
+		if !p.isPeekToken(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return pattern
+}
+
+// parseHashPatternKey parses the literal or "Enum.Variant" as the key of hash pattern
+func (p *Parser) parseHashPatternKey() ast.Expression {
+	if !p.isCurToken(token.IDENT) || !p.isPeekToken(token.DOT) {
+		return p.parsePatternLiteral()
+	}
+	left := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	p.nextToken()
+	key := &ast.PropertyExpression{Token: p.curToken, Left: left}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	key.Property = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	return key
+}
+
+// parseVariantPattern parses "Enum.Variant" or "Enum.Variant(patterns)"
+func (p *Parser) parseVariantPattern() ast.Pattern {
+	pattern := &ast.VariantPattern{
+		Token: p.curToken,
+		Enum:  &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+	}
+	p.nextToken()
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	pattern.Variant = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if !p.isPeekToken(token.LPAREN) {
+		return pattern
+	}
+	p.nextToken()
+
+	for !p.isPeekToken(token.RPAREN) {
+		p.nextToken()
+		field := p.parsePattern()
+		if field == nil {
+			return nil
+		}
+		pattern.Fields = append(pattern.Fields, field)
+		if !p.isPeekToken(token.RPAREN) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+	p.nextToken()
+
+	return pattern
+}
+
+func (p *Parser) parseRestPattern() *ast.RestPattern {
+	rest := &ast.RestPattern{Token: p.curToken}
+	if p.isPeekToken(token.IDENT) {
+		p.nextToken()
+		if p.curToken.Literal != "_" {
+			rest.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		}
+	}
+	return rest
+}
+
+func isCatchAllPattern(pattern ast.Pattern) bool {
+	switch pattern := pattern.(type) {
+	case *ast.WildcardPattern, *ast.BindingPattern:
+		return true
+	case *ast.AlternativePattern:
+		for _, a := range pattern.Alternatives {
+			if isCatchAllPattern(a) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *Parser) parseElement() ast.Expression {
+	if p.isCurToken(token.ELLIPSIS) {
+		return p.parseSpreadElement()
+	}
+	return p.parseExpression(LOWEST)
+}
+
+func (p *Parser) parseSpreadElement() ast.Expression {
+	spread := &ast.SpreadElement{Token: p.curToken}
+	p.nextToken()
+	spread.Value = p.parseExpression(LOWEST)
+	return spread
+}
+
 func (p *Parser) parseExpression(precedence int) ast.Expression {
 	t := p.curToken.Type
 	prefix := p.prefixParseFns[t]
@@ -381,8 +1638,11 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 		p.appendErrorNoPrefixParseFn(t)
 		return nil
 	}
-	leftExp := prefix()
+	return p.parseExpressionFrom(prefix(), precedence)
+}
 
+// parseExpressionFrom parses the infix operators following leftExp
+func (p *Parser) parseExpressionFrom(leftExp ast.Expression, precedence int) ast.Expression {
 	for !p.isPeekToken(token.SEMICOLON) && precedence < p.peekPrecedence() {
 		infix := p.infixParseFns[p.peekToken.Type]
 		if infix == nil {
@@ -405,7 +1665,7 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
 		err := errors.Wrapf(err, "could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, err)
+		p.appendError(p.curToken.Pos, err)
 		return nil
 	}
 
@@ -418,6 +1678,10 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+func (p *Parser) parseNullLiteral() ast.Expression {
+	return &ast.NullLiteral{Token: p.curToken}
+}
+
 func (p *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{Token: p.curToken, Value: p.isCurToken(token.TRUE)}
 }
@@ -431,15 +1695,101 @@ func (p *Parser) expectPeek(t token.Type) bool {
 	return false
 }
 
+// appendError records the error found at the position in the source
+func (p *Parser) appendError(pos token.Position, err error) {
+	p.errors = append(p.errors, &Error{Pos: pos, err: err})
+}
+
 func (p *Parser) appendErrorPeek(t token.Type) {
 	err := errors.Errorf("expected next token to be %s, got %s instead",
 		t, p.peekToken.Type)
-	p.errors = append(p.errors, err)
+	p.appendError(p.peekToken.Pos, err)
+}
+
+func (p *Parser) appendErrorCur(t token.Type) {
+	err := errors.Errorf("expected token to be %s, got %s instead",
+		t, p.curToken.Type)
+	p.appendError(p.curToken.Pos, err)
 }
 
 func (p *Parser) appendErrorNoPrefixParseFn(t token.Type) {
 	err := errors.Errorf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, err)
+	p.appendError(p.curToken.Pos, err)
+}
+
+func (p *Parser) appendErrorAssignTarget(target ast.Expression) {
+	err := errors.Errorf("invalid assignment target: %s", target)
+	p.appendError(target.Pos(), err)
+}
+
+func (p *Parser) appendErrorDuplicateField(kind string, name, field *ast.Identifier) {
+	err := errors.Errorf("duplicate field %s in %s %s", field, kind, name)
+	p.appendError(field.Pos(), err)
+}
+
+func (p *Parser) appendErrorDuplicateVariant(enumName, variant *ast.Identifier) {
+	err := errors.Errorf("duplicate variant %s in enum %s", variant, enumName)
+	p.appendError(variant.Pos(), err)
+}
+
+func (p *Parser) appendErrorDuplicateMethod(className *ast.Identifier, method string) {
+	err := errors.Errorf("duplicate method %s in class %s", method, className)
+	p.appendError(p.curToken.Pos, err)
+}
+
+func (p *Parser) appendErrorSelectCase(exp ast.Expression) {
+	err := errors.Errorf("select case must be recv(channel) or send(channel, value), got %s", exp)
+	p.appendError(exp.Pos(), err)
+}
+
+func (p *Parser) appendErrorAwaitOutsideAsync() {
+	err := errors.New("await outside async function")
+	p.appendError(p.curToken.Pos, err)
+}
+
+func (p *Parser) appendErrorAsyncGenerator() {
+	err := errors.New("yield in async function")
+	p.appendError(p.curToken.Pos, err)
+}
+
+func (p *Parser) appendErrorYieldOutsideFunction() {
+	err := errors.New("yield outside function")
+	p.appendError(p.curToken.Pos, err)
+}
+
+func (p *Parser) appendErrorOptionalSlice() {
+	err := errors.New("optional chaining is not supported for slice expression")
+	p.appendError(p.curToken.Pos, err)
+}
+
+func (p *Parser) appendErrorNoType(t token.Type) {
+	err := errors.Errorf("no type starts with %s", t)
+	p.appendError(p.curToken.Pos, err)
+}
+
+func (p *Parser) appendErrorNoPattern(t token.Type) {
+	err := errors.Errorf("no pattern starts with %s", t)
+	p.appendError(p.curToken.Pos, err)
+}
+
+func (p *Parser) appendErrorUnreachableArm(arm *ast.MatchArm) {
+	err := errors.Errorf("unreachable match arm after catch-all pattern: %s", arm)
+	p.appendError(arm.Pos(), err)
+}
+
+func (p *Parser) appendErrorMultipleDefaults(t token.Token) {
+	err := errors.Errorf("multiple defaults in %s", t.Literal)
+	p.appendError(p.curToken.Pos, err)
+}
+
+func (p *Parser) appendErrorMisplacedFallthrough() {
+	err := errors.New("fallthrough statement out of place")
+	p.appendError(p.curToken.Pos, err)
+}
+
+func (p *Parser) appendErrorFinalFallthrough() {
+	err := errors.New("cannot fallthrough final case in switch")
+	p.appendError(p.curToken.Pos, err)
 }
 
 func (p *Parser) nextToken() {
@@ -42,6 +42,292 @@ func TestLetStatements(t *testing.T) {
 	}
 }
 
+func TestConstStatements(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedIdentifier string
+		expectedValue      interface{}
+	}{
+		{"const x = 5;", "x", 5},
+		{"const y = true;", "y", true},
+		{"const foobar = y;", "foobar", "y"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statements. got=%d",
+				len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ConstStatement)
+		if !ok {
+			t.Fatalf("stmt not *ast.ConstStatement. got=%T", program.Statements[0])
+		}
+		if stmt.TokenLiteral() != "const" {
+			t.Errorf("stmt.TokenLiteral not 'const'. got=%q", stmt.TokenLiteral())
+		}
+		if !testIdentifier(t, stmt.Name, tt.expectedIdentifier) {
+			return
+		}
+		if !testLiteralExpression(t, stmt.Value, tt.expectedValue) {
+			return
+		}
+	}
+}
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x = 5", "(x = 5)"},
+		{"x = y = 5", "(x = (y = 5))"},
+		{"x = 1 + 2 * 3", "(x = (1 + (2 * 3)))"},
+		{"f(x = 1)", "f((x = 1))"},
+		{"p.x = p.y = 1", "((p.x) = ((p.y) = 1))"},
+		{"a.b.c = 2", "(((a.b).c) = 2)"},
+		{"x += 1 * 2", "(x += (1 * 2))"},
+		{"x -= y *= 2", "(x -= (y *= 2))"},
+		{"self.n /= 2", "((self.n) /= 2)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		actual := program.String()
+		if actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+
+	for input, expected := range map[string]string{
+		"1 = 2":    "invalid assignment target: 1",
+		"a?.b = 2": "invalid assignment target: (a?.b)",
+		"a[0] = 2": "invalid assignment target: (a[0])",
+		"f() = 2":  "invalid assignment target: f()",
+		"1 += 2":   "invalid assignment target: 1",
+	} {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) != 1 || errors[0].Error() != expected {
+			t.Errorf("wrong errors for %q. expected=%q, got=%q", input, expected, errors)
+		}
+	}
+}
+
+func TestStructStatementParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct Point { x, y }", "struct Point { x, y }"},
+		{"struct Point { x, y, };", "struct Point { x, y }"},
+		{"struct Empty {}", "struct Empty {  }"},
+		{"impl Point { fn len(self) { self.x } fn zero() { 0 }; }", "impl Point { fn len(self) (self.x) fn zero() 0 }"},
+		{"impl Point { async fn load(self) { 1 } }", "impl Point { async fn load(self) 1 }"},
+		{"impl Point {}", "impl Point {  }"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	for input, expected := range map[string]string{
+		"struct { x }":              "expected next token to be IDENT, got { instead",
+		"struct P { x y }":          "expected next token to be ,, got IDENT instead",
+		"struct P { x, x }":         "duplicate field x in struct P",
+		"struct P { 1 }":            "expected next token to be IDENT, got INT instead",
+		"impl P { let x = 1 }":      "expected token to be FUNCTION, got LET instead",
+		"impl P { fn() { 1 } }":     "expected next token to be IDENT, got ( instead",
+		"impl P { fn f(self) { 1 }": "expected token to be FUNCTION, got EOF instead",
+	} {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0].Error() != expected {
+			t.Errorf("wrong errors for %q. expected=%q, got=%q", input, expected, errors)
+		}
+	}
+}
+
+func TestClassStatementParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"class Counter { init(start) { self.n = start } inc() { self.n += 1 } }",
+			"class Counter { init(start) ((self.n) = start) inc() ((self.n) += 1) }"},
+		{"class B extends A { f() { super.f() }; }", "class B extends A { f() (super.f)() }"},
+		{"class A { async load(x) { await x } }", "class A { async load(x) (await x) }"},
+		{"class Empty {}", "class Empty {  }"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	for input, expected := range map[string]string{
+		"class { }":                    "expected next token to be IDENT, got { instead",
+		"class A extends { }":          "expected next token to be IDENT, got { instead",
+		"class A { f() { 1 } f() {} }": "duplicate method f in class A",
+		"class A { fn f() { 1 } }":     "expected token to be IDENT, got FUNCTION instead",
+		"class A { f { 1 } }":          "expected next token to be (, got { instead",
+		"class A { f() { 1 }":          "expected token to be IDENT, got EOF instead",
+	} {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0].Error() != expected {
+			t.Errorf("wrong errors for %q. expected=%q, got=%q", input, expected, errors)
+		}
+	}
+}
+
+func TestEnumStatementParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"enum Result { Ok(value), Err(error) }", "enum Result { Ok(value), Err(error) }"},
+		{"enum Status { Pending, Done(result), Failed(err), };", "enum Status { Pending, Done(result), Failed(err) }"},
+		{"enum Point { At(x, y), Origin() }", "enum Point { At(x, y), Origin }"},
+		{"enum Never {}", "enum Never {  }"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	for input, expected := range map[string]string{
+		"enum { A }":         "expected next token to be IDENT, got { instead",
+		"enum E { A B }":     "expected next token to be ,, got IDENT instead",
+		"enum E { A, A(x) }": "duplicate variant A in enum E",
+		"enum E { A(x, x) }": "duplicate field x in variant A",
+		"enum E { A(1) }":    "expected next token to be IDENT, got INT instead",
+		"enum E { A(x }":     "expected next token to be ,, got } instead",
+	} {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0].Error() != expected {
+			t.Errorf("wrong errors for %q. expected=%q, got=%q", input, expected, errors)
+		}
+	}
+}
+
+func TestTypeAnnotationParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x: int = 1", "let x: int = 1;"},
+		{"const xs: [string] = []", "const xs: [string] = [];"},
+		{"let h: {string: [int]} = {}", "let h: {string: [int]} = {};"},
+		{"let f: fn(int, string) -> null = g", "let f: fn(int, string) -> null = g;"},
+		{"let f: fn() -> fn(int) -> bool = g", "let f: fn() -> fn(int) -> bool = g;"},
+		{"fn(a: string, b: [int]) -> bool { true }", "fn(a: string, b: [int]) -> bool true"},
+		{"fn(a, b: int) { a }", "fn(a, b: int) a"},
+		{"fn add(a: int, b: int) -> int { a + b }", "fn add(a: int, b: int) -> int (a + b)"},
+		{"|x: int, y| x + y", "|x: int, y| (x + y)"},
+		{"class A { get(i: int) -> Point { i } }", "class A { get(i: int) -> Point i }"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	for input, expected := range map[string]string{
+		"let x: = 1":         "no type starts with =",
+		"let x: [int = 1":    "expected next token to be ], got = instead",
+		"let x: {int} = 1":   "expected next token to be :, got } instead",
+		"let f: fn(int) = g": "expected next token to be ->, got = instead",
+		"fn(a: 1) { a }":     "no type starts with INT",
+		"fn(a) -> ) { a }":   "no type starts with )",
+		"let x int = 1":      "expected next token to be =, got IDENT instead",
+	} {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0].Error() != expected {
+			t.Errorf("wrong errors for %q. expected=%q, got=%q", input, expected, errors)
+		}
+	}
+}
+
+func TestNodePositions(t *testing.T) {
+	input := "let x = 1;\nfn f(a) {\n  a + x\n}"
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	fs := program.Statements[1].(*ast.FunctionStatement)
+	infix := fs.Function.Body.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.InfixExpression)
+	tests := []struct {
+		node     ast.Node
+		expected string
+	}{
+		{program, "1:1"},
+		{program.Statements[0], "1:1"},
+		{fs, "2:1"},
+		{fs.Function.Parameters[0], "2:6"},
+		{infix, "3:5"},
+		{infix.Left, "3:3"},
+		{infix.Right, "3:7"},
+	}
+
+	for _, tt := range tests {
+		if tt.node.Pos().String() != tt.expected {
+			t.Errorf("wrong position of %q. expected=%s, got=%s", tt.node, tt.expected, tt.node.Pos())
+		}
+	}
+}
+
 func TestReturnStatements(t *testing.T) {
 	tests := []struct {
 		input         string
@@ -355,6 +641,62 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		{
+			"a.b.c + d?.e",
+			"(((a.b).c) + (d?.e))",
+		},
+		{
+			"a?.[1] + f?.(2) + a.b(c)[d]",
+			"(((a?.[1]) + f?.(2)) + ((a.b)(c)[d]))",
+		},
+		{
+			"a ?? b ?? c",
+			"((a ?? b) ?? c)",
+		},
+		{
+			"a ?? b == c",
+			"(a ?? (b == c))",
+		},
+		{
+			"x = a ?? b",
+			"(x = (a ?? b))",
+		},
+		{
+			"a instanceof B == true",
+			"((a instanceof B) == true)",
+		},
+		{
+			"a.b instanceof C < d",
+			"(((a.b) instanceof C) < d)",
+		},
+		{
+			"-a.b",
+			"(-(a.b))",
+		},
+		{
+			"xs |> map(f) |> sum",
+			"((xs |> map(f)) |> sum)",
+		},
+		{
+			"x = a + b |> f ?? g",
+			"(x = ((a + b) |> (f ?? g)))",
+		},
+		{
+			"xs |> map(|x| x * 2)",
+			"(xs |> map(|x| (x * 2)))",
+		},
+		{
+			"|x, y| x + y |> f",
+			"|x, y| ((x + y) |> f)",
+		},
+		{
+			"(|| 1)()",
+			"|| 1()",
+		},
+		{
+			"a[1:2] + b[:c * 2] + d[::-1]",
+			"(((a[1:2]) + (b[:(c * 2)])) + (d[::(-1)]))",
+		},
 	}
 
 	for _, tt := range tests {
@@ -516,84 +858,66 @@ func TestIfElseExpression(t *testing.T) {
 	}
 }
 
-func TestFunctionLiteralParsing(t *testing.T) {
-	input := `fn(x, y) { x + y; }`
+func TestElseIfExpression(t *testing.T) {
+	input := `if (x < y) { x } else if (x > y) { y } else { z }`
+
 	l := lexer.New(input)
 	p := New(l)
 	program := p.ParseProgram()
 	checkParserErrors(t, p)
 
-	if len(program.Statements) != 1 {
-		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
-			1, len(program.Statements))
-	}
-
-	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.IfExpression)
 	if !ok {
-		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
-			program.Statements[0])
+		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
 	}
 
-	function, ok := stmt.Expression.(*ast.FunctionLiteral)
-	if !ok {
-		t.Fatalf("stmt.Expression is not ast.FunctionLiteral. got=%T",
-			stmt.Expression)
+	if !testInfixExpression(t, exp.Condition, "x", "<", "y") {
+		return
 	}
 
-	if len(function.Parameters) != 2 {
-		t.Fatalf("function literal parameters wrong. want 2, got=%d\n",
-			len(function.Parameters))
+	if exp.Alternative != nil {
+		t.Errorf("exp.Alternative was not nil. got=%+v", exp.Alternative)
 	}
 
-	testLiteralExpression(t, function.Parameters[0], "x")
-	testLiteralExpression(t, function.Parameters[1], "y")
-
-	if len(function.Body.Statements) != 1 {
-		t.Fatalf("function.Body.Statements has not 1 statements. got=%d\n",
-			len(function.Body.Statements))
+	if exp.ElseIf == nil {
+		t.Fatalf("exp.ElseIf is nil")
 	}
 
-	bodyStmt, ok := function.Body.Statements[0].(*ast.ExpressionStatement)
-	if !ok {
-		t.Fatalf("function body stmt is not ast.ExpressionStatement. got=%T",
-			function.Body.Statements[0])
+	if !testInfixExpression(t, exp.ElseIf.Condition, "x", ">", "y") {
+		return
 	}
 
-	testInfixExpression(t, bodyStmt.Expression, "x", "+", "y")
-}
-
-func TestFunctionParameterParsing(t *testing.T) {
-	tests := []struct {
-		input          string
-		expectedParams []string
-	}{
-		{input: "fn() {};", expectedParams: []string{}},
-		{input: "fn(x) {};", expectedParams: []string{"x"}},
-		{input: "fn(x, y, z) {};", expectedParams: []string{"x", "y", "z"}},
+	if exp.ElseIf.ElseIf != nil {
+		t.Errorf("exp.ElseIf.ElseIf was not nil. got=%+v", exp.ElseIf.ElseIf)
 	}
 
-	for _, tt := range tests {
-		l := lexer.New(tt.input)
-		p := New(l)
-		program := p.ParseProgram()
-		checkParserErrors(t, p)
-
-		stmt := program.Statements[0].(*ast.ExpressionStatement)
-		function := stmt.Expression.(*ast.FunctionLiteral)
+	if len(exp.ElseIf.Alternative.Statements) != 1 {
+		t.Fatalf("exp.ElseIf.Alternative.Statements does not contain 1 statements. got=%d\n",
+			len(exp.ElseIf.Alternative.Statements))
+	}
 
-		if len(function.Parameters) != len(tt.expectedParams) {
-			t.Errorf("length parameters wrong. want %d, got=%d\n",
-				len(tt.expectedParams), len(function.Parameters))
-		}
+	alternative := exp.ElseIf.Alternative.Statements[0].(*ast.ExpressionStatement)
+	if !testIdentifier(t, alternative.Expression, "z") {
+		return
+	}
 
-		for i, ident := range tt.expectedParams {
-			testLiteralExpression(t, function.Parameters[i], ident)
-		}
+	expected := "if(x < y) xelse if(x > y) yelse z"
+	if program.String() != expected {
+		t.Errorf("program.String() wrong. expected=%q, got=%q", expected, program.String())
 	}
 }
 
-func TestCallExpressionParsing(t *testing.T) {
-	input := "add(1, 2 * 3, 4 + 5);"
+func TestSwitchExpressionParsing(t *testing.T) {
+	input := `switch (x) {
+	case 1, 2:
+		a;
+		fallthrough;
+	case 3:
+		b
+	default:
+		c
+	}`
 
 	l := lexer.New(input)
 	p := New(l)
@@ -601,31 +925,539 @@ func TestCallExpressionParsing(t *testing.T) {
 	checkParserErrors(t, p)
 
 	if len(program.Statements) != 1 {
-		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
-			1, len(program.Statements))
-	}
-
-	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
-	if !ok {
-		t.Fatalf("stmt is not ast.ExpressionStatement. got=%T",
-			program.Statements[0])
+		t.Fatalf("program.Statements does not contain 1 statements. got=%d",
+			len(program.Statements))
 	}
 
-	exp, ok := stmt.Expression.(*ast.CallExpression)
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.SwitchExpression)
 	if !ok {
-		t.Fatalf("stmt.Expression is not ast.CallExpression. got=%T",
-			stmt.Expression)
+		t.Fatalf("stmt.Expression is not ast.SwitchExpression. got=%T", stmt.Expression)
 	}
 
-	if !testIdentifier(t, exp.Function, "add") {
+	if !testIdentifier(t, exp.Subject, "x") {
 		return
 	}
 
-	if len(exp.Arguments) != 3 {
-		t.Fatalf("wrong length of arguments. got=%d", len(exp.Arguments))
+	if len(exp.Cases) != 3 {
+		t.Fatalf("exp.Cases does not contain 3 cases. got=%d", len(exp.Cases))
 	}
 
-	testLiteralExpression(t, exp.Arguments[0], 1)
+	tests := []struct {
+		values    []int64
+		body      string
+		isDefault bool
+		falls     bool
+	}{
+		{[]int64{1, 2}, "a", false, true},
+		{[]int64{3}, "b", false, false},
+		{nil, "c", true, false},
+	}
+
+	for i, tt := range tests {
+		c := exp.Cases[i]
+		if len(c.Values) != len(tt.values) {
+			t.Fatalf("exp.Cases[%d].Values wrong length. want=%d, got=%d",
+				i, len(tt.values), len(c.Values))
+		}
+		for j, v := range tt.values {
+			testIntegerLiteral(t, c.Values[j], v)
+		}
+		if c.Body.String() != tt.body {
+			t.Errorf("exp.Cases[%d].Body wrong. want=%q, got=%q", i, tt.body, c.Body.String())
+		}
+		if c.IsDefault() != tt.isDefault {
+			t.Errorf("exp.Cases[%d].IsDefault() wrong. want=%t", i, tt.isDefault)
+		}
+		if c.Fallthrough != tt.falls {
+			t.Errorf("exp.Cases[%d].Fallthrough wrong. want=%t", i, tt.falls)
+		}
+	}
+}
+
+func TestSwitchExpressionParsingErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"switch (x) { default: 1 default: 2 }",
+			"multiple defaults in switch",
+		},
+		{
+			"switch (x) { case 1: fallthrough; 2 case 2: 3 }",
+			"fallthrough statement out of place",
+		},
+		{
+			"switch (x) { case 1: 1 case 2: fallthrough }",
+			"cannot fallthrough final case in switch",
+		},
+		{
+			"switch (x) { 1 }",
+			"expected token to be CASE, got INT instead",
+		},
+		{
+			"switch (x) { case 1: 1",
+			"expected token to be }, got EOF instead",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("parser has no errors for %q", tt.input)
+			continue
+		}
+		if errors[0].Error() != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestFunctionLiteralParsing(t *testing.T) {
+	input := `fn(x, y) { x + y; }`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+
+	function, ok := stmt.Expression.(*ast.FunctionLiteral)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.FunctionLiteral. got=%T",
+			stmt.Expression)
+	}
+
+	if len(function.Parameters) != 2 {
+		t.Fatalf("function literal parameters wrong. want 2, got=%d\n",
+			len(function.Parameters))
+	}
+
+	testLiteralExpression(t, function.Parameters[0], "x")
+	testLiteralExpression(t, function.Parameters[1], "y")
+
+	if len(function.Body.Statements) != 1 {
+		t.Fatalf("function.Body.Statements has not 1 statements. got=%d\n",
+			len(function.Body.Statements))
+	}
+
+	bodyStmt, ok := function.Body.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("function body stmt is not ast.ExpressionStatement. got=%T",
+			function.Body.Statements[0])
+	}
+
+	testInfixExpression(t, bodyStmt.Expression, "x", "+", "y")
+}
+
+func TestLambdaLiteralParsing(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedParams []string
+		expectedBody   string
+	}{
+		{input: "|| 1", expectedParams: []string{}, expectedBody: "1"},
+		{input: "|x| x * 2", expectedParams: []string{"x"}, expectedBody: "(x * 2)"},
+		{input: "|x, y| { let z = x; z + y }", expectedParams: []string{"x", "y"}, expectedBody: "let z = x;(z + y)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		function, ok := stmt.Expression.(*ast.FunctionLiteral)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.FunctionLiteral. got=%T",
+				stmt.Expression)
+		}
+
+		if len(function.Parameters) != len(tt.expectedParams) {
+			t.Errorf("length parameters wrong. want %d, got=%d\n",
+				len(tt.expectedParams), len(function.Parameters))
+		}
+		for i, ident := range tt.expectedParams {
+			testLiteralExpression(t, function.Parameters[i], ident)
+		}
+
+		if function.Body.String() != tt.expectedBody {
+			t.Errorf("function.Body wrong. want %q, got=%q",
+				tt.expectedBody, function.Body.String())
+		}
+	}
+
+	for input, expected := range map[string]string{
+		"|x y| x":  "expected next token to be |, got IDENT instead",
+		"|1| x":    "expected next token to be IDENT, got INT instead",
+		"|x,| x":   "expected next token to be IDENT, got | instead",
+		"xs |> ":   "no prefix parse function for EOF found",
+		"|x| |> f": "no prefix parse function for |> found",
+	} {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0].Error() != expected {
+			t.Errorf("wrong errors for %q. expected=%q, got=%q", input, expected, errors)
+		}
+	}
+}
+
+func TestSelectExpressionParsing(t *testing.T) {
+	input := `select {
+	case let v = recv(a):
+		v
+	case send(b, 1 + 2):
+		0
+	default:
+	}`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.SelectExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.SelectExpression. got=%T", stmt.Expression)
+	}
+	if len(exp.Cases) != 3 {
+		t.Fatalf("exp.Cases does not contain 3 cases. got=%d", len(exp.Cases))
+	}
+	if exp.Cases[0].Name == nil || exp.Cases[0].Name.Value != "v" || exp.Cases[0].IsSend() {
+		t.Errorf("exp.Cases[0] is not a receive bound to v. got=%q", exp.Cases[0])
+	}
+	if !exp.Cases[1].IsSend() || exp.Cases[1].Name != nil {
+		t.Errorf("exp.Cases[1] is not a send. got=%q", exp.Cases[1])
+	}
+	if !exp.Cases[2].IsDefault() {
+		t.Errorf("exp.Cases[2] is not default. got=%q", exp.Cases[2])
+	}
+
+	expected := "select {case let v = recv(a): v case send(b, (1 + 2)): 0 default: }"
+	if exp.String() != expected {
+		t.Errorf("exp.String() wrong. expected=%q, got=%q", expected, exp.String())
+	}
+
+	for input, expected := range map[string]string{
+		"select { case f(a): 1 }":             "select case must be recv(channel) or send(channel, value), got f(a)",
+		"select { case let v = send(a, 1): }": "select case must be recv(channel) or send(channel, value), got send(a, 1)",
+		"select { case recv(a, b): }":         "select case must be recv(channel) or send(channel, value), got recv(a, b)",
+		"select { case recv(...a): }":         "select case must be recv(channel) or send(channel, value), got recv(...a)",
+		"select { default: 1 default: 2 }":    "multiple defaults in select",
+		"select { 1 }":                        "expected token to be CASE, got INT instead",
+	} {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0].Error() != expected {
+			t.Errorf("wrong errors for %q. expected=%q, got=%q", input, expected, errors)
+		}
+	}
+}
+
+func TestAsyncFunctionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"async fn fetch(x) { await get(x) + 1 }", "async fn fetch(x) ((await get(x)) + 1)"},
+		{"let f = async fn() { await x }", "let f = async fn() (await x);"},
+		{"async fn() { 1 }()", "async fn() 1()"},
+		{"async fn(x) { x } |> wrap", "(async fn(x) x |> wrap)"},
+		{"let f = async |x| await x", "let f = async |x| (await x);"},
+		{"await a + await b", "((await a) + (await b))"},
+		{"await f(x)[0]", "(await (f(x)[0]))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	p := New(lexer.New("async fn f() {}"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	stmt, ok := program.Statements[0].(*ast.FunctionStatement)
+	if !ok || !stmt.Function.Async {
+		t.Errorf("statement is not an async function declaration. got=%T", program.Statements[0])
+	}
+
+	for input, expected := range map[string]string{
+		"fn() { await x }":                   "await outside async function",
+		"async fn() { fn() { await x } }":    "await outside async function",
+		"async fn() { yield 1 }":             "yield in async function",
+		"async 1":                            "expected next token to be FUNCTION, got INT instead",
+		"async fn f() { |x| await x }":       "await outside async function",
+		"async fn f() { async |x| await x }": "",
+	} {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if expected == "" {
+			if len(errors) != 0 {
+				t.Errorf("unexpected errors for %q. got=%q", input, errors)
+			}
+			continue
+		}
+		if len(errors) == 0 || errors[0].Error() != expected {
+			t.Errorf("wrong errors for %q. expected=%q, got=%q", input, expected, errors)
+		}
+	}
+}
+
+func TestForExpressionParsing(t *testing.T) {
+	input := `for ([k, v] in pairs(h)) { k }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.ForExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.ForExpression. got=%T", stmt.Expression)
+	}
+	if _, ok := exp.Pattern.(*ast.ArrayPattern); !ok {
+		t.Errorf("exp.Pattern is not ast.ArrayPattern. got=%T", exp.Pattern)
+	}
+	if exp.Iterable.String() != "pairs(h)" {
+		t.Errorf("exp.Iterable is not %q. got=%q", "pairs(h)", exp.Iterable.String())
+	}
+	if exp.Body.String() != "k" {
+		t.Errorf("exp.Body is not %q. got=%q", "k", exp.Body.String())
+	}
+	if exp.String() != "for ([k, v] in pairs(h)) k" {
+		t.Errorf("exp.String() wrong. got=%q", exp.String())
+	}
+
+	for input, expected := range map[string]string{
+		"for x in xs { x }":   "expected next token to be (, got IDENT instead",
+		"for (x of xs) { x }": "expected next token to be IN, got IDENT instead",
+		"for (x in xs) x":     "expected next token to be {, got IDENT instead",
+	} {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0].Error() != expected {
+			t.Errorf("wrong errors for %q. expected=%q, got=%q", input, expected, errors)
+		}
+	}
+}
+
+func TestYieldExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input     string
+		generator bool
+		body      string
+	}{
+		{"fn() { yield 1 + 2; }", true, "yield (1 + 2)"},
+		{"fn() { yield; }", true, "yield"},
+		{"fn() { yield }", true, "yield"},
+		{"|x| yield x", true, "yield x"},
+		{"fn() { fn() { yield 1 } }", false, "fn() yield 1"},
+		{"fn() { 1 }", false, "1"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		function, ok := stmt.Expression.(*ast.FunctionLiteral)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.FunctionLiteral. got=%T", stmt.Expression)
+		}
+		if function.Generator != tt.generator {
+			t.Errorf("function.Generator wrong for %q. want %t, got=%t",
+				tt.input, tt.generator, function.Generator)
+		}
+		if function.Body.String() != tt.body {
+			t.Errorf("function.Body wrong. want %q, got=%q", tt.body, function.Body.String())
+		}
+	}
+
+	l := lexer.New("fn gen() { yield 1 }")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	if !program.Statements[0].(*ast.FunctionStatement).Function.Generator {
+		t.Errorf("function declaration is not a generator")
+	}
+
+	p = New(lexer.New("yield 1"))
+	p.ParseProgram()
+	errors := p.Errors()
+	if len(errors) != 1 || errors[0].Error() != "yield outside function" {
+		t.Errorf("wrong errors for yield outside function. got=%q", errors)
+	}
+}
+
+func TestFunctionStatementParsing(t *testing.T) {
+	input := `fn add(x, y) { x + y; };`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.FunctionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.FunctionStatement. got=%T",
+			program.Statements[0])
+	}
+
+	if !testIdentifier(t, stmt.Name, "add") {
+		return
+	}
+
+	if stmt.Function.Name != "add" {
+		t.Errorf("stmt.Function.Name is not %q. got=%q", "add", stmt.Function.Name)
+	}
+
+	if len(stmt.Function.Parameters) != 2 {
+		t.Fatalf("function parameters wrong. want 2, got=%d\n",
+			len(stmt.Function.Parameters))
+	}
+
+	testLiteralExpression(t, stmt.Function.Parameters[0], "x")
+	testLiteralExpression(t, stmt.Function.Parameters[1], "y")
+
+	if program.String() != "fn add(x, y) (x + y)" {
+		t.Errorf("program.String() wrong. got=%q", program.String())
+	}
+}
+
+func TestFunctionLiteralNames(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedName string
+	}{
+		{"let add = fn(x, y) { x + y };", "add"},
+		{"const add = fn(x, y) { x + y };", "add"},
+		{"let add = id(fn(x, y) { x + y });", ""},
+		{"fn(x, y) { x + y };", ""},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		var function *ast.FunctionLiteral
+		switch stmt := program.Statements[0].(type) {
+		case *ast.LetStatement:
+			function, _ = stmt.Value.(*ast.FunctionLiteral)
+			if call, ok := stmt.Value.(*ast.CallExpression); ok {
+				function = call.Arguments[0].(*ast.FunctionLiteral)
+			}
+		case *ast.ConstStatement:
+			function = stmt.Value.(*ast.FunctionLiteral)
+		case *ast.ExpressionStatement:
+			function = stmt.Expression.(*ast.FunctionLiteral)
+		}
+
+		if function.Name != tt.expectedName {
+			t.Errorf("function.Name wrong for %q. want=%q, got=%q",
+				tt.input, tt.expectedName, function.Name)
+		}
+	}
+}
+
+func TestFunctionParameterParsing(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedParams []string
+	}{
+		{input: "fn() {};", expectedParams: []string{}},
+		{input: "fn(x) {};", expectedParams: []string{"x"}},
+		{input: "fn(x, y, z) {};", expectedParams: []string{"x", "y", "z"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		function := stmt.Expression.(*ast.FunctionLiteral)
+
+		if len(function.Parameters) != len(tt.expectedParams) {
+			t.Errorf("length parameters wrong. want %d, got=%d\n",
+				len(tt.expectedParams), len(function.Parameters))
+		}
+
+		for i, ident := range tt.expectedParams {
+			testLiteralExpression(t, function.Parameters[i], ident)
+		}
+	}
+}
+
+func TestCallExpressionParsing(t *testing.T) {
+	input := "add(1, 2 * 3, 4 + 5);"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
+			1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("stmt is not ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+
+	exp, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.CallExpression. got=%T",
+			stmt.Expression)
+	}
+
+	if !testIdentifier(t, exp.Function, "add") {
+		return
+	}
+
+	if len(exp.Arguments) != 3 {
+		t.Fatalf("wrong length of arguments. got=%d", len(exp.Arguments))
+	}
+
+	testLiteralExpression(t, exp.Arguments[0], 1)
 	testInfixExpression(t, exp.Arguments[1], 2, "*", 3)
 	testInfixExpression(t, exp.Arguments[2], 4, "+", 5)
 }
@@ -684,6 +1516,58 @@ func TestCallExpressionParameterParsing(t *testing.T) {
 	}
 }
 
+func TestNullLiteralExpression(t *testing.T) {
+	l := lexer.New("null;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	if _, ok := stmt.Expression.(*ast.NullLiteral); !ok {
+		t.Fatalf("exp not *ast.NullLiteral. got=%T", stmt.Expression)
+	}
+}
+
+func TestParsingOptionalChains(t *testing.T) {
+	input := "a?.b?.[c]?.(d)"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	call, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok || !call.Optional {
+		t.Fatalf("exp not optional *ast.CallExpression. got=%T (%+v)", stmt.Expression, stmt.Expression)
+	}
+	index, ok := call.Function.(*ast.IndexExpression)
+	if !ok || !index.Optional {
+		t.Fatalf("call.Function not optional *ast.IndexExpression. got=%T (%+v)", call.Function, call.Function)
+	}
+	property, ok := index.Left.(*ast.PropertyExpression)
+	if !ok || !property.Optional {
+		t.Fatalf("index.Left not optional *ast.PropertyExpression. got=%T (%+v)", index.Left, index.Left)
+	}
+	testIdentifier(t, property.Left, "a")
+	testIdentifier(t, property.Property, "b")
+	testIdentifier(t, index.Index, "c")
+	testIdentifier(t, call.Arguments[0], "d")
+
+	for input, expected := range map[string]string{
+		"a?.[1:2]": "optional chaining is not supported for slice expression",
+		"a?.1":     "expected next token to be IDENT, got INT instead",
+		"a.(b)":    "expected next token to be IDENT, got ( instead",
+	} {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 || errors[0].Error() != expected {
+			t.Errorf("wrong errors for %q. expected=%q, got=%q", input, expected, errors)
+		}
+	}
+}
+
 func TestParsingArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 
@@ -730,6 +1614,59 @@ func TestParsingIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestParsingSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input string
+		start interface{}
+		end   interface{}
+		step  interface{}
+	}{
+		{"xs[1:3]", 1, 3, nil},
+		{"xs[:2]", nil, 2, nil},
+		{"xs[1:]", 1, nil, nil},
+		{"xs[:]", nil, nil, nil},
+		{"xs[::2]", nil, nil, 2},
+		{"xs[a:b:c]", "a", "b", "c"},
+		{"xs[1::]", 1, nil, nil},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		sliceExp, ok := stmt.Expression.(*ast.SliceExpression)
+		if !ok {
+			t.Fatalf("exp not *ast.SliceExpression. got=%T", stmt.Expression)
+		}
+
+		if !testIdentifier(t, sliceExp.Left, "xs") {
+			return
+		}
+
+		bounds := []struct {
+			name     string
+			actual   ast.Expression
+			expected interface{}
+		}{
+			{"Start", sliceExp.Start, tt.start},
+			{"End", sliceExp.End, tt.end},
+			{"Step", sliceExp.Step, tt.step},
+		}
+		for _, b := range bounds {
+			if b.expected == nil {
+				if b.actual != nil {
+					t.Errorf("%s of %q is not nil. got=%s", b.name, tt.input, b.actual)
+				}
+				continue
+			}
+			testLiteralExpression(t, b.actual, b.expected)
+		}
+	}
+}
+
 func TestParsingHashLiteralsStringKeys(t *testing.T) {
 	input := `{"one": 1, "two": 2, "three": 3}`
 
@@ -825,6 +1762,194 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 	}
 }
 
+func TestMatchExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"match (x) { 1 => a, _ => b }",
+			"match (x) {1 => a, _ => b}",
+		},
+		{
+			"match (x) { 1 | -2 | \"three\" => a, true => b, }",
+			"match (x) {1 | (-2) | three => a, true => b}",
+		},
+		{
+			"match (x) { n if n > 0 => n * 2, n => 0 }",
+			"match (x) {n if (n > 0) => (n * 2), n => 0}",
+		},
+		{
+			"match (xs) { [] => 0, [x] => x, [x, _, ...rest] => x, [...] => 1 }",
+			"match (xs) {[] => 0, [x] => x, [x, _, ...rest] => x, [...] => 1}",
+		},
+		{
+			`match (h) { {"a": 1, "b": [x, y]} => x, {"a": a, ...rest} => rest, {...} => 0 }`,
+			"match (h) {{a:1, b:[x, y]} => x, {a:a, ...rest} => rest, {...} => 0}",
+		},
+		{
+			"match (h) { {Status.Done: d, ...} => d }",
+			"match (h) {{(Status.Done):d, ...} => d}",
+		},
+		{
+			"match (r) { Result.Ok([x, _]) | Result.Ok(x) => x, Result.Err(e) if e => e, Result.None => 0 }",
+			"match (r) {Result.Ok([x, _]) | Result.Ok(x) => x, Result.Err(e) if e => e, Result.None => 0}",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		if _, ok := stmt.Expression.(*ast.MatchExpression); !ok {
+			t.Fatalf("stmt.Expression is not ast.MatchExpression. got=%T",
+				stmt.Expression)
+		}
+
+		actual := program.String()
+		if actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestMatchExpressionParsingErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"match (x) { _ => 1, 2 => 2 }",
+			"unreachable match arm after catch-all pattern: 2 => 2",
+		},
+		{
+			"match (x) { 1 | n => 1, 2 => 2 }",
+			"unreachable match arm after catch-all pattern: 2 => 2",
+		},
+		{
+			"match (x) { [a, ...rest, b] => 1 }",
+			"expected next token to be ], got , instead",
+		},
+		{
+			"match (x) { fn => 1 }",
+			"no pattern starts with FUNCTION",
+		},
+		{
+			"match (x) { Result.1 => 1 }",
+			"expected next token to be IDENT, got INT instead",
+		},
+		{
+			"match (x) { Result.Ok(a b) => 1 }",
+			"expected next token to be ,, got IDENT instead",
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("parser has no errors for %q", tt.input)
+			continue
+		}
+		if errors[0].Error() != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, errors[0])
+		}
+	}
+}
+
+func TestMatchExpressionGuardAllowsLaterArms(t *testing.T) {
+	input := "match (x) { n if n > 0 => 1, _ => 2 }"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp := stmt.Expression.(*ast.MatchExpression)
+	if len(exp.Arms) != 2 {
+		t.Fatalf("exp.Arms does not contain 2 arms. got=%d", len(exp.Arms))
+	}
+	if _, ok := exp.Arms[0].Pattern.(*ast.BindingPattern); !ok {
+		t.Errorf("exp.Arms[0].Pattern is not ast.BindingPattern. got=%T",
+			exp.Arms[0].Pattern)
+	}
+	if !testInfixExpression(t, exp.Arms[0].Guard, "n", ">", 0) {
+		return
+	}
+	if _, ok := exp.Arms[1].Pattern.(*ast.WildcardPattern); !ok {
+		t.Errorf("exp.Arms[1].Pattern is not ast.WildcardPattern. got=%T",
+			exp.Arms[1].Pattern)
+	}
+}
+
+func TestParsingSpreadElements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[...a, x, ...b]", "[...a, x, ...b]"},
+		{"[...a + b]", "[...(a + b)]"},
+		{"f(...args)", "f(...args)"},
+		{"f(x, ...rest(xs))", "f(x, ...rest(xs))"},
+		{`{...defaults, "a": 1, ...overrides}`, "{...defaults, a:1, ...overrides}"},
+		{`{...defaults}`, "{...defaults}"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		actual := program.String()
+		if actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestParsingHashLiteralSpreadOrder(t *testing.T) {
+	input := `{...defaults, "a": 1, ...overrides}`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	hash, ok := stmt.Expression.(*ast.HashLiteral)
+	if !ok {
+		t.Fatalf("exp is not ast.HashLiteral. got=%T", stmt.Expression)
+	}
+	if len(hash.Pairs) != 1 {
+		t.Errorf("hash.Pairs has wrong length. got=%d", len(hash.Pairs))
+	}
+	if len(hash.Keys) != 3 {
+		t.Fatalf("hash.Keys has wrong length. got=%d", len(hash.Keys))
+	}
+	for i, name := range []string{"defaults", "", "overrides"} {
+		if name == "" {
+			if _, ok := hash.Keys[i].(*ast.StringLiteral); !ok {
+				t.Errorf("hash.Keys[%d] is not ast.StringLiteral. got=%T", i, hash.Keys[i])
+			}
+			continue
+		}
+		spread, ok := hash.Keys[i].(*ast.SpreadElement)
+		if !ok {
+			t.Errorf("hash.Keys[%d] is not ast.SpreadElement. got=%T", i, hash.Keys[i])
+			continue
+		}
+		testIdentifier(t, spread.Value, name)
+	}
+}
+
 func testLetStatement(t *testing.T, s ast.Statement, name string) bool {
 	if s.TokenLiteral() != "let" {
 		t.Errorf("s.TokenLiteral not 'let'. got=%q", s.TokenLiteral())
@@ -955,6 +2080,47 @@ func testIdentifier(t *testing.T, exp ast.Expression, value string) bool {
 	return true
 }
 
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let = 1", "1:5: expected next token to be IDENT, got = instead"},
+		{"let x = 1;\nx + * 2", "2:5: no prefix parse function for * found"},
+		{"let x = 1; 1 = x", "1:12: invalid assignment target: 1"},
+		{"struct P { x, x }", "1:15: duplicate field x in struct P"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("no errors for %q", tt.input)
+			continue
+		}
+		err, ok := errors[0].(*Error)
+		if !ok {
+			t.Errorf("error is not *Error. got=%T", errors[0])
+			continue
+		}
+		if actual := fmt.Sprintf("%s: %s", err.Pos, err); actual != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestAssignmentToFailedExpression(t *testing.T) {
+	// 左辺の解析に失敗しても代入の解析で panic せず、エラーを返す
+	for _, input := range []string{"switch = 1", "match =", "* = 1", "let x = 1; x + * = 2"} {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		if len(p.Errors()) == 0 {
+			t.Errorf("no errors for %q", input)
+		}
+	}
+}
+
 func checkParserErrors(t *testing.T, p *Parser) {
 	errors := p.Errors()
 	if len(errors) == 0 {
@@ -0,0 +1,174 @@
+package profiler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// The field numbers of profile.proto used by pprof
+const (
+	profileSampleType        = 1
+	profileSample            = 2
+	profileLocation          = 4
+	profileFunction          = 5
+	profileStringTable       = 6
+	profileTimeNanos         = 9
+	profileDurationNanos     = 10
+	profilePeriodType        = 11
+	profilePeriod            = 12
+	profileDefaultSampleType = 14
+
+	valueTypeType = 1
+	valueTypeUnit = 2
+
+	sampleLocationID = 1
+	sampleValue      = 2
+
+	locationID   = 1
+	locationLine = 4
+
+	lineFunctionID = 1
+	lineLine       = 2
+
+	functionID         = 1
+	functionName       = 2
+	functionSystemName = 3
+	functionFilename   = 4
+	functionStartLine  = 5
+)
+
+// WriteProfile writes the samples as the gzipped profile.proto, which go tool pprof reads.
+// Each sample has the number of calls and the time in nanoseconds.
+func (p *Profiler) WriteProfile(w io.Writer) error {
+	samples := p.Samples()
+	p.mu.Lock()
+	begin, duration := p.begin, p.duration
+	p.mu.Unlock()
+
+	indices := map[string]int64{"": 0}
+	table := []string{""}
+	str := func(s string) int64 {
+		i, ok := indices[s]
+		if !ok {
+			i = int64(len(table))
+			indices[s] = i
+			table = append(table, s)
+		}
+		return i
+	}
+
+	var out protobuf
+	valueType := func(field int, typ, unit string) {
+		var vt protobuf
+		vt.int64(valueTypeType, str(typ))
+		vt.int64(valueTypeUnit, str(unit))
+		out.message(field, &vt)
+	}
+	valueType(profileSampleType, "calls", "count")
+	valueType(profileSampleType, "time", "nanoseconds")
+
+	functions := map[Function]uint64{}
+	locations := map[Frame]uint64{}
+	for _, sample := range samples {
+		ids := make([]uint64, len(sample.Stack))
+		for i, frame := range sample.Stack {
+			fnID, ok := functions[frame.Function]
+			if !ok {
+				fnID = uint64(len(functions) + 1)
+				functions[frame.Function] = fnID
+				var fn protobuf
+				fn.uint64(functionID, fnID)
+				fn.int64(functionName, str(frame.Function.Name))
+				fn.int64(functionSystemName, str(frame.Function.Name))
+				fn.int64(functionFilename, str(p.File))
+				fn.int64(functionStartLine, int64(frame.Function.Line))
+				out.message(profileFunction, &fn)
+			}
+			locID, ok := locations[frame]
+			if !ok {
+				locID = uint64(len(locations) + 1)
+				locations[frame] = locID
+				var line, loc protobuf
+				line.uint64(lineFunctionID, fnID)
+				line.int64(lineLine, int64(frame.Line))
+				loc.uint64(locationID, locID)
+				loc.message(locationLine, &line)
+				out.message(profileLocation, &loc)
+			}
+			ids[i] = locID
+		}
+		var s protobuf
+		s.packed(sampleLocationID, ids)
+		s.packed(sampleValue, []uint64{uint64(sample.Calls), uint64(sample.Time.Nanoseconds())})
+		out.message(profileSample, &s)
+	}
+
+	out.int64(profileTimeNanos, begin.UnixNano())
+	out.int64(profileDurationNanos, duration.Nanoseconds())
+	valueType(profilePeriodType, "time", "nanoseconds")
+	out.int64(profilePeriod, 1)
+	out.int64(profileDefaultSampleType, str("time"))
+	for _, s := range table {
+		out.string(profileStringTable, s)
+	}
+
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(out.Bytes()); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// protobuf encodes the fields of a protocol buffers message
+type protobuf struct {
+	bytes.Buffer
+}
+
+func (b *protobuf) varint(v uint64) {
+	for v >= 0x80 {
+		b.WriteByte(byte(v) | 0x80)
+		v >>= 7
+	}
+	b.WriteByte(byte(v))
+}
+
+func (b *protobuf) key(field int, wireType uint64) {
+	b.varint(uint64(field)<<3 | wireType)
+}
+
+// int64 writes the varint field, omitting the default value 0
+func (b *protobuf) int64(field int, v int64) {
+	if v != 0 {
+		b.key(field, 0)
+		b.varint(uint64(v))
+	}
+}
+
+func (b *protobuf) uint64(field int, v uint64) {
+	b.int64(field, int64(v))
+}
+
+// packed writes the repeated varint field
+func (b *protobuf) packed(field int, vs []uint64) {
+	var values protobuf
+	for _, v := range vs {
+		values.varint(v)
+	}
+	b.bytes(field, values.Bytes())
+}
+
+// string writes the string field even if it is empty, since the string table starts with ""
+func (b *protobuf) string(field int, s string) {
+	b.bytes(field, []byte(s))
+}
+
+func (b *protobuf) message(field int, m *protobuf) {
+	b.bytes(field, m.Bytes())
+}
+
+func (b *protobuf) bytes(field int, data []byte) {
+	b.key(field, 2)
+	b.varint(uint64(len(data)))
+	b.Write(data)
+}
@@ -0,0 +1,251 @@
+package profiler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tshinag/monkey/ast"
+	"github.com/tshinag/monkey/evaluator"
+	"github.com/tshinag/monkey/object"
+)
+
+// mainFunction is the name of the top level of the script in the profile.
+// The names don't have angle brackets, which pprof strips as template parameters.
+const mainFunction = "main"
+
+// Function is the function of the script
+type Function struct {
+	Name string
+	Line int // 定義の行。トップレベルは 1
+}
+
+func (f Function) String() string {
+	return fmt.Sprintf("%s:%d", f.Name, f.Line)
+}
+
+// Frame is the function on the stack and the line being evaluated in it,
+// which is the call site of the next frame, or the start of the function for the innermost one
+type Frame struct {
+	Function Function
+	Line     int
+}
+
+// Sample is the calls with the same stack, which is the innermost first and ends with the top level
+type Sample struct {
+	Stack []Frame
+	Calls int64
+	Time  time.Duration // 呼び出された関数自身で経過した時間。内側の呼び出しの時間は含まない
+}
+
+// CallSite is the calls of the function from the same line
+type CallSite struct {
+	Function Function
+	Caller   Function
+	Line     int // 呼び出した行。組み込み関数や演算子からの呼び出しは 0
+	Calls    int64
+	Time     time.Duration // 内側の呼び出しを含む時間
+}
+
+// call is the function call in progress
+type call struct {
+	function  Function
+	site      int         // 呼び出した行
+	key       string      // 呼び出し元と行を含む CallSite のキー
+	coroutine interface{} // 呼び出しを積んだコルーチン。メインのスクリプトは nil
+	parent    *call
+	begin     time.Time
+	children  time.Duration
+}
+
+// Profiler measures the wall-clock time and the number of calls of the functions
+// with their stacks and call sites.
+//
+// Generators and async functions have their own stacks, and the calls in them
+// are recorded as called from the top level. Tasks started by spawn share the stack
+// with the main script, so the stacks of the calls running concurrently are approximate.
+type Profiler struct {
+	File string // プロファイルに記録するスクリプトのパス
+
+	mu        sync.Mutex
+	now       func() time.Time
+	begin     time.Time
+	duration  time.Duration
+	current   map[interface{}]*call // コルーチンごとの最も内側の呼び出し
+	coroutine interface{}           // 評価中のコルーチン
+	children  time.Duration         // トップレベルから呼び出した関数の時間
+	samples   map[string]*Sample
+	sites     map[string]*CallSite
+}
+
+// Start attaches a new profiler of the script at the path to the evaluator
+func Start(file string) *Profiler {
+	p := newProfiler(file, time.Now)
+	evaluator.SetProfiler(p)
+	return p
+}
+
+func newProfiler(file string, now func() time.Time) *Profiler {
+	return &Profiler{
+		File:    file,
+		now:     now,
+		begin:   now(),
+		current: make(map[interface{}]*call),
+		samples: make(map[string]*Sample),
+		sites:   make(map[string]*CallSite),
+	}
+}
+
+// Stop detaches the profiler, and records the time spent in the top level
+func (p *Profiler) Stop() {
+	evaluator.SetProfiler(nil)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.duration = p.now().Sub(p.begin)
+	main := Function{Name: mainFunction, Line: 1}
+	p.record([]Frame{{Function: main, Line: 1}}, p.duration-p.children)
+}
+
+// Enter implements evaluator.Profiler
+func (p *Profiler) Enter(fn *object.Function, site ast.Node) func() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c := &call{function: functionOf(fn), coroutine: p.coroutine, parent: p.current[p.coroutine], begin: p.now()}
+	if site != nil {
+		c.site = site.Pos().Line
+	}
+	caller := Function{Name: mainFunction, Line: 1}
+	if c.parent != nil {
+		caller = c.parent.function
+	}
+	c.key = fmt.Sprintf("%s %s %d", c.function, caller, c.site)
+	p.current[p.coroutine] = c
+	return func() {
+		p.exit(c)
+	}
+}
+
+// Switch implements evaluator.Profiler
+func (p *Profiler) Switch(coroutine interface{}) func() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	previous := p.coroutine
+	p.coroutine = coroutine
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.coroutine = previous
+	}
+}
+
+func functionOf(fn *object.Function) Function {
+	line := fn.Body.Pos().Line
+	if fn.Name == "" {
+		return Function{Name: fmt.Sprintf("anonymous@%d", line), Line: line}
+	}
+	return Function{Name: fn.Name, Line: line}
+}
+
+func (p *Profiler) exit(c *call) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	elapsed := p.now().Sub(c.begin)
+	if c.parent != nil {
+		c.parent.children += elapsed
+	} else {
+		p.children += elapsed
+	}
+	if c.parent != nil {
+		p.current[c.coroutine] = c.parent
+	} else {
+		delete(p.current, c.coroutine)
+	}
+
+	stack := []Frame{{Function: c.function, Line: c.function.Line}}
+	for callee := c; ; callee = callee.parent {
+		caller := Function{Name: mainFunction, Line: 1}
+		if callee.parent != nil {
+			caller = callee.parent.function
+		}
+		line := callee.site
+		if line == 0 {
+			line = caller.Line
+		}
+		stack = append(stack, Frame{Function: caller, Line: line})
+		if callee.parent == nil {
+			break
+		}
+	}
+	p.record(stack, elapsed-c.children)
+
+	site, ok := p.sites[c.key]
+	if !ok {
+		site = &CallSite{Function: c.function, Caller: stack[1].Function, Line: c.site}
+		p.sites[c.key] = site
+	}
+	site.Calls++
+	// 再帰呼び出しで同じ呼び出し元の時間を重ねて数えないように、一番外側の呼び出しだけ時間を足す
+	for outer := c.parent; outer != nil; outer = outer.parent {
+		if outer.key == c.key {
+			return
+		}
+	}
+	site.Time += elapsed
+}
+
+func (p *Profiler) record(stack []Frame, self time.Duration) {
+	keys := make([]string, len(stack))
+	for i, f := range stack {
+		keys[i] = fmt.Sprintf("%s@%d", f.Function, f.Line)
+	}
+	key := strings.Join(keys, " ")
+	sample, ok := p.samples[key]
+	if !ok {
+		sample = &Sample{Stack: stack}
+		p.samples[key] = sample
+	}
+	sample.Calls++
+	sample.Time += self
+}
+
+// Samples returns the samples sorted by the stacks
+func (p *Profiler) Samples() []*Sample {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	keys := make([]string, 0, len(p.samples))
+	for key := range p.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	samples := make([]*Sample, len(keys))
+	for i, key := range keys {
+		samples[i] = p.samples[key]
+	}
+	return samples
+}
+
+// CallSites returns the call sites, the longest time first
+func (p *Profiler) CallSites() []*CallSite {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	sites := make([]*CallSite, 0, len(p.sites))
+	for _, site := range p.sites {
+		sites = append(sites, site)
+	}
+	sort.Slice(sites, func(i, j int) bool {
+		a, b := sites[i], sites[j]
+		if a.Time != b.Time {
+			return a.Time > b.Time
+		}
+		if a.Function != b.Function {
+			return a.Function.String() < b.Function.String()
+		}
+		if a.Caller != b.Caller {
+			return a.Caller.String() < b.Caller.String()
+		}
+		return a.Line < b.Line
+	})
+	return sites
+}
@@ -0,0 +1,168 @@
+package profiler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tshinag/monkey/evaluator"
+	"github.com/tshinag/monkey/lexer"
+	"github.com/tshinag/monkey/object"
+	"github.com/tshinag/monkey/parser"
+)
+
+const testScript = `let fact = fn(n) {
+  if (n < 2) { return 1 }
+  n * fact(n - 1)
+}
+let twice = fn(x) { x * 2 }
+fact(3) + twice(1);
+[1, 2] |> map(fn(x) { twice(x) })`
+
+// profile runs the script with the clock advancing 1ms on each reading
+func profile(t *testing.T, source string) *Profiler {
+	t.Helper()
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has errors: %v", p.Errors())
+	}
+	clock := time.Unix(0, 0)
+	profiler := newProfiler("test.mk", func() time.Time {
+		clock = clock.Add(time.Millisecond)
+		return clock
+	})
+	evaluator.SetProfiler(profiler)
+	evaluator.Eval(program, object.NewEnvironment())
+	profiler.Stop()
+	return profiler
+}
+
+func TestSamples(t *testing.T) {
+	p := profile(t, testScript)
+	expected := []string{
+		"anonymous@7:7 main:1@1 calls=2 time=4ms",
+		"fact:1 fact:1@3 fact:1@3 main:1@6 calls=1 time=1ms",
+		"fact:1 fact:1@3 main:1@6 calls=1 time=2ms",
+		"fact:1 main:1@6 calls=1 time=2ms",
+		"main:1@1 calls=1 time=5ms",
+		"twice:5 anonymous@7:7@7 main:1@1 calls=2 time=2ms",
+		"twice:5 main:1@6 calls=1 time=1ms",
+	}
+	actual := []string{}
+	for _, sample := range p.Samples() {
+		frames := []string{}
+		for i, f := range sample.Stack {
+			if i == 0 && f.Function.Name != mainFunction {
+				frames = append(frames, f.Function.String())
+				continue
+			}
+			frames = append(frames, fmt.Sprintf("%s@%d", f.Function, f.Line))
+		}
+		actual = append(actual, fmt.Sprintf("%s calls=%d time=%s", strings.Join(frames, " "), sample.Calls, sample.Time))
+	}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("wrong samples. expected=\n%s\ngot=\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+}
+
+func TestReport(t *testing.T) {
+	p := profile(t, testScript)
+	var out bytes.Buffer
+	if err := p.WriteReport(&out, 3); err != nil {
+		t.Fatalf("WriteReport failed: %s", err)
+	}
+	expected := `Total: 17.00ms, 4 functions
+    flat   flat%      cum     cum%  calls  function
+  5.00ms  29.41%   5.00ms   29.41%      3  fact (test.mk:1)
+  5.00ms  29.41%  17.00ms  100.00%      1  main (test.mk:1)
+  4.00ms  23.53%   6.00ms   35.29%      2  anonymous@7 (test.mk:7)
+
+Call sites:
+    time  calls  function
+  6.00ms      2  anonymous@7 from main by a builtin or an operator
+  5.00ms      1  fact from main at test.mk:6
+  3.00ms      2  fact from fact at test.mk:3
+`
+	if out.String() != expected {
+		t.Errorf("wrong report. expected=\n%s\ngot=\n%s", expected, out.String())
+	}
+}
+
+func TestGoroutines(t *testing.T) {
+	// ジェネレータの本体の呼び出しは、next を呼んだ pull の内側ではなくジェネレータのスタックに積む
+	p := profile(t, `let id = fn(x) { x }
+let gen = fn() { yield id(1); yield id(2) }
+let pull = fn(it) { next(it) }
+let it = gen()
+pull(it) + pull(it)`)
+	actual := []string{}
+	for _, site := range p.CallSites() {
+		actual = append(actual, fmt.Sprintf("%s from %s at %d calls=%d", site.Function.Name, site.Caller.Name, site.Line, site.Calls))
+	}
+	expected := []string{
+		"pull from main at 5 calls=2",
+		"id from main at 2 calls=2",
+	}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("wrong call sites. expected=\n%s\ngot=\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+}
+
+func TestWriteProfile(t *testing.T) {
+	p := profile(t, testScript)
+	var out bytes.Buffer
+	if err := p.WriteProfile(&out); err != nil {
+		t.Fatalf("WriteProfile failed: %s", err)
+	}
+	gz, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatalf("profile is not gzipped: %s", err)
+	}
+	data, _ := ioutil.ReadAll(gz)
+
+	// トップレベルのフィールドの数と文字列表を読む
+	counts := map[uint64]int{}
+	table := []string{}
+	for len(data) > 0 {
+		key, n := varint(data)
+		data = data[n:]
+		field, wireType := key>>3, key&7
+		counts[field]++
+		switch wireType {
+		case 0:
+			_, n = varint(data)
+			data = data[n:]
+		case 2:
+			length, n := varint(data)
+			if field == profileStringTable {
+				table = append(table, string(data[n:n+int(length)]))
+			}
+			data = data[n+int(length):]
+		default:
+			t.Fatalf("unexpected wire type %d", wireType)
+		}
+	}
+	if counts[profileSampleType] != 2 || counts[profileSample] != 7 || counts[profileFunction] != 4 || counts[profileLocation] != 6 {
+		t.Errorf("wrong number of fields: %v", counts)
+	}
+	expected := []string{"", "calls", "count", "time", "nanoseconds", "anonymous@7", "test.mk", "main", "fact", "twice"}
+	if fmt.Sprint(table) != fmt.Sprint(expected) {
+		t.Errorf("wrong string table. expected=%q, got=%q", expected, table)
+	}
+}
+
+func varint(data []byte) (uint64, int) {
+	var v uint64
+	for i, b := range data {
+		v |= uint64(b&0x7f) << (7 * uint(i))
+		if b < 0x80 {
+			return v, i + 1
+		}
+	}
+	return v, len(data)
+}
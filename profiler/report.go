@@ -0,0 +1,109 @@
+package profiler
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// Entry is the total of the samples for a function
+type Entry struct {
+	Function Function
+	Flat     time.Duration // 関数自身の時間
+	Cum      time.Duration // 内側の呼び出しを含む時間
+	Calls    int64
+}
+
+// Top returns the n functions of the longest time spent in themselves, or all the functions if n <= 0
+func (p *Profiler) Top(n int) []*Entry {
+	entries := map[Function]*Entry{}
+	entry := func(f Function) *Entry {
+		e, ok := entries[f]
+		if !ok {
+			e = &Entry{Function: f}
+			entries[f] = e
+		}
+		return e
+	}
+	for _, sample := range p.Samples() {
+		leaf := entry(sample.Stack[0].Function)
+		leaf.Flat += sample.Time
+		leaf.Calls += sample.Calls
+		// 再帰呼び出しでは同じ関数を一度だけ数える
+		seen := map[Function]bool{}
+		for _, frame := range sample.Stack {
+			if !seen[frame.Function] {
+				seen[frame.Function] = true
+				entry(frame.Function).Cum += sample.Time
+			}
+		}
+	}
+
+	top := make([]*Entry, 0, len(entries))
+	for _, e := range entries {
+		top = append(top, e)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Flat != top[j].Flat {
+			return top[i].Flat > top[j].Flat
+		}
+		return top[i].Function.String() < top[j].Function.String()
+	})
+	if n > 0 && len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+// WriteReport writes the n functions and the n call sites of the longest time as text
+func (p *Profiler) WriteReport(w io.Writer, n int) error {
+	p.mu.Lock()
+	total := p.duration
+	p.mu.Unlock()
+	percent := func(d time.Duration) float64 {
+		if total == 0 {
+			return 0
+		}
+		return float64(d) * 100 / float64(total)
+	}
+
+	top := p.Top(0)
+	fmt.Fprintf(w, "Total: %s, %d functions\n", milliseconds(total), len(top))
+	if n > 0 && len(top) > n {
+		top = top[:n]
+	}
+	// 数値の列を右寄せにし、名前はその後に続ける
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "flat\tflat%%\tcum\tcum%%\tcalls\t  function\n")
+	for _, e := range top {
+		fmt.Fprintf(tw, "%s\t%.2f%%\t%s\t%.2f%%\t%d\t  %s (%s:%d)\n",
+			milliseconds(e.Flat), percent(e.Flat), milliseconds(e.Cum), percent(e.Cum), e.Calls,
+			e.Function.Name, p.File, e.Function.Line)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	sites := p.CallSites()
+	if n > 0 && len(sites) > n {
+		sites = sites[:n]
+	}
+	fmt.Fprintf(w, "\nCall sites:\n")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "time\tcalls\t  function\n")
+	for _, site := range sites {
+		at := "by a builtin or an operator"
+		if site.Line != 0 {
+			at = fmt.Sprintf("at %s:%d", p.File, site.Line)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t  %s from %s %s\n", milliseconds(site.Time), site.Calls, site.Function.Name, site.Caller.Name, at)
+	}
+	return tw.Flush()
+}
+
+// milliseconds formats the duration like "1.25ms"
+func milliseconds(d time.Duration) string {
+	return fmt.Sprintf("%.2fms", float64(d)/float64(time.Millisecond))
+}
@@ -4,11 +4,13 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/tshinag/monkey/evaluator"
 	"github.com/tshinag/monkey/lexer"
 	"github.com/tshinag/monkey/object"
 	"github.com/tshinag/monkey/parser"
+	"github.com/tshinag/monkey/types"
 )
 
 // MonkeyFace is the ASCII art of monkey lang.
@@ -28,10 +30,15 @@ const MonkeyFace = `            __,__
 // PROMPT is the prompt message for REPL
 const PROMPT = ">> "
 
+// TYPE is the command to print the inferred type of expression instead of evaluating it
+const TYPE = ":type "
+
 // Start starts REPL
 func Start(in io.Reader, out io.Writer) {
 	scanner := bufio.NewScanner(in)
 	env := object.NewEnvironment()
+	env.AllowRedeclaration()
+	inferer := types.NewInferer()
 
 	for {
 		fmt.Printf(PROMPT)
@@ -41,6 +48,10 @@ func Start(in io.Reader, out io.Writer) {
 		}
 
 		line := scanner.Text()
+		if strings.HasPrefix(line, TYPE) {
+			printType(out, inferer, strings.TrimPrefix(line, TYPE))
+			continue
+		}
 		l := lexer.New(line)
 		p := parser.New(l)
 
@@ -50,12 +61,38 @@ func Start(in io.Reader, out io.Writer) {
 			continue
 		}
 
+		// 型エラーがあっても評価する。推論は :type のために束縛を記録するだけ
+		inferer.Infer(program)
 		evaluated := evaluator.Eval(program, env)
 		if evaluated != nil {
 			io.WriteString(out, evaluated.Inspect())
 			io.WriteString(out, "\n")
 		}
+		// 入力した行が登録したタイマーやタスクを、次の入力の前に実行する
+		if err := evaluator.RunEventLoop(); err != nil {
+			io.WriteString(out, err.Inspect())
+			io.WriteString(out, "\n")
+		}
+	}
+}
+
+func printType(out io.Writer, inferer *types.Inferer, input string) {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		printParserErrors(out, p.Errors())
+		return
+	}
+
+	t, errors := inferer.Query(program)
+	if len(errors) != 0 {
+		io.WriteString(out, " type errors:\n")
+		for _, err := range errors {
+			io.WriteString(out, "\t"+err.Error()+"\n")
+		}
+		return
 	}
+	io.WriteString(out, t.String()+"\n")
 }
 
 func printParserErrors(out io.Writer, errors []error) {
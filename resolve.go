@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/tshinag/monkey/resolver"
+)
+
+// resolve reports the scope problems of the scripts without running them.
+// Only the errors make the exit code non-zero.
+func resolve(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintf(stderr, "usage: monkey resolve FILE...\n")
+		return 2
+	}
+	status := 0
+	for _, path := range args {
+		program, ok := parseFile(path, stderr)
+		if !ok {
+			status = 1
+			continue
+		}
+		result := resolver.Resolve(program)
+		for _, d := range result.Diagnostics {
+			fmt.Fprintf(stdout, "%s:%s\n", path, d)
+		}
+		if len(result.Errors()) != 0 {
+			status = 1
+		}
+	}
+	return status
+}
@@ -0,0 +1,63 @@
+package resolver
+
+import "github.com/tshinag/monkey/ast"
+
+// Kind is the kind of declaration
+type Kind int
+
+const (
+	// Let is the variable declared by let
+	Let Kind = iota
+	// Const is the constant declared by const
+	Const
+	// Function is the function declared by fn statement
+	Function
+	// Parameter is the parameter of function
+	Parameter
+	// PatternBinding is the name bound by pattern of match or for
+	PatternBinding
+	// Struct is the struct declaration
+	Struct
+	// Class is the class declaration
+	Class
+	// Enum is the enum declaration
+	Enum
+	// Implicit is the name bound without declaration, such as self in class methods
+	Implicit
+)
+
+var kindNames = map[Kind]string{
+	Let:            "let",
+	Const:          "const",
+	Function:       "fn",
+	Parameter:      "parameter",
+	PatternBinding: "pattern",
+	Struct:         "struct",
+	Class:          "class",
+	Enum:           "enum",
+	Implicit:       "implicit",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// Binding is the declaration of name
+type Binding struct {
+	Name        *ast.Identifier // 宣言された識別子。Implicit なら nil
+	Kind        Kind
+	Declaration ast.Node          // 宣言している文、関数リテラル、またはパターン
+	Uses        []*ast.Identifier // この宣言を参照している識別子。代入先も含む
+	reads       int               // 値を読む参照の数
+	writes      int               // 代入の数
+}
+
+// Assigned reports whether the name is rebound by assignments after the declaration
+func (b *Binding) Assigned() bool {
+	return b.writes > 0
+}
+
+// used reports whether the value is read
+func (b *Binding) used() bool {
+	return b.reads > 0
+}
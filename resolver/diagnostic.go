@@ -0,0 +1,31 @@
+package resolver
+
+import (
+	"fmt"
+
+	"github.com/tshinag/monkey/token"
+)
+
+// Severity is the severity of diagnostic
+type Severity int
+
+const (
+	// Error means that the program fails when the code runs
+	Error Severity = iota
+	// Warning means that the code is suspicious
+	Warning
+)
+
+// Diagnostic is the problem found by Resolve
+type Diagnostic struct {
+	Pos      token.Position
+	Severity Severity
+	Message  string
+}
+
+func (d *Diagnostic) Error() string {
+	if d.Severity == Warning {
+		return fmt.Sprintf("%s: warning: %s", d.Pos, d.Message)
+	}
+	return fmt.Sprintf("%s: %s", d.Pos, d.Message)
+}
@@ -0,0 +1,415 @@
+package resolver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tshinag/monkey/ast"
+	"github.com/tshinag/monkey/evaluator"
+	"github.com/tshinag/monkey/token"
+)
+
+// Result is the result of Resolve
+type Result struct {
+	Bindings    []*Binding                   // 宣言順の束縛
+	Uses        map[*ast.Identifier]*Binding // 参照している識別子と、その宣言。組み込み関数への参照は含まない
+	Diagnostics []*Diagnostic                // 位置順の問題
+}
+
+// Errors returns the diagnostics of severity Error
+func (r *Result) Errors() []*Diagnostic {
+	errors := []*Diagnostic{}
+	for _, d := range r.Diagnostics {
+		if d.Severity == Error {
+			errors = append(errors, d)
+		}
+	}
+	return errors
+}
+
+// Resolve resolves the identifiers of program in the same lexical scopes as the evaluator,
+// and reports undefined names, redeclarations and assignments to constants as errors,
+// and unused local bindings and shadowing as warnings.
+//
+// The names referred in a function may be declared after it in the enclosing scopes,
+// since they are looked up when the function is called.
+func Resolve(program *ast.Program) *Result {
+	r := &resolver{
+		result:   &Result{Uses: make(map[*ast.Identifier]*Binding)},
+		builtins: evaluator.BuiltinNames(),
+	}
+	r.scope = newScope(nil, false)
+	r.resolveStatements(program.Statements)
+	r.leaveScope()
+
+	// 宣言より前の参照は後から解決されるので、位置順に並べ直す
+	for _, b := range r.result.Bindings {
+		sort.SliceStable(b.Uses, func(i, j int) bool {
+			return before(b.Uses[i].Pos(), b.Uses[j].Pos())
+		})
+	}
+	sort.SliceStable(r.result.Diagnostics, func(i, j int) bool {
+		return before(r.result.Diagnostics[i].Pos, r.result.Diagnostics[j].Pos)
+	})
+	return r.result
+}
+
+func before(a, b token.Position) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+}
+
+type resolver struct {
+	result   *Result
+	scope    *scope
+	builtins []string
+}
+
+func (r *resolver) errorf(node ast.Node, format string, args ...interface{}) {
+	r.report(node, Error, format, args...)
+}
+
+func (r *resolver) warnf(node ast.Node, format string, args ...interface{}) {
+	r.report(node, Warning, format, args...)
+}
+
+func (r *resolver) report(node ast.Node, severity Severity, format string, args ...interface{}) {
+	r.result.Diagnostics = append(r.result.Diagnostics, &Diagnostic{
+		Pos:      node.Pos(),
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (r *resolver) enterScope(function bool) {
+	r.scope = newScope(r.scope, function)
+}
+
+// leaveScope resolves the references waiting for the declarations in the scope,
+// and reports the unused bindings
+func (r *resolver) leaveScope() {
+	s := r.scope
+	for _, ref := range s.pending {
+		if b, ok := s.bindings[ref.ident.Value]; ok {
+			r.use(ref, b)
+		} else if s.outer != nil {
+			s.outer.pending = append(s.outer.pending, ref)
+		} else {
+			r.undefined(ref)
+		}
+	}
+
+	// グローバルな束縛は REPL や他のスクリプトから使われうるので報告しない
+	if s.outer != nil {
+		for _, b := range s.declared {
+			if !b.used() && b.Kind != Parameter && !strings.HasPrefix(b.Name.Value, "_") {
+				r.warnf(b.Name, "declared and not used: %s", b.Name.Value)
+			}
+		}
+	}
+	r.scope = s.outer
+}
+
+func (r *resolver) isBuiltin(name string) bool {
+	i := sort.SearchStrings(r.builtins, name)
+	return i < len(r.builtins) && r.builtins[i] == name
+}
+
+// declare binds the name in the current scope
+func (r *resolver) declare(name *ast.Identifier, kind Kind, declaration ast.Node) *Binding {
+	if prev, ok := r.scope.bindings[name.Value]; ok {
+		switch {
+		case prev.Kind == PatternBinding && kind == PatternBinding:
+			// "a | a" のような選択パターンは同じ名前を束縛する
+			return prev
+		case declares(prev.Kind) && declares(kind):
+			r.errorf(name, "identifier already declared: %s", name.Value)
+		}
+	} else if !strings.HasPrefix(name.Value, "_") {
+		if outer, ok := r.scope.outer.lookup(name.Value); ok && outer.Kind != Implicit {
+			r.warnf(name, "%s shadows the declaration at %s", name.Value, outer.Name.Pos())
+		} else if !ok && r.isBuiltin(name.Value) {
+			r.warnf(name, "%s shadows the builtin function", name.Value)
+		}
+	}
+
+	b := &Binding{Name: name, Kind: kind, Declaration: declaration}
+	r.scope.bindings[name.Value] = b
+	r.scope.declared = append(r.scope.declared, b)
+	r.result.Bindings = append(r.result.Bindings, b)
+	return b
+}
+
+// declares reports whether the kind of binding is declared by Environment.Declare,
+// which fails if the name is already declared in the scope
+func declares(kind Kind) bool {
+	switch kind {
+	case Let, Const, Function, Struct, Class, Enum:
+		return true
+	}
+	return false
+}
+
+func (r *resolver) declareImplicit(name string) {
+	r.scope.bindings[name] = &Binding{Kind: Implicit}
+}
+
+// resolveIdentifier resolves the identifier which is read, or assigned if write is true
+func (r *resolver) resolveIdentifier(ident *ast.Identifier, read, write bool) {
+	ref := &reference{ident: ident, from: r.scope, read: read, write: write}
+	if b, ok := r.scope.lookup(ident.Value); ok {
+		r.use(ref, b)
+		return
+	}
+	if s := r.scope.deferred(); s != nil {
+		s.pending = append(s.pending, ref)
+		return
+	}
+	r.undefined(ref)
+}
+
+func (r *resolver) use(ref *reference, b *Binding) {
+	if b.Kind == Implicit {
+		return
+	}
+	if ref.write && b.Kind == Const {
+		r.errorf(ref.ident, "cannot assign to constant: %s", ref.ident.Value)
+	}
+	b.Uses = append(b.Uses, ref.ident)
+	if ref.read {
+		b.reads++
+	}
+	if ref.write {
+		b.writes++
+	}
+	r.result.Uses[ref.ident] = b
+}
+
+func (r *resolver) undefined(ref *reference) {
+	name := ref.ident.Value
+	if r.isBuiltin(name) {
+		return
+	}
+	candidates := append(ref.from.names(), r.builtins...)
+	if s := suggest(name, candidates); s != "" {
+		r.errorf(ref.ident, "identifier not found: %s, did you mean %s?", name, s)
+		return
+	}
+	r.errorf(ref.ident, "identifier not found: %s", name)
+}
+
+func (r *resolver) resolveStatements(statements []ast.Statement) {
+	// 評価器と同様に関数宣言を巻き上げる
+	for _, statement := range statements {
+		if fs, ok := statement.(*ast.FunctionStatement); ok {
+			r.declare(fs.Name, Function, fs)
+		}
+	}
+	for _, statement := range statements {
+		r.resolveStatement(statement)
+	}
+}
+
+func (r *resolver) resolveBlock(block *ast.BlockStatement) {
+	r.enterScope(false)
+	r.resolveStatements(block.Statements)
+	r.leaveScope()
+}
+
+func (r *resolver) resolveStatement(statement ast.Statement) {
+	switch s := statement.(type) {
+	case *ast.LetStatement:
+		r.resolveExpression(s.Value)
+		r.declare(s.Name, Let, s)
+	case *ast.ConstStatement:
+		r.resolveExpression(s.Value)
+		r.declare(s.Name, Const, s)
+	case *ast.ReturnStatement:
+		r.resolveExpression(s.ReturnValue)
+	case *ast.ExpressionStatement:
+		r.resolveExpression(s.Expression)
+	case *ast.FunctionStatement:
+		r.resolveFunction(s.Function)
+	case *ast.StructStatement:
+		r.declare(s.Name, Struct, s)
+	case *ast.ImplStatement:
+		r.resolveIdentifier(s.Name, true, false)
+		for _, method := range s.Methods {
+			r.resolveFunction(method.Function)
+		}
+	case *ast.ClassStatement:
+		if s.Superclass != nil {
+			r.resolveIdentifier(s.Superclass, true, false)
+		}
+		r.declare(s.Name, Class, s)
+		for _, method := range s.Methods {
+			// メソッドは self と super を束縛した環境で呼び出される
+			r.enterScope(false)
+			r.declareImplicit("self")
+			if s.Superclass != nil {
+				r.declareImplicit("super")
+			}
+			r.resolveFunction(method)
+			r.leaveScope()
+		}
+	case *ast.EnumStatement:
+		r.declare(s.Name, Enum, s)
+	}
+}
+
+func (r *resolver) resolveFunction(lit *ast.FunctionLiteral) {
+	r.enterScope(true)
+	for _, param := range lit.Parameters {
+		r.declare(param, Parameter, lit)
+	}
+	r.resolveBlock(lit.Body)
+	r.leaveScope()
+}
+
+func (r *resolver) resolveExpressions(expressions []ast.Expression) {
+	for _, e := range expressions {
+		r.resolveExpression(e)
+	}
+}
+
+func (r *resolver) resolveExpression(expression ast.Expression) {
+	switch e := expression.(type) {
+	case *ast.Identifier:
+		r.resolveIdentifier(e, true, false)
+	case *ast.PrefixExpression:
+		r.resolveExpression(e.Right)
+	case *ast.InfixExpression:
+		r.resolveExpression(e.Left)
+		r.resolveExpression(e.Right)
+	case *ast.AssignExpression:
+		if target, ok := e.Target.(*ast.Identifier); ok {
+			// 複合代入は変数の値も読む
+			r.resolveIdentifier(target, e.Operator != "=", true)
+		} else {
+			r.resolveExpression(e.Target)
+		}
+		r.resolveExpression(e.Value)
+	case *ast.FunctionLiteral:
+		r.resolveFunction(e)
+	case *ast.CallExpression:
+		r.resolveExpression(e.Function)
+		r.resolveExpressions(e.Arguments)
+	case *ast.PipeExpression:
+		r.resolveExpression(e.Left)
+		r.resolveExpression(e.Right)
+	case *ast.ArrayLiteral:
+		r.resolveExpressions(e.Elements)
+	case *ast.HashLiteral:
+		for _, key := range e.Keys {
+			r.resolveExpression(key)
+			if value, ok := e.Pairs[key]; ok {
+				r.resolveExpression(value)
+			}
+		}
+	case *ast.IndexExpression:
+		r.resolveExpression(e.Left)
+		r.resolveExpression(e.Index)
+	case *ast.SliceExpression:
+		r.resolveExpression(e.Left)
+		for _, bound := range []ast.Expression{e.Start, e.End, e.Step} {
+			if bound != nil {
+				r.resolveExpression(bound)
+			}
+		}
+	case *ast.PropertyExpression:
+		r.resolveExpression(e.Left)
+	case *ast.SpreadElement:
+		r.resolveExpression(e.Value)
+	case *ast.IfExpression:
+		r.resolveIfExpression(e)
+	case *ast.MatchExpression:
+		r.resolveExpression(e.Subject)
+		for _, arm := range e.Arms {
+			r.enterScope(false)
+			r.bindPattern(arm.Pattern)
+			if arm.Guard != nil {
+				r.resolveExpression(arm.Guard)
+			}
+			r.resolveExpression(arm.Body)
+			r.leaveScope()
+		}
+	case *ast.SwitchExpression:
+		r.resolveExpression(e.Subject)
+		for _, sc := range e.Cases {
+			r.resolveExpressions(sc.Values)
+			r.resolveBlock(sc.Body)
+		}
+	case *ast.ForExpression:
+		r.resolveExpression(e.Iterable)
+		// 本体は各回のスコープで直接評価される
+		r.enterScope(false)
+		r.bindPattern(e.Pattern)
+		r.resolveStatements(e.Body.Statements)
+		r.leaveScope()
+	case *ast.SelectExpression:
+		for _, sc := range e.Cases {
+			if sc.Operation != nil {
+				r.resolveExpression(sc.Operation)
+			}
+			r.enterScope(false)
+			if sc.Name != nil {
+				r.declare(sc.Name, Let, sc)
+			}
+			r.resolveStatements(sc.Body.Statements)
+			r.leaveScope()
+		}
+	case *ast.YieldExpression:
+		if e.Value != nil {
+			r.resolveExpression(e.Value)
+		}
+	case *ast.AwaitExpression:
+		r.resolveExpression(e.Value)
+	}
+}
+
+func (r *resolver) resolveIfExpression(ie *ast.IfExpression) {
+	r.resolveExpression(ie.Condition)
+	r.resolveBlock(ie.Consequence)
+	switch {
+	case ie.ElseIf != nil:
+		r.resolveIfExpression(ie.ElseIf)
+	case ie.Alternative != nil:
+		r.resolveBlock(ie.Alternative)
+	}
+}
+
+// bindPattern resolves the values in the pattern, and declares the names bound by it
+func (r *resolver) bindPattern(pattern ast.Pattern) {
+	switch p := pattern.(type) {
+	case *ast.BindingPattern:
+		r.declare(p.Name, PatternBinding, p)
+	case *ast.LiteralPattern:
+		r.resolveExpression(p.Value)
+	case *ast.AlternativePattern:
+		for _, a := range p.Alternatives {
+			r.bindPattern(a)
+		}
+	case *ast.ArrayPattern:
+		for _, e := range p.Elements {
+			r.bindPattern(e)
+		}
+		r.bindRestPattern(p.Rest)
+	case *ast.HashPattern:
+		for _, pair := range p.Pairs {
+			r.resolveExpression(pair.Key)
+			r.bindPattern(pair.Value)
+		}
+		r.bindRestPattern(p.Rest)
+	case *ast.VariantPattern:
+		r.resolveIdentifier(p.Enum, true, false)
+		for _, f := range p.Fields {
+			r.bindPattern(f)
+		}
+	}
+}
+
+func (r *resolver) bindRestPattern(rest *ast.RestPattern) {
+	if rest != nil && rest.Name != nil {
+		r.declare(rest.Name, PatternBinding, rest)
+	}
+}
@@ -0,0 +1,136 @@
+package resolver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tshinag/monkey/lexer"
+	"github.com/tshinag/monkey/parser"
+)
+
+func TestResolveDiagnostics(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 1; puts(x + len([]))", nil},
+		{"puts(y)", []string{"1:6: identifier not found: y"}},
+		{"let total = 1; totl", []string{"1:16: identifier not found: totl, did you mean total?"}},
+		{"lenn([])", []string{"1:1: identifier not found: lenn, did you mean len?"}},
+		{"let count = 1; cuont", []string{"1:16: identifier not found: cuont, did you mean count?"}},
+		{"x; let x = 1", []string{"1:1: identifier not found: x"}},
+		// 関数の中の名前は呼び出し時に探される
+		{"let f = fn() { g() }; let g = fn() { f() }", nil},
+		{"let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }", nil},
+		{"main(); fn main() { helper() } fn helper() { 1 }", nil},
+		{"let f = fn() { nope }", []string{"1:16: identifier not found: nope"}},
+		{"let f = fn() { foo }", []string{"1:16: identifier not found: foo"}},
+		{"if (true) { let y = 1; y }; y", []string{"1:29: identifier not found: y"}},
+		{"fn(x) { x }; x", []string{"1:14: identifier not found: x"}},
+		// 未使用の束縛
+		{"fn f(a, b) { let c = 1; let _d = 2; a }", []string{"1:18: warning: declared and not used: c"}},
+		{"fn f() { let x = 1; x = 2 }", []string{"1:14: warning: declared and not used: x"}},
+		{"fn f() { let x = 1; x += 2 }", nil},
+		{"let unused = 1", nil},
+		{"match ([1, 2]) { [a, ...tail] => a, _ => 0 }", []string{"1:25: warning: declared and not used: tail"}},
+		// 隠蔽
+		{"let x = 1; let f = fn(x) { x }", []string{"1:23: warning: x shadows the declaration at 1:5"}},
+		{"fn f() { let len = 1; len }", []string{"1:14: warning: len shadows the builtin function"}},
+		{"let x = 1; fn f() { let x = 2; x }", []string{"1:25: warning: x shadows the declaration at 1:5"}},
+		// 実行時にエラーになる宣言と代入
+		{"let x = 1; let x = 2", []string{"1:16: identifier already declared: x"}},
+		{"fn f() { 1 } let f = 2", []string{"1:18: identifier already declared: f"}},
+		{"const c = 1; c = 2", []string{"1:14: cannot assign to constant: c"}},
+		{"let f = fn() { c = 2 }; const c = 1", []string{"1:16: cannot assign to constant: c"}},
+		// 宣言とパターン
+		{"struct P { x }; impl P { fn get(self) { self.x } }; P(1).get()", nil},
+		{"class A { init() { self.x = 1 } } class B extends A { get() { super.get() + self.x } }", nil},
+		{"class B extends Missing { }", []string{"1:17: identifier not found: Missing"}},
+		{"enum E { A(x), B }; match (E.A(1)) { E.A(v) => v, E.B => 0 }", nil},
+		{"match (1) { Opt.None => 0 }", []string{"1:13: identifier not found: Opt"}},
+		{"for ([k, v] in [[1, 2]]) { puts(k, v) }", nil},
+		{"let ch = channel(); select { case let v = recv(ch): v }", nil},
+		{"match (1) { 1 | 2 => 0, n if (n > 2) => n, _ => 1 }", nil},
+	}
+
+	for _, tt := range tests {
+		result := resolveTestProgram(t, tt.input)
+		actual := []string{}
+		for _, d := range result.Diagnostics {
+			actual = append(actual, d.Error())
+		}
+		if strings.Join(actual, "\n") != strings.Join(tt.expected, "\n") {
+			t.Errorf("wrong diagnostics for %q.\nexpected=%q\ngot=%q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestResolveUses(t *testing.T) {
+	input := `
+let x = 1;
+let f = fn(y) { x + y };
+x = f(x);
+`
+	result := resolveTestProgram(t, input)
+	if len(result.Bindings) != 3 {
+		t.Fatalf("wrong number of bindings. got=%d", len(result.Bindings))
+	}
+
+	tests := []struct {
+		name string
+		kind Kind
+		uses []string
+	}{
+		{"x", Let, []string{"3:17", "4:1", "4:7"}},
+		{"y", Parameter, []string{"3:21"}},
+		{"f", Let, []string{"4:5"}},
+	}
+
+	for i, tt := range tests {
+		b := result.Bindings[i]
+		if b.Name.Value != tt.name || b.Kind != tt.kind {
+			t.Errorf("bindings[%d] wrong. expected=%s %s, got=%s %s", i, tt.kind, tt.name, b.Kind, b.Name)
+		}
+		uses := []string{}
+		for _, use := range b.Uses {
+			uses = append(uses, use.Pos().String())
+			if result.Uses[use] != b {
+				t.Errorf("Uses[%s] is not the binding of %s", use.Pos(), tt.name)
+			}
+		}
+		if strings.Join(uses, " ") != strings.Join(tt.uses, " ") {
+			t.Errorf("uses of %s wrong. expected=%v, got=%v", tt.name, tt.uses, uses)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []string
+		expected   string
+	}{
+		{"lenght", []string{"length", "len"}, "length"},
+		{"x", []string{"y"}, ""},
+		{"pust", []string{"puts", "push"}, "push"},
+		{"counter", []string{"count", "encounter"}, "count"},
+		{"abc", []string{"xyz"}, ""},
+		{"cuont", []string{"count", "counter"}, "count"},
+		{"ab", []string{"ba"}, "ba"},
+	}
+
+	for _, tt := range tests {
+		if actual := suggest(tt.name, tt.candidates); actual != tt.expected {
+			t.Errorf("suggest(%q) wrong. expected=%q, got=%q", tt.name, tt.expected, actual)
+		}
+	}
+}
+
+func resolveTestProgram(t *testing.T, input string) *Result {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+	return Resolve(program)
+}
@@ -0,0 +1,60 @@
+package resolver
+
+import "github.com/tshinag/monkey/ast"
+
+// scope is the static counterpart of object.Environment
+type scope struct {
+	bindings map[string]*Binding
+	declared []*Binding // 宣言順の束縛
+	outer    *scope
+	function bool         // 関数の引数のスコープ。ここより内側は呼び出し時に評価される
+	pending  []*reference // 宣言より前に関数の中で参照された名前
+}
+
+// reference is the identifier whose declaration may follow it
+type reference struct {
+	ident *ast.Identifier
+	from  *scope // 参照しているスコープ
+	read  bool   // 値を読む参照
+	write bool   // 代入先の参照。複合代入なら read も真
+}
+
+func newScope(outer *scope, function bool) *scope {
+	return &scope{
+		bindings: make(map[string]*Binding),
+		outer:    outer,
+		function: function,
+	}
+}
+
+func (s *scope) lookup(name string) (*Binding, bool) {
+	for sc := s; sc != nil; sc = sc.outer {
+		if b, ok := sc.bindings[name]; ok {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+// deferred returns the scope defining the innermost function containing s,
+// whose later declarations are visible when the function is called.
+// It returns nil if s is not in a function.
+func (s *scope) deferred() *scope {
+	for sc := s; sc != nil; sc = sc.outer {
+		if sc.function {
+			return sc.outer
+		}
+	}
+	return nil
+}
+
+// names returns the names visible from s
+func (s *scope) names() []string {
+	names := []string{}
+	for sc := s; sc != nil; sc = sc.outer {
+		for name := range sc.bindings {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,60 @@
+package resolver
+
+import "sort"
+
+// suggest returns the candidate closest to the misspelled name, or "" if none is close enough
+func suggest(name string, candidates []string) string {
+	sort.Strings(candidates)
+	// 名前の長さの 1/3 までの編集距離を許す
+	best, bestDistance := "", len(name)/3+1
+	if bestDistance < 2 {
+		bestDistance = 2
+	}
+	for _, c := range candidates {
+		if c == name {
+			continue
+		}
+		d := distance(name, c)
+		if d < bestDistance && d < len(name) {
+			best, bestDistance = c, d
+		}
+	}
+	return best
+}
+
+// distance returns the Damerau–Levenshtein distance between a and b,
+// which counts the swap of adjacent characters as one edit, as in "cuont" for "count"
+func distance(a, b string) int {
+	// 直前の二行があれば隣り合う文字の入れ替えを数えられる
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minimum(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				curr[j] = minimum(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(b)]
+}
+
+func minimum(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/tshinag/monkey/ast"
+	"github.com/tshinag/monkey/coverage"
+	"github.com/tshinag/monkey/evaluator"
+	"github.com/tshinag/monkey/lexer"
+	"github.com/tshinag/monkey/object"
+	"github.com/tshinag/monkey/parser"
+	"github.com/tshinag/monkey/profiler"
+)
+
+// run evaluates the script and runs the event loop until the timers settle.
+// With -profile, it writes the pprof profile of the functions and prints the top of them to stderr.
+// With -coverprofile or -covermin, it measures the coverage and prints the summary to stderr.
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	profilePath := flags.String("profile", "", "write the pprof profile of the functions to the file")
+	top := flags.Int("top", 10, "the number of the functions and the call sites reported with -profile")
+	coverProfile := flags.String("coverprofile", "", "write the coverage of the statements and the branches to the file")
+	coverMin := flags.Float64("covermin", 0, "fail if less than the percentage of the statements are covered")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintf(stderr, "usage: monkey run [-profile FILE] [-top N] [-coverprofile FILE] [-covermin PERCENT] FILE\n")
+		return 2
+	}
+	path := flags.Arg(0)
+	program, ok := parseFile(path, stderr)
+	if !ok {
+		return 1
+	}
+
+	var p *profiler.Profiler
+	if *profilePath != "" {
+		p = profiler.Start(path)
+	}
+	var c *coverage.Coverage
+	if *coverProfile != "" || *coverMin > 0 {
+		c = coverage.New()
+		c.Add(path, program)
+		c.Start()
+	}
+	status := evaluate(program, path, stderr)
+	if p != nil {
+		p.Stop()
+		if err := writeFile(*profilePath, p.WriteProfile); err != nil {
+			fmt.Fprintf(stderr, "%s\n", err)
+			return 1
+		}
+		p.WriteReport(stderr, *top)
+	}
+	if c != nil {
+		c.Stop()
+		if !reportCoverage(c.Profile(), *coverProfile, *coverMin, stderr) && status == 0 {
+			status = 1
+		}
+	}
+	return status
+}
+
+// reportCoverage writes the profile if path is not empty and prints the summary.
+// It returns false if the profile can't be written or the coverage of the statements is below min.
+func reportCoverage(profile *coverage.Profile, path string, min float64, stderr io.Writer) bool {
+	if path != "" {
+		if err := writeFile(path, profile.Write); err != nil {
+			fmt.Fprintf(stderr, "%s\n", err)
+			return false
+		}
+	}
+	summary := profile.Summary()
+	fmt.Fprintf(stderr, "coverage: %s\n", summary)
+	if summary.StatementPercent() < min {
+		fmt.Fprintf(stderr, "coverage of statements %.1f%% is below %.1f%%\n", summary.StatementPercent(), min)
+		return false
+	}
+	return true
+}
+
+// evaluate runs the script and returns the exit code
+func evaluate(program *ast.Program, path string, stderr io.Writer) int {
+	env := object.NewEnvironment()
+	evaluated := evaluator.Eval(program, env)
+	if err, ok := evaluated.(*object.Error); ok {
+		fmt.Fprintf(stderr, "%s: %s\n", path, err.Inspect())
+		return 1
+	}
+	if err := evaluator.RunEventLoop(); err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", path, err.Inspect())
+		return 1
+	}
+	return 0
+}
+
+// writeFile creates the file and writes to it with write
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// parseFile parses the script, printing the parser errors to stderr
+func parseFile(path string, stderr io.Writer) (*ast.Program, bool) {
+	program, _, ok := parseFileWithComments(path, stderr)
+	return program, ok
+}
+
+// parseFileWithComments parses the script, and returns the comments in it as well.
+// The parser errors are printed with their positions.
+func parseFileWithComments(path string, stderr io.Writer) (*ast.Program, []lexer.Comment, bool) {
+	input, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s\n", err)
+		return nil, nil, false
+	}
+	l := lexer.New(string(input))
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		for _, err := range p.Errors() {
+			if perr, ok := err.(*parser.Error); ok {
+				fmt.Fprintf(stderr, "%s:%s: %s\n", path, perr.Pos, perr)
+				continue
+			}
+			fmt.Fprintf(stderr, "%s: %s\n", path, err)
+		}
+		return nil, nil, false
+	}
+	return program, l.Comments(), true
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/tshinag/monkey/coverage"
+	"github.com/tshinag/monkey/testrunner"
+)
+
+// test runs the test functions of the test files in the paths, the current directory by default.
+// With -coverprofile or -covermin, it measures the coverage of the test files and prints the summary to stderr.
+func test(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	run := flags.String("run", "", "run only the tests whose names match the regular expression")
+	verbose := flags.Bool("v", false, "print the passed tests and their output as well")
+	format := flags.String("format", "text", "the format of the results: text, tap or junit")
+	coverProfile := flags.String("coverprofile", "", "write the coverage of the statements and the branches to the file")
+	coverMin := flags.Float64("covermin", 0, "fail if less than the percentage of the statements are covered")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	var write func(io.Writer, []testrunner.Result) error
+	switch *format {
+	case "text":
+		write = func(w io.Writer, results []testrunner.Result) error {
+			return testrunner.WriteText(w, results, *verbose)
+		}
+	case "tap":
+		write = testrunner.WriteTAP
+	case "junit":
+		write = testrunner.WriteJUnit
+	default:
+		fmt.Fprintf(stderr, "unknown format: %s\n", *format)
+		return 2
+	}
+	var options testrunner.Options
+	if *run != "" {
+		filter, err := regexp.Compile(*run)
+		if err != nil {
+			fmt.Fprintf(stderr, "invalid -run: %s\n", err)
+			return 2
+		}
+		options.Filter = filter
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	files, err := testrunner.Discover(paths)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s\n", err)
+		return 1
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(stderr, "no test files\n")
+		return 0
+	}
+
+	if *coverProfile != "" || *coverMin > 0 {
+		options.Coverage = coverage.New()
+	}
+	results := testrunner.RunFiles(files, options)
+	if err := write(stdout, results); err != nil {
+		fmt.Fprintf(stderr, "%s\n", err)
+		return 1
+	}
+	status := 0
+	for _, r := range results {
+		if !r.Passed {
+			status = 1
+		}
+	}
+	if options.Coverage != nil && !reportCoverage(options.Coverage.Profile(), *coverProfile, *coverMin, stderr) {
+		status = 1
+	}
+	return status
+}
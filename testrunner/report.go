@@ -0,0 +1,173 @@
+package testrunner
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// WriteText writes the failed tests, or all the tests if verbose, and the summary of each file
+func WriteText(w io.Writer, results []Result, verbose bool) error {
+	failed := 0
+	for _, file := range byFile(results) {
+		fileFailed := 0
+		var elapsed time.Duration
+		for _, r := range file {
+			elapsed += r.Duration
+			if !r.Passed {
+				fileFailed++
+			}
+			if r.Passed && !verbose {
+				continue
+			}
+			status := "PASS"
+			if !r.Passed {
+				status = "FAIL"
+			}
+			fmt.Fprintf(w, "--- %s: %s (%.2fs)\n", status, r.Name, r.Duration.Seconds())
+			writeIndented(w, r.Output)
+			writeIndented(w, r.Message)
+		}
+		status := "ok  "
+		if fileFailed > 0 {
+			status = "FAIL"
+		}
+		fmt.Fprintf(w, "%s %s (%s, %.3fs)\n", status, file[0].File, plural(len(file), "test"), elapsed.Seconds())
+		failed += fileFailed
+	}
+	var err error
+	if failed > 0 {
+		_, err = fmt.Fprintf(w, "FAIL: %d of %s failed\n", failed, plural(len(results), "test"))
+	} else {
+		_, err = fmt.Fprintf(w, "PASS\n")
+	}
+	return err
+}
+
+// WriteTAP writes the results in the Test Anything Protocol version 13
+func WriteTAP(w io.Writer, results []Result) error {
+	fmt.Fprintf(w, "TAP version 13\n1..%d\n", len(results))
+	for i, r := range results {
+		status := "ok"
+		if !r.Passed {
+			status = "not ok"
+		}
+		fmt.Fprintf(w, "%s %d - %s: %s\n", status, i+1, r.File, r.Name)
+		for _, line := range lines(r.Output) {
+			fmt.Fprintf(w, "# %s\n", line)
+		}
+		if !r.Passed {
+			// 失敗の詳細は YAML ブロックで書く
+			fmt.Fprintf(w, "  ---\n  message: |\n")
+			for _, line := range lines(r.Message) {
+				fmt.Fprintf(w, "    %s\n", line)
+			}
+			fmt.Fprintf(w, "  duration_ms: %.3f\n  ...\n", r.Duration.Seconds()*1000)
+		}
+	}
+	return nil
+}
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the results in the JUnit XML format, a test suite for each file
+func WriteJUnit(w io.Writer, results []Result) error {
+	var suites junitSuites
+	for _, file := range byFile(results) {
+		suite := junitSuite{Name: file[0].File, Tests: len(file)}
+		var elapsed time.Duration
+		for _, r := range file {
+			elapsed += r.Duration
+			c := junitCase{
+				Name:      r.Name,
+				ClassName: r.File,
+				Time:      seconds(r.Duration),
+				SystemOut: r.Output,
+			}
+			if !r.Passed {
+				suite.Failures++
+				// message 属性には最初の行だけを入れる
+				c.Failure = &junitFailure{Message: strings.SplitN(r.Message, "\n", 2)[0], Text: r.Message}
+			}
+			suite.Cases = append(suite.Cases, c)
+		}
+		suite.Time = seconds(elapsed)
+		suites.Suites = append(suites.Suites, suite)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// byFile groups the consecutive results of the same file
+func byFile(results []Result) [][]Result {
+	var groups [][]Result
+	for i, r := range results {
+		if i == 0 || results[i-1].File != r.File {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], r)
+	}
+	return groups
+}
+
+func writeIndented(w io.Writer, s string) {
+	if s == "" {
+		return
+	}
+	for _, line := range lines(s) {
+		fmt.Fprintf(w, "    %s\n", line)
+	}
+}
+
+// lines splits s into the lines without the last newline
+func lines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,237 @@
+package testrunner
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tshinag/monkey/ast"
+	"github.com/tshinag/monkey/coverage"
+	"github.com/tshinag/monkey/evaluator"
+	"github.com/tshinag/monkey/lexer"
+	"github.com/tshinag/monkey/object"
+	"github.com/tshinag/monkey/parser"
+)
+
+// Suffix is the suffix of the names of the test files
+const Suffix = "_test.mk"
+
+// Prefix is the prefix of the names of the test functions
+const Prefix = "test_"
+
+// Result is the result of a test function
+type Result struct {
+	File     string
+	Name     string
+	Passed   bool
+	Message  string // 失敗した理由。成功した場合は空
+	Output   string // テスト中に puts で出力された内容
+	Duration time.Duration
+}
+
+// Options changes how the tests run
+type Options struct {
+	Filter   *regexp.Regexp     // nil でなければ名前が一致するテストだけ実行する
+	Coverage *coverage.Coverage // nil でなければテストファイルを追加して計測する
+}
+
+// Discover returns the test files in the paths, walking into directories.
+// The hidden directories are skipped. The files are sorted and listed once.
+func Discover(paths []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+	add := func(path string) {
+		path = filepath.Clean(path)
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+	for _, root := range paths {
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			// 明示されたファイルは名前に関係なく実行する
+			add(root)
+			continue
+		}
+		err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				if path != root && strings.HasPrefix(info.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(info.Name(), Suffix) {
+				add(path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Tests returns the names of the top-level test functions of the program in the source order
+func Tests(program *ast.Program) []string {
+	var names []string
+	for _, stmt := range program.Statements {
+		var name string
+		var value ast.Expression
+		switch stmt := stmt.(type) {
+		case *ast.FunctionStatement:
+			name, value = stmt.Name.Value, stmt.Function
+		case *ast.LetStatement:
+			name, value = stmt.Name.Value, stmt.Value
+		case *ast.ConstStatement:
+			name, value = stmt.Name.Value, stmt.Value
+		default:
+			continue
+		}
+		if _, ok := value.(*ast.FunctionLiteral); ok && strings.HasPrefix(name, Prefix) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// RunFiles runs the test functions of the files in order.
+// A file that can't be loaded is reported as a failed result named after the file,
+// so that the reports don't look successful.
+func RunFiles(files []string, options Options) []Result {
+	var results []Result
+	for _, file := range files {
+		fileResults, err := RunFile(file, options)
+		if err != nil {
+			results = append(results, Result{File: file, Name: file, Message: err.Error()})
+			continue
+		}
+		results = append(results, fileResults...)
+	}
+	return results
+}
+
+// RunFile runs the test functions of the file one by one.
+// Each test runs in a new environment where the file is evaluated again,
+// so that the tests don't share the state. The timers run on a virtual clock without waiting. It returns an error if the file can't be parsed.
+func RunFile(path string, options Options) ([]Result, error) {
+	input, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p := parser.New(lexer.New(string(input)))
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		if perr, ok := errs[0].(*parser.Error); ok {
+			return nil, errors.Errorf("%s:%s: %s", path, perr.Pos, perr)
+		}
+		return nil, errors.Errorf("%s: %s", path, errs[0])
+	}
+	if options.Coverage != nil {
+		options.Coverage.Add(path, program)
+		options.Coverage.Start()
+		defer options.Coverage.Stop()
+	}
+
+	output := &buffer{}
+	evaluator.SetOutput(output)
+	defer evaluator.SetOutput(os.Stdout)
+	defer evaluator.SetClock(evaluator.SystemClock{})
+
+	var results []Result
+	for _, name := range Tests(program) {
+		if options.Filter != nil && !options.Filter.MatchString(name) {
+			continue
+		}
+		output.reset()
+		start := time.Now()
+		message := runTest(program, name)
+		results = append(results, Result{
+			File:     path,
+			Name:     name,
+			Passed:   message == "",
+			Message:  message,
+			Output:   output.String(),
+			Duration: time.Since(start),
+		})
+	}
+	return results, nil
+}
+
+// runTest evaluates the program and calls the test function, and returns why it failed
+func runTest(program *ast.Program, name string) string {
+	// 前のテストのタイマーが残らないように空のイベントループで始める。
+	// 仮想時計なので sleep や set_timeout を待たずに毎回同じ順序で実行する
+	evaluator.SetClock(evaluator.NewVirtualClock(time.Unix(0, 0)))
+	env := object.NewEnvironment()
+	if err, ok := evaluator.Eval(program, env).(*object.Error); ok {
+		return "setup failed: " + failure(err, name)
+	}
+	fn, _ := env.Get(name)
+	result := evaluator.Call(fn)
+	if err, ok := result.(*object.Error); ok {
+		return failure(err, name)
+	}
+	if err := evaluator.RunEventLoop(); err != nil {
+		return failure(err, name)
+	}
+	// async fn のテストは返した promise の結果で判定する
+	if promise, ok := result.(*object.Promise); ok {
+		value, settled := promise.Result()
+		if !settled {
+			return "promise never settled"
+		}
+		if err, ok := value.(*object.Error); ok {
+			return failure(err, name)
+		}
+	}
+	return ""
+}
+
+// failure returns the message of the error with the function where it is raised
+func failure(err *object.Error, test string) string {
+	if err.Function != "" && err.Function != test {
+		return fmt.Sprintf("%s (in function %s)", err.Message, err.Function)
+	}
+	return err.Message
+}
+
+// buffer collects the output of puts, which may be called from the tasks
+type buffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *buffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *buffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (b *buffer) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Reset()
+}
@@ -0,0 +1,333 @@
+package testrunner
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tshinag/monkey/coverage"
+	"github.com/tshinag/monkey/lexer"
+	"github.com/tshinag/monkey/parser"
+)
+
+const testScript = `let counter = 0
+let double = fn(x) { x * 2 }
+fn helper() { assert(false, "broken") }
+
+fn test_double() {
+  puts("doubling")
+  assert_eq(double(2), 4)
+}
+let test_isolated = fn() {
+  counter = counter + 1
+  assert_eq(counter, 1)
+}
+const test_again = fn() {
+  counter = counter + 1
+  assert_eq(counter, 1)
+}
+fn test_hash() {
+  assert_eq({"a": 1, "b": [1, 2]}, {"a": 1, "b": [1, 3]}, "hashes")
+}
+fn test_helper() { helper() }
+async fn test_async() {
+  await sleep(1)
+  assert_eq(1, 2)
+}
+fn test_error() { assert_error(fn() { 1 + "a" }, "type mismatch") }
+fn test_no_error() { assert_error(fn() { 1 }) }
+fn not_a_test() { assert(false) }
+let test_value = 1`
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "testrunner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDiscover(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"b_test.mk":          "",
+		"a_test.mk":          "",
+		"main.mk":            "",
+		"sub/c_test.mk":      "",
+		".hidden/d_test.mk":  "",
+		"sub/.git/e_test.mk": "",
+	})
+	defer os.RemoveAll(dir)
+
+	// 同じファイルを二度指定しても一度だけ実行する
+	files, err := Discover([]string{dir, filepath.Join(dir, "a_test.mk"), filepath.Join(dir, "main.mk")})
+	if err != nil {
+		t.Fatalf("Discover failed: %s", err)
+	}
+	var names []string
+	for _, f := range files {
+		rel, _ := filepath.Rel(dir, f)
+		names = append(names, rel)
+	}
+	expected := []string{"a_test.mk", "b_test.mk", "main.mk", "sub/c_test.mk"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("wrong files. expected=%v, got=%v", expected, names)
+	}
+
+	if _, err := Discover([]string{filepath.Join(dir, "missing")}); err == nil {
+		t.Errorf("expected an error for the missing path")
+	}
+}
+
+func TestTests(t *testing.T) {
+	p := parser.New(lexer.New(testScript))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has errors: %v", p.Errors())
+	}
+	expected := []string{"test_double", "test_isolated", "test_again", "test_hash",
+		"test_helper", "test_async", "test_error", "test_no_error"}
+	if names := Tests(program); !reflect.DeepEqual(names, expected) {
+		t.Errorf("wrong tests. expected=%v, got=%v", expected, names)
+	}
+}
+
+func TestRunFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"math_test.mk": testScript, "bad_test.mk": "fn test_x( {"})
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "math_test.mk")
+
+	results, err := RunFile(path, Options{})
+	if err != nil {
+		t.Fatalf("RunFile failed: %s", err)
+	}
+	tests := []struct {
+		name    string
+		message string
+		output  string
+	}{
+		{"test_double", "", "doubling\n"},
+		{"test_isolated", "", ""},
+		{"test_again", "", ""},
+		{"test_hash", "assert_eq failed: hashes: expected {a: 1, b: [1, 3]}, got {a: 1, b: [1, 2]}\n  [\"b\"][1]: expected 3, got 2", ""},
+		{"test_helper", "assertion failed: broken (in function helper)", ""},
+		{"test_async", "assert_eq failed: expected 2, got 1", ""},
+		{"test_error", "", ""},
+		{"test_no_error", "assert_error failed: expected an error, got 1", ""},
+	}
+	if len(results) != len(tests) {
+		t.Fatalf("wrong number of results. expected=%d, got=%d", len(tests), len(results))
+	}
+	for i, tt := range tests {
+		r := results[i]
+		if r.File != path || r.Name != tt.name {
+			t.Errorf("results[%d] is %s: %s, expected %s", i, r.File, r.Name, tt.name)
+			continue
+		}
+		if r.Passed != (tt.message == "") || r.Message != tt.message {
+			t.Errorf("%s: wrong result. expected=%q, got passed=%t %q", tt.name, tt.message, r.Passed, r.Message)
+		}
+		if r.Output != tt.output {
+			t.Errorf("%s: wrong output. expected=%q, got=%q", tt.name, tt.output, r.Output)
+		}
+	}
+
+	results, err = RunFile(path, Options{Filter: regexp.MustCompile("^test_(double|error)$")})
+	if err != nil {
+		t.Fatalf("RunFile failed: %s", err)
+	}
+	if len(results) != 2 || results[0].Name != "test_double" || results[1].Name != "test_error" {
+		t.Errorf("wrong filtered results: %+v", results)
+	}
+
+	bad := filepath.Join(dir, "bad_test.mk")
+	_, err = RunFile(bad, Options{})
+	expected := bad + ":1:13: expected next token to be ), got EOF instead"
+	if err == nil || err.Error() != expected {
+		t.Errorf("wrong error. expected=%q, got=%v", expected, err)
+	}
+}
+
+func TestRunFiles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a_test.mk": "fn test_a() { assert(true) }",
+		"b_test.mk": "fn test_b( {",
+	})
+	defer os.RemoveAll(dir)
+	a, b := filepath.Join(dir, "a_test.mk"), filepath.Join(dir, "b_test.mk")
+
+	// 読み込めないファイルは失敗したテストとして報告する
+	results := RunFiles([]string{a, b}, Options{})
+	expected := []Result{
+		{File: a, Name: "test_a", Passed: true},
+		{File: b, Name: b, Message: b + ":1:13: expected next token to be ), got EOF instead"},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("wrong number of results. expected=%d, got=%d", len(expected), len(results))
+	}
+	for i, r := range results {
+		r.Duration = 0
+		if r != expected[i] {
+			t.Errorf("results[%d] wrong. expected=%+v, got=%+v", i, expected[i], r)
+		}
+	}
+
+	var out bytes.Buffer
+	WriteText(&out, results, false)
+	if !strings.HasSuffix(out.String(), "FAIL: 1 of 2 tests failed\n") {
+		t.Errorf("wrong text summary:\n%s", out.String())
+	}
+}
+
+func TestRunFileVirtualClock(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"timer_test.mk": `async fn test_sleep() {
+  let start = now()
+  await sleep(60000)
+  assert_eq(now() - start, 60000)
+}
+fn test_timeout() {
+  set_timeout(fn() { puts("late") }, 3600000)
+  set_timeout(fn() { puts("early") }, 1000)
+}`})
+	defer os.RemoveAll(dir)
+
+	// 一時間のタイマーも待たずに終わる
+	start := time.Now()
+	results, err := RunFile(filepath.Join(dir, "timer_test.mk"), Options{})
+	if err != nil {
+		t.Fatalf("RunFile failed: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("tests waited in real time: %s", elapsed)
+	}
+	if len(results) != 2 || !results[0].Passed || !results[1].Passed {
+		t.Fatalf("wrong results: %+v", results)
+	}
+	if results[1].Output != "early\nlate\n" {
+		t.Errorf("wrong output. got=%q", results[1].Output)
+	}
+}
+
+func TestRunFileCoverage(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"sign_test.mk": `let sign = fn(n) { if (n < 0) { -1 } else { 1 } }
+fn test_sign() { assert_eq(sign(1), 1) }`})
+	defer os.RemoveAll(dir)
+
+	c := coverage.New()
+	if _, err := RunFile(filepath.Join(dir, "sign_test.mk"), Options{Coverage: c}); err != nil {
+		t.Fatalf("RunFile failed: %s", err)
+	}
+	expected := "83.3% of statements (5/6), 50.0% of branches (1/2)"
+	if summary := c.Profile().Summary().String(); summary != expected {
+		t.Errorf("wrong summary. expected=%q, got=%q", expected, summary)
+	}
+}
+
+var testResults = []Result{
+	{File: "a_test.mk", Name: "test_ok", Passed: true, Output: "hello\n", Duration: 2 * time.Millisecond},
+	{File: "a_test.mk", Name: "test_ng", Message: "assert_eq failed: expected [1], got [2]\n  [0]: expected 1, got 2", Duration: time.Millisecond},
+	{File: "b_test.mk", Name: "test_b", Passed: true, Duration: 1500 * time.Millisecond},
+}
+
+func TestWriteText(t *testing.T) {
+	tests := []struct {
+		verbose  bool
+		expected string
+	}{
+		{false, `--- FAIL: test_ng (0.00s)
+    assert_eq failed: expected [1], got [2]
+      [0]: expected 1, got 2
+FAIL a_test.mk (2 tests, 0.003s)
+ok   b_test.mk (1 test, 1.500s)
+FAIL: 1 of 3 tests failed
+`},
+		{true, `--- PASS: test_ok (0.00s)
+    hello
+--- FAIL: test_ng (0.00s)
+    assert_eq failed: expected [1], got [2]
+      [0]: expected 1, got 2
+FAIL a_test.mk (2 tests, 0.003s)
+--- PASS: test_b (1.50s)
+ok   b_test.mk (1 test, 1.500s)
+FAIL: 1 of 3 tests failed
+`},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := WriteText(&out, testResults, tt.verbose); err != nil {
+			t.Fatalf("WriteText failed: %s", err)
+		}
+		if out.String() != tt.expected {
+			t.Errorf("wrong text with verbose=%t. expected=\n%s\ngot=\n%s", tt.verbose, tt.expected, out.String())
+		}
+	}
+
+	var out bytes.Buffer
+	WriteText(&out, testResults[2:], false)
+	if expected := "ok   b_test.mk (1 test, 1.500s)\nPASS\n"; out.String() != expected {
+		t.Errorf("wrong text. expected=\n%s\ngot=\n%s", expected, out.String())
+	}
+}
+
+func TestWriteTAP(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteTAP(&out, testResults); err != nil {
+		t.Fatalf("WriteTAP failed: %s", err)
+	}
+	expected := `TAP version 13
+1..3
+ok 1 - a_test.mk: test_ok
+# hello
+not ok 2 - a_test.mk: test_ng
+  ---
+  message: |
+    assert_eq failed: expected [1], got [2]
+      [0]: expected 1, got 2
+  duration_ms: 1.000
+  ...
+ok 3 - b_test.mk: test_b
+`
+	if out.String() != expected {
+		t.Errorf("wrong TAP. expected=\n%s\ngot=\n%s", expected, out.String())
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteJUnit(&out, testResults); err != nil {
+		t.Fatalf("WriteJUnit failed: %s", err)
+	}
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="a_test.mk" tests="2" failures="1" time="0.003">
+    <testcase name="test_ok" classname="a_test.mk" time="0.002">
+      <system-out>hello&#xA;</system-out>
+    </testcase>
+    <testcase name="test_ng" classname="a_test.mk" time="0.001">
+      <failure message="assert_eq failed: expected [1], got [2]">assert_eq failed: expected [1], got [2]&#xA;  [0]: expected 1, got 2</failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="b_test.mk" tests="1" failures="0" time="1.500">
+    <testcase name="test_b" classname="b_test.mk" time="1.500"></testcase>
+  </testsuite>
+</testsuites>
+`
+	if out.String() != expected {
+		t.Errorf("wrong JUnit XML. expected=\n%s\ngot=\n%s", expected, out.String())
+	}
+}
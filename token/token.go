@@ -1,5 +1,10 @@
 package token
 
+import (
+	"fmt"
+	"sort"
+)
+
 // Type is the type of token
 type Type string
 
@@ -7,6 +12,22 @@ type Type string
 type Token struct {
 	Type    Type
 	Literal string
+	Pos     Position // トークンの先頭の位置
+}
+
+// Position is the position in source code
+type Position struct {
+	Line   int // 1 から始まる行番号
+	Column int // 1 から始まるバイト単位の列番号
+}
+
+// IsValid reports whether the position is set
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
 }
 
 const (
@@ -33,12 +54,36 @@ const (
 	ASTERISK = "*"
 	// SLASH means slash token
 	SLASH = "/"
+	// PLUSASSIGN means "+=" token
+	PLUSASSIGN = "+="
+	// MINUSASSIGN means "-=" token
+	MINUSASSIGN = "-="
+	// ASTERISKASSIGN means "*=" token
+	ASTERISKASSIGN = "*="
+	// SLASHASSIGN means "/=" token
+	SLASHASSIGN = "/="
 	// COMMA means comma token
 	COMMA = ","
 	// COLON means colon token
 	COLON = ":"
 	// SEMICOLON means semicolon token
 	SEMICOLON = ";"
+	// PIPE means pipe token
+	PIPE = "|"
+	// PIPELINE means pipeline token
+	PIPELINE = "|>"
+	// ARROW means arrow token
+	ARROW = "=>"
+	// RARROW means return type arrow token
+	RARROW = "->"
+	// ELLIPSIS means ellipsis token
+	ELLIPSIS = "..."
+	// DOT means dot token
+	DOT = "."
+	// QUESTIONDOT means optional chaining token
+	QUESTIONDOT = "?."
+	// NULLISH means null-coalescing token
+	NULLISH = "??"
 
 	// LT means less-than token
 	LT = "<"
@@ -67,31 +112,98 @@ const (
 	FUNCTION = "FUNCTION"
 	// LET means let token
 	LET = "LET"
+	// CONST means const token
+	CONST = "CONST"
 	// TRUE means true token
 	TRUE = "TRUE"
 	// FALSE means false token
 	FALSE = "FALSE"
+	// NULL means null token
+	NULL = "NULL"
 	// IF means if token
 	IF = "IF"
 	// ELSE means else token
 	ELSE = "ELSE"
 	// RETURN means return token
 	RETURN = "RETURN"
+	// MATCH means match token
+	MATCH = "MATCH"
+	// SWITCH means switch token
+	SWITCH = "SWITCH"
+	// CASE means case token
+	CASE = "CASE"
+	// DEFAULT means default token
+	DEFAULT = "DEFAULT"
+	// FALLTHROUGH means fallthrough token
+	FALLTHROUGH = "FALLTHROUGH"
+	// FOR means for token
+	FOR = "FOR"
+	// IN means in token
+	IN = "IN"
+	// YIELD means yield token
+	YIELD = "YIELD"
+	// SELECT means select token
+	SELECT = "SELECT"
+	// ASYNC means async token
+	ASYNC = "ASYNC"
+	// AWAIT means await token
+	AWAIT = "AWAIT"
+	// STRUCT means struct token
+	STRUCT = "STRUCT"
+	// IMPL means impl token
+	IMPL = "IMPL"
+	// CLASS means class token
+	CLASS = "CLASS"
+	// EXTENDS means extends token
+	EXTENDS = "EXTENDS"
+	// INSTANCEOF means instanceof token
+	INSTANCEOF = "INSTANCEOF"
+	// ENUM means enum token
+	ENUM = "ENUM"
 )
 
 var keywords = map[string]Type{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"fn":          FUNCTION,
+	"let":         LET,
+	"const":       CONST,
+	"true":        TRUE,
+	"false":       FALSE,
+	"null":        NULL,
+	"if":          IF,
+	"else":        ELSE,
+	"return":      RETURN,
+	"match":       MATCH,
+	"switch":      SWITCH,
+	"case":        CASE,
+	"default":     DEFAULT,
+	"fallthrough": FALLTHROUGH,
+	"for":         FOR,
+	"in":          IN,
+	"yield":       YIELD,
+	"select":      SELECT,
+	"async":       ASYNC,
+	"await":       AWAIT,
+	"struct":      STRUCT,
+	"impl":        IMPL,
+	"class":       CLASS,
+	"extends":     EXTENDS,
+	"instanceof":  INSTANCEOF,
+	"enum":        ENUM,
+}
+
+// Keywords returns the sorted keywords
+func Keywords() []string {
+	names := make([]string, 0, len(keywords))
+	for name := range keywords {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 // New initializes Token
 func New(t Type, l string) Token {
-	return Token{t, l}
+	return Token{Type: t, Literal: l}
 }
 
 // NewChar initializes Token
@@ -0,0 +1,10 @@
+package types
+
+// Array is the type of arrays "[element]"
+type Array struct {
+	Element Type
+}
+
+func (a *Array) String() string {
+	return "[" + a.Element.String() + "]"
+}
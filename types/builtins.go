@@ -0,0 +1,50 @@
+package types
+
+// builtinTypes returns the types of the builtin functions of the evaluator.
+// The builtins accepting a variable number of arguments, such as puts and range, have type Any.
+func builtinTypes() map[string]Type {
+	a, b := generic(), generic()
+	fn := func(ret Type, params ...Type) *Function {
+		return &Function{Parameters: params, Return: ret}
+	}
+	return map[string]Type{
+		"len":   fn(Int, Any),
+		"first": fn(a, &Array{Element: a}),
+		"last":  fn(a, &Array{Element: a}),
+		"rest":  fn(&Array{Element: a}, &Array{Element: a}),
+		"push":  fn(&Array{Element: a}, &Array{Element: a}, a),
+		"puts":  Any,
+		// イテレータは要素の型を持たないので Any とする
+		"iter":    fn(Any, Any),
+		"next":    fn(&Hash{Key: String, Value: Any}, Any),
+		"range":   Any,
+		"take":    fn(&Array{Element: a}, Any, Int),
+		"collect": fn(&Array{Element: a}, Any),
+		"map":     fn(&Array{Element: b}, &Array{Element: a}, fn(b, a)),
+		"filter":  fn(&Array{Element: a}, &Array{Element: a}, fn(Bool, a)),
+		"reduce":  fn(b, &Array{Element: a}, b, fn(b, b, a)),
+		// 並行処理
+		"spawn":   Any,
+		"wait":    fn(Any, Any),
+		"channel": Any,
+		"send":    fn(Null, Any, Any),
+		"recv":    fn(Any, Any),
+		"close":   fn(Null, Any),
+		// 非同期処理
+		"sleep":          fn(Any, Int),
+		"set_timeout":    Any,
+		"set_interval":   Any,
+		"clear_timeout":  fn(Null, Int),
+		"clear_interval": fn(Null, Int),
+		"now":            fn(Int),
+		"all":            fn(Any, &Array{Element: Any}),
+		// テスト。メッセージは省略できる
+		"assert":       Any,
+		"assert_eq":    Any,
+		"assert_error": Any,
+	}
+}
+
+func generic() *Variable {
+	return &Variable{level: genericLevel}
+}
@@ -0,0 +1,571 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/tshinag/monkey/ast"
+)
+
+// Check checks the program using the type annotations and local inference.
+// Unannotated parameters and unknown identifiers have type Any,
+// so that unannotated code is not checked.
+func Check(program *ast.Program) []*Error {
+	c := &checker{
+		scope:      newScope(nil),
+		signatures: make(map[*ast.FunctionLiteral]*Function),
+		returns:    make(map[*ast.FunctionLiteral]Type),
+	}
+	c.checkStatements(program.Statements)
+	return c.errors
+}
+
+type checker struct {
+	errors     []*Error
+	scope      *scope
+	signatures map[*ast.FunctionLiteral]*Function
+	returns    map[*ast.FunctionLiteral]Type // 戻り値の型注釈
+	functions  []*ast.FunctionLiteral        // 検査中の関数。内側の関数が末尾
+}
+
+func (c *checker) errorf(node ast.Node, format string, args ...interface{}) {
+	c.errors = append(c.errors, &Error{Pos: node.Pos(), Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) enterScope() {
+	c.scope = newScope(c.scope)
+}
+
+func (c *checker) leaveScope() {
+	c.scope = c.scope.outer
+}
+
+// checkStatements checks the statements of program or block,
+// and returns the type of the last statement
+func (c *checker) checkStatements(statements []ast.Statement) Type {
+	for _, statement := range statements {
+		switch s := statement.(type) {
+		case *ast.StructStatement:
+			c.scope.types[s.Name.Value] = &Named{Name: s.Name.Value}
+		case *ast.ClassStatement:
+			c.scope.types[s.Name.Value] = &Named{Name: s.Name.Value}
+		case *ast.EnumStatement:
+			c.scope.types[s.Name.Value] = &Named{Name: s.Name.Value}
+		}
+	}
+	// 評価器と同様に関数宣言を巻き上げる
+	for _, statement := range statements {
+		if fs, ok := statement.(*ast.FunctionStatement); ok {
+			c.scope.declare(fs.Name.Value, c.functionType(fs.Function), true)
+		}
+	}
+
+	var result Type = Null
+	for _, statement := range statements {
+		result = c.checkStatement(statement)
+	}
+	return result
+}
+
+func (c *checker) checkBlock(block *ast.BlockStatement) Type {
+	c.enterScope()
+	defer c.leaveScope()
+	return c.checkStatements(block.Statements)
+}
+
+func (c *checker) checkStatement(statement ast.Statement) Type {
+	switch s := statement.(type) {
+	case *ast.LetStatement:
+		c.checkDeclaration(s.Name, s.Type, s.Value)
+	case *ast.ConstStatement:
+		c.checkDeclaration(s.Name, s.Type, s.Value)
+	case *ast.ReturnStatement:
+		c.checkReturn(s.ReturnValue, c.checkExpression(s.ReturnValue))
+		return Any
+	case *ast.ExpressionStatement:
+		return c.checkExpression(s.Expression)
+	case *ast.FunctionStatement:
+		c.checkFunction(s.Function, nil)
+	case *ast.StructStatement:
+		params := make([]Type, len(s.Fields))
+		for i := range params {
+			params[i] = Any
+		}
+		named, _ := c.scope.lookupType(s.Name.Value)
+		c.scope.declare(s.Name.Value, &Function{Parameters: params, Return: named}, true)
+	case *ast.ImplStatement:
+		receiver, ok := c.scope.lookupType(s.Name.Value)
+		if !ok {
+			receiver = Any
+		}
+		for _, method := range s.Methods {
+			c.checkFunction(method.Function, receiver)
+		}
+	case *ast.ClassStatement:
+		c.scope.declare(s.Name.Value, Any, true)
+		self, _ := c.scope.lookupType(s.Name.Value)
+		for _, method := range s.Methods {
+			c.enterScope()
+			c.scope.declare("self", self, true)
+			if s.Superclass != nil {
+				c.scope.declare("super", Any, true)
+			}
+			c.checkFunction(method, nil)
+			c.leaveScope()
+		}
+	case *ast.EnumStatement:
+		c.scope.declare(s.Name.Value, Any, true)
+	}
+	return Null
+}
+
+func (c *checker) checkDeclaration(name *ast.Identifier, annotation ast.TypeExpression, value ast.Expression) {
+	var declared Type = Any
+	if annotation != nil {
+		declared = c.resolve(annotation)
+	}
+	// 再帰呼び出しのため、関数リテラルは本体より先に束縛する
+	if lit, ok := value.(*ast.FunctionLiteral); ok && annotation == nil {
+		c.scope.declare(name.Value, c.functionType(lit), true)
+	} else if annotation != nil {
+		c.scope.declare(name.Value, declared, true)
+	}
+
+	t := c.checkExpression(value)
+	if annotation == nil {
+		if _, ok := value.(*ast.FunctionLiteral); !ok {
+			c.scope.declare(name.Value, t, false)
+		}
+		return
+	}
+	if !AssignableTo(t, declared) {
+		c.errorf(value, "cannot use %s as %s in declaration of %s", t, declared, name)
+	}
+}
+
+func (c *checker) checkReturn(node ast.Node, t Type) {
+	if len(c.functions) == 0 {
+		return
+	}
+	lit := c.functions[len(c.functions)-1]
+	want := c.returns[lit]
+	if lit.ReturnType != nil && !lit.Generator && !AssignableTo(t, want) {
+		c.errorf(node, "cannot return %s from function returning %s", t, want)
+	}
+}
+
+// signature returns the type of function given by the annotations.
+// Generators and async functions return Any since calling them returns an iterator or a promise.
+func (c *checker) signature(lit *ast.FunctionLiteral) *Function {
+	if sig, ok := c.signatures[lit]; ok {
+		return sig
+	}
+	params := make([]Type, len(lit.Parameters))
+	for i := range lit.Parameters {
+		params[i] = c.resolve(lit.ParameterType(i))
+	}
+	ret := c.resolve(lit.ReturnType)
+	c.returns[lit] = ret
+	if lit.Generator || lit.Async {
+		ret = Any
+	}
+	sig := &Function{Parameters: params, Return: ret}
+	c.signatures[lit] = sig
+	return sig
+}
+
+// checkFunction checks the body of function.
+// If receiver is given, the unannotated first parameter "self" has the type.
+func (c *checker) checkFunction(lit *ast.FunctionLiteral, receiver Type) {
+	sig := c.signature(lit)
+
+	c.enterScope()
+	defer c.leaveScope()
+	for i, param := range lit.Parameters {
+		t := sig.Parameters[i]
+		if i == 0 && receiver != nil && param.Value == "self" && lit.ParameterType(i) == nil {
+			t = receiver
+		}
+		c.scope.declare(param.Value, t, lit.ParameterType(i) != nil)
+	}
+
+	c.functions = append(c.functions, lit)
+	defer func() { c.functions = c.functions[:len(c.functions)-1] }()
+
+	result := c.checkBlock(lit.Body)
+	statements := lit.Body.Statements
+	if len(statements) == 0 {
+		c.checkReturn(lit.Body, result)
+		return
+	}
+	// return 文で終わらなければ、最後の文の値が戻り値になる
+	switch last := statements[len(statements)-1].(type) {
+	case *ast.ReturnStatement:
+	case *ast.ExpressionStatement:
+		c.checkReturn(last.Expression, result)
+	default:
+		c.checkReturn(last, result)
+	}
+}
+
+// functionType returns the type of function value.
+// The function without annotations has type Any, so that its calls are not checked.
+func (c *checker) functionType(lit *ast.FunctionLiteral) Type {
+	sig := c.signature(lit)
+	if lit.ReturnType != nil {
+		return sig
+	}
+	for i := range lit.Parameters {
+		if lit.ParameterType(i) != nil {
+			return sig
+		}
+	}
+	return Any
+}
+
+// resolve returns the type of the annotation. The type of nil annotation is Any.
+func (c *checker) resolve(annotation ast.TypeExpression) Type {
+	return resolve(annotation, c.scope, c.errorf)
+}
+
+func (c *checker) checkExpression(expression ast.Expression) Type {
+	switch e := expression.(type) {
+	case *ast.IntegerLiteral:
+		return Int
+	case *ast.StringLiteral:
+		return String
+	case *ast.Boolean:
+		return Bool
+	case *ast.NullLiteral:
+		return Null
+	case *ast.Identifier:
+		if b, ok := c.scope.lookup(e.Value); ok {
+			return b.typ
+		}
+		return Any
+	case *ast.PrefixExpression:
+		return c.checkPrefixExpression(e)
+	case *ast.InfixExpression:
+		return c.checkInfixExpression(e)
+	case *ast.AssignExpression:
+		return c.checkAssignExpression(e)
+	case *ast.FunctionLiteral:
+		c.checkFunction(e, nil)
+		return c.functionType(e)
+	case *ast.CallExpression:
+		return c.checkCallExpression(e)
+	case *ast.ArrayLiteral:
+		return c.checkArrayLiteral(e)
+	case *ast.HashLiteral:
+		return c.checkHashLiteral(e)
+	case *ast.IndexExpression:
+		return c.checkIndexExpression(e)
+	case *ast.SliceExpression:
+		left := c.checkExpression(e.Left)
+		for _, bound := range []ast.Expression{e.Start, e.End, e.Step} {
+			if bound != nil {
+				c.expectInt(bound, c.checkExpression(bound), "slice bound")
+			}
+		}
+		if _, ok := left.(*Array); ok || left == String {
+			return left
+		}
+		return Any
+	case *ast.PropertyExpression:
+		c.checkExpression(e.Left)
+	case *ast.IfExpression:
+		return c.checkIfExpression(e)
+	case *ast.PipeExpression:
+		c.checkExpression(e.Left)
+		c.checkExpression(e.Right)
+	case *ast.MatchExpression:
+		c.checkExpression(e.Subject)
+		for _, arm := range e.Arms {
+			c.enterScope()
+			c.declarePattern(arm.Pattern)
+			if arm.Guard != nil {
+				c.checkExpression(arm.Guard)
+			}
+			c.checkExpression(arm.Body)
+			c.leaveScope()
+		}
+	case *ast.SwitchExpression:
+		c.checkExpression(e.Subject)
+		for _, sc := range e.Cases {
+			for _, v := range sc.Values {
+				c.checkExpression(v)
+			}
+			c.checkBlock(sc.Body)
+		}
+	case *ast.ForExpression:
+		c.checkExpression(e.Iterable)
+		c.enterScope()
+		c.declarePattern(e.Pattern)
+		c.checkBlock(e.Body)
+		c.leaveScope()
+	case *ast.SelectExpression:
+		for _, sc := range e.Cases {
+			if sc.Operation != nil {
+				c.checkExpression(sc.Operation)
+			}
+			c.enterScope()
+			if sc.Name != nil {
+				c.scope.declare(sc.Name.Value, Any, false)
+			}
+			c.checkBlock(sc.Body)
+			c.leaveScope()
+		}
+	case *ast.YieldExpression:
+		if e.Value != nil {
+			c.checkExpression(e.Value)
+		}
+	case *ast.AwaitExpression:
+		c.checkExpression(e.Value)
+	case *ast.SpreadElement:
+		c.checkExpression(e.Value)
+	}
+	return Any
+}
+
+func (c *checker) checkPrefixExpression(pe *ast.PrefixExpression) Type {
+	right := c.checkExpression(pe.Right)
+	switch pe.Operator {
+	case "!":
+		return Bool
+	case "-":
+		if right == Int {
+			return Int
+		}
+		if right == Any || isNamed(right) {
+			return Any
+		}
+	}
+	c.errorf(pe, "unknown operator: %s%s", pe.Operator, right)
+	return Any
+}
+
+func (c *checker) checkInfixExpression(ie *ast.InfixExpression) Type {
+	left := c.checkExpression(ie.Left)
+	right := c.checkExpression(ie.Right)
+	switch ie.Operator {
+	case "??":
+		if left == Null {
+			return right
+		}
+		return join(left, right)
+	case "instanceof", "==", "!=":
+		return Bool
+	}
+	return c.operatorType(ie, ie.Operator, left, right)
+}
+
+// operatorType returns the type of "left operator right" in the same way as the evaluator
+func (c *checker) operatorType(node ast.Node, operator string, left, right Type) Type {
+	// 左辺の struct やクラスは演算子をオーバーロードできる
+	if left == Any || right == Any || isNamed(left) {
+		return Any
+	}
+	if left == Int && right == Int {
+		switch operator {
+		case "+", "-", "*", "/":
+			return Int
+		case "<", ">":
+			return Bool
+		}
+	}
+	if left == String && right == String && operator == "+" {
+		return String
+	}
+	if !Identical(left, right) {
+		c.errorf(node, "type mismatch: %s %s %s", left, operator, right)
+	} else {
+		c.errorf(node, "unknown operator: %s %s %s", left, operator, right)
+	}
+	return Any
+}
+
+func (c *checker) checkAssignExpression(ae *ast.AssignExpression) Type {
+	value := c.checkExpression(ae.Value)
+	switch target := ae.Target.(type) {
+	case *ast.Identifier:
+		b, ok := c.scope.lookup(target.Value)
+		if !ok {
+			return value
+		}
+		if ae.Operator != "=" {
+			value = c.operatorType(ae, ae.Operator[:1], b.typ, value)
+		}
+		if b.annotated {
+			if !AssignableTo(value, b.typ) {
+				c.errorf(ae.Value, "cannot assign %s to %s of type %s", value, target, b.typ)
+			}
+		} else if !Identical(value, b.typ) {
+			// 注釈のない変数は、異なる型の値が代入されたら検査しない
+			b.typ = Any
+		}
+		return value
+	case *ast.PropertyExpression:
+		c.checkExpression(target.Left)
+		if ae.Operator != "=" {
+			return Any
+		}
+	}
+	return value
+}
+
+func (c *checker) checkCallExpression(ce *ast.CallExpression) Type {
+	callee := c.checkExpression(ce.Function)
+	args := []Type{}
+	spread := false
+	for _, arg := range ce.Arguments {
+		if _, ok := arg.(*ast.SpreadElement); ok {
+			spread = true
+		}
+		args = append(args, c.checkExpression(arg))
+	}
+
+	fn, ok := callee.(*Function)
+	if !ok {
+		return Any
+	}
+	if spread {
+		return fn.Return
+	}
+	if len(args) != len(fn.Parameters) {
+		c.errorf(ce, "wrong number of arguments to %s: got=%d, want=%d",
+			ce.Function, len(args), len(fn.Parameters))
+		return fn.Return
+	}
+	for i, arg := range args {
+		if !AssignableTo(arg, fn.Parameters[i]) {
+			c.errorf(ce.Arguments[i], "cannot use %s as %s in argument %d to %s",
+				arg, fn.Parameters[i], i+1, ce.Function)
+		}
+	}
+	if ce.Optional {
+		return join(fn.Return, Null)
+	}
+	return fn.Return
+}
+
+func (c *checker) checkArrayLiteral(al *ast.ArrayLiteral) Type {
+	var element Type
+	for _, e := range al.Elements {
+		t := c.checkExpression(e)
+		if _, ok := e.(*ast.SpreadElement); ok {
+			t = Any
+		}
+		if element == nil {
+			element = t
+		} else {
+			element = join(element, t)
+		}
+	}
+	if element == nil {
+		element = Any
+	}
+	return &Array{Element: element}
+}
+
+func (c *checker) checkHashLiteral(hl *ast.HashLiteral) Type {
+	var key, value Type
+	for _, k := range hl.Keys {
+		if _, ok := k.(*ast.SpreadElement); ok {
+			c.checkExpression(k)
+			key, value = Any, Any
+			continue
+		}
+		kt := c.checkExpression(k)
+		vt := c.checkExpression(hl.Pairs[k])
+		if key == nil {
+			key, value = kt, vt
+			continue
+		}
+		key, value = join(key, kt), join(value, vt)
+	}
+	if key == nil {
+		key, value = Any, Any
+	}
+	return &Hash{Key: key, Value: value}
+}
+
+func (c *checker) checkIndexExpression(ie *ast.IndexExpression) Type {
+	left := c.checkExpression(ie.Left)
+	index := c.checkExpression(ie.Index)
+	var result Type = Any
+	switch l := left.(type) {
+	case *Array:
+		c.expectInt(ie.Index, index, "array index")
+		result = l.Element
+	case *Hash:
+		if !AssignableTo(index, l.Key) {
+			c.errorf(ie.Index, "cannot use %s as hash key of type %s", index, l.Key)
+		}
+		result = l.Value
+	case *Basic:
+		if l == String {
+			c.expectInt(ie.Index, index, "string index")
+			result = String
+		}
+	}
+	if ie.Optional {
+		return join(result, Null)
+	}
+	return result
+}
+
+func (c *checker) expectInt(node ast.Node, t Type, context string) {
+	if !AssignableTo(t, Int) {
+		c.errorf(node, "%s must be int, got %s", context, t)
+	}
+}
+
+func (c *checker) checkIfExpression(ie *ast.IfExpression) Type {
+	c.checkExpression(ie.Condition)
+	consequence := c.checkBlock(ie.Consequence)
+	switch {
+	case ie.ElseIf != nil:
+		return join(consequence, c.checkIfExpression(ie.ElseIf))
+	case ie.Alternative != nil:
+		return join(consequence, c.checkBlock(ie.Alternative))
+	default:
+		// 条件が偽なら null になる
+		return join(consequence, Null)
+	}
+}
+
+// declarePattern declares the names bound by the pattern with type Any
+func (c *checker) declarePattern(pattern ast.Pattern) {
+	switch p := pattern.(type) {
+	case *ast.BindingPattern:
+		c.scope.declare(p.Name.Value, Any, false)
+	case *ast.AlternativePattern:
+		for _, a := range p.Alternatives {
+			c.declarePattern(a)
+		}
+	case *ast.ArrayPattern:
+		for _, e := range p.Elements {
+			c.declarePattern(e)
+		}
+		c.declareRestPattern(p.Rest)
+	case *ast.HashPattern:
+		for _, pair := range p.Pairs {
+			c.declarePattern(pair.Value)
+		}
+		c.declareRestPattern(p.Rest)
+	case *ast.VariantPattern:
+		for _, f := range p.Fields {
+			c.declarePattern(f)
+		}
+	}
+}
+
+func (c *checker) declareRestPattern(rest *ast.RestPattern) {
+	if rest != nil && rest.Name != nil {
+		c.scope.declare(rest.Name.Value, Any, false)
+	}
+}
+
+func isNamed(t Type) bool {
+	_, ok := t.(*Named)
+	return ok
+}
@@ -0,0 +1,17 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/tshinag/monkey/token"
+)
+
+// Error is the type error found by Check
+type Error struct {
+	Pos     token.Position
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Message)
+}
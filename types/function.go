@@ -0,0 +1,25 @@
+package types
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Function is the type of functions "fn(parameters) -> return"
+type Function struct {
+	Parameters []Type
+	Return     Type
+}
+
+func (f *Function) String() string {
+	var out bytes.Buffer
+	params := []string{}
+	for _, p := range f.Parameters {
+		params = append(params, p.String())
+	}
+	out.WriteString("fn(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") -> ")
+	out.WriteString(f.Return.String())
+	return out.String()
+}
@@ -0,0 +1,11 @@
+package types
+
+// Hash is the type of hashes "{key: value}"
+type Hash struct {
+	Key   Type
+	Value Type
+}
+
+func (h *Hash) String() string {
+	return "{" + h.Key.String() + ": " + h.Value.String() + "}"
+}
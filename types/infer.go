@@ -0,0 +1,734 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/tshinag/monkey/ast"
+)
+
+// Infer infers the type of the program by Hindley–Milner type inference
+func Infer(program *ast.Program) (Type, []*Error) {
+	return NewInferer().Infer(program)
+}
+
+// Inferer infers the types of programs without annotations.
+// The functions bound by let or fn statements are generalized, so that "fn(x) { x }" has type "fn(a) -> a".
+// The global bindings are kept between programs, as the REPL evaluates lines in one environment.
+type Inferer struct {
+	scope   *scope
+	level   int         // let の深さ
+	nextID  int         // 次に作る型変数の番号
+	trail   []*Variable // tryUnify で取り消すために記録した束縛
+	returns []Type      // 推論中の関数の戻り値の型。内側の関数が末尾
+	errors  []*Error
+}
+
+// NewInferer initializes Inferer with the builtin functions
+func NewInferer() *Inferer {
+	in := &Inferer{scope: newScope(nil)}
+	for name, t := range builtinTypes() {
+		in.scope.declare(name, t, false)
+	}
+	return in
+}
+
+// Infer infers the type of the value of program, and declares its global bindings.
+// The unbound type variables of the result are named a, b, c, ...
+func (in *Inferer) Infer(program *ast.Program) (Type, []*Error) {
+	in.errors = nil
+	t := in.inferStatements(program.Statements)
+	return Display(t), in.errors
+}
+
+// Query infers the type of the value of program like Infer, but leaves the inferer as it was,
+// so that asking the type of an expression doesn't change the types of the global bindings.
+func (in *Inferer) Query(program *ast.Program) (Type, []*Error) {
+	scope, trail := in.scope, in.trail
+	// 宣言は捨てる子のスコープに入れ、単一化で束縛した変数は記録して元に戻す
+	in.scope = newScope(scope)
+	in.trail = []*Variable{}
+	t, errors := in.Infer(program)
+	for _, v := range in.trail {
+		v.instance = nil
+	}
+	in.scope, in.trail = scope, trail
+	return t, errors
+}
+
+func (in *Inferer) errorf(node ast.Node, format string, args ...interface{}) {
+	// 一つのメッセージに現れる型変数は同じ名前で表示する
+	names := make(map[*Variable]*Variable)
+	for i, arg := range args {
+		if t, ok := arg.(Type); ok {
+			args[i] = rename(t, names)
+		}
+	}
+	in.errors = append(in.errors, &Error{Pos: node.Pos(), Message: fmt.Sprintf(format, args...)})
+}
+
+func (in *Inferer) enterScope() {
+	in.scope = newScope(in.scope)
+}
+
+func (in *Inferer) leaveScope() {
+	in.scope = in.scope.outer
+}
+
+func (in *Inferer) newVariable() *Variable {
+	in.nextID++
+	return &Variable{id: in.nextID, level: in.level}
+}
+
+// generalize makes the variables created in the inner let generic
+func (in *Inferer) generalize(t Type) Type {
+	switch t := prune(t).(type) {
+	case *Variable:
+		if t.level > in.level {
+			t.level = genericLevel
+		}
+	case *Array:
+		in.generalize(t.Element)
+	case *Hash:
+		in.generalize(t.Key)
+		in.generalize(t.Value)
+	case *Function:
+		for _, p := range t.Parameters {
+			in.generalize(p)
+		}
+		in.generalize(t.Return)
+	}
+	return t
+}
+
+// instantiate returns the copy of t whose generic variables are replaced with new variables
+func (in *Inferer) instantiate(t Type) Type {
+	return in.instantiateWith(t, make(map[*Variable]*Variable))
+}
+
+func (in *Inferer) instantiateWith(t Type, vars map[*Variable]*Variable) Type {
+	switch t := prune(t).(type) {
+	case *Variable:
+		if t.level != genericLevel {
+			return t
+		}
+		if v, ok := vars[t]; ok {
+			return v
+		}
+		v := in.newVariable()
+		v.oneOf = t.oneOf
+		vars[t] = v
+		return v
+	case *Array:
+		return &Array{Element: in.instantiateWith(t.Element, vars)}
+	case *Hash:
+		return &Hash{Key: in.instantiateWith(t.Key, vars), Value: in.instantiateWith(t.Value, vars)}
+	case *Function:
+		params := make([]Type, len(t.Parameters))
+		for i, p := range t.Parameters {
+			params[i] = in.instantiateWith(p, vars)
+		}
+		return &Function{Parameters: params, Return: in.instantiateWith(t.Return, vars)}
+	default:
+		return t
+	}
+}
+
+// annotated returns the type of annotation, or a new variable if it is not annotated
+func (in *Inferer) annotated(annotation ast.TypeExpression) Type {
+	if annotation == nil {
+		return in.newVariable()
+	}
+	return resolve(annotation, in.scope, in.errorf)
+}
+
+func (in *Inferer) inferStatements(statements []ast.Statement) Type {
+	for _, statement := range statements {
+		switch s := statement.(type) {
+		case *ast.StructStatement:
+			in.scope.types[s.Name.Value] = &Named{Name: s.Name.Value}
+		case *ast.ClassStatement:
+			in.scope.types[s.Name.Value] = &Named{Name: s.Name.Value}
+		case *ast.EnumStatement:
+			in.scope.types[s.Name.Value] = &Named{Name: s.Name.Value}
+		}
+	}
+	// 関数宣言は巻き上げられるので、宣言より前の呼び出しのために単相の型変数で束縛しておく
+	for _, statement := range statements {
+		if fs, ok := statement.(*ast.FunctionStatement); ok {
+			in.scope.declare(fs.Name.Value, in.newVariable(), false)
+		}
+	}
+
+	var result Type = Null
+	for _, statement := range statements {
+		result = in.inferStatement(statement)
+	}
+	return result
+}
+
+func (in *Inferer) inferBlock(block *ast.BlockStatement) Type {
+	in.enterScope()
+	defer in.leaveScope()
+	return in.inferStatements(block.Statements)
+}
+
+func (in *Inferer) inferStatement(statement ast.Statement) Type {
+	switch s := statement.(type) {
+	case *ast.LetStatement:
+		in.inferDeclaration(s.Name, s.Type, s.Value)
+	case *ast.ConstStatement:
+		in.inferDeclaration(s.Name, s.Type, s.Value)
+	case *ast.ReturnStatement:
+		t := in.infer(s.ReturnValue)
+		if len(in.returns) > 0 {
+			ret := in.returns[len(in.returns)-1]
+			in.expect(s.ReturnValue, ret, t, "cannot return %s from function returning %s", t, ret)
+		}
+		// return の後には制御が来ないので、どの型とも単一化できる
+		return in.newVariable()
+	case *ast.ExpressionStatement:
+		return in.infer(s.Expression)
+	case *ast.FunctionStatement:
+		in.inferFunctionStatement(s)
+	case *ast.StructStatement:
+		params := make([]Type, len(s.Fields))
+		for i := range params {
+			params[i] = Any
+		}
+		named, _ := in.scope.lookupType(s.Name.Value)
+		in.scope.declare(s.Name.Value, &Function{Parameters: params, Return: named}, false)
+	case *ast.ImplStatement:
+		receiver, ok := in.scope.lookupType(s.Name.Value)
+		if !ok {
+			receiver = Any
+		}
+		for _, method := range s.Methods {
+			in.inferFunction(method.Function, receiver)
+		}
+	case *ast.ClassStatement:
+		in.scope.declare(s.Name.Value, Any, false)
+		self, _ := in.scope.lookupType(s.Name.Value)
+		for _, method := range s.Methods {
+			in.enterScope()
+			in.scope.declare("self", self, false)
+			if s.Superclass != nil {
+				in.scope.declare("super", Any, false)
+			}
+			in.inferFunction(method, nil)
+			in.leaveScope()
+		}
+	case *ast.EnumStatement:
+		in.scope.declare(s.Name.Value, Any, false)
+	}
+	return Null
+}
+
+// inferDeclaration binds the name to the type of value.
+// Only function values are generalized, since other values may be mutated by assignments.
+func (in *Inferer) inferDeclaration(name *ast.Identifier, annotation ast.TypeExpression, value ast.Expression) {
+	var declared Type
+	if annotation != nil {
+		declared = resolve(annotation, in.scope, in.errorf)
+	}
+
+	var t Type
+	if lit, ok := value.(*ast.FunctionLiteral); ok {
+		t = in.inferPolymorphicFunction(name.Value, lit)
+		if declared != nil {
+			instance := in.instantiate(t)
+			in.expect(value, declared, instance, "cannot use %s as %s in declaration of %s", instance, declared, name)
+		}
+	} else {
+		t = in.infer(value)
+		if declared != nil {
+			in.expect(value, declared, t, "cannot use %s as %s in declaration of %s", t, declared, name)
+		}
+	}
+	if declared != nil {
+		t = declared
+	}
+	in.scope.declare(name.Value, t, annotation != nil)
+}
+
+func (in *Inferer) inferFunctionStatement(fs *ast.FunctionStatement) {
+	hoisted, ok := in.scope.bindings[fs.Name.Value]
+	t := in.inferPolymorphicFunction(fs.Name.Value, fs.Function)
+	if !ok {
+		in.scope.declare(fs.Name.Value, t, false)
+		return
+	}
+	// 宣言より前の呼び出しがあれば、その使い方と宣言の型を照合する
+	if v, ok := hoisted.typ.(*Variable); ok && v.instance != nil {
+		instance := in.instantiate(t)
+		in.expect(fs, v, instance, "%s has type %s, but is used as %s before its declaration", fs.Name, instance, v)
+	}
+	in.scope.declare(fs.Name.Value, t, false)
+}
+
+// inferPolymorphicFunction infers the type of function bound to the name, and generalizes it.
+// The name is monomorphic in the body of function.
+func (in *Inferer) inferPolymorphicFunction(name string, lit *ast.FunctionLiteral) Type {
+	in.level++
+	self := in.newVariable()
+	in.enterScope()
+	in.scope.declare(name, self, false)
+	t := in.inferFunction(lit, nil)
+	in.leaveScope()
+	in.expect(lit, self, t, "%s has type %s, but is used as %s in its body", name, t, self)
+	in.level--
+	return in.generalize(t)
+}
+
+// inferFunction infers the type of function.
+// If receiver is given, the unannotated first parameter "self" has the type.
+func (in *Inferer) inferFunction(lit *ast.FunctionLiteral, receiver Type) Type {
+	in.enterScope()
+	defer in.leaveScope()
+
+	params := make([]Type, len(lit.Parameters))
+	for i, param := range lit.Parameters {
+		if i == 0 && receiver != nil && param.Value == "self" && lit.ParameterType(i) == nil {
+			params[i] = receiver
+		} else {
+			params[i] = in.annotated(lit.ParameterType(i))
+		}
+		in.scope.declare(param.Value, params[i], lit.ParameterType(i) != nil)
+	}
+
+	ret := in.annotated(lit.ReturnType)
+	if lit.Generator {
+		// ジェネレータの return は反復の終わりを表すだけなので検査しない
+		ret = Any
+	}
+	in.returns = append(in.returns, ret)
+	defer func() { in.returns = in.returns[:len(in.returns)-1] }()
+
+	result := in.inferBlock(lit.Body)
+	statements := lit.Body.Statements
+	var last ast.Node = lit.Body
+	if len(statements) > 0 {
+		last = statements[len(statements)-1]
+		if es, ok := last.(*ast.ExpressionStatement); ok {
+			last = es.Expression
+		}
+	}
+	if _, ok := last.(*ast.ReturnStatement); !ok {
+		in.expect(last, ret, result, "cannot return %s from function returning %s", result, ret)
+	}
+
+	// ジェネレータはイテレータを、非同期関数は Promise を返す
+	if lit.Generator || lit.Async {
+		return &Function{Parameters: params, Return: Any}
+	}
+	return &Function{Parameters: params, Return: ret}
+}
+
+func (in *Inferer) infer(expression ast.Expression) Type {
+	switch e := expression.(type) {
+	case *ast.IntegerLiteral:
+		return Int
+	case *ast.StringLiteral:
+		return String
+	case *ast.Boolean:
+		return Bool
+	case *ast.NullLiteral:
+		return Null
+	case *ast.Identifier:
+		b, ok := in.scope.lookup(e.Value)
+		if !ok {
+			in.errorf(e, "identifier not found: %s", e.Value)
+			return Any
+		}
+		return in.instantiate(b.typ)
+	case *ast.PrefixExpression:
+		return in.inferPrefixExpression(e)
+	case *ast.InfixExpression:
+		return in.inferInfixExpression(e)
+	case *ast.AssignExpression:
+		return in.inferAssignExpression(e)
+	case *ast.FunctionLiteral:
+		return in.inferFunction(e, nil)
+	case *ast.CallExpression:
+		callee := in.infer(e.Function)
+		return in.inferCall(e, e.Function, callee, e.Arguments, nil)
+	case *ast.PipeExpression:
+		return in.inferPipeExpression(e)
+	case *ast.ArrayLiteral:
+		return in.inferArrayLiteral(e)
+	case *ast.HashLiteral:
+		return in.inferHashLiteral(e)
+	case *ast.IndexExpression:
+		return in.inferIndexExpression(e)
+	case *ast.SliceExpression:
+		left := in.infer(e.Left)
+		for _, bound := range []ast.Expression{e.Start, e.End, e.Step} {
+			if bound != nil {
+				in.expectInt(bound, in.infer(bound), "slice bound")
+			}
+		}
+		return left
+	case *ast.PropertyExpression:
+		// フィールドとメソッドは動的に解決されるので推論しない
+		in.infer(e.Left)
+		return Any
+	case *ast.IfExpression:
+		return in.inferIfExpression(e)
+	case *ast.MatchExpression:
+		return in.inferMatchExpression(e)
+	case *ast.SwitchExpression:
+		return in.inferSwitchExpression(e)
+	case *ast.ForExpression:
+		iterable := in.infer(e.Iterable)
+		var element Type = in.newVariable()
+		switch t := prune(iterable).(type) {
+		case *Array:
+			element = t.Element
+		case *Basic:
+			if t == String {
+				element = String
+			}
+		}
+		in.enterScope()
+		in.bindPattern(e.Pattern, element)
+		in.inferBlock(e.Body)
+		in.leaveScope()
+		return Null
+	case *ast.SelectExpression:
+		for _, sc := range e.Cases {
+			if sc.Operation != nil {
+				in.infer(sc.Operation)
+			}
+			in.enterScope()
+			if sc.Name != nil {
+				in.scope.declare(sc.Name.Value, Any, false)
+			}
+			in.inferBlock(sc.Body)
+			in.leaveScope()
+		}
+	case *ast.YieldExpression:
+		if e.Value != nil {
+			in.infer(e.Value)
+		}
+	case *ast.AwaitExpression:
+		in.infer(e.Value)
+	case *ast.SpreadElement:
+		in.infer(e.Value)
+	}
+	return Any
+}
+
+func (in *Inferer) inferPrefixExpression(pe *ast.PrefixExpression) Type {
+	right := in.infer(pe.Right)
+	if pe.Operator == "!" {
+		return Bool
+	}
+	if r := prune(right); r == Any || isNamed(r) {
+		return Any
+	}
+	if in.unify(Int, right) != nil {
+		in.errorf(pe, "unknown operator: %s%s", pe.Operator, right)
+	}
+	return Int
+}
+
+func (in *Inferer) inferInfixExpression(ie *ast.InfixExpression) Type {
+	left := in.infer(ie.Left)
+	right := in.infer(ie.Right)
+	switch ie.Operator {
+	case "==", "!=", "instanceof":
+		return Bool
+	case "??":
+		if prune(left) == Null {
+			return right
+		}
+		in.expect(ie, left, right, "type mismatch: %s ?? %s", left, right)
+		return left
+	}
+	return in.operatorType(ie, ie.Operator, left, right)
+}
+
+// operatorType unifies the operands of the arithmetic or comparison operator.
+// "+" concatenates strings if either operand is known to be string, and adds integers if either is known
+// to be int. Otherwise the operands of "+" are either integers or strings, and those of the others are integers.
+func (in *Inferer) operatorType(node ast.Node, operator string, left, right Type) Type {
+	l, r := prune(left), prune(right)
+	// 左辺の struct やクラスは演算子をオーバーロードできる
+	if l == Any || r == Any || isNamed(l) {
+		return Any
+	}
+	var operand Type = Int
+	if operator == "+" {
+		_, lv := l.(*Variable)
+		_, rv := r.(*Variable)
+		switch {
+		case l == String || r == String:
+			operand = String
+		case lv && rv:
+			v := in.newVariable()
+			v.oneOf = []Type{Int, String}
+			operand = v
+		}
+	}
+	if in.unify(operand, left) != nil || in.unify(operand, right) != nil {
+		if Identical(left, right) {
+			in.errorf(node, "unknown operator: %s %s %s", left, operator, right)
+		} else {
+			in.errorf(node, "type mismatch: %s %s %s", left, operator, right)
+		}
+	}
+	if operator == "<" || operator == ">" {
+		return Bool
+	}
+	return operand
+}
+
+func (in *Inferer) inferAssignExpression(ae *ast.AssignExpression) Type {
+	value := in.infer(ae.Value)
+	target, ok := ae.Target.(*ast.Identifier)
+	if !ok {
+		in.infer(ae.Target)
+		if ae.Operator != "=" {
+			return Any
+		}
+		return value
+	}
+	b, ok := in.scope.lookup(target.Value)
+	if !ok {
+		in.errorf(target, "identifier not found: %s", target.Value)
+		return value
+	}
+	t := in.instantiate(b.typ)
+	if ae.Operator != "=" {
+		value = in.operatorType(ae, ae.Operator[:1], t, value)
+	}
+	in.expect(ae.Value, t, value, "cannot assign %s to %s of type %s", value, target, t)
+	return value
+}
+
+// inferCall infers the call of the callee with the arguments following the piped value, if any
+func (in *Inferer) inferCall(node ast.Node, function ast.Expression, callee Type, arguments []ast.Expression, piped ast.Expression) Type {
+	nodes := arguments
+	if piped != nil {
+		nodes = append([]ast.Expression{piped}, arguments...)
+	}
+	args := make([]Type, len(nodes))
+	spread := false
+	for i, arg := range nodes {
+		if _, ok := arg.(*ast.SpreadElement); ok {
+			spread = true
+		}
+		args[i] = in.infer(arg)
+	}
+
+	fn, ok := prune(callee).(*Function)
+	if !ok {
+		if spread {
+			return Any
+		}
+		ret := in.newVariable()
+		in.expect(function, callee, &Function{Parameters: args, Return: ret}, "cannot call %s of type %s", function, callee)
+		return ret
+	}
+	if spread {
+		return fn.Return
+	}
+	if len(args) != len(fn.Parameters) {
+		in.errorf(node, "wrong number of arguments to %s: got=%d, want=%d", function, len(args), len(fn.Parameters))
+		return fn.Return
+	}
+	for i, arg := range args {
+		in.expect(nodes[i], fn.Parameters[i], arg, "cannot use %s as %s in argument %d to %s",
+			arg, fn.Parameters[i], i+1, function)
+	}
+	return fn.Return
+}
+
+func (in *Inferer) inferPipeExpression(pe *ast.PipeExpression) Type {
+	if call, ok := pe.Right.(*ast.CallExpression); ok {
+		callee := in.infer(call.Function)
+		return in.inferCall(call, call.Function, callee, call.Arguments, pe.Left)
+	}
+	callee := in.infer(pe.Right)
+	return in.inferCall(pe, pe.Right, callee, nil, pe.Left)
+}
+
+func (in *Inferer) inferArrayLiteral(al *ast.ArrayLiteral) Type {
+	array := &Array{Element: in.newVariable()}
+	for _, e := range al.Elements {
+		if spread, ok := e.(*ast.SpreadElement); ok {
+			t := in.infer(spread.Value)
+			// 文字列は文字の、ハッシュはキーと値の組の配列として展開される
+			switch st := prune(t).(type) {
+			case *Basic:
+				if st == String {
+					in.expect(e, array.Element, String, "cannot spread %s into %s", t, array)
+					continue
+				}
+			case *Hash:
+				in.expect(e, array.Element, &Array{Element: Any}, "cannot spread %s into %s", t, array)
+				continue
+			}
+			in.expect(e, array, t, "cannot spread %s into %s", t, array)
+			continue
+		}
+		t := in.infer(e)
+		in.expect(e, array.Element, t, "cannot use %s as %s in array literal", t, array.Element)
+	}
+	return array
+}
+
+// inferHashLiteral infers the type of hash literal.
+// The values of different types make the value type Any, since hashes are also used as records.
+func (in *Inferer) inferHashLiteral(hl *ast.HashLiteral) Type {
+	key, value := in.newVariable(), Type(in.newVariable())
+	for _, k := range hl.Keys {
+		if spread, ok := k.(*ast.SpreadElement); ok {
+			t := in.infer(spread.Value)
+			hash := &Hash{Key: key, Value: value}
+			if !in.tryUnify(hash, t) {
+				in.expect(k, &Hash{Key: key, Value: Any}, t, "cannot spread %s into %s", t, hash)
+				value = Any
+			}
+			continue
+		}
+		kt := in.infer(k)
+		in.expect(k, key, kt, "cannot use %s as hash key of type %s", kt, key)
+		vt := in.infer(hl.Pairs[k])
+		if !in.tryUnify(value, vt) {
+			value = Any
+		}
+	}
+	return &Hash{Key: key, Value: value}
+}
+
+func (in *Inferer) inferIndexExpression(ie *ast.IndexExpression) Type {
+	left := in.infer(ie.Left)
+	index := in.infer(ie.Index)
+	switch l := prune(left).(type) {
+	case *Array:
+		in.expectInt(ie.Index, index, "array index")
+		return l.Element
+	case *Hash:
+		in.expect(ie.Index, l.Key, index, "cannot use %s as hash key of type %s", index, l.Key)
+		return l.Value
+	case *Variable:
+		// 添字が文字列ならハッシュ、そうでなければ配列とみなす
+		element := in.newVariable()
+		if prune(index) == String {
+			in.unify(l, &Hash{Key: String, Value: element})
+			return element
+		}
+		in.unify(l, &Array{Element: element})
+		in.expectInt(ie.Index, index, "array index")
+		return element
+	case *Basic:
+		if l == String {
+			in.expectInt(ie.Index, index, "string index")
+			return String
+		}
+		if l == Any {
+			return Any
+		}
+	case *Named:
+		// __index__ でオーバーロードできる
+		return Any
+	}
+	in.errorf(ie, "index operator not supported: %s", left)
+	return Any
+}
+
+func (in *Inferer) expectInt(node ast.Node, t Type, context string) {
+	if in.unify(Int, t) != nil {
+		in.errorf(node, "%s must be int, got %s", context, t)
+	}
+}
+
+// inferIfExpression infers the type of if expression.
+// The if expression without else has the type of its consequence, as if null had every type.
+func (in *Inferer) inferIfExpression(ie *ast.IfExpression) Type {
+	in.infer(ie.Condition)
+	consequence := in.inferBlock(ie.Consequence)
+	var alternative Type
+	switch {
+	case ie.ElseIf != nil:
+		alternative = in.inferIfExpression(ie.ElseIf)
+	case ie.Alternative != nil:
+		alternative = in.inferBlock(ie.Alternative)
+	default:
+		return consequence
+	}
+	in.expect(ie, consequence, alternative, "mismatched types %s and %s in branches of if", consequence, alternative)
+	return consequence
+}
+
+func (in *Inferer) inferMatchExpression(me *ast.MatchExpression) Type {
+	subject := in.infer(me.Subject)
+	result := in.newVariable()
+	for _, arm := range me.Arms {
+		in.enterScope()
+		in.bindPattern(arm.Pattern, subject)
+		if arm.Guard != nil {
+			in.infer(arm.Guard)
+		}
+		body := in.infer(arm.Body)
+		in.expect(arm.Body, result, body, "mismatched types %s and %s in arms of match", result, body)
+		in.leaveScope()
+	}
+	return result
+}
+
+// inferSwitchExpression infers the type of switch expression.
+// The cases of different types make the type Any, since switch is also used as a statement.
+func (in *Inferer) inferSwitchExpression(se *ast.SwitchExpression) Type {
+	in.infer(se.Subject)
+	var result Type = in.newVariable()
+	for _, sc := range se.Cases {
+		for _, v := range sc.Values {
+			in.infer(v)
+		}
+		body := in.inferBlock(sc.Body)
+		if !in.tryUnify(result, body) {
+			result = Any
+		}
+	}
+	return result
+}
+
+// bindPattern declares the names bound by the pattern matching the value of type t
+func (in *Inferer) bindPattern(pattern ast.Pattern, t Type) {
+	switch p := pattern.(type) {
+	case *ast.BindingPattern:
+		in.scope.declare(p.Name.Value, t, false)
+	case *ast.AlternativePattern:
+		for _, a := range p.Alternatives {
+			in.bindPattern(a, t)
+		}
+	case *ast.ArrayPattern:
+		var element Type = Any
+		if array, ok := prune(t).(*Array); ok {
+			element = array.Element
+		}
+		for _, e := range p.Elements {
+			in.bindPattern(e, element)
+		}
+		if p.Rest != nil && p.Rest.Name != nil {
+			in.scope.declare(p.Rest.Name.Value, &Array{Element: element}, false)
+		}
+	case *ast.HashPattern:
+		var hash Type = Any
+		var value Type = Any
+		if h, ok := prune(t).(*Hash); ok {
+			hash, value = h, h.Value
+		}
+		for _, pair := range p.Pairs {
+			in.bindPattern(pair.Value, value)
+		}
+		if p.Rest != nil && p.Rest.Name != nil {
+			in.scope.declare(p.Rest.Name.Value, hash, false)
+		}
+	case *ast.VariantPattern:
+		for _, f := range p.Fields {
+			in.bindPattern(f, Any)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package types
+
+// Named is the type of values of struct, class or enum declared by the name
+type Named struct {
+	Name string
+}
+
+func (n *Named) String() string {
+	return n.Name
+}
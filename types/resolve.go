@@ -0,0 +1,41 @@
+package types
+
+import "github.com/tshinag/monkey/ast"
+
+// resolve returns the type of the annotation in the scope, and reports unknown type names by errorf.
+// The type of nil annotation is Any.
+func resolve(annotation ast.TypeExpression, s *scope, errorf func(ast.Node, string, ...interface{})) Type {
+	switch a := annotation.(type) {
+	case nil:
+		return Any
+	case *ast.NamedType:
+		switch a.Name {
+		case "int":
+			return Int
+		case "string":
+			return String
+		case "bool":
+			return Bool
+		case "null":
+			return Null
+		case "any":
+			return Any
+		}
+		if t, ok := s.lookupType(a.Name); ok {
+			return t
+		}
+		errorf(a, "unknown type %s", a.Name)
+		return Any
+	case *ast.ArrayType:
+		return &Array{Element: resolve(a.Element, s, errorf)}
+	case *ast.HashType:
+		return &Hash{Key: resolve(a.Key, s, errorf), Value: resolve(a.Value, s, errorf)}
+	case *ast.FunctionType:
+		params := make([]Type, len(a.Parameters))
+		for i, p := range a.Parameters {
+			params[i] = resolve(p, s, errorf)
+		}
+		return &Function{Parameters: params, Return: resolve(a.Return, s, errorf)}
+	}
+	return Any
+}
@@ -0,0 +1,44 @@
+package types
+
+// binding is the type of variable
+type binding struct {
+	typ       Type
+	annotated bool // 型注釈で宣言された場合
+}
+
+// scope is the static counterpart of object.Environment
+type scope struct {
+	bindings map[string]*binding
+	types    map[string]Type // struct, class, enum で宣言された型
+	outer    *scope
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{
+		bindings: make(map[string]*binding),
+		types:    make(map[string]Type),
+		outer:    outer,
+	}
+}
+
+func (s *scope) lookup(name string) (*binding, bool) {
+	for sc := s; sc != nil; sc = sc.outer {
+		if b, ok := sc.bindings[name]; ok {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+func (s *scope) declare(name string, typ Type, annotated bool) {
+	s.bindings[name] = &binding{typ: typ, annotated: annotated}
+}
+
+func (s *scope) lookupType(name string) (Type, bool) {
+	for sc := s; sc != nil; sc = sc.outer {
+		if t, ok := sc.types[name]; ok {
+			return t, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,97 @@
+package types
+
+// Type is the static type of expression
+type Type interface {
+	String() string
+}
+
+// Basic is the type of primitive values
+type Basic struct {
+	Name string
+}
+
+func (b *Basic) String() string {
+	return b.Name
+}
+
+var (
+	// Int is the type of integers
+	Int = &Basic{Name: "int"}
+	// String is the type of strings
+	String = &Basic{Name: "string"}
+	// Bool is the type of booleans
+	Bool = &Basic{Name: "bool"}
+	// Null is the type of null
+	Null = &Basic{Name: "null"}
+	// Any is the type of unannotated values. It is compatible with every type,
+	// so that the values are not checked.
+	Any = &Basic{Name: "any"}
+)
+
+// Identical reports whether a and b are the same type
+func Identical(a, b Type) bool {
+	a, b = prune(a), prune(b)
+	switch a := a.(type) {
+	case *Variable:
+		return a == b
+	case *Basic:
+		return a == b
+	case *Array:
+		b, ok := b.(*Array)
+		return ok && Identical(a.Element, b.Element)
+	case *Hash:
+		b, ok := b.(*Hash)
+		return ok && Identical(a.Key, b.Key) && Identical(a.Value, b.Value)
+	case *Function:
+		b, ok := b.(*Function)
+		if !ok || len(a.Parameters) != len(b.Parameters) || !Identical(a.Return, b.Return) {
+			return false
+		}
+		for i := range a.Parameters {
+			if !Identical(a.Parameters[i], b.Parameters[i]) {
+				return false
+			}
+		}
+		return true
+	case *Named:
+		b, ok := b.(*Named)
+		return ok && a.Name == b.Name
+	}
+	return false
+}
+
+// AssignableTo reports whether a value of type from can be used as type to.
+// Any is assignable to and from every type.
+func AssignableTo(from, to Type) bool {
+	if from == Any || to == Any {
+		return true
+	}
+	switch to := to.(type) {
+	case *Array:
+		from, ok := from.(*Array)
+		return ok && AssignableTo(from.Element, to.Element)
+	case *Hash:
+		from, ok := from.(*Hash)
+		return ok && AssignableTo(from.Key, to.Key) && AssignableTo(from.Value, to.Value)
+	case *Function:
+		from, ok := from.(*Function)
+		if !ok || len(from.Parameters) != len(to.Parameters) || !AssignableTo(from.Return, to.Return) {
+			return false
+		}
+		for i := range to.Parameters {
+			if !AssignableTo(to.Parameters[i], from.Parameters[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return Identical(from, to)
+}
+
+// join returns the type of value which is either a or b
+func join(a, b Type) Type {
+	if Identical(a, b) {
+		return a
+	}
+	return Any
+}
@@ -0,0 +1,306 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tshinag/monkey/evaluator"
+	"github.com/tshinag/monkey/lexer"
+	"github.com/tshinag/monkey/parser"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		// 注釈のないコードは検査しない
+		{"let add = fn(a, b) { a + b }; add(1, \"two\"); add(1)", nil},
+		{"let x = 1; x = \"s\"; x + \"t\"", nil},
+		{"let f = fn(x) { x }; f(1) - f(\"a\")", nil},
+		{"puts(foo + 1); len(1, 2)", nil},
+		// リテラルからの推論
+		{`"a" - 1`, []string{"1:5: type mismatch: string - int"}},
+		{`let s = "a"; s * 2`, []string{"1:16: type mismatch: string * int"}},
+		{"true + false", []string{"1:6: unknown operator: bool + bool"}},
+		{`-"a"`, []string{"1:1: unknown operator: -string"}},
+		{`[1 < 2, "a" + "b", 1 == "a", !1, null ?? 1]`, nil},
+		{"let x = [1, 2][0]; x + \"a\"", []string{"1:22: type mismatch: int + string"}},
+		{`let h = {"a": 1}; h["a"] + h[1]`, []string{"1:30: cannot use int as hash key of type string"}},
+		{`"abc"["a"]; [1][true]; [1, 2][0:"x"]`, []string{
+			"1:7: string index must be int, got string",
+			"1:17: array index must be int, got bool",
+			"1:33: slice bound must be int, got string",
+		}},
+		{`let x = if (true) { 1 } else { 2 }; x + "a"`, []string{"1:39: type mismatch: int + string"}},
+		{`let x = if (true) { 1 }; x + "a"`, nil},
+		// 注釈
+		{"let x: int = 1; let y: string = x", []string{"1:33: cannot use int as string in declaration of y"}},
+		{"const x: [int] = [\"a\"]; let y: [int] = [1, \"a\"]; let z: [int] = []", []string{"1:18: cannot use [string] as [int] in declaration of x"}},
+		{"let h: {string: int} = {\"a\": true}", []string{"1:24: cannot use {string: bool} as {string: int} in declaration of h"}},
+		{"let x: int = 1; x = \"a\"; x += 1", []string{"1:21: cannot assign string to x of type int"}},
+		{"let x: string = \"a\"; x -= 1", []string{"1:24: type mismatch: string - int"}},
+		{"let x: any = 1; x = \"a\"", nil},
+		{"let p: Point = 1", []string{"1:8: unknown type Point"}},
+		{"fn add(a: int, b: int) -> int { a + b }; add(1, \"2\"); add(1); add(1, 2) + \"a\"", []string{
+			"1:49: cannot use string as int in argument 2 to add",
+			"1:58: wrong number of arguments to add: got=1, want=2",
+			"1:73: type mismatch: int + string",
+		}},
+		{"add(\"a\", 1); fn add(a: int, b) { a }", []string{"1:5: cannot use string as int in argument 1 to add"}},
+		{"let f = fn(a: string, b: [int]) -> bool { len(b) > 0 }; f(\"x\", [\"y\"]); f(\"x\", [1, 2]); f(...[1, 2])",
+			[]string{"1:64: cannot use [string] as [int] in argument 2 to f"}},
+		{"fn f(n: int) -> string { if (n > 0) { return n } \"neg\" }", []string{"1:46: cannot return int from function returning string"}},
+		{"fn f() -> int { \"a\" }", []string{"1:17: cannot return string from function returning int"}},
+		{"fn f() -> int { let x = 1 }; fn g() -> int {}", []string{
+			"1:17: cannot return null from function returning int",
+			"1:44: cannot return null from function returning int",
+		}},
+		{"fn f(n) -> int { if (n) { 1 } }", nil},
+		{"fn fact(n: int) -> int { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(\"a\")",
+			[]string{"1:76: cannot use string as int in argument 1 to fact"}},
+		{"let fact = fn(n: int) -> int { fact(n) }; fact(true)", []string{"1:48: cannot use bool as int in argument 1 to fact"}},
+		{"let apply: fn(fn(int) -> int, int) -> int = fn(f, x) { f(x) }; apply(|x: int| x * 2, 1); apply(|s: string| s, 1)",
+			[]string{"1:96: cannot use fn(string) -> any as fn(int) -> int in argument 1 to apply"}},
+		{"let x: int = 1; let f = fn(x) { x + \"a\" }; f", nil},
+		{"let x: int = 1; match (1) { [x] => x + \"a\", _ => 0 }", nil},
+		{"let x: int = 1; for (x in [\"a\"]) { x + \"b\" }", nil},
+		{"async fn f() -> int { 1 }; let p: int = f()", nil},
+		{"async fn f() -> int { \"a\" }", []string{"1:23: cannot return string from function returning int"}},
+		{"fn g() -> int { yield 1; \"a\" }", nil},
+		// struct、クラス、enum
+		{"struct P { x }; let p: P = P(1); let q: P = 1; P(1, 2)", []string{
+			"1:45: cannot use int as P in declaration of q",
+			"1:49: wrong number of arguments to P: got=2, want=1",
+		}},
+		{"struct V { x }; impl V { fn __add__(self, o) { V(self.x + o.x) } }; V(1) + 1", nil},
+		{"struct V { x }; impl V { fn f(self) -> int { self } }", []string{"1:46: cannot return V from function returning int"}},
+		{"class C { get() -> int { self } }; let c: C = C()", []string{"1:26: cannot return C from function returning int"}},
+		{"enum E { A }; let e: E = E.A; fn f(e: E) -> E { e }", nil},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("parser errors for %q: %v", tt.input, p.Errors())
+		}
+
+		errors := Check(program)
+		actual := []string{}
+		for _, err := range errors {
+			actual = append(actual, err.Error())
+		}
+		if strings.Join(actual, "\n") != strings.Join(tt.expected, "\n") {
+			t.Errorf("wrong errors for %q.\nexpected=%q\ngot=%q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestInfer(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1", "int"},
+		{`"a" + "b"`, "string"},
+		{"1 < 2", "bool"},
+		{"fn(x) { x }", "fn(a) -> a"},
+		{"fn(x, y) { x }", "fn(a, b) -> a"},
+		{"fn(f, x) { f(x) }", "fn(fn(a) -> b, a) -> b"},
+		{"fn(x) { x + 1 }", "fn(int) -> int"},
+		{`fn(x) { x + "!" }`, "fn(string) -> string"},
+		{"fn(x, y) { x + y }", "fn(a: int|string, a) -> a"},
+		{"fn(f, x) { f(x + x) }", "fn(fn(a: int|string) -> b, a) -> b"},
+		{`fn(a, b) { a + b }("x", "y")`, "string"},
+		{`let add = fn(a, b) { a + b }; add(1, 2); add("a", "b")`, "string"},
+		{"fn(x, y) { x + y - 1 }", "fn(int, int) -> int"},
+		{"fn(x, y) { x < y }", "fn(int, int) -> bool"},
+		{"fn(xs) { xs[0] }", "fn([a]) -> a"},
+		{`fn(h) { h["k"] }`, "fn({string: a}) -> a"},
+		{"fn(x) { if (x) { 1 } else { 2 } }", "fn(a) -> int"},
+		{"fn(n) { if (n < 2) { return n } return 0 }", "fn(int) -> int"},
+		{"fn() { let x = 1 }", "fn() -> null"},
+		{"[]", "[a]"},
+		{"[1, 2]", "[int]"},
+		{`[..."ab", "c"]`, "[string]"},
+		{`[...{"a": 1}]`, "[[any]]"},
+		{`{"a": 1, "b": true}`, "{string: any}"},
+		{"let id = fn(x) { x }; [id(1), id(2)]; id(true)", "bool"},
+		{"let id = fn(x) { x }; id(id)", "fn(a) -> a"},
+		{"fn compose(f, g) { fn(x) { f(g(x)) } } compose", "fn(fn(a) -> b, fn(c) -> a) -> fn(c) -> b"},
+		{"let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact", "fn(int) -> int"},
+		{"fn even(n) { if (n == 0) { true } else { odd(n - 1) } } fn odd(n) { if (n == 0) { false } else { even(n - 1) } } odd", "fn(int) -> bool"},
+		{"let x = 1; x = 2; x", "int"},
+		{"map([1, 2], fn(x) { x * 2 })", "[int]"},
+		{`map(["a"], len)`, "[int]"},
+		{"filter([1, 2], fn(x) { x > 1 })", "[int]"},
+		{`reduce([1, 2], "", fn(acc, x) { acc + "x" })`, "string"},
+		{"first", "fn([a]) -> a"},
+		{"push([1], 2)", "[int]"},
+		{"[1, 2] |> map(fn(x) { x > 1 })", "[bool]"},
+		{"1 |> fn(x) { [x] }", "[int]"},
+		{"match (1) { 0 => \"zero\", n => \"many\" }", "string"},
+		{"match ([1]) { [x, ...rest] => rest, _ => [] }", "[int]"},
+		{"for (x in [1]) { x + 1 }", "null"},
+		{"let f: fn(int) -> int = fn(x) { x }; f", "fn(int) -> int"},
+		{"fn(x: string) { x }", "fn(string) -> string"},
+		{"struct P { x }; let p = P(1); p.x", "any"},
+		{"struct P { x }; P", "fn(any) -> P"},
+		{"async fn f() { 1 }; f", "fn() -> any"},
+		{"fn g() { yield 1 }; g", "fn() -> any"},
+	}
+
+	for _, tt := range tests {
+		typ, errors := inferTestProgram(t, tt.input)
+		if len(errors) != 0 {
+			t.Errorf("unexpected errors for %q: %v", tt.input, errors)
+			continue
+		}
+		if typ.String() != tt.expected {
+			t.Errorf("wrong type for %q. expected=%q, got=%q", tt.input, tt.expected, typ)
+		}
+	}
+}
+
+func TestInferErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{`1 + "a"`, []string{"1:3: type mismatch: int + string"}},
+		{"true + false", []string{"1:6: unknown operator: bool + bool"}},
+		{`-"a"`, []string{"1:1: unknown operator: -string"}},
+		{"foo", []string{"1:1: identifier not found: foo"}},
+		{`let f = fn(x) { x + 1 }; f("a")`, []string{"1:28: cannot use string as int in argument 1 to f"}},
+		{"let f = fn(x) { x }; f(1, 2)", []string{"1:23: wrong number of arguments to f: got=2, want=1"}},
+		{"let add = fn(a, b) { a + b }; add(true, false)", []string{
+			"1:35: cannot use bool as a: int|string in argument 1 to add",
+			"1:41: cannot use bool as a: int|string in argument 2 to add",
+		}},
+		{`let add = fn(a, b) { a + b }; add(1, "b")`, []string{"1:38: cannot use string as int in argument 2 to add"}},
+		{"1(2)", []string{"1:1: cannot call 1 of type int"}},
+		{`[1, "a"]`, []string{"1:5: cannot use string as int in array literal"}},
+		{`[1, ..."a"]`, []string{"1:5: cannot spread string into [int]"}},
+		{`if (true) { 1 } else { "a" }`, []string{"1:1: mismatched types int and string in branches of if"}},
+		{`match (1) { 0 => 1, _ => "a" }`, []string{"1:26: mismatched types int and string in arms of match"}},
+		{`fn(x) { if (x) { return 1 } "a" }`, []string{"1:29: cannot return string from function returning int"}},
+		{"fn(x) { x(x) }", []string{"1:9: infinite type: a = fn(a) -> b"}},
+		{`let x = 1; x = "a"`, []string{"1:16: cannot assign string to x of type int"}},
+		{`[1][true]; "a"["b"]`, []string{"1:5: array index must be int, got bool", "1:16: string index must be int, got string"}},
+		{`let h = {"a": 1}; h[1]`, []string{"1:21: cannot use int as hash key of type string"}},
+		{`let f = fn(f) { f(1) }; f(fn(s) { s + "a" })`, []string{"1:27: cannot use fn(string) -> string as fn(int) -> a in argument 1 to f"}},
+		{"f(1); fn f(s) { s + \"a\" }", []string{"1:7: f has type fn(string) -> string, but is used as fn(int) -> a before its declaration"}},
+		{"let x: string = 1", []string{"1:17: cannot use int as string in declaration of x"}},
+		{"let xs = [1]; push(xs, true)", []string{"1:24: cannot use bool as int in argument 2 to push"}},
+	}
+
+	for _, tt := range tests {
+		_, errors := inferTestProgram(t, tt.input)
+		actual := []string{}
+		for _, err := range errors {
+			actual = append(actual, err.Error())
+		}
+		if strings.Join(actual, "\n") != strings.Join(tt.expected, "\n") {
+			t.Errorf("wrong errors for %q.\nexpected=%q\ngot=%q", tt.input, tt.expected, actual)
+		}
+	}
+}
+
+func TestInfererKeepsBindings(t *testing.T) {
+	in := NewInferer()
+	lines := []struct {
+		input    string
+		expected string
+	}{
+		{"let id = fn(x) { x }", "null"},
+		{"let xs = []", "null"},
+		{"id", "fn(a) -> a"},
+		{"xs", "[a]"},
+		{"push(xs, id(1))", "[int]"},
+		{"xs", "[int]"},
+	}
+
+	for _, tt := range lines {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		typ, errors := in.Infer(program)
+		if len(errors) != 0 {
+			t.Fatalf("unexpected errors for %q: %v", tt.input, errors)
+		}
+		if typ.String() != tt.expected {
+			t.Errorf("wrong type for %q. expected=%q, got=%q", tt.input, tt.expected, typ)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	in := NewInferer()
+	in.Infer(parser.New(lexer.New("let xs = []")).ParseProgram())
+	queries := []struct {
+		input    string
+		expected string
+	}{
+		{`push(xs, "a")`, "[string]"},
+		{"let xs = 1; xs", "int"},
+		{"xs", "[a]"},
+		{"push(xs, 1)", "[int]"},
+	}
+	for _, tt := range queries {
+		typ, errors := in.Query(parser.New(lexer.New(tt.input)).ParseProgram())
+		if len(errors) != 0 {
+			t.Fatalf("unexpected errors for %q: %v", tt.input, errors)
+		}
+		if typ.String() != tt.expected {
+			t.Errorf("wrong type for %q. expected=%q, got=%q", tt.input, tt.expected, typ)
+		}
+	}
+}
+
+func TestBuiltinTypes(t *testing.T) {
+	types := builtinTypes()
+	for _, name := range evaluator.BuiltinNames() {
+		if _, ok := types[name]; !ok {
+			t.Errorf("builtin %s has no type", name)
+		}
+	}
+}
+
+func inferTestProgram(t *testing.T, input string) (Type, []*Error) {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+	return Infer(program)
+}
+
+func TestAssignableTo(t *testing.T) {
+	fnIntInt := &Function{Parameters: []Type{Int}, Return: Int}
+	tests := []struct {
+		from, to Type
+		expected bool
+	}{
+		{Int, Int, true},
+		{Int, String, false},
+		{Any, Int, true},
+		{Int, Any, true},
+		{Null, Int, false},
+		{&Array{Element: Int}, &Array{Element: Int}, true},
+		{&Array{Element: Any}, &Array{Element: Int}, true},
+		{&Array{Element: String}, &Array{Element: Int}, false},
+		{&Hash{Key: String, Value: Int}, &Hash{Key: String, Value: Any}, true},
+		{&Hash{Key: Int, Value: Int}, &Hash{Key: String, Value: Int}, false},
+		{fnIntInt, fnIntInt, true},
+		{&Function{Parameters: []Type{Any}, Return: Int}, fnIntInt, true},
+		{&Function{Parameters: []Type{Int, Int}, Return: Int}, fnIntInt, false},
+		{&Function{Parameters: []Type{Int}, Return: String}, fnIntInt, false},
+		{&Named{Name: "P"}, &Named{Name: "P"}, true},
+		{&Named{Name: "P"}, &Named{Name: "Q"}, false},
+	}
+
+	for _, tt := range tests {
+		if AssignableTo(tt.from, tt.to) != tt.expected {
+			t.Errorf("AssignableTo(%s, %s) wrong. expected=%t", tt.from, tt.to, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package types
+
+import "github.com/tshinag/monkey/ast"
+
+// mismatch is the pair of types which cannot be unified
+type mismatch struct {
+	want, got Type
+	infinite  bool // 変数が自身を含む型に束縛されようとした場合
+}
+
+// unify makes a and b the same type by binding the variables in them.
+// Any is unified with every type without binding.
+func (in *Inferer) unify(a, b Type) *mismatch {
+	a, b = prune(a), prune(b)
+	if a == b || a == Any || b == Any {
+		return nil
+	}
+	if v, ok := a.(*Variable); ok {
+		return in.bind(v, b)
+	}
+	if v, ok := b.(*Variable); ok {
+		return in.bind(v, a)
+	}
+
+	switch a := a.(type) {
+	case *Array:
+		if b, ok := b.(*Array); ok {
+			return in.unify(a.Element, b.Element)
+		}
+	case *Hash:
+		if b, ok := b.(*Hash); ok {
+			if m := in.unify(a.Key, b.Key); m != nil {
+				return m
+			}
+			return in.unify(a.Value, b.Value)
+		}
+	case *Function:
+		if b, ok := b.(*Function); ok && len(a.Parameters) == len(b.Parameters) {
+			for i := range a.Parameters {
+				if m := in.unify(a.Parameters[i], b.Parameters[i]); m != nil {
+					return m
+				}
+			}
+			return in.unify(a.Return, b.Return)
+		}
+	case *Named:
+		if b, ok := b.(*Named); ok && a.Name == b.Name {
+			return nil
+		}
+	}
+	return &mismatch{want: a, got: b}
+}
+
+func (in *Inferer) bind(v *Variable, t Type) *mismatch {
+	if v.oneOf != nil {
+		// 候補のある変数は、候補のない変数に束縛させて候補を残す
+		if w, ok := t.(*Variable); ok {
+			if w.oneOf == nil {
+				return in.bind(w, v)
+			}
+		} else if !v.allows(t) {
+			return &mismatch{want: v, got: t}
+		}
+	}
+	if occurs(v, t) {
+		return &mismatch{want: v, got: t, infinite: true}
+	}
+	v.instance = t
+	if in.trail != nil {
+		in.trail = append(in.trail, v)
+	}
+	return nil
+}
+
+// occurs reports whether v occurs in t.
+// It also lowers the levels of variables in t to the level of v,
+// since they are now reachable from the binding of v.
+func occurs(v *Variable, t Type) bool {
+	switch t := prune(t).(type) {
+	case *Variable:
+		if t == v {
+			return true
+		}
+		if t.level > v.level {
+			t.level = v.level
+		}
+	case *Array:
+		return occurs(v, t.Element)
+	case *Hash:
+		return occurs(v, t.Key) || occurs(v, t.Value)
+	case *Function:
+		for _, p := range t.Parameters {
+			if occurs(v, p) {
+				return true
+			}
+		}
+		return occurs(v, t.Return)
+	}
+	return false
+}
+
+// tryUnify unifies a and b, or leaves them as they were if they cannot be unified
+func (in *Inferer) tryUnify(a, b Type) bool {
+	outer := in.trail
+	in.trail = []*Variable{}
+	m := in.unify(a, b)
+	if m != nil {
+		for _, v := range in.trail {
+			v.instance = nil
+		}
+		in.trail = outer
+		return false
+	}
+	if outer != nil {
+		in.trail = append(outer, in.trail...)
+	} else {
+		in.trail = nil
+	}
+	return true
+}
+
+// expect unifies want and got, and reports the error with the message if they cannot be unified
+func (in *Inferer) expect(node ast.Node, want, got Type, format string, args ...interface{}) bool {
+	m := in.unify(want, got)
+	if m == nil {
+		return true
+	}
+	if m.infinite {
+		in.errorf(node, "infinite type: %s = %s", m.want, m.got)
+	} else {
+		in.errorf(node, format, args...)
+	}
+	return false
+}
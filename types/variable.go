@@ -0,0 +1,104 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// genericLevel is the level of generalized variables, which are instantiated on each use
+const genericLevel = 1 << 30
+
+// Variable is the type variable of type inference
+type Variable struct {
+	id       int
+	level    int    // 作られた let の深さ。汎化されたら genericLevel
+	instance Type   // 単一化で束縛された型。未束縛なら nil
+	name     string // 表示用の名前
+	oneOf    []Type // 束縛できる型の候補。nil なら任意の型
+}
+
+// allows reports whether the variable can be bound to t
+func (v *Variable) allows(t Type) bool {
+	if v.oneOf == nil {
+		return true
+	}
+	for _, c := range v.oneOf {
+		if Identical(c, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *Variable) String() string {
+	if v.instance != nil {
+		return v.instance.String()
+	}
+	name := v.name
+	if name == "" {
+		name = fmt.Sprintf("t%d", v.id)
+	}
+	if v.oneOf == nil {
+		return name
+	}
+	candidates := make([]string, len(v.oneOf))
+	for i, c := range v.oneOf {
+		candidates[i] = c.String()
+	}
+	return name + ": " + strings.Join(candidates, "|")
+}
+
+// prune returns the type which the bound variables refer to
+func prune(t Type) Type {
+	for {
+		v, ok := t.(*Variable)
+		if !ok || v.instance == nil {
+			return t
+		}
+		t = v.instance
+	}
+}
+
+// Display returns the copy of t whose unbound variables are named a, b, c, ...
+// in order of appearance, so that "fn(x) { x }" is printed as "fn(a) -> a".
+// The candidates of a variable are printed at its first appearance like "fn(a: int|string, a) -> a".
+func Display(t Type) Type {
+	return rename(t, make(map[*Variable]*Variable))
+}
+
+func rename(t Type, names map[*Variable]*Variable) Type {
+	switch t := prune(t).(type) {
+	case *Variable:
+		if v, ok := names[t]; ok {
+			return v
+		}
+		v := &Variable{name: variableName(len(names))}
+		names[t] = v
+		if t.oneOf != nil {
+			// 候補は最初に現れたところでだけ表示する
+			return &Variable{name: v.name, oneOf: t.oneOf}
+		}
+		return v
+	case *Array:
+		return &Array{Element: rename(t.Element, names)}
+	case *Hash:
+		return &Hash{Key: rename(t.Key, names), Value: rename(t.Value, names)}
+	case *Function:
+		params := make([]Type, len(t.Parameters))
+		for i, p := range t.Parameters {
+			params[i] = rename(p, names)
+		}
+		return &Function{Parameters: params, Return: rename(t.Return, names)}
+	default:
+		return t
+	}
+}
+
+// variableName returns "a", ..., "z", "a1", ..., "z1", ...
+func variableName(i int) string {
+	name := string(rune('a' + i%26))
+	if i >= 26 {
+		name += fmt.Sprint(i / 26)
+	}
+	return name
+}